@@ -30,6 +30,12 @@ func AdminGetStats(c *models.ReqContext) response.Response {
 	return response.JSON(200, statsQuery.Result)
 }
 
+// AdminGetDataSourcesHealth returns the results of the last background health checks of the data sources.
+// GET /api/admin/datasources/health
+func (hs *HTTPServer) AdminGetDataSourcesHealth(c *models.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, hs.DatasourceHealth.Results())
+}
+
 func (hs *HTTPServer) getAuthorizedSettings(ctx context.Context, user *models.SignedInUser, bag setting.SettingsBag) (setting.SettingsBag, error) {
 	if hs.AccessControl.IsDisabled() {
 		return bag, nil
@@ -83,6 +83,7 @@ type HTTPServer struct {
 	HooksService           *hooks.HooksService                     `inject:""`
 	CacheService           *localcache.CacheService                `inject:""`
 	DatasourceCache        datasources.CacheService                `inject:""`
+	DatasourceHealth       *datasources.HealthCheckService         `inject:""`
 	AuthTokenService       models.UserTokenService                 `inject:""`
 	QuotaService           *quota.QuotaService                     `inject:""`
 	RemoteCacheService     *remotecache.RemoteCache                `inject:""`
@@ -13,14 +13,33 @@ import (
 	"github.com/grafana/grafana/pkg/util"
 )
 
+// DSInfo is the information of a data source needed to apply a plugin route. The access tokens
+// of the routes are cached until the version of the data source changes.
+type DSInfo struct {
+	ID                      int64
+	Version                 int
+	JSONData                map[string]interface{}
+	DecryptedSecureJSONData map[string]string
+}
+
+// DSInfoFromDataSource returns the information of the data source needed to apply a plugin route.
+func DSInfoFromDataSource(ds *models.DataSource) DSInfo {
+	return DSInfo{
+		ID:                      ds.Id,
+		Version:                 ds.Version,
+		JSONData:                ds.JsonData.Interface().(map[string]interface{}),
+		DecryptedSecureJSONData: ds.SecureJsonData.Decrypt(),
+	}
+}
+
 // ApplyRoute should use the plugin route data to set auth headers and custom headers.
 func ApplyRoute(ctx context.Context, req *http.Request, proxyPath string, route *plugins.AppPluginRoute,
-	ds *models.DataSource, cfg *setting.Cfg) {
+	ds DSInfo, cfg *setting.Cfg) {
 	proxyPath = strings.TrimPrefix(proxyPath, route.Path)
 
 	data := templateData{
-		JsonData:       ds.JsonData.Interface().(map[string]interface{}),
-		SecureJsonData: ds.SecureJsonData.Decrypt(),
+		JsonData:       ds.JSONData,
+		SecureJsonData: ds.DecryptedSecureJSONData,
 	}
 
 	if len(route.URL) > 0 {
@@ -69,12 +88,12 @@ func ApplyRoute(ctx context.Context, req *http.Request, proxyPath string, route
 	}
 }
 
-func getTokenProvider(ctx context.Context, cfg *setting.Cfg, ds *models.DataSource, pluginRoute *plugins.AppPluginRoute,
+func getTokenProvider(ctx context.Context, cfg *setting.Cfg, ds DSInfo, pluginRoute *plugins.AppPluginRoute,
 	data templateData) (accessTokenProvider, error) {
 	authType := pluginRoute.AuthType
 
 	// Plugin can override authentication type specified in route configuration
-	if authTypeOverride := ds.JsonData.Get("authenticationType").MustString(); authTypeOverride != "" {
+	if authTypeOverride, ok := ds.JSONData["authenticationType"].(string); ok && authTypeOverride != "" {
 		authType = authTypeOverride
 	}
 
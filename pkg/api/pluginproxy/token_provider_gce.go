@@ -3,7 +3,6 @@ package pluginproxy
 import (
 	"context"
 
-	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"golang.org/x/oauth2/google"
 )
@@ -16,10 +15,10 @@ type gceAccessTokenProvider struct {
 	authParams        *plugins.JwtTokenAuth
 }
 
-func newGceAccessTokenProvider(ctx context.Context, ds *models.DataSource, pluginRoute *plugins.AppPluginRoute,
+func newGceAccessTokenProvider(ctx context.Context, ds DSInfo, pluginRoute *plugins.AppPluginRoute,
 	authParams *plugins.JwtTokenAuth) *gceAccessTokenProvider {
 	return &gceAccessTokenProvider{
-		datasourceId:      ds.Id,
+		datasourceId:      ds.ID,
 		datasourceVersion: ds.Version,
 		ctx:               ctx,
 		route:             pluginRoute,
@@ -10,7 +10,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 )
 
@@ -68,10 +67,10 @@ func (token *jwtToken) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
-func newGenericAccessTokenProvider(ds *models.DataSource, pluginRoute *plugins.AppPluginRoute,
+func newGenericAccessTokenProvider(ds DSInfo, pluginRoute *plugins.AppPluginRoute,
 	authParams *plugins.JwtTokenAuth) *genericAccessTokenProvider {
 	return &genericAccessTokenProvider{
-		datasourceId:      ds.Id,
+		datasourceId:      ds.ID,
 		datasourceVersion: ds.Version,
 		route:             pluginRoute,
 		authParams:        authParams,
@@ -6,7 +6,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/jwt"
@@ -31,10 +30,10 @@ type jwtAccessTokenProvider struct {
 	authParams        *plugins.JwtTokenAuth
 }
 
-func newJwtAccessTokenProvider(ctx context.Context, ds *models.DataSource, pluginRoute *plugins.AppPluginRoute,
+func newJwtAccessTokenProvider(ctx context.Context, ds DSInfo, pluginRoute *plugins.AppPluginRoute,
 	authParams *plugins.JwtTokenAuth) *jwtAccessTokenProvider {
 	return &jwtAccessTokenProvider{
-		datasourceId:      ds.Id,
+		datasourceId:      ds.ID,
 		datasourceVersion: ds.Version,
 		ctx:               ctx,
 		route:             pluginRoute,
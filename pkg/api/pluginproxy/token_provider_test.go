@@ -12,7 +12,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/jwt"
@@ -63,7 +62,7 @@ func TestAccessToken_pluginWithJWTTokenAuthRoute(t *testing.T) {
 		getTokenSource = fn
 	}
 
-	ds := &models.DataSource{Id: 1, Version: 2}
+	ds := DSInfo{ID: 1, Version: 2}
 
 	t.Run("should fetch token using JWT private key", func(t *testing.T) {
 		setUp(t, func(conf *jwt.Config, ctx context.Context) (*oauth2.Token, error) {
@@ -111,6 +110,26 @@ func TestAccessToken_pluginWithJWTTokenAuthRoute(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "abc", token2)
 	})
+
+	t.Run("should not use cached token of a previous version of the data source", func(t *testing.T) {
+		setUp(t, func(conf *jwt.Config, ctx context.Context) (*oauth2.Token, error) {
+			return &oauth2.Token{
+				AccessToken: "abc",
+				Expiry:      time.Now().Add(1 * time.Minute)}, nil
+		})
+		provider := newJwtAccessTokenProvider(context.Background(), DSInfo{ID: 1, Version: 4}, pluginRoute, authParams)
+		token1, err := provider.GetAccessToken()
+		require.NoError(t, err)
+		assert.Equal(t, "abc", token1)
+
+		getTokenSource = func(conf *jwt.Config, ctx context.Context) (*oauth2.Token, error) {
+			return &oauth2.Token{AccessToken: "def"}, nil
+		}
+		provider = newJwtAccessTokenProvider(context.Background(), DSInfo{ID: 1, Version: 5}, pluginRoute, authParams)
+		token2, err := provider.GetAccessToken()
+		require.NoError(t, err)
+		assert.Equal(t, "def", token2)
+	})
 }
 
 func TestAccessToken_pluginWithTokenAuthRoute(t *testing.T) {
@@ -170,7 +189,7 @@ func TestAccessToken_pluginWithTokenAuthRoute(t *testing.T) {
 
 		mockTimeNow(time.Now())
 		defer resetTimeNow()
-		provider := newGenericAccessTokenProvider(&models.DataSource{}, pluginRoute, authParams)
+		provider := newGenericAccessTokenProvider(DSInfo{}, pluginRoute, authParams)
 
 		testCases := []tokenTestDescription{
 			{
@@ -252,7 +271,7 @@ func TestAccessToken_pluginWithTokenAuthRoute(t *testing.T) {
 
 		mockTimeNow(time.Now())
 		defer resetTimeNow()
-		provider := newGenericAccessTokenProvider(&models.DataSource{}, pluginRoute, authParams)
+		provider := newGenericAccessTokenProvider(DSInfo{}, pluginRoute, authParams)
 
 		token = map[string]interface{}{
 			"access_token":  "2YotnFZFEjr1zCsicMWpAA",
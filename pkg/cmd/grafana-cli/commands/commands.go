@@ -121,6 +121,26 @@ var adminCommands = []*cli.Command{
 			},
 		},
 	},
+	{
+		Name:   "import-prometheus-rules",
+		Usage:  "import-prometheus-rules <rule file>",
+		Action: runDbCommand(importPrometheusRulesCommand),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "folder",
+				Usage: "Folder the alert rules are saved in, it is created if it does not exist",
+			},
+			&cli.StringFlag{
+				Name:  "datasource-uid",
+				Usage: "UID of the Prometheus or Loki data source the alert rules query",
+			},
+			&cli.IntFlag{
+				Name:  "org-id",
+				Usage: "Organization of the alert rules",
+				Value: 1,
+			},
+		},
+	},
 	{
 		Name:  "data-migration",
 		Usage: "Runs a script that migrates or cleanups data in your db",
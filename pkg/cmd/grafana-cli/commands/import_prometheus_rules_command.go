@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/fatih/color"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/prom"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// importPrometheusRulesCommand converts the alerting rules of a Prometheus rule file to Grafana managed
+// alert rules, which are saved in a folder that is created if it does not exist.
+func importPrometheusRulesCommand(c utils.CommandLine, sqlStore *sqlstore.SQLStore) error {
+	path := c.Args().First()
+	if path == "" {
+		return errors.New("missing path to the rule file")
+	}
+	folderTitle := c.String("folder")
+	if folderTitle == "" {
+		return errors.New("missing folder of the alert rules")
+	}
+	datasourceUID := c.String("datasource-uid")
+	if datasourceUID == "" {
+		return errors.New("missing UID of the data source of the alert rules")
+	}
+	orgID := int64(c.Int("org-id"))
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the rule file: %w", err)
+	}
+	groups, err := prom.ParseRuleGroups(b)
+	if err != nil {
+		return err
+	}
+
+	ds, err := sqlStore.GetDataSource(datasourceUID, 0, "", orgID)
+	if err != nil {
+		return fmt.Errorf("failed to get data source %s: %w", datasourceUID, err)
+	}
+	groups, err = prom.ConvertRuleGroups(groups, prom.Datasource{UID: ds.Uid, Type: ds.Type})
+	if err != nil {
+		return err
+	}
+
+	user := &models.SignedInUser{OrgId: orgID, OrgRole: models.ROLE_ADMIN}
+	folders := dashboards.NewFolderService(orgID, user, sqlStore)
+	folder, err := folders.GetFolderByTitle(folderTitle)
+	if errors.Is(err, models.ErrFolderNotFound) {
+		folder, err = folders.CreateFolder(folderTitle, "")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get folder %q: %w", folderTitle, err)
+	}
+
+	// the intervals are the ones of the scheduler of the alert rules
+	baseInterval := sqlStore.Cfg.UnifiedAlerting.BaseInterval
+	st := store.DBstore{
+		BaseInterval:           baseInterval,
+		DefaultIntervalSeconds: 6 * int64(baseInterval.Seconds()),
+		SQLStore:               sqlStore,
+		Logger:                 log.New("ngalert.prom"),
+	}
+	for _, g := range groups {
+		q := ngmodels.ListRuleGroupAlertRulesQuery{
+			OrgID:        orgID,
+			NamespaceUID: folder.Uid,
+			RuleGroup:    g.Name,
+		}
+		if err := st.GetRuleGroupAlertRules(&q); err != nil {
+			return fmt.Errorf("failed to get rule group %q: %w", g.Name, err)
+		}
+		prom.KeepRuleUIDs(g, q.Result)
+		if err := st.UpdateRuleGroup(store.UpdateRuleGroupCmd{
+			OrgID:           orgID,
+			NamespaceUID:    folder.Uid,
+			RuleGroupConfig: g,
+		}); err != nil {
+			return fmt.Errorf("failed to import rule group %q: %w", g.Name, err)
+		}
+		logger.Infof("Rule group %s imported with %d alert rules %s\n", g.Name, len(g.Rules), color.GreenString("✔"))
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+const ruleFile = `
+groups:
+  - name: targets
+    rules:
+      - alert: TargetDown
+        expr: up == 0
+      - alert: TargetsMissing
+        expr: count(up) < 3
+`
+
+func TestImportPrometheusRulesCommand(t *testing.T) {
+	sqlStore := sqlstore.InitTestDB(t)
+	sqlStore.Cfg.UnifiedAlerting.BaseInterval = 10 * time.Second
+	require.NoError(t, sqlstore.AddDataSource(&models.AddDataSourceCommand{
+		OrgId:  1,
+		Name:   "prometheus",
+		Type:   "prometheus",
+		Access: models.DS_ACCESS_PROXY,
+		Uid:    "prom",
+	}))
+
+	path := filepath.Join(t.TempDir(), "rules.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(ruleFile), 0600))
+
+	importRules := func(t *testing.T) {
+		t.Helper()
+		flagSet := flag.NewFlagSet("Test", 0)
+		flagSet.String("folder", "", "")
+		flagSet.String("datasource-uid", "", "")
+		flagSet.Int("org-id", 1, "")
+		require.NoError(t, flagSet.Parse([]string{"--folder", "Node", "--datasource-uid", "prom", path}))
+		c := &utils.ContextCommandLine{Context: cli.NewContext(&cli.App{Name: "Test"}, flagSet, nil)}
+		require.NoError(t, importPrometheusRulesCommand(c, sqlStore))
+	}
+	ruleUIDs := func(t *testing.T) []string {
+		t.Helper()
+		user := &models.SignedInUser{OrgId: 1, OrgRole: models.ROLE_ADMIN}
+		folder, err := dashboards.NewFolderService(1, user, sqlStore).GetFolderByTitle("Node")
+		require.NoError(t, err)
+		st := store.DBstore{SQLStore: sqlStore}
+		q := ngmodels.ListRuleGroupAlertRulesQuery{OrgID: 1, NamespaceUID: folder.Uid, RuleGroup: "targets"}
+		require.NoError(t, st.GetRuleGroupAlertRules(&q))
+		uids := make([]string, 0, len(q.Result))
+		for _, r := range q.Result {
+			uids = append(uids, r.UID)
+		}
+		return uids
+	}
+
+	importRules(t)
+	imported := ruleUIDs(t)
+	require.Len(t, imported, 2)
+
+	t.Run("importing the same file again updates the alert rules", func(t *testing.T) {
+		importRules(t)
+		require.ElementsMatch(t, imported, ruleUIDs(t))
+	})
+}
@@ -2,6 +2,7 @@ package expr
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -20,6 +21,7 @@ type Command interface {
 type MathCommand struct {
 	RawExpression string
 	Expression    *mathexp.Expr
+	UnionOptions  mathexp.UnionOptions
 	refID         string
 }
 
@@ -52,6 +54,19 @@ func UnmarshalMathCommand(rn *rawNode) (*MathCommand, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid math command type in '%v': %v", rn.RefID, err)
 	}
+
+	if rawMatching, ok := rn.Query["matching"]; ok {
+		jsonFromM, err := json.Marshal(rawMatching)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remarshal math command matching options for refId %v: %w", rn.RefID, err)
+		}
+		if err := json.Unmarshal(jsonFromM, &gm.UnionOptions); err != nil {
+			return nil, fmt.Errorf("invalid math command matching options for refId %v: %w", rn.RefID, err)
+		}
+		if err := gm.UnionOptions.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid math command matching options for refId %v: %w", rn.RefID, err)
+		}
+	}
 	return gm, nil
 }
 
@@ -64,24 +79,40 @@ func (gm *MathCommand) NeedsVars() []string {
 // Execute runs the command and returns the results or an error if the command
 // failed to execute.
 func (gm *MathCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathexp.Results, error) {
-	return gm.Expression.Execute(gm.refID, vars)
+	return gm.Expression.ExecuteWithUnionOptions(gm.refID, vars, gm.UnionOptions)
 }
 
 // ReduceCommand is an expression command for reduction of a timeseries such as a min, mean, or max.
 type ReduceCommand struct {
-	Reducer     string
-	VarToReduce string
-	refID       string
+	Reducer      string
+	VarToReduce  string
+	refID        string
+	seriesMapper mathexp.ReduceMapper
 }
 
+// ReduceMode defines how the reduce command handles non-numeric (null and NaN) values.
+type ReduceMode string
+
+const (
+	// ReduceModeStrict makes reducers return NaN if the series contains non-numeric values.
+	ReduceModeStrict ReduceMode = ""
+	// ReduceModeDrop removes non-numeric values from the series before reduction.
+	ReduceModeDrop ReduceMode = "dropNN"
+	// ReduceModeReplace replaces non-numeric values with a fixed value before reduction.
+	ReduceModeReplace ReduceMode = "replaceNN"
+)
+
 // NewReduceCommand creates a new ReduceCMD.
-func NewReduceCommand(refID, reducer, varToReduce string) *ReduceCommand {
-	// TODO: validate reducer here, before execution
-	return &ReduceCommand{
-		Reducer:     reducer,
-		VarToReduce: varToReduce,
-		refID:       refID,
+func NewReduceCommand(refID, reducer, varToReduce string, mapper mathexp.ReduceMapper) (*ReduceCommand, error) {
+	if err := mathexp.IsValidReducer(reducer); err != nil {
+		return nil, err
 	}
+	return &ReduceCommand{
+		Reducer:      reducer,
+		VarToReduce:  varToReduce,
+		refID:        refID,
+		seriesMapper: mapper,
+	}, nil
 }
 
 // UnmarshalReduceCommand creates a MathCMD from Grafana's frontend query.
@@ -105,7 +136,50 @@ func UnmarshalReduceCommand(rn *rawNode) (*ReduceCommand, error) {
 		return nil, fmt.Errorf("expected reducer to be a string, got %T for refId %v", rawReducer, rn.RefID)
 	}
 
-	return NewReduceCommand(rn.RefID, redFunc, varToReduce), nil
+	var mapper mathexp.ReduceMapper
+	if rawSettings, ok := rn.Query["settings"]; ok {
+		var err error
+		mapper, err = unmarshalReduceSettings(rn.RefID, rawSettings)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewReduceCommand(rn.RefID, redFunc, varToReduce, mapper)
+}
+
+// unmarshalReduceSettings returns the mathexp.ReduceMapper for the mode in the settings of a reduce command.
+func unmarshalReduceSettings(refID string, rawSettings interface{}) (mathexp.ReduceMapper, error) {
+	settings, ok := rawSettings.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected reduce settings to be an object, got %T for refId %v", rawSettings, refID)
+	}
+	rawMode, ok := settings["mode"]
+	if !ok {
+		return nil, nil
+	}
+	mode, ok := rawMode.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected reduce mode to be a string, got %T for refId %v", rawMode, refID)
+	}
+	switch ReduceMode(mode) {
+	case ReduceModeStrict, "strict":
+		return nil, nil
+	case ReduceModeDrop:
+		return mathexp.DropNonNumber{}, nil
+	case ReduceModeReplace:
+		rawValue, ok := settings["replaceWithValue"]
+		if !ok {
+			return nil, fmt.Errorf("no replaceWithValue specified for reduce mode %v for refId %v", mode, refID)
+		}
+		value, ok := rawValue.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected replaceWithValue to be a number, got %T for refId %v", rawValue, refID)
+		}
+		return mathexp.ReplaceNonNumberWithValue{Value: value}, nil
+	default:
+		return nil, fmt.Errorf("reduce mode '%v' is not supported for refId %v", mode, refID)
+	}
 }
 
 // NeedsVars returns the variable names (refIds) that are dependencies
@@ -123,7 +197,7 @@ func (gr *ReduceCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathex
 		if !ok {
 			return newRes, fmt.Errorf("can only reduce type series, got type %v", val.Type())
 		}
-		num, err := series.Reduce(gr.refID, gr.Reducer)
+		num, err := series.Reduce(gr.refID, gr.Reducer, gr.seriesMapper)
 		if err != nil {
 			return newRes, err
 		}
@@ -139,22 +213,51 @@ type ResampleCommand struct {
 	Downsampler   string
 	Upsampler     string
 	TimeRange     TimeRange
+	Alignment     ResampleAlignment
+	Location      *time.Location
 	refID         string
 }
 
-// NewResampleCommand creates a new ResampleCMD.
-func NewResampleCommand(refID, rawWindow, varToResample string, downsampler string, upsampler string, tr TimeRange) (*ResampleCommand, error) {
-	// TODO: validate reducer here, before execution
+// ResampleAlignment defines where the windows of a ResampleCommand start.
+type ResampleAlignment string
+
+const (
+	// AlignToFrom starts the windows at the start of the time range.
+	AlignToFrom ResampleAlignment = ""
+	// AlignToClock starts the windows at the first wall-clock boundary of the window
+	// duration (e.g. an exact minute or hour) in the location of the command.
+	AlignToClock ResampleAlignment = "clock"
+)
+
+// NewResampleCommand creates a new ResampleCMD. The location is only used when the windows
+// are aligned to the clock, and defaults to UTC.
+func NewResampleCommand(refID, rawWindow, varToResample string, downsampler string, upsampler string, tr TimeRange, alignment ResampleAlignment, loc *time.Location) (*ResampleCommand, error) {
 	window, err := gtime.ParseDuration(rawWindow)
 	if err != nil {
 		return nil, fmt.Errorf(`failed to parse resample "window" duration field %q: %w`, window, err)
 	}
+	if !mathexp.IsValidDownsampler(downsampler) {
+		return nil, fmt.Errorf("downsampler '%v' is not supported for refId %v", downsampler, refID)
+	}
+	if !mathexp.IsValidUpsampler(upsampler) {
+		return nil, fmt.Errorf("upsampler '%v' is not supported for refId %v", upsampler, refID)
+	}
+	switch alignment {
+	case AlignToFrom, AlignToClock:
+	default:
+		return nil, fmt.Errorf("resample alignment '%v' is not supported for refId %v, must be empty or clock", alignment, refID)
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
 	return &ResampleCommand{
 		Window:        window,
 		VarToResample: varToResample,
 		Downsampler:   downsampler,
 		Upsampler:     upsampler,
 		TimeRange:     tr,
+		Alignment:     alignment,
+		Location:      loc,
 		refID:         refID,
 	}, nil
 }
@@ -199,7 +302,26 @@ func UnmarshalResampleCommand(rn *rawNode) (*ResampleCommand, error) {
 		return nil, fmt.Errorf("expected resample downsampler to be a string, got type %T for refId %v", upsampler, rn.RefID)
 	}
 
-	return NewResampleCommand(rn.RefID, window, varToResample, downsampler, upsampler, rn.TimeRange)
+	var alignment string
+	if rawAlignment, ok := rn.Query["alignment"]; ok {
+		if alignment, ok = rawAlignment.(string); !ok {
+			return nil, fmt.Errorf("expected resample alignment to be a string, got type %T for refId %v", rawAlignment, rn.RefID)
+		}
+	}
+
+	loc := time.UTC
+	if rawTimezone, ok := rn.Query["timezone"]; ok {
+		timezone, ok := rawTimezone.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected resample timezone to be a string, got type %T for refId %v", rawTimezone, rn.RefID)
+		}
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid resample timezone %q for refId %v: %w", timezone, rn.RefID, err)
+		}
+	}
+
+	return NewResampleCommand(rn.RefID, window, varToResample, downsampler, upsampler, rn.TimeRange, ResampleAlignment(alignment), loc)
 }
 
 // NeedsVars returns the variable names (refIds) that are dependencies
@@ -212,12 +334,16 @@ func (gr *ResampleCommand) NeedsVars() []string {
 // failed to execute.
 func (gr *ResampleCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathexp.Results, error) {
 	newRes := mathexp.Results{}
+	from := gr.TimeRange.From
+	if gr.Alignment == AlignToClock {
+		from = mathexp.AlignToWallClock(from, gr.Window, gr.Location)
+	}
 	for _, val := range vars[gr.VarToResample].Values {
 		series, ok := val.(mathexp.Series)
 		if !ok {
 			return newRes, fmt.Errorf("can only resample type series, got type %v", val.Type())
 		}
-		num, err := series.Resample(gr.refID, gr.Window, gr.Downsampler, gr.Upsampler, gr.TimeRange.From, gr.TimeRange.To)
+		num, err := series.Resample(gr.refID, gr.Window, gr.Downsampler, gr.Upsampler, from, gr.TimeRange.To)
 		if err != nil {
 			return newRes, err
 		}
@@ -240,6 +366,10 @@ const (
 	TypeResample
 	// TypeClassicConditions is the CMDType for the classic condition operation.
 	TypeClassicConditions
+	// TypeThreshold is the CMDType for a threshold expression.
+	TypeThreshold
+	// TypeSQL is the CMDType for a SQL expression.
+	TypeSQL
 )
 
 func (gt CommandType) String() string {
@@ -252,6 +382,10 @@ func (gt CommandType) String() string {
 		return "resample"
 	case TypeClassicConditions:
 		return "classic_conditions"
+	case TypeThreshold:
+		return "threshold"
+	case TypeSQL:
+		return "sql"
 	default:
 		return "unknown"
 	}
@@ -268,6 +402,10 @@ func ParseCommandType(s string) (CommandType, error) {
 		return TypeResample, nil
 	case "classic_conditions":
 		return TypeClassicConditions, nil
+	case "threshold":
+		return TypeThreshold, nil
+	case "sql":
+		return TypeSQL, nil
 	default:
 		return TypeUnknown, fmt.Errorf("'%v' is not a recognized expression type", s)
 	}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/grafana/grafana/pkg/expr/mathexp"
 
@@ -45,19 +47,133 @@ type Node interface {
 // DataPipeline is an ordered set of nodes returned from DPGraph processing.
 type DataPipeline []Node
 
-// execute runs all the command/datasource requests in the pipeline return a
-// map of the refId of the of each command
-func (dp *DataPipeline) execute(c context.Context, s *Service) (mathexp.Vars, error) {
+// maxConcurrentDSQueries is the maximum number of datasource queries of a
+// pipeline that are executed at the same time.
+const maxConcurrentDSQueries = 8
+
+// nodeResult holds the outcome of the execution of a node.
+type nodeResult struct {
+	results  mathexp.Results
+	err      error
+	duration time.Duration
+}
+
+// execute runs all the command/datasource requests in the pipeline and returns
+// the outcome of each node by refId.
+//
+// Datasource nodes do not depend on other nodes, so they are executed first and
+// concurrently, and identical datasource queries are only sent once. Command nodes
+// are then executed in order, and fail without being executed if one of the nodes
+// they depend on has failed.
+func (dp *DataPipeline) execute(c context.Context, s *Service) map[string]nodeResult {
+	outcomes := make(map[string]nodeResult, len(*dp))
+
+	var dsNodes []*DSNode
+	for _, node := range *dp {
+		if dsNode, ok := node.(*DSNode); ok {
+			dsNodes = append(dsNodes, dsNode)
+		}
+	}
+	for refID, outcome := range executeDSNodes(c, dsNodes, s) {
+		outcomes[refID] = outcome
+	}
+
 	vars := make(mathexp.Vars)
+	for refID, outcome := range outcomes {
+		if outcome.err == nil {
+			vars[refID] = outcome.results
+		}
+	}
+
 	for _, node := range *dp {
+		cmdNode, ok := node.(*CMDNode)
+		if !ok {
+			continue
+		}
+		if err := failedDependency(cmdNode, outcomes); err != nil {
+			outcomes[node.RefID()] = nodeResult{err: err}
+			continue
+		}
+
+		start := time.Now()
 		res, err := node.Execute(c, vars, s)
+		outcomes[node.RefID()] = nodeResult{results: res, err: err, duration: time.Since(start)}
+		if err == nil {
+			vars[node.RefID()] = res
+		}
+	}
+	return outcomes
+}
+
+// executeDSNodes executes the datasource nodes with a bounded number of concurrent
+// queries. Nodes with identical queries share a single query, and each of them
+// gets its own copy of the results.
+func executeDSNodes(c context.Context, nodes []*DSNode, s *Service) map[string]nodeResult {
+	groups := make(map[string][]*DSNode)
+	var keys []string
+	for _, node := range nodes {
+		key, err := node.queryKey()
 		if err != nil {
-			return nil, err
+			// the node can not be compared with others, so it is executed on its own.
+			key = "refId:" + node.RefID()
 		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], node)
+	}
 
-		vars[node.RefID()] = res
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		outcomes = make(map[string]nodeResult, len(nodes))
+		sem      = make(chan struct{}, maxConcurrentDSQueries)
+	)
+	for _, key := range keys {
+		group := groups[key]
+		select {
+		case sem <- struct{}{}:
+		case <-c.Done():
+			// the request is cancelled, the queries still waiting for a slot are not sent.
+			mu.Lock()
+			for _, node := range group {
+				outcomes[node.RefID()] = nodeResult{err: c.Err()}
+			}
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			start := time.Now()
+			res, err := group[0].Execute(c, nil, s)
+			duration := time.Since(start)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for i, node := range group {
+				if i > 0 && err == nil {
+					res = copyResults(res)
+				}
+				outcomes[node.RefID()] = nodeResult{results: res, err: err, duration: duration}
+			}
+		}()
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// failedDependency returns an error if one of the nodes the command node depends on has failed.
+func failedDependency(node *CMDNode, outcomes map[string]nodeResult) error {
+	for _, refID := range node.Command.NeedsVars() {
+		if outcome, ok := outcomes[refID]; ok && outcome.err != nil {
+			return fmt.Errorf("dependency %v of %v failed: %w", refID, node.RefID(), outcome.err)
+		}
 	}
-	return vars, nil
+	return nil
 }
 
 // BuildPipeline builds a graph of the nodes, and returns the nodes in an
@@ -182,6 +298,10 @@ func buildGraphEdges(dp *simple.DirectedGraph, registry map[string]Node) error {
 
 		cmdNode := node.(*CMDNode)
 
+		if sqlCmd, ok := cmdNode.Command.(*SQLCommand); ok {
+			sqlCmd.keepRefIDTables(registry)
+		}
+
 		for _, neededVar := range cmdNode.Command.NeedsVars() {
 			neededNode, ok := registry[neededVar]
 			if !ok {
@@ -195,6 +195,26 @@ func TestServicebuildPipeLine(t *testing.T) {
 			},
 			expectErrContains: "classic conditions may not be the input for other expressions",
 		},
+		{
+			name: "sql: only refIds are dependencies",
+			req: &Request{
+				Queries: []Query{
+					{
+						RefID:         "A",
+						DatasourceUID: DatasourceUID,
+						JSON: json.RawMessage(`{
+							"expression": "SELECT B.value * j.value AS value FROM B JOIN json_each('[2]') AS j",
+							"type": "sql"
+						}`),
+					},
+					{
+						RefID:         "B",
+						DatasourceUID: "Fake",
+					},
+				},
+			},
+			expectedOrder: []string{"B", "A"},
+		},
 	}
 	s := Service{}
 	for _, tt := range tests {
@@ -1,6 +1,7 @@
 package mathexp
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
@@ -21,9 +22,10 @@ type State struct {
 	*Expr
 	Vars Vars
 	// Could hold more properties that change behavior around:
-	//  - Unions (How many result A and many Result B in case A + B are joined)
 	//  - NaN/Null behavior
 	RefID string
+	// UnionOptions controls how many result A and many result B are joined in case of A + B.
+	UnionOptions UnionOptions
 }
 
 // Vars holds the results of datasource queries or other expression commands.
@@ -44,10 +46,20 @@ func New(expr string, funcs ...map[string]parse.Func) (*Expr, error) {
 
 // Execute applies a parse expression to the context and executes it
 func (e *Expr) Execute(refID string, vars Vars) (r Results, err error) {
+	return e.ExecuteWithUnionOptions(refID, vars, UnionOptions{})
+}
+
+// ExecuteWithUnionOptions applies a parse expression to the context and executes it,
+// matching the values of binary operations according to the union options.
+func (e *Expr) ExecuteWithUnionOptions(refID string, vars Vars, opts UnionOptions) (r Results, err error) {
+	if err := opts.Validate(); err != nil {
+		return r, err
+	}
 	s := &State{
-		Expr:  e,
-		Vars:  vars,
-		RefID: refID,
+		Expr:         e,
+		Vars:         vars,
+		RefID:        refID,
+		UnionOptions: opts,
 	}
 	return e.executeState(s)
 }
@@ -252,7 +264,10 @@ func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
 	if err != nil {
 		return res, err
 	}
-	unions := union(ar, br)
+	unions, mismatchWarning, err := e.union(node, ar, br)
+	if err != nil {
+		return res, err
+	}
 	for _, uni := range unions {
 		var value Value
 		switch at := uni.A.(type) {
@@ -321,9 +336,54 @@ func (e *State) walkBinary(node *parse.BinaryNode) (Results, error) {
 		}
 		res.Values = append(res.Values, value)
 	}
+	if mismatchWarning != "" {
+		for _, v := range res.Values {
+			addNotice(v, mismatchWarning)
+		}
+	}
 	return res, nil
 }
 
+// union joins the results of both sides of the binary operation node according to the union options
+// of the state. The values that could not be matched are handled according to the mismatch mode: in
+// warn mode, the returned warning describes them.
+func (e *State) union(node *parse.BinaryNode, ar, br Results) ([]*Union, string, error) {
+	var unions []*Union
+	if e.UnionOptions.isLabelMatching() {
+		var err error
+		unions, err = matchUnion(ar, br, e.UnionOptions)
+		if err != nil {
+			return nil, "", fmt.Errorf("%v: %w", node, err)
+		}
+	} else {
+		unions = union(ar, br)
+	}
+
+	if e.UnionOptions.OnMismatch == MismatchDrop {
+		return unions, "", nil
+	}
+	aCount, bCount := unmatched(ar, br, unions)
+	if aCount == 0 && bCount == 0 {
+		return unions, "", nil
+	}
+	msg := fmt.Sprintf("%v: %d result(s) of %v and %d result(s) of %v did not match and were dropped",
+		node, aCount, node.Args[0], bCount, node.Args[1])
+	if e.UnionOptions.OnMismatch == MismatchError {
+		return nil, "", errors.New(msg)
+	}
+	return unions, msg, nil
+}
+
+// addNotice adds a warning notice to the data frame of the value.
+func addNotice(v Value, text string) {
+	frame := v.AsDataFrame()
+	notice := data.Notice{Severity: data.NoticeSeverityWarning, Text: text}
+	if frame.Meta == nil {
+		frame.SetMeta(&data.FrameMeta{})
+	}
+	frame.Meta.Notices = append(frame.Meta.Notices, notice)
+}
+
 // binaryOp performs a binary operations (e.g. A+B or A>B) on two
 // float values
 // nolint:gocyclo
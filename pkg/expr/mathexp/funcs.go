@@ -1,8 +1,13 @@
 package mathexp
 
 import (
+	"fmt"
 	"math"
+	"strings"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/gtime"
 	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
 )
 
@@ -29,32 +34,163 @@ var builtins = map[string]parse.Func{
 		Return: parse.TypeScalar,
 		F:      null,
 	},
+	"sqrt": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet},
+		VariantReturn: true,
+		F:             sqrt,
+	},
+	"exp": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet},
+		VariantReturn: true,
+		F:             exp,
+	},
+	"round": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet},
+		VariantReturn: true,
+		F:             round,
+	},
+	"floor": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet},
+		VariantReturn: true,
+		F:             floor,
+	},
+	"ceil": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet},
+		VariantReturn: true,
+		F:             ceil,
+	},
+	"pow": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet, parse.TypeScalar},
+		VariantReturn: true,
+		F:             pow,
+	},
+	"clamp_min": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet, parse.TypeScalar},
+		VariantReturn: true,
+		F:             clampMin,
+	},
+	"clamp_max": {
+		Args:          []parse.ReturnType{parse.TypeVariantSet, parse.TypeScalar},
+		VariantReturn: true,
+		F:             clampMax,
+	},
+	"delta": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      delta,
+	},
+	"increase": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      increase,
+	},
+	"rate": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      rate,
+	},
+	"derivative": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      derivative,
+	},
+	"moving_avg": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeScalar},
+		Return: parse.TypeSeriesSet,
+		F:      movingAvg,
+		Check:  checkWindowArg,
+	},
+	"cumulative_sum": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      cumulativeSum,
+	},
+	"time_shift": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeString},
+		Return: parse.TypeSeriesSet,
+		F:      timeShift,
+		Check:  checkDurationArg,
+	},
+	"vector": {
+		Args:   []parse.ReturnType{parse.TypeScalar, parse.TypeString},
+		Return: parse.TypeNumberSet,
+		F:      vector,
+		Check:  checkLabelsArg,
+	},
 }
 
 // abs returns the absolute value for each result in NumberSet, SeriesSet, or Scalar
 func abs(e *State, varSet Results) (Results, error) {
-	newRes := Results{}
-	for _, res := range varSet.Values {
-		newVal, err := perFloat(e, res, math.Abs)
-		if err != nil {
-			return newRes, err
-		}
-		newRes.Values = append(newRes.Values, newVal)
-	}
-	return newRes, nil
+	return perFloatResults(e, varSet, math.Abs)
 }
 
 // log returns the natural logarithm value for each result in NumberSet, SeriesSet, or Scalar
 func log(e *State, varSet Results) (Results, error) {
-	newRes := Results{}
-	for _, res := range varSet.Values {
-		newVal, err := perFloat(e, res, math.Log)
-		if err != nil {
-			return newRes, err
+	return perFloatResults(e, varSet, math.Log)
+}
+
+// sqrt returns the square root for each result in NumberSet, SeriesSet, or Scalar
+func sqrt(e *State, varSet Results) (Results, error) {
+	return perFloatResults(e, varSet, math.Sqrt)
+}
+
+// exp returns e**x for each result in NumberSet, SeriesSet, or Scalar
+func exp(e *State, varSet Results) (Results, error) {
+	return perFloatResults(e, varSet, math.Exp)
+}
+
+// round returns the nearest integer, rounding half away from zero, for each result in NumberSet, SeriesSet, or Scalar
+func round(e *State, varSet Results) (Results, error) {
+	return perFloatResults(e, varSet, math.Round)
+}
+
+// floor returns the greatest integer less than or equal to each result in NumberSet, SeriesSet, or Scalar
+func floor(e *State, varSet Results) (Results, error) {
+	return perFloatResults(e, varSet, math.Floor)
+}
+
+// ceil returns the least integer greater than or equal to each result in NumberSet, SeriesSet, or Scalar
+func ceil(e *State, varSet Results) (Results, error) {
+	return perFloatResults(e, varSet, math.Ceil)
+}
+
+// pow returns each result in NumberSet, SeriesSet, or Scalar raised to the power of the exponent
+func pow(e *State, varSet Results, exponent Results) (Results, error) {
+	y, err := scalarArg("pow", exponent)
+	if err != nil {
+		return Results{}, err
+	}
+	return perFloatResults(e, varSet, func(x float64) float64 {
+		return math.Pow(x, y)
+	})
+}
+
+// clampMin replaces each value in NumberSet, SeriesSet, or Scalar that is lower than min with min
+func clampMin(e *State, varSet Results, minRes Results) (Results, error) {
+	m, err := scalarArg("clamp_min", minRes)
+	if err != nil {
+		return Results{}, err
+	}
+	return perFloatResults(e, varSet, func(x float64) float64 {
+		if math.IsNaN(x) || math.IsNaN(m) {
+			return math.NaN()
 		}
-		newRes.Values = append(newRes.Values, newVal)
+		return math.Max(x, m)
+	})
+}
+
+// clampMax replaces each value in NumberSet, SeriesSet, or Scalar that is greater than max with max
+func clampMax(e *State, varSet Results, maxRes Results) (Results, error) {
+	m, err := scalarArg("clamp_max", maxRes)
+	if err != nil {
+		return Results{}, err
 	}
-	return newRes, nil
+	return perFloatResults(e, varSet, func(x float64) float64 {
+		if math.IsNaN(x) || math.IsNaN(m) {
+			return math.NaN()
+		}
+		return math.Min(x, m)
+	})
 }
 
 // nan returns a scalar nan value
@@ -74,6 +210,262 @@ func null(e *State) Results {
 	return NewScalarResults(e.RefID, nil)
 }
 
+// vector returns a number with the value of the scalar and the labels described by the
+// labels string (e.g. "host=a, dc=b"), so constants can be joined with labelled results.
+func vector(e *State, scalar Results, labels string) (Results, error) {
+	v, err := scalarArg("vector", scalar)
+	if err != nil {
+		return Results{}, err
+	}
+	l, err := data.LabelsFromString(labels)
+	if err != nil {
+		return Results{}, err
+	}
+	n := NewNumber(e.RefID, l)
+	n.SetValue(&v)
+	return Results{Values: Values{n}}, nil
+}
+
+// delta returns, for each series, the difference between each point and the previous one.
+// The first point of each series is dropped.
+func delta(e *State, varSet Results) (Results, error) {
+	return perPointPair(e, "delta", varSet, func(prev, cur float64, _ time.Duration) float64 {
+		return cur - prev
+	})
+}
+
+// increase returns, for each series, the increase between each point and the previous one
+// treating the series as a monotonic counter: a decrease is handled as a counter reset.
+// The first point of each series is dropped.
+func increase(e *State, varSet Results) (Results, error) {
+	return perPointPair(e, "increase", varSet, counterIncrease)
+}
+
+// rate returns, for each series, the per-second rate of increase between each point and the previous
+// one treating the series as a monotonic counter. The first point of each series is dropped.
+func rate(e *State, varSet Results) (Results, error) {
+	return perPointPair(e, "rate", varSet, func(prev, cur float64, d time.Duration) float64 {
+		return counterIncrease(prev, cur, d) / d.Seconds()
+	})
+}
+
+// derivative returns, for each series, the per-second change between each point and the previous one.
+// The first point of each series is dropped.
+func derivative(e *State, varSet Results) (Results, error) {
+	return perPointPair(e, "derivative", varSet, func(prev, cur float64, d time.Duration) float64 {
+		return (cur - prev) / d.Seconds()
+	})
+}
+
+func counterIncrease(prev, cur float64, _ time.Duration) float64 {
+	if cur < prev { // counter reset
+		return cur
+	}
+	return cur - prev
+}
+
+// movingAvg returns, for each series, the mean of the window of the last n points at each point.
+// Null points in a window are ignored, and the first n-1 points of each series are dropped.
+func movingAvg(e *State, varSet Results, window Results) (Results, error) {
+	w, err := scalarArg("moving_avg", window)
+	if err != nil {
+		return Results{}, err
+	}
+	n := int(w)
+	if float64(n) != w || n < 1 {
+		return Results{}, fmt.Errorf("moving_avg window must be a positive integer, got %v", w)
+	}
+	newRes := Results{}
+	for _, val := range varSet.Values {
+		s, ok := val.(Series)
+		if !ok {
+			return newRes, fmt.Errorf("moving_avg can only be applied to series, got %v", val.Type())
+		}
+		newSeries := NewSeries(e.RefID, s.GetLabels(), 0)
+		for i := n - 1; i < s.Len(); i++ {
+			var sum float64
+			count := 0
+			for j := i - n + 1; j <= i; j++ {
+				f := s.GetValue(j)
+				if f == nil {
+					continue
+				}
+				sum += *f
+				count++
+			}
+			var nF *float64
+			if count > 0 {
+				avg := sum / float64(count)
+				nF = &avg
+			}
+			if err := newSeries.AppendPoint(i, s.GetTime(i), nF); err != nil {
+				return newRes, err
+			}
+		}
+		newRes.Values = append(newRes.Values, newSeries)
+	}
+	return newRes, nil
+}
+
+// cumulativeSum returns, for each series, the running total of the series.
+// Null points stay null and do not contribute to the total.
+func cumulativeSum(e *State, varSet Results) (Results, error) {
+	newRes := Results{}
+	for _, val := range varSet.Values {
+		s, ok := val.(Series)
+		if !ok {
+			return newRes, fmt.Errorf("cumulative_sum can only be applied to series, got %v", val.Type())
+		}
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		var sum float64
+		for i := 0; i < s.Len(); i++ {
+			t, f := s.GetPoint(i)
+			if f == nil {
+				if err := newSeries.SetPoint(i, t, nil); err != nil {
+					return newRes, err
+				}
+				continue
+			}
+			sum += *f
+			nF := sum
+			if err := newSeries.SetPoint(i, t, &nF); err != nil {
+				return newRes, err
+			}
+		}
+		newRes.Values = append(newRes.Values, newSeries)
+	}
+	return newRes, nil
+}
+
+// timeShift returns each series with its timestamps moved by the duration (e.g. "1h" or "-5m"),
+// so a series can be compared with itself at a different time.
+func timeShift(e *State, varSet Results, duration string) (Results, error) {
+	d, err := parseShiftDuration(duration)
+	if err != nil {
+		return Results{}, err
+	}
+	newRes := Results{}
+	for _, val := range varSet.Values {
+		s, ok := val.(Series)
+		if !ok {
+			return newRes, fmt.Errorf("time_shift can only be applied to series, got %v", val.Type())
+		}
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		for i := 0; i < s.Len(); i++ {
+			t, f := s.GetPoint(i)
+			if err := newSeries.SetPoint(i, t.Add(d), f); err != nil {
+				return newRes, err
+			}
+		}
+		newRes.Values = append(newRes.Values, newSeries)
+	}
+	return newRes, nil
+}
+
+func parseShiftDuration(s string) (time.Duration, error) {
+	neg := strings.HasPrefix(s, "-")
+	d, err := gtime.ParseDuration(strings.TrimPrefix(s, "-"))
+	if err != nil {
+		return 0, err
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+// checkWindowArg validates at parse time that a literal window argument is a positive integer.
+func checkWindowArg(t *parse.Tree, f *parse.FuncNode) error {
+	if n, ok := f.Args[1].(*parse.ScalarNode); ok {
+		if n.Float64 < 1 || n.Float64 != math.Trunc(n.Float64) {
+			return fmt.Errorf("parse: %s window must be a positive integer, got %v", f.Name, n.Float64)
+		}
+	}
+	return nil
+}
+
+// checkDurationArg validates at parse time the duration argument of a function.
+func checkDurationArg(t *parse.Tree, f *parse.FuncNode) error {
+	if n, ok := f.Args[1].(*parse.StringNode); ok {
+		if _, err := parseShiftDuration(n.Text); err != nil {
+			return fmt.Errorf("parse: invalid duration for %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// checkLabelsArg validates at parse time the labels argument of a function.
+func checkLabelsArg(t *parse.Tree, f *parse.FuncNode) error {
+	if n, ok := f.Args[1].(*parse.StringNode); ok {
+		if _, err := data.LabelsFromString(n.Text); err != nil {
+			return fmt.Errorf("parse: invalid labels for %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// scalarArg returns the value of a function argument that must be a single scalar.
+// A null scalar is returned as NaN.
+func scalarArg(funcName string, res Results) (float64, error) {
+	if len(res.Values) != 1 {
+		return 0, fmt.Errorf("%s expects a single scalar argument, got %v values", funcName, len(res.Values))
+	}
+	s, ok := res.Values[0].(Scalar)
+	if !ok {
+		return 0, fmt.Errorf("%s expects a scalar argument, got %v", funcName, res.Values[0].Type())
+	}
+	f := s.GetFloat64Value()
+	if f == nil {
+		return math.NaN(), nil
+	}
+	return *f, nil
+}
+
+// perPointPair applies pairF to every pair of consecutive points of each series in varSet. The
+// result at a point is null if either point of the pair is null. The first point of each series is dropped.
+func perPointPair(e *State, funcName string, varSet Results, pairF func(prev, cur float64, d time.Duration) float64) (Results, error) {
+	newRes := Results{}
+	for _, val := range varSet.Values {
+		s, ok := val.(Series)
+		if !ok {
+			return newRes, fmt.Errorf("%s can only be applied to series, got %v", funcName, val.Type())
+		}
+		newSeries := NewSeries(e.RefID, s.GetLabels(), 0)
+		for i := 1; i < s.Len(); i++ {
+			prevT, prevF := s.GetPoint(i - 1)
+			t, f := s.GetPoint(i)
+			if prevF == nil || f == nil {
+				if err := newSeries.AppendPoint(i-1, t, nil); err != nil {
+					return newRes, err
+				}
+				continue
+			}
+			nF := math.NaN()
+			if d := t.Sub(prevT); d > 0 {
+				nF = pairF(*prevF, *f, d)
+			}
+			if err := newSeries.AppendPoint(i-1, t, &nF); err != nil {
+				return newRes, err
+			}
+		}
+		newRes.Values = append(newRes.Values, newSeries)
+	}
+	return newRes, nil
+}
+
+// perFloatResults applies floatF to each value in NumberSet, SeriesSet, or Scalar.
+func perFloatResults(e *State, varSet Results, floatF func(x float64) float64) (Results, error) {
+	newRes := Results{}
+	for _, res := range varSet.Values {
+		newVal, err := perFloat(e, res, floatF)
+		if err != nil {
+			return newRes, err
+		}
+		newRes.Values = append(newRes.Values, newVal)
+	}
+	return newRes, nil
+}
+
 func perFloat(e *State, val Value, floatF func(x float64) float64) (Value, error) {
 	var newVal Value
 	switch val.Type() {
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -74,6 +75,277 @@ func TestFunc(t *testing.T) {
 			vars:     Vars{},
 			newErrIs: assert.Error,
 		},
+		{
+			name:     "empty argument - should error",
+			expr:     "pow($A,,2)",
+			vars:     Vars{},
+			newErrIs: assert.Error,
+		},
+		{
+			name:     "trailing comma - should error",
+			expr:     "pow($A, 2,)",
+			vars:     Vars{},
+			newErrIs: assert.Error,
+		},
+		{
+			name:      "sqrt on scalar",
+			expr:      "sqrt(16)",
+			vars:      Vars{},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results:   Results{[]Value{NewScalar("", float64Pointer(4))}},
+		},
+		{
+			name: "round on number",
+			expr: "round($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeNumber("", nil, float64Pointer(2.5)),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results:   Results{[]Value{makeNumber("", nil, float64Pointer(3))}},
+		},
+		{
+			name: "pow on series",
+			expr: "pow($A, 2)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil, tp{
+							time.Unix(5, 0), float64Pointer(3),
+						}, tp{
+							time.Unix(10, 0), float64Pointer(-2),
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", nil, tp{
+						time.Unix(5, 0), float64Pointer(9),
+					}, tp{
+						time.Unix(10, 0), float64Pointer(4),
+					}),
+				},
+			},
+		},
+		{
+			name: "clamp_min and clamp_max on series",
+			expr: "clamp_max(clamp_min($A, 0), 10)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil, tp{
+							time.Unix(5, 0), float64Pointer(-3),
+						}, tp{
+							time.Unix(10, 0), float64Pointer(5),
+						}, tp{
+							time.Unix(15, 0), float64Pointer(20),
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", nil, tp{
+						time.Unix(5, 0), float64Pointer(0),
+					}, tp{
+						time.Unix(10, 0), float64Pointer(5),
+					}, tp{
+						time.Unix(15, 0), float64Pointer(10),
+					}),
+				},
+			},
+		},
+		{
+			name: "rate on counter series with reset and null",
+			expr: "rate($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", data.Labels{"host": "a"}, tp{
+							time.Unix(0, 0), float64Pointer(10),
+						}, tp{
+							time.Unix(10, 0), float64Pointer(30),
+						}, tp{
+							time.Unix(20, 0), float64Pointer(5),
+						}, tp{
+							time.Unix(30, 0), nil,
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", data.Labels{"host": "a"}, tp{
+						time.Unix(10, 0), float64Pointer(2),
+					}, tp{
+						time.Unix(20, 0), float64Pointer(0.5),
+					}, tp{
+						time.Unix(30, 0), nil,
+					}),
+				},
+			},
+		},
+		{
+			name: "delta on series",
+			expr: "delta($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil, tp{
+							time.Unix(0, 0), float64Pointer(10),
+						}, tp{
+							time.Unix(10, 0), float64Pointer(4),
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", nil, tp{
+						time.Unix(10, 0), float64Pointer(-6),
+					}),
+				},
+			},
+		},
+		{
+			name: "rate on number - should error",
+			expr: "rate($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeNumber("", nil, float64Pointer(1)),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.Error,
+			resultIs:  assert.Equal,
+			results:   Results{},
+		},
+		{
+			name: "moving_avg on series",
+			expr: "moving_avg($A, 2)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil, tp{
+							time.Unix(0, 0), float64Pointer(1),
+						}, tp{
+							time.Unix(10, 0), float64Pointer(3),
+						}, tp{
+							time.Unix(20, 0), nil,
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", nil, tp{
+						time.Unix(10, 0), float64Pointer(2),
+					}, tp{
+						time.Unix(20, 0), float64Pointer(3),
+					}),
+				},
+			},
+		},
+		{
+			name:     "moving_avg with invalid window - should error",
+			expr:     "moving_avg($A, 1.5)",
+			vars:     Vars{},
+			newErrIs: assert.Error,
+		},
+		{
+			name: "cumulative_sum on series",
+			expr: "cumulative_sum($A)",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil, tp{
+							time.Unix(0, 0), float64Pointer(1),
+						}, tp{
+							time.Unix(10, 0), nil,
+						}, tp{
+							time.Unix(20, 0), float64Pointer(2),
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", nil, tp{
+						time.Unix(0, 0), float64Pointer(1),
+					}, tp{
+						time.Unix(10, 0), nil,
+					}, tp{
+						time.Unix(20, 0), float64Pointer(3),
+					}),
+				},
+			},
+		},
+		{
+			name: "time_shift on series",
+			expr: `time_shift($A, "-1m")`,
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("", nil, tp{
+							time.Unix(120, 0), float64Pointer(1),
+						}),
+					},
+				},
+			},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results: Results{
+				[]Value{
+					makeSeries("", nil, tp{
+						time.Unix(60, 0), float64Pointer(1),
+					}),
+				},
+			},
+		},
+		{
+			name:     "time_shift with invalid duration - should error",
+			expr:     `time_shift($A, "soon")`,
+			vars:     Vars{},
+			newErrIs: assert.Error,
+		},
+		{
+			name:      "vector with labels",
+			expr:      `vector(5, "host=a")`,
+			vars:      Vars{},
+			newErrIs:  assert.NoError,
+			execErrIs: assert.NoError,
+			resultIs:  assert.Equal,
+			results:   Results{[]Value{makeNumber("", data.Labels{"host": "a"}, float64Pointer(5))}},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -314,6 +314,8 @@ func (t *Tree) Func() (f *FuncNode) {
 	}
 	f = newFunc(token.pos, token.val, funcv)
 	t.expect(itemLeftParen, "func")
+	// afterComma is true when an argument is expected after a comma, empty arguments are not allowed.
+	afterComma := false
 	for {
 		switch token = t.next(); token.typ {
 		default:
@@ -329,9 +331,19 @@ func (t *Tree) Func() (f *FuncNode) {
 				t.errorf("Unquoting error: %s", err)
 			}
 			f.append(newString(token.pos, token.val, s))
+		case itemComma:
+			if len(f.Args) == 0 || afterComma {
+				t.unexpected(token, "func")
+			}
+			afterComma = true
+			continue
 		case itemRightParen:
+			if afterComma {
+				t.unexpected(token, "func")
+			}
 			return
 		}
+		afterComma = false
 	}
 }
 
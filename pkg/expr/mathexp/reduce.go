@@ -3,6 +3,9 @@ package mathexp
 import (
 	"fmt"
 	"math"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
@@ -69,16 +72,222 @@ func Count(fv *Float64Field) *float64 {
 	return &f
 }
 
-// Reduce turns the Series into a Number based on the given reduction function
-func (s Series) Reduce(refID, rFunc string) (Number, error) {
+// Last returns the last value of the series. If any values in the series are null or NaN,
+// or if the series is empty, NaN is returned.
+func Last(fv *Float64Field) *float64 {
+	if fv.Len() == 0 || hasNonNumber(fv) {
+		nan := math.NaN()
+		return &nan
+	}
+	f := *fv.GetValue(fv.Len() - 1)
+	return &f
+}
+
+// First returns the first value of the series. If any values in the series are null or NaN,
+// or if the series is empty, NaN is returned.
+func First(fv *Float64Field) *float64 {
+	if fv.Len() == 0 || hasNonNumber(fv) {
+		nan := math.NaN()
+		return &nan
+	}
+	f := *fv.GetValue(0)
+	return &f
+}
+
+// Median returns the median value of the series. If any values in the series are null or NaN,
+// or if the series is empty, NaN is returned.
+func Median(fv *Float64Field) *float64 {
+	return Percentile(fv, 50)
+}
+
+// Percentile returns the p-th percentile (0 <= p <= 100) of the series, linearly interpolated
+// between the closest ranks. If any values in the series are null or NaN, or if the series
+// is empty, NaN is returned.
+func Percentile(fv *Float64Field, p float64) *float64 {
+	if fv.Len() == 0 || hasNonNumber(fv) || p < 0 || p > 100 {
+		nan := math.NaN()
+		return &nan
+	}
+	vals := make([]float64, fv.Len())
+	for i := 0; i < fv.Len(); i++ {
+		vals[i] = *fv.GetValue(i)
+	}
+	sort.Float64s(vals)
+	rank := p / 100 * float64(len(vals)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	f := vals[lower] + (vals[upper]-vals[lower])*(rank-float64(lower))
+	return &f
+}
+
+// StdDev returns the population standard deviation of the series. If any values in the series
+// are null or NaN, or if the series is empty, NaN is returned.
+func StdDev(fv *Float64Field) *float64 {
+	if fv.Len() == 0 {
+		nan := math.NaN()
+		return &nan
+	}
+	mean := Avg(fv)
+	if math.IsNaN(*mean) {
+		return mean
+	}
+	var sumSquares float64
+	for i := 0; i < fv.Len(); i++ {
+		d := *fv.GetValue(i) - *mean
+		sumSquares += d * d
+	}
+	f := math.Sqrt(sumSquares / float64(fv.Len()))
+	return &f
+}
+
+// Range returns the difference between the largest and the smallest value in the series.
+// If any values in the series are null or NaN, or if the series is empty, NaN is returned.
+func Range(fv *Float64Field) *float64 {
+	f := *Max(fv) - *Min(fv)
+	return &f
+}
+
+// Diff returns the difference between the last and the first value of the series.
+// If any values in the series are null or NaN, or if the series is empty, NaN is returned.
+func Diff(fv *Float64Field) *float64 {
+	f := *Last(fv) - *First(fv)
+	return &f
+}
+
+// DiffAbs returns the absolute difference between the last and the first value of the series.
+func DiffAbs(fv *Float64Field) *float64 {
+	f := math.Abs(*Diff(fv))
+	return &f
+}
+
+// PercentDiff returns the difference between the last and the first value of the series as a
+// percentage of the absolute first value.
+func PercentDiff(fv *Float64Field) *float64 {
+	f := *Diff(fv) / math.Abs(*First(fv)) * 100
+	return &f
+}
+
+// PercentDiffAbs returns the absolute value of PercentDiff.
+func PercentDiffAbs(fv *Float64Field) *float64 {
+	f := math.Abs(*PercentDiff(fv))
+	return &f
+}
+
+// CountNonNull returns the number of values in the series that are neither null nor NaN.
+func CountNonNull(fv *Float64Field) *float64 {
+	var f float64
+	for i := 0; i < fv.Len(); i++ {
+		v := fv.GetValue(i)
+		if v != nil && !math.IsNaN(*v) {
+			f++
+		}
+	}
+	return &f
+}
+
+func hasNonNumber(fv *Float64Field) bool {
+	for i := 0; i < fv.Len(); i++ {
+		v := fv.GetValue(i)
+		if v == nil || math.IsNaN(*v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReduceMapper maps the values of a series before it is reduced,
+// changing how the reducers handle non-numeric values.
+type ReduceMapper interface {
+	MapInput(s Series) Series
+}
+
+// DropNonNumber is a ReduceMapper that removes null and NaN values from the series.
+type DropNonNumber struct{}
+
+// MapInput returns a new series without the null and NaN values of s.
+func (d DropNonNumber) MapInput(s Series) Series {
+	newSeries := NewSeries(s.GetName(), s.GetLabels(), 0)
+	for i := 0; i < s.Len(); i++ {
+		t, f := s.GetPoint(i)
+		if f == nil || math.IsNaN(*f) {
+			continue
+		}
+		_ = newSeries.AppendPoint(i, t, f)
+	}
+	return newSeries
+}
+
+// ReplaceNonNumberWithValue is a ReduceMapper that replaces null and NaN values of the series with Value.
+type ReplaceNonNumberWithValue struct {
+	Value float64
+}
+
+// MapInput returns a new series where the null and NaN values of s are replaced.
+func (r ReplaceNonNumberWithValue) MapInput(s Series) Series {
+	newSeries := NewSeries(s.GetName(), s.GetLabels(), s.Len())
+	for i := 0; i < s.Len(); i++ {
+		t, f := s.GetPoint(i)
+		if f == nil || math.IsNaN(*f) {
+			v := r.Value
+			f = &v
+		}
+		_ = newSeries.SetPoint(i, t, f)
+	}
+	return newSeries
+}
+
+// IsValidReducer returns an error if rFunc is not a reduction function supported by Series.Reduce.
+func IsValidReducer(rFunc string) error {
+	if _, ok, err := parsePercentile(rFunc); ok {
+		return err
+	}
+	switch rFunc {
+	case "sum", "mean", "min", "max", "count", "last", "first", "median", "stddev",
+		"range", "diff", "diff_abs", "percent_diff", "percent_diff_abs", "count_non_null":
+		return nil
+	default:
+		return fmt.Errorf("reduction %v not implemented", rFunc)
+	}
+}
+
+// parsePercentile parses a reducer of the form "percentile(N)". ok is false when
+// rFunc is not a percentile reducer.
+func parsePercentile(rFunc string) (p float64, ok bool, err error) {
+	if !strings.HasPrefix(rFunc, "percentile(") || !strings.HasSuffix(rFunc, ")") {
+		return 0, false, nil
+	}
+	raw := strings.TrimSuffix(strings.TrimPrefix(rFunc, "percentile("), ")")
+	p, err = strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid percentile in reduction %v: %w", rFunc, err)
+	}
+	if p < 0 || p > 100 {
+		return 0, true, fmt.Errorf("invalid percentile in reduction %v: must be between 0 and 100", rFunc)
+	}
+	return p, true, nil
+}
+
+// Reduce turns the Series into a Number based on the given reduction function.
+// If mapper is not nil, the series is mapped with it before being reduced.
+func (s Series) Reduce(refID, rFunc string, mapper ReduceMapper) (Number, error) {
 	var l data.Labels
 	if s.GetLabels() != nil {
 		l = s.GetLabels().Copy()
 	}
 	number := NewNumber(refID, l)
+	if mapper != nil {
+		s = mapper.MapInput(s)
+	}
 	var f *float64
 	fVec := s.Frame.Fields[seriesTypeValIdx]
 	floatField := Float64Field(*fVec)
+	if p, ok, err := parsePercentile(rFunc); ok {
+		if err != nil {
+			return number, err
+		}
+		number.SetValue(Percentile(&floatField, p))
+		return number, nil
+	}
 	switch rFunc {
 	case "sum":
 		f = Sum(&floatField)
@@ -90,6 +299,26 @@ func (s Series) Reduce(refID, rFunc string) (Number, error) {
 		f = Max(&floatField)
 	case "count":
 		f = Count(&floatField)
+	case "last":
+		f = Last(&floatField)
+	case "first":
+		f = First(&floatField)
+	case "median":
+		f = Median(&floatField)
+	case "stddev":
+		f = StdDev(&floatField)
+	case "range":
+		f = Range(&floatField)
+	case "diff":
+		f = Diff(&floatField)
+	case "diff_abs":
+		f = DiffAbs(&floatField)
+	case "percent_diff":
+		f = PercentDiff(&floatField)
+	case "percent_diff_abs":
+		f = PercentDiffAbs(&floatField)
+	case "count_non_null":
+		f = CountNonNull(&floatField)
 	default:
 		return number, fmt.Errorf("reduction %v not implemented", rFunc)
 	}
@@ -34,6 +34,7 @@ func TestSeriesReduce(t *testing.T) {
 	var tests = []struct {
 		name        string
 		red         string
+		mapper      ReduceMapper
 		vars        Vars
 		varToReduce string
 		errIs       require.ErrorAssertionFunc
@@ -214,6 +215,188 @@ func TestSeriesReduce(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "last series",
+			red:         "last",
+			varToReduce: "A",
+			vars:        aSeries,
+			errIs:       require.NoError,
+			resultsIs:   require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, float64Pointer(1)),
+				},
+			},
+		},
+		{
+			name:        "last series with a nil value",
+			red:         "last",
+			varToReduce: "A",
+			vars:        seriesWithNil,
+			errIs:       require.NoError,
+			resultsIs:   require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, NaN),
+				},
+			},
+		},
+		{
+			name:        "last series with a nil value dropping non numbers",
+			red:         "last",
+			mapper:      DropNonNumber{},
+			varToReduce: "A",
+			vars:        seriesWithNil,
+			errIs:       require.NoError,
+			resultsIs:   require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, float64Pointer(2)),
+				},
+			},
+		},
+		{
+			name:        "sum series with a nil value replacing non numbers",
+			red:         "sum",
+			mapper:      ReplaceNonNumberWithValue{Value: 5},
+			varToReduce: "A",
+			vars:        seriesWithNil,
+			errIs:       require.NoError,
+			resultsIs:   require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, float64Pointer(7)),
+				},
+			},
+		},
+		{
+			name:        "count_non_null series with a nil value",
+			red:         "count_non_null",
+			varToReduce: "A",
+			vars:        seriesWithNil,
+			errIs:       require.NoError,
+			resultsIs:   require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, float64Pointer(1)),
+				},
+			},
+		},
+		{
+			name:        "first series",
+			red:         "first",
+			varToReduce: "A",
+			vars:        aSeries,
+			errIs:       require.NoError,
+			resultsIs:   require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, float64Pointer(2)),
+				},
+			},
+		},
+		{
+			name:        "diff series",
+			red:         "diff",
+			varToReduce: "A",
+			vars:        aSeries,
+			errIs:       require.NoError,
+			resultsIs:   require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, float64Pointer(-1)),
+				},
+			},
+		},
+		{
+			name:        "percent_diff series",
+			red:         "percent_diff",
+			varToReduce: "A",
+			vars:        aSeries,
+			errIs:       require.NoError,
+			resultsIs:   require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, float64Pointer(-50)),
+				},
+			},
+		},
+		{
+			name:        "range series",
+			red:         "range",
+			varToReduce: "A",
+			vars:        aSeries,
+			errIs:       require.NoError,
+			resultsIs:   require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, float64Pointer(1)),
+				},
+			},
+		},
+		{
+			name:        "median series",
+			red:         "median",
+			varToReduce: "A",
+			vars:        aSeries,
+			errIs:       require.NoError,
+			resultsIs:   require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, float64Pointer(1.5)),
+				},
+			},
+		},
+		{
+			name:        "stddev series",
+			red:         "stddev",
+			varToReduce: "A",
+			vars:        aSeries,
+			errIs:       require.NoError,
+			resultsIs:   require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, float64Pointer(0.5)),
+				},
+			},
+		},
+		{
+			name:        "percentile series",
+			red:         "percentile(75)",
+			varToReduce: "A",
+			vars: Vars{
+				"A": Results{
+					[]Value{
+						makeSeries("temp", nil, tp{
+							time.Unix(5, 0), float64Pointer(4),
+						}, tp{
+							time.Unix(10, 0), float64Pointer(1),
+						}, tp{
+							time.Unix(15, 0), float64Pointer(2),
+						}, tp{
+							time.Unix(20, 0), float64Pointer(3),
+						}, tp{
+							time.Unix(25, 0), float64Pointer(5),
+						}),
+					},
+				},
+			},
+			errIs:     require.NoError,
+			resultsIs: require.Equal,
+			results: Results{
+				[]Value{
+					makeNumber("", nil, float64Pointer(4)),
+				},
+			},
+		},
+		{
+			name:        "invalid percentile will error",
+			red:         "percentile(101)",
+			varToReduce: "A",
+			vars:        aSeries,
+			errIs:       require.Error,
+			resultsIs:   require.Equal,
+		},
 	}
 
 	for _, tt := range tests {
@@ -221,7 +404,7 @@ func TestSeriesReduce(t *testing.T) {
 			results := Results{}
 			seriesSet := tt.vars[tt.varToReduce]
 			for _, series := range seriesSet.Values {
-				ns, err := series.Value().(*Series).Reduce("", tt.red)
+				ns, err := series.Value().(*Series).Reduce("", tt.red, tt.mapper)
 				tt.errIs(t, err)
 				if err != nil {
 					return
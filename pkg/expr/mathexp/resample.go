@@ -7,6 +7,24 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 )
 
+// IsValidDownsampler returns true if the downsampler is supported by Resample.
+func IsValidDownsampler(downsampler string) bool {
+	switch downsampler {
+	case "sum", "mean", "min", "max", "median", "first", "last":
+		return true
+	}
+	return false
+}
+
+// IsValidUpsampler returns true if the upsampler is supported by Resample.
+func IsValidUpsampler(upsampler string) bool {
+	switch upsampler {
+	case "pad", "backfilling", "linear", "fillna":
+		return true
+	}
+	return false
+}
+
 // Resample turns the Series into a Number based on the given reduction function
 func (s Series) Resample(refID string, interval time.Duration, downsampler string, upsampler string, from, to time.Time) (Series, error) {
 	newSeriesLength := int(float64(to.Sub(from).Nanoseconds()) / float64(interval.Nanoseconds()))
@@ -16,6 +34,7 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler strin
 	resampled := NewSeries(refID, s.GetLabels(), newSeriesLength+1)
 	bookmark := 0
 	var lastSeen *float64
+	var lastSeenTime time.Time
 	idx := 0
 	t := from
 	for !t.After(to) && idx <= newSeriesLength {
@@ -32,6 +51,7 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler strin
 			bookmark++
 			sIdx++
 			lastSeen = v
+			lastSeenTime = st
 			vals = append(vals, v)
 		}
 		var value *float64
@@ -49,6 +69,13 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler strin
 				} else {
 					_, value = s.GetPoint(sIdx)
 				}
+			case "linear":
+				if sIdx == s.Len() || bookmark == 0 { // no point on one of the sides
+					value = nil
+				} else {
+					nextTime, next := s.GetPoint(sIdx)
+					value = interpolate(t, lastSeenTime, lastSeen, nextTime, next)
+				}
 			case "fillna":
 				value = nil
 			default:
@@ -67,6 +94,12 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler strin
 				tmp = Min(&ff)
 			case "max":
 				tmp = Max(&ff)
+			case "median":
+				tmp = Median(&ff)
+			case "first":
+				tmp = vals[0]
+			case "last":
+				tmp = vals[len(vals)-1]
 			default:
 				return s, fmt.Errorf("downsampling %v not implemented", downsampler)
 			}
@@ -80,3 +113,35 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler strin
 	}
 	return resampled, nil
 }
+
+// interpolate returns the value at t on the line between the points (t1, v1) and (t2, v2),
+// or nil if one of the values is nil.
+func interpolate(t, t1 time.Time, v1 *float64, t2 time.Time, v2 *float64) *float64 {
+	if v1 == nil || v2 == nil {
+		return nil
+	}
+	span := t2.Sub(t1)
+	if span <= 0 {
+		return v1
+	}
+	ratio := float64(t.Sub(t1)) / float64(span)
+	f := *v1 + (*v2-*v1)*ratio
+	return &f
+}
+
+// AlignToWallClock returns the first time at or after t that is on a boundary of the interval
+// in the location loc, counting from the start of the day of t in loc. For example, with an
+// interval of 15 minutes, the result is the next quarter of an hour of the clock in loc.
+func AlignToWallClock(t time.Time, interval time.Duration, loc *time.Location) time.Time {
+	if interval <= 0 {
+		return t
+	}
+	local := t.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	sinceMidnight := local.Sub(midnight)
+	aligned := midnight.Add(sinceMidnight / interval * interval)
+	if aligned.Before(local) {
+		aligned = aligned.Add(interval)
+	}
+	return aligned.In(t.Location())
+}
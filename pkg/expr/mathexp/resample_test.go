@@ -245,6 +245,112 @@ func TestResampleSeries(t *testing.T) {
 				time.Unix(10, 0), nil,
 			}),
 		},
+		{
+			name:        "resample series: downsampling (last / fillna)",
+			interval:    time.Second * 3,
+			downsampler: "last",
+			upsampler:   "fillna",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(6, 0),
+			},
+			seriesToResample: makeSeries("", nil, tp{
+				time.Unix(1, 0), float64Pointer(3),
+			}, tp{
+				time.Unix(2, 0), float64Pointer(1),
+			}, tp{
+				time.Unix(3, 0), float64Pointer(2),
+			}, tp{
+				time.Unix(5, 0), float64Pointer(4),
+			}),
+			series: makeSeries("", nil, tp{
+				time.Unix(0, 0), nil,
+			}, tp{
+				time.Unix(3, 0), float64Pointer(2),
+			}, tp{
+				time.Unix(6, 0), float64Pointer(4),
+			}),
+		},
+		{
+			name:        "resample series: downsampling (first / fillna)",
+			interval:    time.Second * 3,
+			downsampler: "first",
+			upsampler:   "fillna",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(6, 0),
+			},
+			seriesToResample: makeSeries("", nil, tp{
+				time.Unix(1, 0), float64Pointer(3),
+			}, tp{
+				time.Unix(2, 0), float64Pointer(1),
+			}, tp{
+				time.Unix(3, 0), float64Pointer(2),
+			}, tp{
+				time.Unix(5, 0), float64Pointer(4),
+			}),
+			series: makeSeries("", nil, tp{
+				time.Unix(0, 0), nil,
+			}, tp{
+				time.Unix(3, 0), float64Pointer(3),
+			}, tp{
+				time.Unix(6, 0), float64Pointer(4),
+			}),
+		},
+		{
+			name:        "resample series: downsampling (median / fillna)",
+			interval:    time.Second * 3,
+			downsampler: "median",
+			upsampler:   "fillna",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(6, 0),
+			},
+			seriesToResample: makeSeries("", nil, tp{
+				time.Unix(1, 0), float64Pointer(3),
+			}, tp{
+				time.Unix(2, 0), float64Pointer(1),
+			}, tp{
+				time.Unix(3, 0), float64Pointer(2),
+			}, tp{
+				time.Unix(5, 0), float64Pointer(4),
+			}),
+			series: makeSeries("", nil, tp{
+				time.Unix(0, 0), nil,
+			}, tp{
+				time.Unix(3, 0), float64Pointer(2),
+			}, tp{
+				time.Unix(6, 0), float64Pointer(4),
+			}),
+		},
+		{
+			name:        "resample series: upsampling (mean / linear)",
+			interval:    time.Second,
+			downsampler: "mean",
+			upsampler:   "linear",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(5, 0),
+			},
+			seriesToResample: makeSeries("", nil, tp{
+				time.Unix(0, 0), float64Pointer(0),
+			}, tp{
+				time.Unix(4, 0), float64Pointer(8),
+			}),
+			series: makeSeries("", nil, tp{
+				time.Unix(0, 0), float64Pointer(0),
+			}, tp{
+				time.Unix(1, 0), float64Pointer(2),
+			}, tp{
+				time.Unix(2, 0), float64Pointer(4),
+			}, tp{
+				time.Unix(3, 0), float64Pointer(6),
+			}, tp{
+				time.Unix(4, 0), float64Pointer(8),
+			}, tp{
+				time.Unix(5, 0), nil,
+			}),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -258,3 +364,53 @@ func TestResampleSeries(t *testing.T) {
 		})
 	}
 }
+
+func TestAlignToWallClock(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	var tests = []struct {
+		name     string
+		t        time.Time
+		interval time.Duration
+		loc      *time.Location
+		expected time.Time
+	}{
+		{
+			name:     "next minute",
+			t:        time.Date(2021, 6, 1, 10, 4, 30, 0, time.UTC),
+			interval: time.Minute,
+			loc:      time.UTC,
+			expected: time.Date(2021, 6, 1, 10, 5, 0, 0, time.UTC),
+		},
+		{
+			name:     "already aligned",
+			t:        time.Date(2021, 6, 1, 10, 15, 0, 0, time.UTC),
+			interval: 15 * time.Minute,
+			loc:      time.UTC,
+			expected: time.Date(2021, 6, 1, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "next hour in a half-hour offset timezone",
+			t:        time.Date(2021, 6, 1, 10, 10, 0, 0, time.UTC),
+			interval: time.Hour,
+			loc:      kolkata,
+			expected: time.Date(2021, 6, 1, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "next day in a timezone",
+			t:        time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC),
+			interval: 24 * time.Hour,
+			loc:      paris,
+			expected: time.Date(2021, 6, 1, 22, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aligned := AlignToWallClock(tt.t, tt.interval, tt.loc)
+			require.True(t, tt.expected.Equal(aligned), "expected %v, got %v", tt.expected, aligned)
+		})
+	}
+}
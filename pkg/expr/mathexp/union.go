@@ -0,0 +1,181 @@
+package mathexp
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
+)
+
+// UnionOptions controls how the values on each side of a binary operation are matched
+// by their labels. The zero value keeps the default behavior of union.
+type UnionOptions struct {
+	// On, when set, restricts the labels used to match values to this list.
+	On []string `json:"on,omitempty"`
+	// Ignoring, when set, excludes these labels when matching values.
+	Ignoring []string `json:"ignoring,omitempty"`
+	// Group allows many-to-one matching: with GroupLeft, each value of the right side can match
+	// several values of the left side and the labels of the left side are kept.
+	// GroupRight is the opposite.
+	Group UnionGroup `json:"group,omitempty"`
+	// OnMismatch defines what happens to values that match no value on the other side.
+	OnMismatch MismatchMode `json:"onMismatch,omitempty"`
+}
+
+// UnionGroup is the side with many values in a many-to-one binary operation.
+type UnionGroup string
+
+const (
+	// GroupNone allows one-to-one matching only.
+	GroupNone UnionGroup = ""
+	// GroupLeft allows many-to-one matching where the left side has many values.
+	GroupLeft UnionGroup = "left"
+	// GroupRight allows one-to-many matching where the right side has many values.
+	GroupRight UnionGroup = "right"
+)
+
+// MismatchMode defines how a binary operation handles the values that do not match.
+type MismatchMode string
+
+const (
+	// MismatchDrop silently drops the values without a match.
+	MismatchDrop MismatchMode = ""
+	// MismatchWarn drops the values without a match and adds a warning notice to the results.
+	MismatchWarn MismatchMode = "warn"
+	// MismatchError fails the operation when a value has no match.
+	MismatchError MismatchMode = "error"
+)
+
+// Validate returns an error if the options are invalid.
+func (o UnionOptions) Validate() error {
+	if len(o.On) > 0 && len(o.Ignoring) > 0 {
+		return fmt.Errorf("on and ignoring can not be used together")
+	}
+	switch o.Group {
+	case GroupNone, GroupLeft, GroupRight:
+	default:
+		return fmt.Errorf("invalid group '%v', must be left or right", o.Group)
+	}
+	switch o.OnMismatch {
+	case MismatchDrop, MismatchWarn, MismatchError:
+	default:
+		return fmt.Errorf("invalid mismatch mode '%v', must be warn or error", o.OnMismatch)
+	}
+	return nil
+}
+
+// isLabelMatching returns true if the options require values to be matched
+// with explicit labels instead of the default union behavior.
+func (o UnionOptions) isLabelMatching() bool {
+	return len(o.On) > 0 || len(o.Ignoring) > 0 || o.Group != GroupNone
+}
+
+// matchingLabels returns the labels of l that are used to match it with another value.
+func (o UnionOptions) matchingLabels(l data.Labels) data.Labels {
+	matching := data.Labels{}
+	if len(o.On) > 0 {
+		for _, k := range o.On {
+			matching[k] = l[k]
+		}
+		return matching
+	}
+	ignored := make(map[string]struct{}, len(o.Ignoring))
+	for _, k := range o.Ignoring {
+		ignored[k] = struct{}{}
+	}
+	for k, v := range l {
+		if _, ok := ignored[k]; !ok {
+			matching[k] = v
+		}
+	}
+	return matching
+}
+
+// matchUnion creates Union objects by matching the values of aResults and bResults on the labels
+// selected by the options, in the way of Prometheus vector matching. A Scalar matches every value
+// of the other side. Without a group, a value matching several values of the other side is an error.
+func matchUnion(aResults, bResults Results, opts UnionOptions) ([]*Union, error) {
+	unions := []*Union{}
+	if len(aResults.Values) == 0 || len(bResults.Values) == 0 {
+		return unions, nil
+	}
+
+	bySignature := func(vals Values) map[string][]Value {
+		m := make(map[string][]Value)
+		for _, v := range vals {
+			if v.Type() == parse.TypeScalar {
+				continue
+			}
+			sig := opts.matchingLabels(v.GetLabels()).String()
+			m[sig] = append(m[sig], v)
+		}
+		return m
+	}
+	aBySig := bySignature(aResults.Values)
+	bBySig := bySignature(bResults.Values)
+
+	for _, a := range aResults.Values {
+		if a.Type() == parse.TypeScalar {
+			for _, b := range bResults.Values {
+				unions = append(unions, &Union{Labels: b.GetLabels(), A: a, B: b})
+			}
+			continue
+		}
+		aMatching := opts.matchingLabels(a.GetLabels())
+		sig := aMatching.String()
+		for _, b := range bResults.Values {
+			if b.Type() == parse.TypeScalar {
+				unions = append(unions, &Union{Labels: a.GetLabels(), A: a, B: b})
+			}
+		}
+		bs := bBySig[sig]
+		if len(bs) == 0 {
+			continue
+		}
+		var labels data.Labels
+		switch opts.Group {
+		case GroupLeft:
+			if len(bs) > 1 {
+				return nil, fmt.Errorf("found duplicate series for the match group {%v} on the right side of the operation, many-to-many matching is not allowed", sig)
+			}
+			labels = a.GetLabels()
+		case GroupRight:
+			if len(aBySig[sig]) > 1 {
+				return nil, fmt.Errorf("found duplicate series for the match group {%v} on the left side of the operation, many-to-many matching is not allowed", sig)
+			}
+		default:
+			if len(bs) > 1 || len(aBySig[sig]) > 1 {
+				return nil, fmt.Errorf("found duplicate series for the match group {%v}, many-to-one matching must be explicit with a group", sig)
+			}
+			labels = aMatching
+		}
+		for _, b := range bs {
+			l := labels
+			if opts.Group == GroupRight {
+				l = b.GetLabels()
+			}
+			unions = append(unions, &Union{Labels: l, A: a, B: b})
+		}
+	}
+	return unions, nil
+}
+
+// unmatched returns the number of values of aResults and bResults that are not part of any union.
+func unmatched(aResults, bResults Results, unions []*Union) (aCount, bCount int) {
+	matched := make(map[*data.Frame]struct{}, 2*len(unions))
+	for _, u := range unions {
+		matched[u.A.AsDataFrame()] = struct{}{}
+		matched[u.B.AsDataFrame()] = struct{}{}
+	}
+	for _, a := range aResults.Values {
+		if _, ok := matched[a.AsDataFrame()]; !ok {
+			aCount++
+		}
+	}
+	for _, b := range bResults.Values {
+		if _, ok := matched[b.AsDataFrame()]; !ok {
+			bCount++
+		}
+	}
+	return aCount, bCount
+}
@@ -242,3 +242,183 @@ func Test_union(t *testing.T) {
 		})
 	}
 }
+
+func Test_matchUnion(t *testing.T) {
+	var tests = []struct {
+		name      string
+		aResults  Results
+		bResults  Results
+		opts      UnionOptions
+		errIs     assert.ErrorAssertionFunc
+		unionsAre assert.ComparisonAssertionFunc
+		unions    []*Union
+	}{
+		{
+			name: "on matches on the listed labels only",
+			aResults: Results{
+				Values: Values{
+					makeSeries("a", data.Labels{"host": "a", "job": "prom"}),
+					makeSeries("b", data.Labels{"host": "b", "job": "prom"}),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeSeries("aa", data.Labels{"host": "a", "source": "influx"}),
+				},
+			},
+			opts:      UnionOptions{On: []string{"host"}},
+			errIs:     assert.NoError,
+			unionsAre: assert.EqualValues,
+			unions: []*Union{
+				{
+					Labels: data.Labels{"host": "a"},
+					A:      makeSeries("a", data.Labels{"host": "a", "job": "prom"}),
+					B:      makeSeries("aa", data.Labels{"host": "a", "source": "influx"}),
+				},
+			},
+		},
+		{
+			name: "ignoring matches on the other labels",
+			aResults: Results{
+				Values: Values{
+					makeSeries("a", data.Labels{"host": "a", "job": "prom"}),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeSeries("aa", data.Labels{"host": "a", "job": "influx"}),
+				},
+			},
+			opts:      UnionOptions{Ignoring: []string{"job"}},
+			errIs:     assert.NoError,
+			unionsAre: assert.EqualValues,
+			unions: []*Union{
+				{
+					Labels: data.Labels{"host": "a"},
+					A:      makeSeries("a", data.Labels{"host": "a", "job": "prom"}),
+					B:      makeSeries("aa", data.Labels{"host": "a", "job": "influx"}),
+				},
+			},
+		},
+		{
+			name: "many to one without group errors",
+			aResults: Results{
+				Values: Values{
+					makeSeries("a", data.Labels{"host": "a", "cpu": "0"}),
+					makeSeries("b", data.Labels{"host": "a", "cpu": "1"}),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeSeries("aa", data.Labels{"host": "a"}),
+				},
+			},
+			opts:  UnionOptions{On: []string{"host"}},
+			errIs: assert.Error,
+		},
+		{
+			name: "group left keeps the labels of the left side",
+			aResults: Results{
+				Values: Values{
+					makeSeries("a", data.Labels{"host": "a", "cpu": "0"}),
+					makeSeries("b", data.Labels{"host": "a", "cpu": "1"}),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeSeries("aa", data.Labels{"host": "a"}),
+				},
+			},
+			opts:      UnionOptions{On: []string{"host"}, Group: GroupLeft},
+			errIs:     assert.NoError,
+			unionsAre: assert.EqualValues,
+			unions: []*Union{
+				{
+					Labels: data.Labels{"host": "a", "cpu": "0"},
+					A:      makeSeries("a", data.Labels{"host": "a", "cpu": "0"}),
+					B:      makeSeries("aa", data.Labels{"host": "a"}),
+				},
+				{
+					Labels: data.Labels{"host": "a", "cpu": "1"},
+					A:      makeSeries("b", data.Labels{"host": "a", "cpu": "1"}),
+					B:      makeSeries("aa", data.Labels{"host": "a"}),
+				},
+			},
+		},
+		{
+			name: "group right keeps the labels of the right side",
+			aResults: Results{
+				Values: Values{
+					makeSeries("a", data.Labels{"host": "a"}),
+				},
+			},
+			bResults: Results{
+				Values: Values{
+					makeSeries("aa", data.Labels{"host": "a", "cpu": "0"}),
+				},
+			},
+			opts:      UnionOptions{On: []string{"host"}, Group: GroupRight},
+			errIs:     assert.NoError,
+			unionsAre: assert.EqualValues,
+			unions: []*Union{
+				{
+					Labels: data.Labels{"host": "a", "cpu": "0"},
+					A:      makeSeries("a", data.Labels{"host": "a"}),
+					B:      makeSeries("aa", data.Labels{"host": "a", "cpu": "0"}),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unions, err := matchUnion(tt.aResults, tt.bResults, tt.opts)
+			tt.errIs(t, err)
+			if err == nil {
+				tt.unionsAre(t, tt.unions, unions)
+			}
+		})
+	}
+}
+
+func TestExecuteWithUnionOptionsMismatch(t *testing.T) {
+	vars := Vars{
+		"A": Results{
+			Values: Values{
+				makeNumber("", data.Labels{"host": "a"}, float64Pointer(1)),
+				makeNumber("", data.Labels{"host": "b"}, float64Pointer(2)),
+			},
+		},
+		"B": Results{
+			Values: Values{
+				makeNumber("", data.Labels{"host": "a", "dc": "x"}, float64Pointer(3)),
+			},
+		},
+	}
+	e, err := New("$A + $B")
+	assert.NoError(t, err)
+
+	t.Run("drop", func(t *testing.T) {
+		res, err := e.ExecuteWithUnionOptions("", vars, UnionOptions{On: []string{"host"}})
+		assert.NoError(t, err)
+		assert.Len(t, res.Values, 1)
+		assert.Nil(t, res.Values[0].AsDataFrame().Meta)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		res, err := e.ExecuteWithUnionOptions("", vars, UnionOptions{On: []string{"host"}, OnMismatch: MismatchWarn})
+		assert.NoError(t, err)
+		assert.Len(t, res.Values, 1)
+		assert.Len(t, res.Values[0].AsDataFrame().Meta.Notices, 1)
+		assert.Equal(t, data.NoticeSeverityWarning, res.Values[0].AsDataFrame().Meta.Notices[0].Severity)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := e.ExecuteWithUnionOptions("", vars, UnionOptions{On: []string{"host"}, OnMismatch: MismatchError})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := e.ExecuteWithUnionOptions("", vars, UnionOptions{On: []string{"host"}, Ignoring: []string{"dc"}})
+		assert.Error(t, err)
+	})
+}
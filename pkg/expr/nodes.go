@@ -115,6 +115,10 @@ func buildCMDNode(dp *simple.DirectedGraph, rn *rawNode) (*CMDNode, error) {
 		node.Command, err = UnmarshalResampleCommand(rn)
 	case TypeClassicConditions:
 		node.Command, err = classic.UnmarshalConditionsCmd(rn.Query, rn.RefID)
+	case TypeThreshold:
+		node.Command, err = UnmarshalThresholdCommand(rn)
+	case TypeSQL:
+		node.Command, err = UnmarshalSQLCommand(rn)
 	default:
 		return nil, fmt.Errorf("expression command type '%v' in '%v' not implemented", commandType, rn.RefID)
 	}
@@ -372,3 +376,76 @@ func WideToMany(frame *data.Frame) ([]mathexp.Series, error) {
 
 	return series, nil
 }
+
+// queryKey returns a key that is identical for the datasource nodes that send the
+// same query, so that the query is only executed once per request. The refId and
+// hide properties of the query are not part of the key as they do not change its results.
+func (dn *DSNode) queryKey() (string, error) {
+	var query map[string]interface{}
+	if err := json.Unmarshal(dn.query, &query); err != nil {
+		return "", err
+	}
+	delete(query, "refId")
+	delete(query, "hide")
+
+	// maps are marshalled with sorted keys, so the key does not depend on the order of the properties.
+	key, err := json.Marshal(struct {
+		DatasourceID  int64
+		DatasourceUID string
+		OrgID         int64
+		QueryType     string
+		From          int64
+		To            int64
+		IntervalMS    int64
+		MaxDP         int64
+		Query         map[string]interface{}
+	}{
+		DatasourceID:  dn.datasourceID,
+		DatasourceUID: dn.datasourceUID,
+		OrgID:         dn.orgID,
+		QueryType:     dn.queryType,
+		From:          dn.timeRange.From.UnixNano(),
+		To:            dn.timeRange.To.UnixNano(),
+		IntervalMS:    dn.intervalMS,
+		MaxDP:         dn.maxDP,
+		Query:         query,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+// copyResults returns a deep copy of the results, so that nodes sharing the results
+// of a query can change their frames (e.g. their RefID) independently.
+func copyResults(res mathexp.Results) mathexp.Results {
+	vals := make([]mathexp.Value, 0, len(res.Values))
+	for _, val := range res.Values {
+		switch v := val.(type) {
+		case mathexp.Series:
+			vals = append(vals, mathexp.Series{Frame: copyFrame(v.Frame)})
+		case mathexp.Number:
+			vals = append(vals, mathexp.Number{Frame: copyFrame(v.Frame)})
+		case mathexp.Scalar:
+			vals = append(vals, mathexp.Scalar{Frame: copyFrame(v.Frame)})
+		default:
+			vals = append(vals, val)
+		}
+	}
+	return mathexp.Results{Values: vals}
+}
+
+func copyFrame(frame *data.Frame) *data.Frame {
+	newFrame := frame.EmptyCopy()
+	for i, field := range frame.Fields {
+		newFrame.Fields[i].Config = field.Config
+	}
+	if frame.Meta != nil {
+		meta := *frame.Meta
+		newFrame.Meta = &meta
+	}
+	for i := 0; i < frame.Rows(); i++ {
+		newFrame.AppendRow(frame.RowCopy(i)...)
+	}
+	return newFrame
+}
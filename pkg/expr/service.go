@@ -2,8 +2,10 @@ package expr
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb"
 )
@@ -39,16 +41,34 @@ func (s *Service) BuildPipeline(req *Request) (DataPipeline, error) {
 }
 
 // ExecutePipeline executes an expression pipeline and returns all the results.
+//
+// The response of each node holds either its frames or the error of its execution,
+// and the frames include the execution time of the node in the stats of their metadata.
 func (s *Service) ExecutePipeline(ctx context.Context, pipeline DataPipeline) (*backend.QueryDataResponse, error) {
 	res := backend.NewQueryDataResponse()
-	vars, err := pipeline.execute(ctx, s)
-	if err != nil {
-		return nil, err
-	}
-	for refID, val := range vars {
-		res.Responses[refID] = backend.DataResponse{
-			Frames: val.Values.AsDataFrames(refID),
+	for refID, outcome := range pipeline.execute(ctx, s) {
+		if outcome.err != nil {
+			res.Responses[refID] = backend.DataResponse{Error: outcome.err}
+			continue
+		}
+		frames := outcome.results.Values.AsDataFrames(refID)
+		for _, frame := range frames {
+			addExecutionTimeStat(frame, outcome.duration)
 		}
+		res.Responses[refID] = backend.DataResponse{Frames: frames}
 	}
 	return res, nil
 }
+
+// addExecutionTimeStat adds the execution time of the node that produced the frame to its metadata.
+func addExecutionTimeStat(frame *data.Frame, duration time.Duration) {
+	meta := data.FrameMeta{}
+	if frame.Meta != nil {
+		meta = *frame.Meta
+	}
+	meta.Stats = append(append([]data.QueryStat(nil), meta.Stats...), data.QueryStat{
+		FieldConfig: data.FieldConfig{DisplayName: "Execution time", Unit: "ms"},
+		Value:       float64(duration.Nanoseconds()) / float64(time.Millisecond),
+	})
+	frame.Meta = &meta
+}
@@ -3,7 +3,10 @@ package expr
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -85,11 +88,142 @@ func TestService(t *testing.T) {
 		return out
 	})
 	options := append([]cmp.Option{trans}, data.FrameTestCompareOptions()...)
+	// the execution time of the nodes is not deterministic.
+	for _, r := range res.Responses {
+		for _, f := range r.Frames {
+			require.Len(t, f.Meta.Stats, 1)
+			f.Meta = nil
+		}
+	}
 	if diff := cmp.Diff(expect, res, options...); diff != "" {
 		t.Errorf("Result mismatch (-want +got):\n%s", diff)
 	}
 }
 
+// nolint:staticcheck // plugins.DataPlugin deprecated
+func TestDataPipelineExecute(t *testing.T) {
+	dataSvc := tsdb.NewService()
+	dataSvc.PluginManager = &manager.PluginManager{
+		BackendPluginManager: fakeBackendPM{},
+	}
+	s := Service{DataService: &dataSvc}
+	// the two distinct queries must be running at the same time to succeed.
+	me := &concurrentEndpoint{
+		expected: 2,
+		ready:    make(chan struct{}),
+	}
+	s.DataService.RegisterQueryHandler("test", func(*models.DataSource) (plugins.DataPlugin, error) {
+		return me, nil
+	})
+	bus.AddHandler("test", func(query *models.GetDataSourceQuery) error {
+		if query.Id == 2 {
+			return models.ErrDataSourceNotFound
+		}
+		query.Result = &models.DataSource{Id: query.Id, OrgId: 1, Type: "test"}
+		return nil
+	})
+
+	dsNode := func(refID string, dsID int64, query string) *DSNode {
+		return &DSNode{
+			baseNode:     baseNode{refID: refID},
+			query:        json.RawMessage(query),
+			datasourceID: dsID,
+			orgID:        1,
+			intervalMS:   defaultIntervalMS,
+			maxDP:        defaultMaxDP,
+		}
+	}
+	cmdNode := func(refID, expr string) *CMDNode {
+		cmd, err := NewMathCommand(refID, expr)
+		require.NoError(t, err)
+		return &CMDNode{baseNode: baseNode{refID: refID}, CMDType: TypeMath, Command: cmd}
+	}
+
+	pipeline := DataPipeline{
+		dsNode("A", 1, `{"refId": "A", "expr": "up"}`),
+		dsNode("B", 1, `{"expr": "up", "refId": "B", "hide": true}`),
+		dsNode("C", 1, `{"refId": "C", "expr": "down"}`),
+		dsNode("D", 2, `{"refId": "D", "expr": "up"}`),
+		cmdNode("E", "$A + $B + $C"),
+		cmdNode("F", "$A + $D"),
+	}
+
+	res, err := s.ExecutePipeline(context.Background(), pipeline)
+	require.NoError(t, err)
+	require.Equal(t, 2, me.calls, "identical queries must be sent once")
+
+	for _, refID := range []string{"A", "B", "C", "E"} {
+		require.NoError(t, res.Responses[refID].Error, refID)
+		require.Len(t, res.Responses[refID].Frames, 1, refID)
+		frame := res.Responses[refID].Frames[0]
+		require.Equal(t, refID, frame.RefID)
+		require.Len(t, frame.Meta.Stats, 1)
+		require.Equal(t, "Execution time", frame.Meta.Stats[0].DisplayName)
+	}
+	require.Equal(t, fp(6), res.Responses["E"].Frames[0].Fields[1].At(0))
+
+	require.Error(t, res.Responses["D"].Error)
+	require.Error(t, res.Responses["F"].Error)
+	require.Empty(t, res.Responses["F"].Frames)
+}
+
+// nolint:staticcheck // plugins.DataPlugin deprecated
+func TestExecuteDSNodesCancelled(t *testing.T) {
+	dataSvc := tsdb.NewService()
+	dataSvc.PluginManager = &manager.PluginManager{
+		BackendPluginManager: fakeBackendPM{},
+	}
+	s := Service{DataService: &dataSvc}
+	me := &blockingEndpoint{
+		started: make(chan struct{}, maxConcurrentDSQueries+1),
+		release: make(chan struct{}),
+	}
+	s.DataService.RegisterQueryHandler("test", func(*models.DataSource) (plugins.DataPlugin, error) {
+		return me, nil
+	})
+	bus.AddHandler("test", func(query *models.GetDataSourceQuery) error {
+		query.Result = &models.DataSource{Id: query.Id, OrgId: 1, Type: "test"}
+		return nil
+	})
+
+	var nodes []*DSNode
+	for i := 0; i <= maxConcurrentDSQueries; i++ {
+		refID := fmt.Sprintf("Q%d", i)
+		nodes = append(nodes, &DSNode{
+			baseNode:     baseNode{refID: refID},
+			query:        json.RawMessage(fmt.Sprintf(`{"refId": %q, "expr": %q}`, refID, refID)),
+			datasourceID: 1,
+			orgID:        1,
+			intervalMS:   defaultIntervalMS,
+			maxDP:        defaultMaxDP,
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan map[string]nodeResult)
+	go func() {
+		done <- executeDSNodes(ctx, nodes, &s)
+	}()
+	for i := 0; i < maxConcurrentDSQueries; i++ {
+		<-me.started
+	}
+	// the last query waits for a slot, and must not be sent once the request is cancelled.
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(me.release)
+
+	outcomes := <-done
+	require.Len(t, outcomes, maxConcurrentDSQueries+1)
+	require.Len(t, me.started, 0, "the query waiting for a slot must not be sent")
+	canceled := 0
+	for _, outcome := range outcomes {
+		if errors.Is(outcome.err, context.Canceled) {
+			canceled++
+		}
+	}
+	require.Equal(t, 1, canceled)
+}
+
 func fp(f float64) *float64 {
 	return &f
 }
@@ -110,6 +244,58 @@ func (me *mockEndpoint) DataQuery(ctx context.Context, ds *models.DataSource, qu
 	}, nil
 }
 
+// concurrentEndpoint fails the queries unless the expected number of queries are running at the same time.
+type concurrentEndpoint struct {
+	mu       sync.Mutex
+	calls    int
+	expected int
+	ready    chan struct{}
+}
+
+// nolint:staticcheck // plugins.DataQueryResult deprecated
+func (ce *concurrentEndpoint) DataQuery(ctx context.Context, ds *models.DataSource, query plugins.DataQuery) (
+	plugins.DataResponse, error) {
+	ce.mu.Lock()
+	ce.calls++
+	if ce.calls == ce.expected {
+		close(ce.ready)
+	}
+	ce.mu.Unlock()
+
+	select {
+	case <-ce.ready:
+	case <-time.After(5 * time.Second):
+		return plugins.DataResponse{}, fmt.Errorf("queries were not executed concurrently")
+	}
+	return plugins.DataResponse{
+		Results: map[string]plugins.DataQueryResult{
+			query.Queries[0].RefID: {
+				Dataframes: plugins.NewDecodedDataFrames(data.Frames{data.NewFrame("",
+					data.NewField("time", nil, []time.Time{time.Unix(1, 0)}),
+					data.NewField("value", nil, []*float64{fp(2)}))}),
+			},
+		},
+	}, nil
+}
+
+// blockingEndpoint blocks the queries until it is released.
+type blockingEndpoint struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+// nolint:staticcheck // plugins.DataQueryResult deprecated
+func (be *blockingEndpoint) DataQuery(ctx context.Context, ds *models.DataSource, query plugins.DataQuery) (
+	plugins.DataResponse, error) {
+	be.started <- struct{}{}
+	<-be.release
+	return plugins.DataResponse{
+		Results: map[string]plugins.DataQueryResult{
+			query.Queries[0].RefID: {RefID: query.Queries[0].RefID},
+		},
+	}, nil
+}
+
 type fakeBackendPM struct {
 	backendplugin.Manager
 }
@@ -0,0 +1,515 @@
+package expr
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	sqlTimeColumn  = "time"
+	sqlValueColumn = "value"
+)
+
+var (
+	sqlTableRefRe = regexp.MustCompile("(?i)\\b(?:from|join)\\s+(\"[^\"]+\"|`[^`]+`|\\[[^\\]]+\\]|[A-Za-z_][A-Za-z0-9_]*)")
+	sqlCTERe      = regexp.MustCompile("(?i)(?:\\bwith(?:\\s+recursive)?|,)\\s*(\"[^\"]+\"|`[^`]+`|\\[[^\\]]+\\]|[A-Za-z_][A-Za-z0-9_]*)\\s+as\\s*\\(")
+)
+
+// SQLCommand is an expression command that runs a SQL SELECT statement over the results
+// of other queries and expressions. Each result is exposed as a table named after its refId,
+// with a "time" column for series, a "value" column and a column per label.
+//
+// The statement is executed by an in-memory SQLite database that only lives for the
+// duration of the command.
+type SQLCommand struct {
+	RawSQL string
+	tables []string
+	refID  string
+}
+
+// NewSQLCommand creates a new SQLCommand. It will return an error if the
+// statement is not a single SELECT statement.
+func NewSQLCommand(refID, rawSQL string) (*SQLCommand, error) {
+	query := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rawSQL), ";"))
+	if query == "" {
+		return nil, fmt.Errorf("sql expression for refId %v is empty", refID)
+	}
+	if hasMultipleSQLStatements(query) {
+		return nil, fmt.Errorf("sql expression for refId %v must be a single statement", refID)
+	}
+	firstWord := strings.ToLower(strings.Fields(query)[0])
+	if firstWord != "select" && firstWord != "with" {
+		return nil, fmt.Errorf("sql expression for refId %v must be a SELECT statement", refID)
+	}
+
+	return &SQLCommand{
+		RawSQL: query,
+		tables: sqlTables(query),
+		refID:  refID,
+	}, nil
+}
+
+// UnmarshalSQLCommand creates a SQLCommand from Grafana's frontend query.
+func UnmarshalSQLCommand(rn *rawNode) (*SQLCommand, error) {
+	rawExpr, ok := rn.Query["expression"]
+	if !ok {
+		return nil, fmt.Errorf("sql command for refId %v is missing an expression", rn.RefID)
+	}
+	expression, ok := rawExpr.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected sql command for refId %v expression to be a string, got %T", rn.RefID, rawExpr)
+	}
+	return NewSQLCommand(rn.RefID, expression)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (gr *SQLCommand) NeedsVars() []string {
+	return gr.tables
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute.
+func (gr *SQLCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathexp.Results, error) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return mathexp.Results{}, err
+	}
+	defer func() { _ = db.Close() }()
+
+	// an in-memory database only exists for the connection that created it
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return mathexp.Results{}, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	for _, table := range gr.tables {
+		if err := createSQLTable(ctx, conn, table, vars[table]); err != nil {
+			return mathexp.Results{}, fmt.Errorf("failed to create table %v for sql expression %v: %w", table, gr.refID, err)
+		}
+	}
+
+	// once the tables are loaded, only allow the statement to read them.
+	err = conn.Raw(func(driverConn interface{}) error {
+		sqliteConn, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("unexpected sql driver connection %T", driverConn)
+		}
+		sqliteConn.RegisterAuthorizer(readOnlyAuthorizer)
+		return nil
+	})
+	if err != nil {
+		return mathexp.Results{}, err
+	}
+
+	rows, err := conn.QueryContext(ctx, gr.RawSQL)
+	if err != nil {
+		return mathexp.Results{}, fmt.Errorf("failed to execute sql expression %v: %w", gr.refID, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	frame, err := frameFromSQLRows(rows)
+	if err != nil {
+		return mathexp.Results{}, fmt.Errorf("failed to read results of sql expression %v: %w", gr.refID, err)
+	}
+	return sqlFrameToResults(gr.refID, frame)
+}
+
+// hasMultipleSQLStatements returns true if the query has a statement separator outside of its
+// string literals, quoted identifiers and comments.
+func hasMultipleSQLStatements(query string) bool {
+	for i := 0; i < len(query); i++ {
+		var end string
+		switch {
+		case query[i] == ';':
+			return true
+		case query[i] == '\'', query[i] == '"', query[i] == '`':
+			end = query[i : i+1]
+		case query[i] == '[':
+			end = "]"
+		case strings.HasPrefix(query[i:], "--"):
+			end = "\n"
+		case strings.HasPrefix(query[i:], "/*"):
+			end = "*/"
+			i++
+		default:
+			continue
+		}
+		// quotes are escaped by doubling them, which is handled as two consecutive quoted parts.
+		n := strings.Index(query[i+1:], end)
+		if n < 0 {
+			return false
+		}
+		i += n + len(end)
+	}
+	return false
+}
+
+// sqlTables returns the names of the tables referenced by the statement,
+// excluding the common table expressions it defines. Some of them may not be
+// refIds, such as table-valued functions, see keepRefIDTables.
+func sqlTables(query string) []string {
+	ctes := make(map[string]struct{})
+	for _, m := range sqlCTERe.FindAllStringSubmatch(query, -1) {
+		ctes[unquoteSQLIdentifier(m[1])] = struct{}{}
+	}
+	seen := make(map[string]struct{})
+	tables := []string{}
+	for _, m := range sqlTableRefRe.FindAllStringSubmatch(query, -1) {
+		name := unquoteSQLIdentifier(m[1])
+		if _, ok := ctes[name]; ok {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		tables = append(tables, name)
+	}
+	return tables
+}
+
+// keepRefIDTables only keeps the tables of the statement which are the refIds of nodes in
+// the registry as dependencies. The other tables are provided by SQLite itself, such as
+// the table-valued function json_each.
+func (gr *SQLCommand) keepRefIDTables(registry map[string]Node) {
+	tables := make([]string, 0, len(gr.tables))
+	for _, table := range gr.tables {
+		if _, ok := registry[table]; ok {
+			tables = append(tables, table)
+		}
+	}
+	gr.tables = tables
+}
+
+func unquoteSQLIdentifier(s string) string {
+	if len(s) >= 2 {
+		switch {
+		case s[0] == '"' && s[len(s)-1] == '"', s[0] == '`' && s[len(s)-1] == '`', s[0] == '[' && s[len(s)-1] == ']':
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
+
+func quoteSQLIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// readOnlyAuthorizer is a SQLite authorizer that only allows reading data.
+func readOnlyAuthorizer(action int, _, _, _ string) int {
+	switch action {
+	case sqlite3.SQLITE_SELECT, sqlite3.SQLITE_READ, sqlite3.SQLITE_FUNCTION, sqliteRecursive:
+		return sqlite3.SQLITE_OK
+	default:
+		return sqlite3.SQLITE_DENY
+	}
+}
+
+// sqliteRecursive is the SQLITE_RECURSIVE authorizer action code used by recursive
+// common table expressions, which the sqlite3 package does not export.
+const sqliteRecursive = 33
+
+// createSQLTable creates a table with the values of res and loads them. Series are
+// stored as one row per point.
+func createSQLTable(ctx context.Context, conn *sql.Conn, name string, res mathexp.Results) error {
+	hasTime := false
+	labelKeySet := make(map[string]struct{})
+	for _, val := range res.Values {
+		if _, ok := val.(mathexp.Series); ok {
+			hasTime = true
+		}
+		for k := range val.GetLabels() {
+			if k == sqlTimeColumn || k == sqlValueColumn {
+				continue
+			}
+			labelKeySet[k] = struct{}{}
+		}
+	}
+	labelKeys := make([]string, 0, len(labelKeySet))
+	for k := range labelKeySet {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+
+	columns := []string{}
+	if hasTime {
+		columns = append(columns, quoteSQLIdentifier(sqlTimeColumn)+" TIMESTAMP")
+	}
+	columns = append(columns, quoteSQLIdentifier(sqlValueColumn)+" REAL")
+	for _, k := range labelKeys {
+		columns = append(columns, quoteSQLIdentifier(k)+" TEXT")
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", quoteSQLIdentifier(name), strings.Join(columns, ", "))); err != nil {
+		return err
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	stmt, err := conn.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s VALUES (%s)", quoteSQLIdentifier(name), placeholders))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+
+	insert := func(t *time.Time, f *float64, labels data.Labels) error {
+		args := make([]interface{}, 0, len(columns))
+		if hasTime {
+			if t != nil {
+				args = append(args, t.UTC())
+			} else {
+				args = append(args, nil)
+			}
+		}
+		if f != nil {
+			args = append(args, *f)
+		} else {
+			args = append(args, nil)
+		}
+		for _, k := range labelKeys {
+			if v, ok := labels[k]; ok {
+				args = append(args, v)
+			} else {
+				args = append(args, nil)
+			}
+		}
+		_, err := stmt.ExecContext(ctx, args...)
+		return err
+	}
+
+	for _, val := range res.Values {
+		switch v := val.(type) {
+		case mathexp.Series:
+			for i := 0; i < v.Len(); i++ {
+				t, f := v.GetPoint(i)
+				if err := insert(&t, f, v.GetLabels()); err != nil {
+					return err
+				}
+			}
+		case mathexp.Number:
+			if err := insert(nil, v.GetFloat64Value(), v.GetLabels()); err != nil {
+				return err
+			}
+		case mathexp.Scalar:
+			if err := insert(nil, v.GetFloat64Value(), nil); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("can not load type %v into a table", val.Type())
+		}
+	}
+	return nil
+}
+
+// frameFromSQLRows reads the rows of a SQLite result into a data frame. Numbers become
+// nullable float64 fields, timestamps nullable time fields and everything else nullable strings.
+func frameFromSQLRows(rows *sql.Rows) (*data.Frame, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]interface{}, len(columns))
+	for rows.Next() {
+		row := make([]interface{}, len(columns))
+		scanArgs := make([]interface{}, len(columns))
+		for i := range row {
+			scanArgs[i] = &row[i]
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, err
+		}
+		for i, v := range row {
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			values[i] = append(values[i], v)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	frame := data.NewFrame("")
+	for i, name := range columns {
+		if name == sqlTimeColumn {
+			// the time column of aggregates, such as max(time), is returned as text
+			if err := parseSQLTimes(values[i]); err != nil {
+				return nil, fmt.Errorf("failed to read the %s column: %w", sqlTimeColumn, err)
+			}
+		}
+		frame.Fields = append(frame.Fields, sqlColumnToField(name, values[i]))
+	}
+	return frame, nil
+}
+
+// parseSQLTimes replaces the text values with the timestamps they are, in one of the formats
+// SQLite stores them in.
+func parseSQLTimes(values []interface{}) error {
+VALUES:
+	for i, v := range values {
+		text, ok := v.(string)
+		if !ok {
+			continue
+		}
+		for _, format := range sqlite3.SQLiteTimestampFormats {
+			if t, err := time.ParseInLocation(format, text, time.UTC); err == nil {
+				values[i] = t
+				continue VALUES
+			}
+		}
+		return fmt.Errorf("%q is not a timestamp", text)
+	}
+	return nil
+}
+
+func sqlColumnToField(name string, values []interface{}) *data.Field {
+	var fieldType data.FieldType
+	// the type of the column is the type of its first non null value.
+VALUES:
+	for _, v := range values {
+		switch v.(type) {
+		case int64, float64, bool:
+			fieldType = data.FieldTypeNullableFloat64
+		case time.Time:
+			fieldType = data.FieldTypeNullableTime
+		case string:
+			fieldType = data.FieldTypeNullableString
+		default:
+			continue
+		}
+		break VALUES
+	}
+	if fieldType == data.FieldTypeUnknown {
+		fieldType = data.FieldTypeNullableFloat64
+	}
+
+	field := data.NewFieldFromFieldType(fieldType, len(values))
+	field.Name = name
+	for i, v := range values {
+		switch fieldType {
+		case data.FieldTypeNullableFloat64:
+			var f float64
+			switch tv := v.(type) {
+			case int64:
+				f = float64(tv)
+			case float64:
+				f = tv
+			case bool:
+				if tv {
+					f = 1
+				}
+			default:
+				continue
+			}
+			field.Set(i, &f)
+		case data.FieldTypeNullableTime:
+			if t, ok := v.(time.Time); ok {
+				field.Set(i, &t)
+			}
+		case data.FieldTypeNullableString:
+			if v != nil {
+				s := fmt.Sprint(v)
+				field.Set(i, &s)
+			}
+		}
+	}
+	return field
+}
+
+// sqlFrameToResults converts the frame returned by a SQL statement to results. If the frame has
+// a time field, a series is created per numeric field and per distinct set of string field values,
+// which become labels. Otherwise a number is created per numeric field and per row. The results
+// are named after the refId like the results of the other commands, and the name of their numeric
+// field is their display name.
+func sqlFrameToResults(refID string, frame *data.Frame) (mathexp.Results, error) {
+	timeIdx := -1
+	var numericIdxs, stringIdxs []int
+	for i, field := range frame.Fields {
+		switch field.Type() {
+		case data.FieldTypeNullableTime:
+			if timeIdx == -1 {
+				timeIdx = i
+			}
+		case data.FieldTypeNullableFloat64:
+			numericIdxs = append(numericIdxs, i)
+		case data.FieldTypeNullableString:
+			stringIdxs = append(stringIdxs, i)
+		}
+	}
+	if len(numericIdxs) == 0 {
+		return mathexp.Results{}, fmt.Errorf("sql expression results must have at least one numeric column")
+	}
+
+	rowLabels := func(rowIdx int) data.Labels {
+		var labels data.Labels
+		for _, idx := range stringIdxs {
+			s := frame.Fields[idx].At(rowIdx).(*string)
+			if s == nil {
+				continue
+			}
+			if labels == nil {
+				labels = data.Labels{}
+			}
+			labels[frame.Fields[idx].Name] = *s
+		}
+		return labels
+	}
+
+	res := mathexp.Results{}
+	if timeIdx == -1 {
+		for _, numIdx := range numericIdxs {
+			for rowIdx := 0; rowIdx < frame.Rows(); rowIdx++ {
+				n := mathexp.NewNumber(refID, rowLabels(rowIdx))
+				n.Frame.Fields[0].Config = sqlColumnConfig(frame.Fields[numIdx].Name)
+				n.SetValue(frame.Fields[numIdx].At(rowIdx).(*float64))
+				res.Values = append(res.Values, n)
+			}
+		}
+		return res, nil
+	}
+
+	for _, numIdx := range numericIdxs {
+		seriesByLabels := make(map[string]mathexp.Series)
+		order := []string{}
+		for rowIdx := 0; rowIdx < frame.Rows(); rowIdx++ {
+			t := frame.Fields[timeIdx].At(rowIdx).(*time.Time)
+			if t == nil {
+				return mathexp.Results{}, fmt.Errorf("sql expression results can not have null time values")
+			}
+			labels := rowLabels(rowIdx)
+			key := labels.String()
+			s, ok := seriesByLabels[key]
+			if !ok {
+				s = mathexp.NewSeries(refID, labels, 0)
+				// the values of a series are its second field, after the time
+				s.Frame.Fields[1].Config = sqlColumnConfig(frame.Fields[numIdx].Name)
+				seriesByLabels[key] = s
+				order = append(order, key)
+			}
+			if err := s.AppendPoint(rowIdx, *t, frame.Fields[numIdx].At(rowIdx).(*float64)); err != nil {
+				return mathexp.Results{}, err
+			}
+		}
+		for _, key := range order {
+			s := seriesByLabels[key]
+			s.SortByTime(false)
+			res.Values = append(res.Values, s)
+		}
+	}
+	return res, nil
+}
+
+// sqlColumnConfig is the config of the values of a result, displayed with the name of their column.
+func sqlColumnConfig(column string) *data.FieldConfig {
+	return &data.FieldConfig{DisplayNameFromDS: column}
+}
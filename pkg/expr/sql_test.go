@@ -0,0 +1,153 @@
+package expr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSQLCommand(t *testing.T) {
+	var tests = []struct {
+		name   string
+		sql    string
+		errIs  require.ErrorAssertionFunc
+		tables []string
+	}{
+		{
+			name:   "select with join",
+			sql:    `SELECT A.value + B.value AS value, A.host FROM A JOIN "B" ON A.host = B.host;`,
+			errIs:  require.NoError,
+			tables: []string{"A", "B"},
+		},
+		{
+			name:   "common table expressions are not dependencies",
+			sql:    "WITH hosts AS (SELECT host, max(value) AS value FROM A GROUP BY host) SELECT * FROM hosts",
+			errIs:  require.NoError,
+			tables: []string{"A"},
+		},
+		{
+			name:  "multiple statements",
+			sql:   "SELECT * FROM A; DROP TABLE A",
+			errIs: require.Error,
+		},
+		{
+			name:   "semicolons in string literals and comments",
+			sql:    "SELECT value FROM A WHERE host = 'a;b' OR host = 'it''s;' /* ; */ -- ;\n;",
+			errIs:  require.NoError,
+			tables: []string{"A"},
+		},
+		{
+			name:  "multiple statements after a string literal",
+			sql:   "SELECT 'a;b' AS host, value FROM A; DROP TABLE A",
+			errIs: require.Error,
+		},
+		{
+			name:  "not a select",
+			sql:   "DELETE FROM A",
+			errIs: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := NewSQLCommand("C", tt.sql)
+			tt.errIs(t, err)
+			if err == nil {
+				require.Equal(t, tt.tables, cmd.NeedsVars())
+			}
+		})
+	}
+}
+
+func TestSQLCommandKeepRefIDTables(t *testing.T) {
+	cmd, err := NewSQLCommand("C", "SELECT A.value FROM A JOIN json_each('[1, 2]') AS j ON A.value = j.value")
+	require.NoError(t, err)
+	require.Equal(t, []string{"A", "json_each"}, cmd.NeedsVars())
+
+	cmd.keepRefIDTables(map[string]Node{"A": nil, "C": nil})
+	require.Equal(t, []string{"A"}, cmd.NeedsVars())
+}
+
+func TestSQLCommandExecute(t *testing.T) {
+	number := func(labels data.Labels, f float64) mathexp.Number {
+		n := mathexp.NewNumber("", labels)
+		n.SetValue(&f)
+		return n
+	}
+	fp := func(f float64) *float64 { return &f }
+
+	vars := mathexp.Vars{
+		"A": mathexp.Results{Values: mathexp.Values{
+			number(data.Labels{"host": "a"}, 1),
+			number(data.Labels{"host": "b"}, 2),
+		}},
+		"B": mathexp.Results{Values: mathexp.Values{
+			number(data.Labels{"host": "a", "dc": "x"}, 10),
+		}},
+	}
+
+	t.Run("join numbers from two results", func(t *testing.T) {
+		cmd, err := NewSQLCommand("C", "SELECT A.host, A.value + B.value AS total FROM A JOIN B ON A.host = B.host")
+		require.NoError(t, err)
+		res, err := cmd.Execute(context.Background(), vars)
+		require.NoError(t, err)
+		require.Len(t, res.Values, 1)
+		n := res.Values[0].(mathexp.Number)
+		require.Equal(t, data.Labels{"host": "a"}, n.GetLabels())
+		require.Equal(t, fp(11), n.GetFloat64Value())
+		require.Equal(t, "C", n.Frame.Fields[0].Name, "the results are named after the refId")
+		require.Equal(t, "total", n.Frame.Fields[0].Config.DisplayNameFromDS)
+	})
+
+	t.Run("series are loaded with a time column", func(t *testing.T) {
+		s := mathexp.NewSeries("", data.Labels{"host": "a"}, 2)
+		require.NoError(t, s.SetPoint(0, time.Unix(10, 0), fp(1)))
+		require.NoError(t, s.SetPoint(1, time.Unix(20, 0), fp(3)))
+		cmd, err := NewSQLCommand("C", "SELECT time, value * 2 AS value, host FROM S WHERE value > 2")
+		require.NoError(t, err)
+		res, err := cmd.Execute(context.Background(), mathexp.Vars{"S": mathexp.Results{Values: mathexp.Values{s}}})
+		require.NoError(t, err)
+		require.Len(t, res.Values, 1)
+		resSeries := res.Values[0].(mathexp.Series)
+		require.Equal(t, data.Labels{"host": "a"}, resSeries.GetLabels())
+		require.Equal(t, 1, resSeries.Len())
+		pt, pf := resSeries.GetPoint(0)
+		require.True(t, time.Unix(20, 0).Equal(pt))
+		require.Equal(t, fp(6), pf)
+		require.Equal(t, "C", resSeries.Frame.Fields[1].Name)
+		require.Equal(t, "value", resSeries.Frame.Fields[1].Config.DisplayNameFromDS)
+	})
+
+	t.Run("aggregated time columns are timestamps", func(t *testing.T) {
+		s := mathexp.NewSeries("", data.Labels{"host": "a"}, 2)
+		require.NoError(t, s.SetPoint(0, time.Unix(10, 0), fp(1)))
+		require.NoError(t, s.SetPoint(1, time.Unix(20, 0), fp(3)))
+		cmd, err := NewSQLCommand("C", "SELECT max(time) AS time, sum(value) AS value, host FROM S GROUP BY host")
+		require.NoError(t, err)
+		res, err := cmd.Execute(context.Background(), mathexp.Vars{"S": mathexp.Results{Values: mathexp.Values{s}}})
+		require.NoError(t, err)
+		require.Len(t, res.Values, 1)
+		resSeries := res.Values[0].(mathexp.Series)
+		require.Equal(t, data.Labels{"host": "a"}, resSeries.GetLabels(), "the time is not a label")
+		pt, pf := resSeries.GetPoint(0)
+		require.True(t, time.Unix(20, 0).Equal(pt))
+		require.Equal(t, fp(4), pf)
+	})
+
+	t.Run("time columns must be timestamps", func(t *testing.T) {
+		cmd, err := NewSQLCommand("C", "SELECT 'yesterday' AS time, value FROM A")
+		require.NoError(t, err)
+		_, err = cmd.Execute(context.Background(), vars)
+		require.Error(t, err)
+	})
+
+	t.Run("results must have a numeric column", func(t *testing.T) {
+		cmd, err := NewSQLCommand("C", "SELECT host FROM A")
+		require.NoError(t, err)
+		_, err = cmd.Execute(context.Background(), vars)
+		require.Error(t, err)
+	})
+}
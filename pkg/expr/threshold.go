@@ -0,0 +1,195 @@
+package expr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+)
+
+// ThresholdCommand is an expression command that compares each value of a variable
+// with an evaluator and returns 1 when the condition is met and 0 when it is not.
+//
+// If a RecoveryEvaluator is set, values whose labels belong to LoadedDimensions
+// (the dimensions that are currently firing) keep returning 1 until the recovery
+// condition is met, which provides hysteresis between firing and resolving.
+type ThresholdCommand struct {
+	ReferenceVar      string
+	Evaluator         ThresholdEvaluator
+	RecoveryEvaluator *ThresholdEvaluator
+	LoadedDimensions  []data.Labels
+	refID             string
+}
+
+// ThresholdEvaluator is the condition of a ThresholdCommand.
+type ThresholdEvaluator struct {
+	// Type is one of "gt", "lt", "within_range" or "outside_range".
+	Type string `json:"type"`
+	// Params holds the threshold for "gt" and "lt", and the bounds for the range types.
+	Params []float64 `json:"params"`
+}
+
+// thresholdCommandJSON is the model of a ThresholdCommand in Grafana's frontend query.
+type thresholdCommandJSON struct {
+	Expression        string              `json:"expression"`
+	Evaluator         *ThresholdEvaluator `json:"evaluator"`
+	RecoveryEvaluator *ThresholdEvaluator `json:"recoveryEvaluator,omitempty"`
+	LoadedDimensions  []data.Labels       `json:"loadedDimensions,omitempty"`
+}
+
+// NewThresholdCommand creates a new ThresholdCommand. It will return an error
+// if an evaluator is invalid.
+func NewThresholdCommand(refID, referenceVar string, evaluator ThresholdEvaluator, recovery *ThresholdEvaluator, loaded []data.Labels) (*ThresholdCommand, error) {
+	if err := evaluator.validate(); err != nil {
+		return nil, fmt.Errorf("invalid threshold evaluator for refId %v: %w", refID, err)
+	}
+	if recovery != nil {
+		if err := recovery.validate(); err != nil {
+			return nil, fmt.Errorf("invalid threshold recovery evaluator for refId %v: %w", refID, err)
+		}
+	}
+	return &ThresholdCommand{
+		ReferenceVar:      referenceVar,
+		Evaluator:         evaluator,
+		RecoveryEvaluator: recovery,
+		LoadedDimensions:  loaded,
+		refID:             refID,
+	}, nil
+}
+
+// UnmarshalThresholdCommand creates a ThresholdCommand from Grafana's frontend query.
+func UnmarshalThresholdCommand(rn *rawNode) (*ThresholdCommand, error) {
+	jsonFromM, err := json.Marshal(rn.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remarshal threshold command body for refId %v: %w", rn.RefID, err)
+	}
+	var tj thresholdCommandJSON
+	if err := json.Unmarshal(jsonFromM, &tj); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal threshold command body for refId %v: %w", rn.RefID, err)
+	}
+
+	referenceVar := strings.TrimPrefix(tj.Expression, "$")
+	if referenceVar == "" {
+		return nil, fmt.Errorf("no variable specified to threshold for refId %v", rn.RefID)
+	}
+	if tj.Evaluator == nil {
+		return nil, fmt.Errorf("no evaluator specified for threshold command for refId %v", rn.RefID)
+	}
+
+	return NewThresholdCommand(rn.RefID, referenceVar, *tj.Evaluator, tj.RecoveryEvaluator, tj.LoadedDimensions)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (tc *ThresholdCommand) NeedsVars() []string {
+	return []string{tc.ReferenceVar}
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute.
+func (tc *ThresholdCommand) Execute(ctx context.Context, vars mathexp.Vars) (mathexp.Results, error) {
+	newRes := mathexp.Results{}
+	for _, val := range vars[tc.ReferenceVar].Values {
+		evaluator := tc.evaluatorFor(val.GetLabels())
+		switch v := val.(type) {
+		case mathexp.Number:
+			n := mathexp.NewNumber(tc.refID, v.GetLabels())
+			n.SetValue(evaluator(v.GetFloat64Value()))
+			newRes.Values = append(newRes.Values, n)
+		case mathexp.Scalar:
+			newRes.Values = append(newRes.Values, mathexp.NewScalar(tc.refID, evaluator(v.GetFloat64Value())))
+		case mathexp.Series:
+			s := mathexp.NewSeries(tc.refID, v.GetLabels(), v.Len())
+			for i := 0; i < v.Len(); i++ {
+				t, f := v.GetPoint(i)
+				if err := s.SetPoint(i, t, evaluator(f)); err != nil {
+					return newRes, err
+				}
+			}
+			newRes.Values = append(newRes.Values, s)
+		default:
+			return newRes, fmt.Errorf("can not apply a threshold to type %v", val.Type())
+		}
+	}
+	return newRes, nil
+}
+
+// evaluatorFor returns the function used to evaluate the values with labels l.
+func (tc *ThresholdCommand) evaluatorFor(l data.Labels) func(*float64) *float64 {
+	if tc.RecoveryEvaluator != nil && tc.isLoaded(l) {
+		return func(f *float64) *float64 {
+			return invert(tc.RecoveryEvaluator.eval(f))
+		}
+	}
+	return tc.Evaluator.eval
+}
+
+// isLoaded returns true if the labels l belong to one of the loaded dimensions.
+// Loaded dimensions may hold more labels than the values, such as the labels of the rule.
+func (tc *ThresholdCommand) isLoaded(l data.Labels) bool {
+	for _, d := range tc.LoadedDimensions {
+		if d.Contains(l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ThresholdEvaluator) validate() error {
+	switch e.Type {
+	case "gt", "lt":
+		if len(e.Params) != 1 {
+			return fmt.Errorf("evaluator '%v' requires 1 parameter, got %v", e.Type, len(e.Params))
+		}
+	case "within_range", "outside_range":
+		if len(e.Params) != 2 {
+			return fmt.Errorf("evaluator '%v' requires 2 parameters, got %v", e.Type, len(e.Params))
+		}
+	default:
+		return fmt.Errorf("evaluator type '%v' is not supported", e.Type)
+	}
+	return nil
+}
+
+// eval returns 1 if f meets the condition of the evaluator and 0 if it does not.
+// Null values return null, and NaN values return NaN.
+func (e ThresholdEvaluator) eval(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	if math.IsNaN(*f) {
+		nan := math.NaN()
+		return &nan
+	}
+	var met bool
+	switch e.Type {
+	case "gt":
+		met = *f > e.Params[0]
+	case "lt":
+		met = *f < e.Params[0]
+	case "within_range":
+		lower, upper := math.Min(e.Params[0], e.Params[1]), math.Max(e.Params[0], e.Params[1])
+		met = *f > lower && *f < upper
+	case "outside_range":
+		lower, upper := math.Min(e.Params[0], e.Params[1]), math.Max(e.Params[0], e.Params[1])
+		met = *f < lower || *f > upper
+	}
+	r := 0.0
+	if met {
+		r = 1
+	}
+	return &r
+}
+
+// invert turns 1 into 0 and 0 into 1, leaving null and NaN values untouched.
+func invert(f *float64) *float64 {
+	if f == nil || math.IsNaN(*f) {
+		return f
+	}
+	r := 1 - *f
+	return &r
+}
@@ -0,0 +1,144 @@
+package expr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalThresholdCommand(t *testing.T) {
+	var tests = []struct {
+		name  string
+		query map[string]interface{}
+		errIs require.ErrorAssertionFunc
+	}{
+		{
+			name: "valid greater than",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"evaluator":  map[string]interface{}{"type": "gt", "params": []interface{}{5.0}},
+			},
+			errIs: require.NoError,
+		},
+		{
+			name: "valid range with recovery",
+			query: map[string]interface{}{
+				"expression":        "A",
+				"evaluator":         map[string]interface{}{"type": "outside_range", "params": []interface{}{1.0, 5.0}},
+				"recoveryEvaluator": map[string]interface{}{"type": "within_range", "params": []interface{}{2.0, 4.0}},
+				"loadedDimensions":  []interface{}{map[string]interface{}{"host": "a"}},
+			},
+			errIs: require.NoError,
+		},
+		{
+			name: "missing evaluator",
+			query: map[string]interface{}{
+				"expression": "$A",
+			},
+			errIs: require.Error,
+		},
+		{
+			name: "unknown evaluator type",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"evaluator":  map[string]interface{}{"type": "eq", "params": []interface{}{5.0}},
+			},
+			errIs: require.Error,
+		},
+		{
+			name: "range evaluator with a single param",
+			query: map[string]interface{}{
+				"expression": "$A",
+				"evaluator":  map[string]interface{}{"type": "within_range", "params": []interface{}{5.0}},
+			},
+			errIs: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalThresholdCommand(&rawNode{RefID: "B", Query: tt.query})
+			tt.errIs(t, err)
+		})
+	}
+}
+
+func TestThresholdCommandExecute(t *testing.T) {
+	number := func(labels data.Labels, f *float64) mathexp.Number {
+		n := mathexp.NewNumber("", labels)
+		n.SetValue(f)
+		return n
+	}
+	fp := func(f float64) *float64 { return &f }
+
+	var tests = []struct {
+		name     string
+		cmd      *ThresholdCommand
+		vars     mathexp.Vars
+		expected []*float64
+	}{
+		{
+			name: "greater than",
+			cmd:  &ThresholdCommand{ReferenceVar: "A", Evaluator: ThresholdEvaluator{Type: "gt", Params: []float64{5}}},
+			vars: mathexp.Vars{"A": mathexp.Results{Values: mathexp.Values{
+				number(data.Labels{"host": "a"}, fp(10)),
+				number(data.Labels{"host": "b"}, fp(1)),
+				number(data.Labels{"host": "c"}, nil),
+			}}},
+			expected: []*float64{fp(1), fp(0), nil},
+		},
+		{
+			name: "within range",
+			cmd:  &ThresholdCommand{ReferenceVar: "A", Evaluator: ThresholdEvaluator{Type: "within_range", Params: []float64{10, 1}}},
+			vars: mathexp.Vars{"A": mathexp.Results{Values: mathexp.Values{
+				number(data.Labels{"host": "a"}, fp(5)),
+				number(data.Labels{"host": "b"}, fp(10)),
+			}}},
+			expected: []*float64{fp(1), fp(0)},
+		},
+		{
+			name: "recovery evaluator applies to loaded dimensions only",
+			cmd: &ThresholdCommand{
+				ReferenceVar:      "A",
+				Evaluator:         ThresholdEvaluator{Type: "gt", Params: []float64{90}},
+				RecoveryEvaluator: &ThresholdEvaluator{Type: "lt", Params: []float64{80}},
+				LoadedDimensions:  []data.Labels{{"host": "a", "alertname": "cpu"}, {"host": "c", "alertname": "cpu"}},
+			},
+			vars: mathexp.Vars{"A": mathexp.Results{Values: mathexp.Values{
+				number(data.Labels{"host": "a"}, fp(85)),
+				number(data.Labels{"host": "b"}, fp(85)),
+				number(data.Labels{"host": "c"}, fp(75)),
+			}}},
+			expected: []*float64{fp(1), fp(0), fp(0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.cmd.Execute(context.Background(), tt.vars)
+			require.NoError(t, err)
+			require.Len(t, res.Values, len(tt.expected))
+			for i, v := range res.Values {
+				n, ok := v.(mathexp.Number)
+				require.True(t, ok)
+				require.Equal(t, tt.expected[i], n.GetFloat64Value())
+				require.Equal(t, tt.vars["A"].Values[i].GetLabels(), n.GetLabels())
+			}
+		})
+	}
+
+	t.Run("series", func(t *testing.T) {
+		s := mathexp.NewSeries("", nil, 2)
+		require.NoError(t, s.SetPoint(0, time.Unix(0, 0), fp(1)))
+		require.NoError(t, s.SetPoint(1, time.Unix(10, 0), fp(10)))
+		cmd := &ThresholdCommand{ReferenceVar: "A", Evaluator: ThresholdEvaluator{Type: "lt", Params: []float64{5}}}
+		res, err := cmd.Execute(context.Background(), mathexp.Vars{"A": mathexp.Results{Values: mathexp.Values{s}}})
+		require.NoError(t, err)
+		require.Len(t, res.Values, 1)
+		resSeries := res.Values[0].(mathexp.Series)
+		require.Equal(t, fp(1), resSeries.GetValue(0))
+		require.Equal(t, fp(0), resSeries.GetValue(1))
+	})
+}
@@ -96,7 +96,7 @@ func (s *Service) TransformData(ctx context.Context, req *Request) (r *backend.Q
 	defer func() {
 		var respStatus string
 		switch {
-		case err == nil:
+		case err == nil && !hasErrorResponse(r):
 			respStatus = "success"
 		default:
 			respStatus = "failure"
@@ -138,6 +138,19 @@ func (s *Service) TransformData(ctx context.Context, req *Request) (r *backend.Q
 	return responses, nil
 }
 
+// hasErrorResponse returns true if the execution of one of the nodes failed.
+func hasErrorResponse(r *backend.QueryDataResponse) bool {
+	if r == nil {
+		return false
+	}
+	for _, res := range r.Responses {
+		if res.Error != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func hiddenRefIDs(queries []Query) (map[string]struct{}, error) {
 	hidden := make(map[string]struct{})
 
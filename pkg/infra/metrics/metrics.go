@@ -97,6 +97,12 @@ var (
 	// MDBDataSourceQueryByID is a metric counter for getting datasource by id
 	MDBDataSourceQueryByID prometheus.Counter
 
+	// MDataSourceQueryCacheTotal is a metric counter for the lookups of the query results cache
+	MDataSourceQueryCacheTotal *prometheus.CounterVec
+
+	// MDataSourceHealth is a metric gauge for the result of the last health check of the data sources
+	MDataSourceHealth *prometheus.GaugeVec
+
 	// LDAPUsersSyncExecutionTime is a metric summary for LDAP users sync execution duration
 	LDAPUsersSyncExecutionTime prometheus.Summary
 
@@ -383,6 +389,24 @@ func init() {
 		Namespace:  ExporterName,
 	})
 
+	MDataSourceQueryCacheTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "datasource_query_cache_total",
+			Help:      "counter for the lookups of the query results cache by data source type and status (hit, miss or bypass)",
+			Namespace: ExporterName,
+		},
+		[]string{"datasource_type", "status"},
+	)
+
+	MDataSourceHealth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "datasource_health",
+			Help:      "result of the last health check of the data sources, 1 when healthy and 0 otherwise",
+			Namespace: ExporterName,
+		},
+		[]string{"org_id", "datasource_uid", "datasource_type"},
+	)
+
 	MRenderingRequestTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name:      "rendering_request_total",
@@ -633,6 +657,8 @@ func initMetricVars() {
 		MAwsCloudWatchListMetrics,
 		MAwsCloudWatchGetMetricData,
 		MDBDataSourceQueryByID,
+		MDataSourceQueryCacheTotal,
+		MDataSourceHealth,
 		LDAPUsersSyncExecutionTime,
 		MRenderingRequestTotal,
 		MRenderingSummary,
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
@@ -83,7 +84,40 @@ func (ds *DataSource) GetHTTPTransport(provider httpclient.Provider, customMiddl
 }
 
 func (ds *DataSource) HTTPClientOptions() sdkhttpclient.Options {
-	tlsOptions := ds.TLSOptions()
+	return ds.httpClientOptions(ds.DecryptedValues())
+}
+
+// HTTPClientOptionsFromInstanceSettings returns the HTTP client options of the data source of type
+// dsType the instance settings of a backend plugin request belong to. It takes the same settings
+// into account as the HTTP client options of the data source, such as the custom headers, the
+// timeouts and SigV4 authentication.
+func HTTPClientOptionsFromInstanceSettings(dsType string, settings backend.DataSourceInstanceSettings) (sdkhttpclient.Options, error) {
+	jsonData := simplejson.New()
+	if len(settings.JSONData) > 0 {
+		var err error
+		if jsonData, err = simplejson.NewJson(settings.JSONData); err != nil {
+			return sdkhttpclient.Options{}, err
+		}
+	}
+
+	ds := &DataSource{
+		Id:            settings.ID,
+		Uid:           settings.UID,
+		Type:          dsType,
+		Name:          settings.Name,
+		Url:           settings.URL,
+		User:          settings.User,
+		Database:      settings.Database,
+		BasicAuth:     settings.BasicAuthEnabled,
+		BasicAuthUser: settings.BasicAuthUser,
+		JsonData:      jsonData,
+		Updated:       settings.Updated,
+	}
+	return ds.httpClientOptions(settings.DecryptedSecureJSONData), nil
+}
+
+func (ds *DataSource) httpClientOptions(decryptedValues map[string]string) sdkhttpclient.Options {
+	tlsOptions := ds.tlsOptions(decryptedValues)
 	timeouts := &sdkhttpclient.TimeoutOptions{
 		Timeout:               ds.getTimeout(),
 		DialTimeout:           time.Duration(setting.DataProxyDialTimeout) * time.Second,
@@ -97,7 +131,7 @@ func (ds *DataSource) HTTPClientOptions() sdkhttpclient.Options {
 	}
 	opts := sdkhttpclient.Options{
 		Timeouts: timeouts,
-		Headers:  getCustomHeaders(ds.JsonData, ds.DecryptedValues()),
+		Headers:  getCustomHeaders(ds.JsonData, decryptedValues),
 		Labels: map[string]string{
 			"datasource_name": ds.Name,
 			"datasource_uid":  ds.Uid,
@@ -112,12 +146,12 @@ func (ds *DataSource) HTTPClientOptions() sdkhttpclient.Options {
 	if ds.BasicAuth {
 		opts.BasicAuth = &sdkhttpclient.BasicAuthOptions{
 			User:     ds.BasicAuthUser,
-			Password: ds.DecryptedBasicAuthPassword(),
+			Password: valueOrDefault(decryptedValues, "basicAuthPassword", ds.BasicAuthPassword),
 		}
 	} else if ds.User != "" {
 		opts.BasicAuth = &sdkhttpclient.BasicAuthOptions{
 			User:     ds.User,
-			Password: ds.DecryptedPassword(),
+			Password: valueOrDefault(decryptedValues, "password", ds.Password),
 		}
 	}
 
@@ -131,11 +165,11 @@ func (ds *DataSource) HTTPClientOptions() sdkhttpclient.Options {
 			Profile:       ds.JsonData.Get("sigV4Profile").MustString(),
 		}
 
-		if val, exists := ds.DecryptedValue("sigV4AccessKey"); exists {
+		if val, exists := decryptedValues["sigV4AccessKey"]; exists {
 			opts.SigV4.AccessKey = val
 		}
 
-		if val, exists := ds.DecryptedValue("sigV4SecretKey"); exists {
+		if val, exists := decryptedValues["sigV4SecretKey"]; exists {
 			opts.SigV4.SecretKey = val
 		}
 	}
@@ -144,6 +178,10 @@ func (ds *DataSource) HTTPClientOptions() sdkhttpclient.Options {
 }
 
 func (ds *DataSource) TLSOptions() sdkhttpclient.TLSOptions {
+	return ds.tlsOptions(ds.DecryptedValues())
+}
+
+func (ds *DataSource) tlsOptions(decryptedValues map[string]string) sdkhttpclient.TLSOptions {
 	var tlsSkipVerify, tlsClientAuth, tlsAuthWithCACert bool
 	var serverName string
 
@@ -161,16 +199,16 @@ func (ds *DataSource) TLSOptions() sdkhttpclient.TLSOptions {
 
 	if tlsClientAuth || tlsAuthWithCACert {
 		if tlsAuthWithCACert {
-			if val, exists := ds.DecryptedValue("tlsCACert"); exists && len(val) > 0 {
+			if val, exists := decryptedValues["tlsCACert"]; exists && len(val) > 0 {
 				opts.CACertificate = val
 			}
 		}
 
 		if tlsClientAuth {
-			if val, exists := ds.DecryptedValue("tlsClientCert"); exists && len(val) > 0 {
+			if val, exists := decryptedValues["tlsClientCert"]; exists && len(val) > 0 {
 				opts.ClientCertificate = val
 			}
-			if val, exists := ds.DecryptedValue("tlsClientKey"); exists && len(val) > 0 {
+			if val, exists := decryptedValues["tlsClientKey"]; exists && len(val) > 0 {
 				opts.ClientKey = val
 			}
 		}
@@ -183,6 +221,14 @@ func (ds *DataSource) GetTLSConfig(httpClientProvider httpclient.Provider) (*tls
 	return httpClientProvider.GetTLSConfig(ds.HTTPClientOptions())
 }
 
+// valueOrDefault returns the decrypted value of the key, or the fallback when there is none.
+func valueOrDefault(decryptedValues map[string]string, key string, fallback string) string {
+	if value, ok := decryptedValues[key]; ok {
+		return value
+	}
+	return fallback
+}
+
 // getCustomHeaders returns a map with all the to be set headers
 // The map key represents the HeaderName and the value represents this header's value
 func getCustomHeaders(jsonData *simplejson.Json, decryptedValues map[string]string) map[string]string {
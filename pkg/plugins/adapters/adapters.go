@@ -7,12 +7,26 @@ import (
 )
 
 // ModelToInstanceSettings converts a models.DataSource to a backend.DataSourceInstanceSettings.
+// The passwords of the deprecated password fields are part of the decrypted secure JSON data,
+// unless it has its own.
 func ModelToInstanceSettings(ds *models.DataSource) (*backend.DataSourceInstanceSettings, error) {
 	jsonDataBytes, err := ds.JsonData.MarshalJSON()
 	if err != nil {
 		return nil, err
 	}
 
+	decrypted := ds.DecryptedValues()
+	secureJSONData := make(map[string]string, len(decrypted)+2)
+	for k, v := range decrypted {
+		secureJSONData[k] = v
+	}
+	if _, exists := secureJSONData["password"]; !exists && ds.Password != "" {
+		secureJSONData["password"] = ds.Password
+	}
+	if _, exists := secureJSONData["basicAuthPassword"]; !exists && ds.BasicAuthPassword != "" {
+		secureJSONData["basicAuthPassword"] = ds.BasicAuthPassword
+	}
+
 	return &backend.DataSourceInstanceSettings{
 		ID:                      ds.Id,
 		Name:                    ds.Name,
@@ -23,7 +37,7 @@ func ModelToInstanceSettings(ds *models.DataSource) (*backend.DataSourceInstance
 		BasicAuthEnabled:        ds.BasicAuth,
 		BasicAuthUser:           ds.BasicAuthUser,
 		JSONData:                jsonDataBytes,
-		DecryptedSecureJSONData: ds.DecryptedValues(),
+		DecryptedSecureJSONData: secureJSONData,
 		Updated:                 ds.Updated,
 	}, nil
 }
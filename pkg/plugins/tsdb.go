@@ -33,6 +33,8 @@ type DataQuery struct {
 	Headers   map[string]string
 	Debug     bool
 	User      *models.SignedInUser
+	// SkipCache is set when the results must not be read from the query cache.
+	SkipCache bool
 }
 
 type DataTimeRange struct {
@@ -250,6 +252,15 @@ func NewDataTimeRange(from, to string) DataTimeRange {
 	}
 }
 
+// NewDataTimeRangeFromBackend returns the DataTimeRange of the time range of a backend data query.
+func NewDataTimeRangeFromBackend(tr backend.TimeRange) DataTimeRange {
+	return DataTimeRange{
+		From: strconv.FormatInt(tr.From.UnixNano()/int64(time.Millisecond), 10),
+		To:   strconv.FormatInt(tr.To.UnixNano()/int64(time.Millisecond), 10),
+		Now:  time.Now(),
+	}
+}
+
 func (tr *DataTimeRange) GetFromAsMsEpoch() int64 {
 	return tr.MustGetFrom().UnixNano() / int64(time.Millisecond)
 }
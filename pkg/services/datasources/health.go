@@ -0,0 +1,192 @@
+package datasources
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/adapters"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// healthCheckedTypes are the types of the data sources the health of which is checked in the background,
+// the core data sources which implement health checks.
+var healthCheckedTypes = []string{
+	"graphite", "prometheus", "elasticsearch", "influxdb", "mysql", "mssql", "postgres", "loki", "tempo",
+}
+
+// healthCheckConcurrency is the maximum number of data sources checked at the same time.
+const healthCheckConcurrency = 10
+
+// DataSourceHealth is the result of the last health check of a data source.
+type DataSourceHealth struct {
+	OrgID     int64           `json:"orgId"`
+	UID       string          `json:"uid"`
+	Name      string          `json:"name"`
+	Type      string          `json:"type"`
+	Status    string          `json:"status"`
+	Message   string          `json:"message"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	CheckedAt time.Time       `json:"checkedAt"`
+}
+
+// HealthCheckService checks the health of the core data sources in the background, and records
+// the results in the grafana_datasource_health metric.
+type HealthCheckService struct {
+	Cfg                  *setting.Cfg          `inject:""`
+	BackendPluginManager backendplugin.Manager `inject:""`
+
+	mu      sync.RWMutex
+	results map[int64]DataSourceHealth
+}
+
+func init() {
+	registry.RegisterService(&HealthCheckService{})
+}
+
+func (s *HealthCheckService) Init() error {
+	s.results = map[int64]DataSourceHealth{}
+	return nil
+}
+
+// IsDisabled returns true when the background health checks are disabled.
+func (s *HealthCheckService) IsDisabled() bool {
+	return s.Cfg.DataSourceHealthCheckInterval <= 0
+}
+
+func (s *HealthCheckService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.Cfg.DataSourceHealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		s.CheckAll(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Results returns the results of the last health checks, sorted by organization and name of the data sources.
+func (s *HealthCheckService) Results() []DataSourceHealth {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]DataSourceHealth, 0, len(s.results))
+	for _, r := range s.results {
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].OrgID != results[j].OrgID {
+			return results[i].OrgID < results[j].OrgID
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+// CheckAll checks the health of the data sources of the health checked types. The results of the data
+// sources which no longer exist are removed. When the data sources of a type cannot be listed, the
+// previous results of this type are kept.
+func (s *HealthCheckService) CheckAll(ctx context.Context) {
+	var dataSources []*models.DataSource
+	failedTypes := make(map[string]struct{})
+	for _, dsType := range healthCheckedTypes {
+		query := &models.GetDataSourcesByTypeQuery{Type: dsType}
+		if err := bus.Dispatch(query); err != nil {
+			plog.Error("Failed to get the data sources to check the health of", "type", dsType, "err", err)
+			failedTypes[dsType] = struct{}{}
+			continue
+		}
+		dataSources = append(dataSources, query.Result...)
+	}
+
+	results := make(map[int64]DataSourceHealth, len(dataSources))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, healthCheckConcurrency)
+	for _, ds := range dataSources {
+		ds := ds
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result := s.check(ctx, ds)
+			mu.Lock()
+			results[ds.Id] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, previous := range s.results {
+		if _, ok := failedTypes[previous.Type]; ok {
+			results[id] = previous
+			continue
+		}
+		if _, ok := results[id]; !ok {
+			metrics.MDataSourceHealth.DeleteLabelValues(strconv.FormatInt(previous.OrgID, 10), previous.UID, previous.Type)
+		}
+	}
+	for _, result := range results {
+		value := 0.0
+		if result.Status == backend.HealthStatusOk.String() {
+			value = 1
+		}
+		metrics.MDataSourceHealth.WithLabelValues(strconv.FormatInt(result.OrgID, 10), result.UID, result.Type).Set(value)
+	}
+	s.results = results
+}
+
+// check checks the health of the data source. The data sources the health of which cannot be checked are unhealthy.
+func (s *HealthCheckService) check(ctx context.Context, ds *models.DataSource) DataSourceHealth {
+	result := DataSourceHealth{
+		OrgID:     ds.OrgId,
+		UID:       ds.Uid,
+		Name:      ds.Name,
+		Type:      ds.Type,
+		Status:    backend.HealthStatusError.String(),
+		CheckedAt: time.Now(),
+	}
+
+	settings, err := adapters.ModelToInstanceSettings(ds)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.Cfg.DataSourceHealthCheckTimeout)
+	defer cancel()
+	resp, err := s.BackendPluginManager.CheckHealth(ctx, backend.PluginContext{
+		OrgID:                      ds.OrgId,
+		PluginID:                   ds.Type,
+		DataSourceInstanceSettings: settings,
+	})
+	if err != nil {
+		plog.Debug("Failed to check the health of the data source", "uid", ds.Uid, "orgId", ds.OrgId, "err", err)
+		result.Message = err.Error()
+		return result
+	}
+
+	result.Status = resp.Status.String()
+	result.Message = resp.Message
+	if len(resp.JSONDetails) > 0 && json.Valid(resp.JSONDetails) {
+		result.Details = resp.JSONDetails
+	}
+	return result
+}
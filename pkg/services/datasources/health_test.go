@@ -0,0 +1,110 @@
+package datasources
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeBackendPluginManager struct {
+	backendplugin.Manager
+	checkHealth func(pCtx backend.PluginContext) (*backend.CheckHealthResult, error)
+}
+
+func (m *fakeBackendPluginManager) CheckHealth(ctx context.Context, pCtx backend.PluginContext) (*backend.CheckHealthResult, error) {
+	return m.checkHealth(pCtx)
+}
+
+func TestHealthCheckService(t *testing.T) {
+	t.Cleanup(bus.ClearBusHandlers)
+
+	dataSources := map[string][]*models.DataSource{
+		"prometheus": {
+			{Id: 1, OrgId: 1, Uid: "prom", Name: "Prometheus", Type: "prometheus", JsonData: simplejson.New()},
+		},
+		"loki": {
+			{Id: 2, OrgId: 2, Uid: "loki", Name: "Loki", Type: "loki", JsonData: simplejson.New()},
+		},
+	}
+	failingTypes := map[string]bool{}
+	bus.AddHandler("test", func(query *models.GetDataSourcesByTypeQuery) error {
+		if failingTypes[query.Type] {
+			return errors.New("database is locked")
+		}
+		query.Result = dataSources[query.Type]
+		return nil
+	})
+
+	s := &HealthCheckService{
+		Cfg: &setting.Cfg{DataSourceHealthCheckTimeout: time.Second},
+		BackendPluginManager: &fakeBackendPluginManager{
+			checkHealth: func(pCtx backend.PluginContext) (*backend.CheckHealthResult, error) {
+				switch pCtx.PluginID {
+				case "prometheus":
+					return &backend.CheckHealthResult{
+						Status:      backend.HealthStatusOk,
+						Message:     "Data source is working",
+						JSONDetails: []byte(`{"version": "2.28.0"}`),
+					}, nil
+				default:
+					return nil, errors.New("failed to check plugin health")
+				}
+			},
+		},
+	}
+	require.NoError(t, s.Init())
+
+	t.Run("the health of the data sources is checked", func(t *testing.T) {
+		s.CheckAll(context.Background())
+
+		results := s.Results()
+		require.Len(t, results, 2)
+		require.Equal(t, "prom", results[0].UID)
+		require.Equal(t, "OK", results[0].Status)
+		require.JSONEq(t, `{"version": "2.28.0"}`, string(results[0].Details))
+		require.Equal(t, "loki", results[1].UID)
+		require.Equal(t, "ERROR", results[1].Status)
+		require.Equal(t, "failed to check plugin health", results[1].Message)
+
+		require.Equal(t, 1.0, testutil.ToFloat64(metrics.MDataSourceHealth.WithLabelValues("1", "prom", "prometheus")))
+		require.Equal(t, 0.0, testutil.ToFloat64(metrics.MDataSourceHealth.WithLabelValues("2", "loki", "loki")))
+	})
+
+	t.Run("the other types are checked when the data sources of a type cannot be listed", func(t *testing.T) {
+		failingTypes["prometheus"] = true
+		t.Cleanup(func() { delete(failingTypes, "prometheus") })
+		previous := s.Results()
+		dataSources["loki"][0].Name = "Loki logs"
+
+		s.CheckAll(context.Background())
+
+		results := s.Results()
+		require.Len(t, results, 2)
+		require.Equal(t, previous[0], results[0], "the previous results of the type are kept")
+		require.Equal(t, "Loki logs", results[1].Name)
+		require.True(t, results[1].CheckedAt.After(previous[1].CheckedAt))
+		require.Equal(t, 1.0, testutil.ToFloat64(metrics.MDataSourceHealth.WithLabelValues("1", "prom", "prometheus")))
+	})
+
+	t.Run("the results of the deleted data sources are removed", func(t *testing.T) {
+		delete(dataSources, "loki")
+		s.CheckAll(context.Background())
+
+		results := s.Results()
+		require.Len(t, results, 1)
+		require.Equal(t, "prom", results[0].UID)
+		require.False(t, metrics.MDataSourceHealth.DeleteLabelValues("2", "loki", "loki"))
+	})
+}
@@ -1,9 +1,16 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -16,20 +23,52 @@ import (
 )
 
 type AlertmanagerSrv struct {
-	am    Alertmanager
-	store store.AlertingStore
-	log   log.Logger
+	mam             *notifier.MultiOrgAlertmanager
+	store           store.AlertingStore
+	provenanceStore store.ProvisioningStore
+	log             log.Logger
+}
+
+// AlertmanagerFor returns the Alertmanager of the organization, or the error response
+// to send if the organization has none. The Alertmanagers are synchronized with the
+// organizations once before giving up, so that a new organization does not have to
+// wait for the next periodic synchronization.
+func (srv AlertmanagerSrv) AlertmanagerFor(ctx context.Context, orgID int64) (Alertmanager, *response.NormalResponse) {
+	am, err := srv.mam.AlertmanagerFor(orgID)
+	if errors.Is(err, notifier.ErrNoAlertmanagerForOrg) {
+		if syncErr := srv.mam.LoadAndSyncAlertmanagersForOrgs(ctx); syncErr != nil {
+			srv.log.Error("failed to synchronize Alertmanagers for orgs", "err", syncErr)
+		}
+		am, err = srv.mam.AlertmanagerFor(orgID)
+	}
+	if err != nil {
+		if errors.Is(err, notifier.ErrNoAlertmanagerForOrg) {
+			return nil, ErrResp(http.StatusNotFound, err, "")
+		}
+		return nil, ErrResp(http.StatusInternalServerError, err, "failed to get Alertmanager of the organization")
+	}
+	return am, nil
 }
 
 func (srv AlertmanagerSrv) RouteGetAMStatus(c *models.ReqContext) response.Response {
-	return response.JSON(http.StatusOK, srv.am.GetStatus())
+	am, errResp := srv.AlertmanagerFor(c.Req.Context(), c.OrgId)
+	if errResp != nil {
+		return errResp
+	}
+
+	return response.JSON(http.StatusOK, am.GetStatus())
 }
 
 func (srv AlertmanagerSrv) RouteCreateSilence(c *models.ReqContext, postableSilence apimodels.PostableSilence) response.Response {
 	if !c.HasUserRole(models.ROLE_EDITOR) {
 		return ErrResp(http.StatusForbidden, errors.New("permission denied"), "")
 	}
-	silenceID, err := srv.am.CreateSilence(&postableSilence)
+	am, errResp := srv.AlertmanagerFor(c.Req.Context(), c.OrgId)
+	if errResp != nil {
+		return errResp
+	}
+
+	silenceID, err := am.CreateSilence(&postableSilence)
 	if err != nil {
 		if errors.Is(err, notifier.ErrSilenceNotFound) {
 			return ErrResp(http.StatusNotFound, err, "")
@@ -53,8 +92,13 @@ func (srv AlertmanagerSrv) RouteDeleteSilence(c *models.ReqContext) response.Res
 	if !c.HasUserRole(models.ROLE_EDITOR) {
 		return ErrResp(http.StatusForbidden, errors.New("permission denied"), "")
 	}
+	am, errResp := srv.AlertmanagerFor(c.Req.Context(), c.OrgId)
+	if errResp != nil {
+		return errResp
+	}
+
 	silenceID := c.Params(":SilenceId")
-	if err := srv.am.DeleteSilence(silenceID); err != nil {
+	if err := am.DeleteSilence(silenceID); err != nil {
 		if errors.Is(err, notifier.ErrSilenceNotFound) {
 			return ErrResp(http.StatusNotFound, err, "")
 		}
@@ -67,7 +111,7 @@ func (srv AlertmanagerSrv) RouteGetAlertingConfig(c *models.ReqContext) response
 	if !c.HasUserRole(models.ROLE_EDITOR) {
 		return ErrResp(http.StatusForbidden, errors.New("permission denied"), "")
 	}
-	query := ngmodels.GetLatestAlertmanagerConfigurationQuery{}
+	query := ngmodels.GetLatestAlertmanagerConfigurationQuery{OrgID: c.OrgId}
 	if err := srv.store.GetLatestAlertmanagerConfiguration(&query); err != nil {
 		if errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
 			return ErrResp(http.StatusNotFound, err, "")
@@ -80,6 +124,11 @@ func (srv AlertmanagerSrv) RouteGetAlertingConfig(c *models.ReqContext) response
 		return ErrResp(http.StatusInternalServerError, err, "failed to unmarshal alertmanager configuration")
 	}
 
+	provenanceQuery := ngmodels.GetProvenancesQuery{OrgID: c.OrgId, RecordType: ngmodels.ProvenanceRecordContactPoint}
+	if err := srv.provenanceStore.GetProvenances(&provenanceQuery); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get the provenance of the contact points")
+	}
+
 	result := apimodels.GettableUserConfig{
 		TemplateFiles: cfg.TemplateFiles,
 		AlertmanagerConfig: apimodels.GettableApiAlertingConfig{
@@ -107,6 +156,7 @@ func (srv AlertmanagerSrv) RouteGetAlertingConfig(c *models.ReqContext) response
 				DisableResolveMessage: pr.DisableResolveMessage,
 				Settings:              pr.Settings,
 				SecureFields:          secureFields,
+				Provenance:            apimodels.Provenance(provenanceQuery.Result[pr.UID]),
 			}
 			receivers = append(receivers, &gr)
 		}
@@ -123,7 +173,12 @@ func (srv AlertmanagerSrv) RouteGetAlertingConfig(c *models.ReqContext) response
 }
 
 func (srv AlertmanagerSrv) RouteGetAMAlertGroups(c *models.ReqContext) response.Response {
-	groups, err := srv.am.GetAlertGroups(
+	am, errResp := srv.AlertmanagerFor(c.Req.Context(), c.OrgId)
+	if errResp != nil {
+		return errResp
+	}
+
+	groups, err := am.GetAlertGroups(
 		c.QueryBoolWithDefault("active", true),
 		c.QueryBoolWithDefault("silenced", true),
 		c.QueryBoolWithDefault("inhibited", true),
@@ -142,7 +197,12 @@ func (srv AlertmanagerSrv) RouteGetAMAlertGroups(c *models.ReqContext) response.
 }
 
 func (srv AlertmanagerSrv) RouteGetAMAlerts(c *models.ReqContext) response.Response {
-	alerts, err := srv.am.GetAlerts(
+	am, errResp := srv.AlertmanagerFor(c.Req.Context(), c.OrgId)
+	if errResp != nil {
+		return errResp
+	}
+
+	alerts, err := am.GetAlerts(
 		c.QueryBoolWithDefault("active", true),
 		c.QueryBoolWithDefault("silenced", true),
 		c.QueryBoolWithDefault("inhibited", true),
@@ -161,8 +221,13 @@ func (srv AlertmanagerSrv) RouteGetAMAlerts(c *models.ReqContext) response.Respo
 }
 
 func (srv AlertmanagerSrv) RouteGetSilence(c *models.ReqContext) response.Response {
+	am, errResp := srv.AlertmanagerFor(c.Req.Context(), c.OrgId)
+	if errResp != nil {
+		return errResp
+	}
+
 	silenceID := c.Params(":SilenceId")
-	gettableSilence, err := srv.am.GetSilence(silenceID)
+	gettableSilence, err := am.GetSilence(silenceID)
 	if err != nil {
 		if errors.Is(err, notifier.ErrSilenceNotFound) {
 			return ErrResp(http.StatusNotFound, err, "")
@@ -174,7 +239,12 @@ func (srv AlertmanagerSrv) RouteGetSilence(c *models.ReqContext) response.Respon
 }
 
 func (srv AlertmanagerSrv) RouteGetSilences(c *models.ReqContext) response.Response {
-	gettableSilences, err := srv.am.ListSilences(c.QueryStrings("filter"))
+	am, errResp := srv.AlertmanagerFor(c.Req.Context(), c.OrgId)
+	if errResp != nil {
+		return errResp
+	}
+
+	gettableSilences, err := am.ListSilences(c.QueryStrings("filter"))
 	if err != nil {
 		if errors.Is(err, notifier.ErrListSilencesBadPayload) {
 			return ErrResp(http.StatusBadRequest, err, "")
@@ -190,8 +260,13 @@ func (srv AlertmanagerSrv) RoutePostAlertingConfig(c *models.ReqContext, body ap
 		return ErrResp(http.StatusForbidden, errors.New("permission denied"), "")
 	}
 
+	am, errResp := srv.AlertmanagerFor(c.Req.Context(), c.OrgId)
+	if errResp != nil {
+		return errResp
+	}
+
 	// Get the last known working configuration
-	query := ngmodels.GetLatestAlertmanagerConfigurationQuery{}
+	query := ngmodels.GetLatestAlertmanagerConfigurationQuery{OrgID: c.OrgId}
 	if err := srv.store.GetLatestAlertmanagerConfiguration(&query); err != nil {
 		// If we don't have a configuration there's nothing for us to know and we should just continue saving the new one
 		if !errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
@@ -206,6 +281,14 @@ func (srv AlertmanagerSrv) RoutePostAlertingConfig(c *models.ReqContext, body ap
 			return ErrResp(http.StatusInternalServerError, err, "failed to load lastest configuration")
 		}
 		currentReceiverMap = currentConfig.GetGrafanaReceiverMap()
+
+		provisioned, err := srv.getProvisionedResources(c.OrgId)
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to get the provisioned resources")
+		}
+		if err := validateProvisionedResources(currentConfig, &body, provisioned); err != nil {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
 	}
 
 	// Copy the previously known secure settings
@@ -245,7 +328,7 @@ func (srv AlertmanagerSrv) RoutePostAlertingConfig(c *models.ReqContext, body ap
 		return ErrResp(http.StatusInternalServerError, err, "failed to post process Alertmanager configuration")
 	}
 
-	if err := srv.am.SaveAndApplyConfig(&body); err != nil {
+	if err := am.SaveAndApplyConfig(&body); err != nil {
 		srv.log.Error("unable to save and apply alertmanager configuration", "err", err)
 		return ErrResp(http.StatusBadRequest, err, "failed to save and apply Alertmanager configuration")
 	}
@@ -253,7 +336,299 @@ func (srv AlertmanagerSrv) RoutePostAlertingConfig(c *models.ReqContext, body ap
 	return response.JSON(http.StatusAccepted, util.DynMap{"message": "configuration created"})
 }
 
+func (srv AlertmanagerSrv) RouteGetTemplates(c *models.ReqContext) response.Response {
+	if !c.HasUserRole(models.ROLE_EDITOR) {
+		return ErrResp(http.StatusForbidden, errors.New("permission denied"), "")
+	}
+	cfg, version, err := srv.latestConfig(c.OrgId)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get latest configuration")
+	}
+
+	templates := make(apimodels.NotificationTemplates, 0, len(cfg.TemplateFiles))
+	for name, content := range cfg.TemplateFiles {
+		templates = append(templates, apimodels.NotificationTemplate{Name: name, Template: content, Version: version})
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return response.JSON(http.StatusOK, templates)
+}
+
+func (srv AlertmanagerSrv) RouteGetTemplate(c *models.ReqContext) response.Response {
+	if !c.HasUserRole(models.ROLE_EDITOR) {
+		return ErrResp(http.StatusForbidden, errors.New("permission denied"), "")
+	}
+	cfg, version, err := srv.latestConfig(c.OrgId)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get latest configuration")
+	}
+
+	name := c.Params(":Name")
+	content, ok := cfg.TemplateFiles[name]
+	if !ok {
+		return ErrResp(http.StatusNotFound, fmt.Errorf("template %s not found", name), "")
+	}
+	return response.JSON(http.StatusOK, apimodels.NotificationTemplate{Name: name, Template: content, Version: version})
+}
+
+func (srv AlertmanagerSrv) RoutePutTemplate(c *models.ReqContext, body apimodels.PostableNotificationTemplate) response.Response {
+	if !c.HasUserRole(models.ROLE_EDITOR) {
+		return ErrResp(http.StatusForbidden, errors.New("permission denied"), "")
+	}
+	name := c.Params(":Name")
+	if err := notifier.ValidateTemplate(name, body.Template); err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+
+	errResp := srv.updateTemplates(c, body.Version, func(cfg *apimodels.PostableUserConfig) response.Response {
+		cfg.TemplateFiles[name] = body.Template
+		return nil
+	})
+	if errResp != nil {
+		return errResp
+	}
+	return response.JSON(http.StatusAccepted, util.DynMap{"message": "template updated"})
+}
+
+func (srv AlertmanagerSrv) RouteDeleteTemplate(c *models.ReqContext) response.Response {
+	if !c.HasUserRole(models.ROLE_EDITOR) {
+		return ErrResp(http.StatusForbidden, errors.New("permission denied"), "")
+	}
+	name := c.Params(":Name")
+
+	errResp := srv.updateTemplates(c, c.QueryInt64("version"), func(cfg *apimodels.PostableUserConfig) response.Response {
+		if _, ok := cfg.TemplateFiles[name]; !ok {
+			return ErrResp(http.StatusNotFound, fmt.Errorf("template %s not found", name), "")
+		}
+		receivers, err := notifier.ReceiversUsingTemplate(cfg, name)
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to get the contact points using the template")
+		}
+		if len(receivers) > 0 {
+			return ErrResp(http.StatusBadRequest, fmt.Errorf("template %s is used by the contact points %s", name, strings.Join(receivers, ", ")), "")
+		}
+		delete(cfg.TemplateFiles, name)
+		return nil
+	})
+	if errResp != nil {
+		return errResp
+	}
+	return response.JSON(http.StatusOK, util.DynMap{"message": "template deleted"})
+}
+
+func (srv AlertmanagerSrv) RoutePostTemplatePreview(c *models.ReqContext, body apimodels.TemplatePreviewConfig) response.Response {
+	if !c.HasUserRole(models.ROLE_EDITOR) {
+		return ErrResp(http.StatusForbidden, errors.New("permission denied"), "")
+	}
+	am, errResp := srv.AlertmanagerFor(c.Req.Context(), c.OrgId)
+	if errResp != nil {
+		return errResp
+	}
+	cfg, _, err := srv.latestConfig(c.OrgId)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get latest configuration")
+	}
+
+	alerts := body.Alerts
+	if body.Live {
+		gettableAlerts, err := am.GetAlerts(true, true, true, body.Filter, "")
+		if err != nil {
+			if errors.Is(err, notifier.ErrGetAlertsBadPayload) {
+				return ErrResp(http.StatusBadRequest, err, "")
+			}
+			return ErrResp(http.StatusInternalServerError, err, "")
+		}
+		alerts = make([]amv2.PostableAlert, 0, len(gettableAlerts))
+		for _, a := range gettableAlerts {
+			alert := amv2.PostableAlert{Alert: a.Alert, Annotations: a.Annotations}
+			if a.StartsAt != nil {
+				alert.StartsAt = *a.StartsAt
+			}
+			if a.EndsAt != nil {
+				alert.EndsAt = *a.EndsAt
+			}
+			alerts = append(alerts, alert)
+		}
+	}
+
+	previews, err := am.PreviewTemplates(cfg.TemplateFiles, body.Name, body.Template, alerts)
+	if err != nil {
+		if errors.Is(err, notifier.ErrInvalidTemplate) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to preview the template")
+	}
+	return response.JSON(http.StatusOK, apimodels.TemplatePreviews{Previews: previews})
+}
+
+// latestConfig returns the latest Alertmanager configuration of the organization and its version,
+// the ID of the configuration, or the default configuration and 0 if it has none yet.
+func (srv AlertmanagerSrv) latestConfig(orgID int64) (*apimodels.PostableUserConfig, int64, error) {
+	query := ngmodels.GetLatestAlertmanagerConfigurationQuery{OrgID: orgID}
+	if err := srv.store.GetLatestAlertmanagerConfiguration(&query); err != nil {
+		if errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
+			cfg, err := notifier.DefaultConfiguration()
+			return cfg, 0, err
+		}
+		return nil, 0, err
+	}
+	cfg, err := notifier.Load([]byte(query.Result.AlertmanagerConfiguration))
+	return cfg, query.Result.ID, err
+}
+
+// updateTemplates changes the template files of the latest Alertmanager configuration of the organization,
+// and applies it. The rest of the configuration is saved as it is, with its secure settings still encrypted.
+// The configuration is not saved if it is not the given version anymore, when a version is given, or if it
+// changes in the meantime, so that concurrent changes are not lost. update returns the error response
+// when the change cannot be made.
+func (srv AlertmanagerSrv) updateTemplates(c *models.ReqContext, version int64, update func(cfg *apimodels.PostableUserConfig) response.Response) response.Response {
+	am, errResp := srv.AlertmanagerFor(c.Req.Context(), c.OrgId)
+	if errResp != nil {
+		return errResp
+	}
+	cfg, latestVersion, err := srv.latestConfig(c.OrgId)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get latest configuration")
+	}
+	if version != 0 && version != latestVersion {
+		return ErrResp(http.StatusConflict, store.ErrAlertmanagerConfigurationChanged, "version %d is not the latest version %d", version, latestVersion)
+	}
+
+	if cfg.TemplateFiles == nil {
+		cfg.TemplateFiles = map[string]string{}
+	}
+	if errResp := update(cfg); errResp != nil {
+		return errResp
+	}
+
+	if err := am.SaveAndApplyConfigVersion(cfg, latestVersion); err != nil {
+		if errors.Is(err, store.ErrAlertmanagerConfigurationChanged) {
+			return ErrResp(http.StatusConflict, err, "")
+		}
+		srv.log.Error("unable to save and apply alertmanager configuration", "err", err)
+		return ErrResp(http.StatusBadRequest, err, "failed to save and apply Alertmanager configuration")
+	}
+	return nil
+}
+
 func (srv AlertmanagerSrv) RoutePostAMAlerts(c *models.ReqContext, body apimodels.PostableAlerts) response.Response {
 	// not implemented
 	return NotImplementedResp
 }
+
+// provisionedResources holds the provenance of the provisioned resources of an Alertmanager configuration.
+type provisionedResources struct {
+	contactPoints map[string]ngmodels.Provenance
+	policies      map[string]ngmodels.Provenance
+	muteTimings   map[string]ngmodels.Provenance
+}
+
+func (srv AlertmanagerSrv) getProvisionedResources(orgID int64) (provisionedResources, error) {
+	get := func(recordType string) (map[string]ngmodels.Provenance, error) {
+		q := ngmodels.GetProvenancesQuery{OrgID: orgID, RecordType: recordType}
+		if err := srv.provenanceStore.GetProvenances(&q); err != nil {
+			return nil, err
+		}
+		return q.Result, nil
+	}
+
+	var (
+		res provisionedResources
+		err error
+	)
+	if res.contactPoints, err = get(ngmodels.ProvenanceRecordContactPoint); err != nil {
+		return res, err
+	}
+	if res.policies, err = get(ngmodels.ProvenanceRecordNotificationPolicy); err != nil {
+		return res, err
+	}
+	if res.muteTimings, err = get(ngmodels.ProvenanceRecordMuteTiming); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// validateProvisionedResources checks that the new configuration leaves the provisioned
+// contact points, notification policies and mute timings of the current one untouched.
+func validateProvisionedResources(current, updated *apimodels.PostableUserConfig, provisioned provisionedResources) error {
+	if current == nil {
+		return nil
+	}
+
+	if len(provisioned.contactPoints) > 0 {
+		updatedReceivers := make(map[string]*apimodels.PostableApiReceiver, len(updated.AlertmanagerConfig.Receivers))
+		updatedIntegrations := make(map[string]*apimodels.PostableGrafanaReceiver)
+		for _, r := range updated.AlertmanagerConfig.Receivers {
+			updatedReceivers[r.Name] = r
+			for _, gr := range r.PostableGrafanaReceivers.GrafanaManagedReceivers {
+				updatedIntegrations[gr.UID] = gr
+			}
+		}
+
+		for _, r := range current.AlertmanagerConfig.Receivers {
+			provisionedReceiver := false
+			for _, gr := range r.PostableGrafanaReceivers.GrafanaManagedReceivers {
+				if _, ok := provisioned.contactPoints[gr.UID]; !ok {
+					continue
+				}
+				provisionedReceiver = true
+				ugr, ok := updatedIntegrations[gr.UID]
+				if !ok || !sameIntegration(gr, ugr) {
+					return fmt.Errorf("%w: contact point %s", ngmodels.ErrCannotChangeProvisionedResource, r.Name)
+				}
+			}
+			if !provisionedReceiver {
+				continue
+			}
+			ur, ok := updatedReceivers[r.Name]
+			if !ok || len(ur.PostableGrafanaReceivers.GrafanaManagedReceivers) != len(r.PostableGrafanaReceivers.GrafanaManagedReceivers) {
+				return fmt.Errorf("%w: contact point %s", ngmodels.ErrCannotChangeProvisionedResource, r.Name)
+			}
+		}
+	}
+
+	if _, ok := provisioned.policies[ngmodels.NotificationPolicyRecordKey]; ok {
+		if !sameJSON(current.AlertmanagerConfig.Route, updated.AlertmanagerConfig.Route) {
+			return fmt.Errorf("%w: notification policies", ngmodels.ErrCannotChangeProvisionedResource)
+		}
+	}
+
+	if len(provisioned.muteTimings) > 0 {
+		updatedMuteTimings := make(map[string]apimodels.MuteTimeInterval, len(updated.AlertmanagerConfig.MuteTimeIntervals))
+		for _, mt := range updated.AlertmanagerConfig.MuteTimeIntervals {
+			updatedMuteTimings[mt.Name] = mt
+		}
+		for _, mt := range current.AlertmanagerConfig.MuteTimeIntervals {
+			if _, ok := provisioned.muteTimings[mt.Name]; !ok {
+				continue
+			}
+			umt, ok := updatedMuteTimings[mt.Name]
+			if !ok || !sameJSON(mt, umt) {
+				return fmt.Errorf("%w: mute timing %s", ngmodels.ErrCannotChangeProvisionedResource, mt.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// sameIntegration reports whether an integration is unchanged. The secure settings are sent only
+// when they are updated, so any secure setting in the new integration is considered a change.
+func sameIntegration(current, updated *apimodels.PostableGrafanaReceiver) bool {
+	return current.Name == updated.Name &&
+		current.Type == updated.Type &&
+		current.DisableResolveMessage == updated.DisableResolveMessage &&
+		len(updated.SecureSettings) == 0 &&
+		sameJSON(current.Settings, updated.Settings)
+}
+
+func sameJSON(a, b interface{}) bool {
+	aj, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bj, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aj, bj)
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const provisioningTestConfig = `{
+	"alertmanager_config": {
+		"route": {
+			"receiver": "email",
+			"routes": [{"receiver": "slack", "mute_time_intervals": ["weekends"]}]
+		},
+		"mute_time_intervals": [
+			{"name": "weekends", "time_intervals": [{"weekdays": ["saturday", "sunday"]}]}
+		],
+		"receivers": [
+			{
+				"name": "email",
+				"grafana_managed_receiver_configs": [
+					{"uid": "email-uid", "name": "email", "type": "email", "settings": {"addresses": "team@example.com"}}
+				]
+			},
+			{
+				"name": "slack",
+				"grafana_managed_receiver_configs": [
+					{"uid": "slack-uid", "name": "slack", "type": "slack", "settings": {"recipient": "#alerts"}}
+				]
+			}
+		]
+	}
+}`
+
+func TestValidateProvisionedResources(t *testing.T) {
+	provisioned := provisionedResources{
+		contactPoints: map[string]ngmodels.Provenance{"email-uid": ngmodels.ProvenanceFile},
+		policies:      map[string]ngmodels.Provenance{ngmodels.NotificationPolicyRecordKey: ngmodels.ProvenanceFile},
+		muteTimings:   map[string]ngmodels.Provenance{"weekends": ngmodels.ProvenanceFile},
+	}
+
+	testCases := []struct {
+		desc        string
+		update      func(cfg *apimodels.PostableUserConfig)
+		provisioned provisionedResources
+		expectedErr bool
+	}{
+		{
+			desc:        "unchanged configuration is accepted",
+			update:      func(cfg *apimodels.PostableUserConfig) {},
+			provisioned: provisioned,
+		},
+		{
+			desc: "changes to a contact point that is not provisioned are accepted",
+			update: func(cfg *apimodels.PostableUserConfig) {
+				cfg.AlertmanagerConfig.Receivers[1].GrafanaManagedReceivers[0].Settings.Set("recipient", "#other")
+			},
+			provisioned: provisioned,
+		},
+		{
+			desc: "changes to the settings of a provisioned contact point are rejected",
+			update: func(cfg *apimodels.PostableUserConfig) {
+				cfg.AlertmanagerConfig.Receivers[0].GrafanaManagedReceivers[0].Settings.Set("addresses", "other@example.com")
+			},
+			provisioned: provisioned,
+			expectedErr: true,
+		},
+		{
+			desc: "new secure settings of a provisioned contact point are rejected",
+			update: func(cfg *apimodels.PostableUserConfig) {
+				cfg.AlertmanagerConfig.Receivers[0].GrafanaManagedReceivers[0].SecureSettings = map[string]string{"password": "secret"}
+			},
+			provisioned: provisioned,
+			expectedErr: true,
+		},
+		{
+			desc: "removing a provisioned contact point is rejected",
+			update: func(cfg *apimodels.PostableUserConfig) {
+				cfg.AlertmanagerConfig.Receivers = cfg.AlertmanagerConfig.Receivers[1:]
+			},
+			provisioned: provisioned,
+			expectedErr: true,
+		},
+		{
+			desc: "adding an integration to a provisioned contact point is rejected",
+			update: func(cfg *apimodels.PostableUserConfig) {
+				r := cfg.AlertmanagerConfig.Receivers[0]
+				r.GrafanaManagedReceivers = append(r.GrafanaManagedReceivers, &apimodels.PostableGrafanaReceiver{Name: "email", Type: "webhook"})
+			},
+			provisioned: provisioned,
+			expectedErr: true,
+		},
+		{
+			desc: "changes to provisioned notification policies are rejected",
+			update: func(cfg *apimodels.PostableUserConfig) {
+				cfg.AlertmanagerConfig.Route.Receiver = "slack"
+			},
+			provisioned: provisioned,
+			expectedErr: true,
+		},
+		{
+			desc: "changes to notification policies that are not provisioned are accepted",
+			update: func(cfg *apimodels.PostableUserConfig) {
+				cfg.AlertmanagerConfig.Route.Receiver = "slack"
+			},
+			provisioned: provisionedResources{},
+		},
+		{
+			desc: "removing a provisioned mute timing is rejected",
+			update: func(cfg *apimodels.PostableUserConfig) {
+				cfg.AlertmanagerConfig.MuteTimeIntervals = nil
+			},
+			provisioned: provisioned,
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var current, updated apimodels.PostableUserConfig
+			require.NoError(t, json.Unmarshal([]byte(provisioningTestConfig), &current))
+			require.NoError(t, json.Unmarshal([]byte(provisioningTestConfig), &updated))
+			tc.update(&updated)
+
+			err := validateProvisionedResources(&current, &updated, tc.provisioned)
+			if tc.expectedErr {
+				require.ErrorIs(t, err, ngmodels.ErrCannotChangeProvisionedResource)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
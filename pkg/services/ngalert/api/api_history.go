@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+const defaultStateHistoryLimit = 100
+
+type HistorySrv struct {
+	log   log.Logger
+	store store.HistoryStore
+}
+
+func (srv HistorySrv) RouteGetStateHistory(c *models.ReqContext) response.Response {
+	labels, err := parseLabels(c.QueryStrings("labels"))
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	limit := c.QueryInt("limit")
+	if limit < 0 {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("invalid limit %d, it must be positive", limit), "")
+	}
+	if limit == 0 {
+		limit = defaultStateHistoryLimit
+	}
+
+	query := ngmodels.ListAlertStateHistoryQuery{
+		RuleOrgID: c.SignedInUser.OrgId,
+		RuleUID:   c.Query("ruleUID"),
+		Labels:    labels,
+		Limit:     limit,
+	}
+	if from := c.QueryInt64("from"); from > 0 {
+		query.From = time.Unix(0, from*int64(time.Millisecond))
+	}
+	if to := c.QueryInt64("to"); to > 0 {
+		query.To = time.Unix(0, to*int64(time.Millisecond))
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.From.After(query.To) {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("from must be before to"), "")
+	}
+
+	if err := srv.store.ListAlertStateHistory(&query); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get state history")
+	}
+
+	history := apimodels.StateHistory{
+		Transitions: make([]apimodels.StateTransition, 0, len(query.Result)),
+	}
+	for _, t := range query.Result {
+		history.Transitions = append(history.Transitions, apimodels.StateTransition{
+			RuleUID:          t.RuleUID,
+			Labels:           t.Labels,
+			PreviousState:    string(t.PreviousState),
+			State:            string(t.State),
+			EvaluationString: t.EvaluationString,
+			Error:            t.ErrorMessage,
+			EvaluatedAt:      t.EvaluatedAt,
+		})
+	}
+	return response.JSON(http.StatusOK, history)
+}
+
+// parseLabels parses labels in the form name=value.
+func parseLabels(values []string) (ngmodels.InstanceLabels, error) {
+	labels := make(ngmodels.InstanceLabels, len(values))
+	for _, v := range values {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid label '%s', it must be in the form name=value", v)
+		}
+		labels[parts[0]] = parts[1]
+	}
+	return labels, nil
+}
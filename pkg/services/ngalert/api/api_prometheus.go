@@ -168,6 +168,11 @@ func (srv PrometheusSrv) RouteGetRuleStatuses(c *models.ReqContext) response.Res
 				alertingRule.Alerts = append(alertingRule.Alerts, alert)
 			}
 
+			if rule.IsPaused {
+				// the states are the ones of the last evaluation before the rule was paused
+				newRule.Health = "paused"
+			}
+
 			alertingRule.Rule = newRule
 			newGroup.Rules = append(newGroup.Rules, alertingRule)
 			newGroup.Interval = float64(rule.IntervalSeconds)
@@ -2,10 +2,12 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/prom"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/quota"
@@ -22,6 +24,7 @@ import (
 
 type RulerSrv struct {
 	store           store.RuleStore
+	provenanceStore store.ProvisioningStore
 	DatasourceCache datasources.CacheService
 	QuotaService    *quota.QuotaService
 	manager         *state.Manager
@@ -35,6 +38,17 @@ func (srv RulerSrv) RouteDeleteNamespaceRulesConfig(c *models.ReqContext) respon
 		return toNamespaceErrorResponse(err)
 	}
 
+	q := ngmodels.ListNamespaceAlertRulesQuery{
+		OrgID:        c.SignedInUser.OrgId,
+		NamespaceUID: namespace.Uid,
+	}
+	if err := srv.store.GetNamespaceAlertRules(&q); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get namespace alert rules")
+	}
+	if errResp := srv.checkNotProvisioned(c.SignedInUser.OrgId, ruleUIDs(q.Result)); errResp != nil {
+		return errResp
+	}
+
 	uids, err := srv.store.DeleteNamespaceAlertRules(c.SignedInUser.OrgId, namespace.Uid)
 	if err != nil {
 		return ErrResp(http.StatusInternalServerError, err, "failed to delete namespace alert rules")
@@ -54,6 +68,18 @@ func (srv RulerSrv) RouteDeleteRuleGroupConfig(c *models.ReqContext) response.Re
 		return toNamespaceErrorResponse(err)
 	}
 	ruleGroup := c.Params(":Groupname")
+	q := ngmodels.ListRuleGroupAlertRulesQuery{
+		OrgID:        c.SignedInUser.OrgId,
+		NamespaceUID: namespace.Uid,
+		RuleGroup:    ruleGroup,
+	}
+	if err := srv.store.GetRuleGroupAlertRules(&q); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get group alert rules")
+	}
+	if errResp := srv.checkNotProvisioned(c.SignedInUser.OrgId, ruleUIDs(q.Result)); errResp != nil {
+		return errResp
+	}
+
 	uids, err := srv.store.DeleteRuleGroupAlertRules(c.SignedInUser.OrgId, namespace.Uid, ruleGroup)
 
 	if err != nil {
@@ -85,6 +111,11 @@ func (srv RulerSrv) RouteGetNamespaceRulesConfig(c *models.ReqContext) response.
 		return ErrResp(http.StatusInternalServerError, err, "failed to update rule group")
 	}
 
+	provenances, err := srv.getRuleProvenances(c.SignedInUser.OrgId)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get the provenance of the alert rules")
+	}
+
 	result := apimodels.NamespaceConfigResponse{}
 	ruleGroupConfigs := make(map[string]apimodels.GettableRuleGroupConfig)
 	for _, r := range q.Result {
@@ -95,11 +126,11 @@ func (srv RulerSrv) RouteGetNamespaceRulesConfig(c *models.ReqContext) response.
 				Name:     r.RuleGroup,
 				Interval: ruleGroupInterval,
 				Rules: []apimodels.GettableExtendedRuleNode{
-					toGettableExtendedRuleNode(*r, namespace.Id),
+					toGettableExtendedRuleNode(*r, namespace.Id, provenances[r.UID]),
 				},
 			}
 		} else {
-			ruleGroupConfig.Rules = append(ruleGroupConfig.Rules, toGettableExtendedRuleNode(*r, namespace.Id))
+			ruleGroupConfig.Rules = append(ruleGroupConfig.Rules, toGettableExtendedRuleNode(*r, namespace.Id, provenances[r.UID]))
 			ruleGroupConfigs[r.RuleGroup] = ruleGroupConfig
 		}
 	}
@@ -128,11 +159,16 @@ func (srv RulerSrv) RouteGetRulegGroupConfig(c *models.ReqContext) response.Resp
 		return ErrResp(http.StatusInternalServerError, err, "failed to get group alert rules")
 	}
 
+	provenances, err := srv.getRuleProvenances(c.SignedInUser.OrgId)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get the provenance of the alert rules")
+	}
+
 	var ruleGroupInterval model.Duration
 	ruleNodes := make([]apimodels.GettableExtendedRuleNode, 0, len(q.Result))
 	for _, r := range q.Result {
 		ruleGroupInterval = model.Duration(time.Duration(r.IntervalSeconds) * time.Second)
-		ruleNodes = append(ruleNodes, toGettableExtendedRuleNode(*r, namespace.Id))
+		ruleNodes = append(ruleNodes, toGettableExtendedRuleNode(*r, namespace.Id, provenances[r.UID]))
 	}
 
 	result := apimodels.RuleGroupConfigResponse{
@@ -153,6 +189,11 @@ func (srv RulerSrv) RouteGetRulesConfig(c *models.ReqContext) response.Response
 		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rules")
 	}
 
+	provenances, err := srv.getRuleProvenances(c.SignedInUser.OrgId)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get the provenance of the alert rules")
+	}
+
 	configs := make(map[string]map[string]apimodels.GettableRuleGroupConfig)
 	for _, r := range q.Result {
 		folder, err := srv.store.GetNamespaceByUID(r.NamespaceUID, c.SignedInUser.OrgId, c.SignedInUser)
@@ -173,7 +214,7 @@ func (srv RulerSrv) RouteGetRulesConfig(c *models.ReqContext) response.Response
 				Name:     r.RuleGroup,
 				Interval: ruleGroupInterval,
 				Rules: []apimodels.GettableExtendedRuleNode{
-					toGettableExtendedRuleNode(*r, folder.Id),
+					toGettableExtendedRuleNode(*r, folder.Id, provenances[r.UID]),
 				},
 			}
 		} else {
@@ -184,11 +225,11 @@ func (srv RulerSrv) RouteGetRulesConfig(c *models.ReqContext) response.Response
 					Name:     r.RuleGroup,
 					Interval: ruleGroupInterval,
 					Rules: []apimodels.GettableExtendedRuleNode{
-						toGettableExtendedRuleNode(*r, folder.Id),
+						toGettableExtendedRuleNode(*r, folder.Id, provenances[r.UID]),
 					},
 				}
 			} else {
-				ruleGroupConfig.Rules = append(ruleGroupConfig.Rules, toGettableExtendedRuleNode(*r, folder.Id))
+				ruleGroupConfig.Rules = append(ruleGroupConfig.Rules, toGettableExtendedRuleNode(*r, folder.Id, provenances[r.UID]))
 				configs[namespace][r.RuleGroup] = ruleGroupConfig
 			}
 		}
@@ -210,6 +251,66 @@ func (srv RulerSrv) RoutePostNameRulesConfig(c *models.ReqContext, ruleGroupConf
 		return toNamespaceErrorResponse(err)
 	}
 
+	if errResp := srv.updateRuleGroup(c, namespace, ruleGroupConfig); errResp != nil {
+		return errResp
+	}
+	return response.JSON(http.StatusAccepted, util.DynMap{"message": "rule group updated successfully"})
+}
+
+// RouteImportPrometheusRules converts the alerting rules of a Prometheus rule file to Grafana managed rules
+// evaluated with the data source of the request, and saves them in the namespace like RoutePostNameRulesConfig.
+// The rule groups of the file replace the ones with the same names, so a file can be imported again.
+func (srv RulerSrv) RouteImportPrometheusRules(c *models.ReqContext) response.Response {
+	namespaceTitle := c.Params(":Namespace")
+	namespace, err := srv.store.GetNamespaceByTitle(namespaceTitle, c.SignedInUser.OrgId, c.SignedInUser, true)
+	if err != nil {
+		return toNamespaceErrorResponse(err)
+	}
+
+	datasourceUID := c.Query("datasourceUID")
+	if datasourceUID == "" {
+		return ErrResp(http.StatusBadRequest, errors.New("the data source of the alert rules is missing"), "")
+	}
+	ds, err := srv.DatasourceCache.GetDatasourceByUID(datasourceUID, c.SignedInUser, c.SkipCache)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to get data source %s", datasourceUID)
+	}
+
+	body, err := c.Req.Body().Bytes()
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to read the rule file")
+	}
+	groups, err := prom.ParseRuleGroups(body)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	groups, err = prom.ConvertRuleGroups(groups, prom.Datasource{UID: ds.Uid, Type: ds.Type})
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		q := ngmodels.ListRuleGroupAlertRulesQuery{
+			OrgID:        c.SignedInUser.OrgId,
+			NamespaceUID: namespace.Uid,
+			RuleGroup:    g.Name,
+		}
+		if err := srv.store.GetRuleGroupAlertRules(&q); err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to get rule group %s", g.Name)
+		}
+		prom.KeepRuleUIDs(g, q.Result)
+		if errResp := srv.updateRuleGroup(c, namespace, g); errResp != nil {
+			return errResp
+		}
+		names = append(names, g.Name)
+	}
+	return response.JSON(http.StatusAccepted, util.DynMap{"message": "rule groups imported successfully", "groups": names})
+}
+
+// updateRuleGroup creates, updates and deletes the rules of the group in the namespace, so that they are
+// the ones of the rule group config. It returns nil on success.
+func (srv RulerSrv) updateRuleGroup(c *models.ReqContext, namespace *models.Folder, ruleGroupConfig apimodels.PostableRuleGroupConfig) response.Response {
 	// quotas are checked in advanced
 	// that is acceptable under the assumption that there will be only one alert rule under the rule group
 	// alternatively we should check the quotas after the rule group update
@@ -242,6 +343,19 @@ func (srv RulerSrv) RoutePostNameRulesConfig(c *models.ReqContext, ruleGroupConf
 		alertRuleUIDs = append(alertRuleUIDs, r.GrafanaManagedAlert.UID)
 	}
 
+	// the rules of the group that are not in the payload are deleted, so none of them can be provisioned either
+	q := ngmodels.ListRuleGroupAlertRulesQuery{
+		OrgID:        c.SignedInUser.OrgId,
+		NamespaceUID: namespace.Uid,
+		RuleGroup:    ruleGroupConfig.Name,
+	}
+	if err := srv.store.GetRuleGroupAlertRules(&q); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get group alert rules")
+	}
+	if errResp := srv.checkNotProvisioned(c.SignedInUser.OrgId, append(ruleUIDs(q.Result), alertRuleUIDs...)); errResp != nil {
+		return errResp
+	}
+
 	if err := srv.store.UpdateRuleGroup(store.UpdateRuleGroupCmd{
 		OrgID:           c.SignedInUser.OrgId,
 		NamespaceUID:    namespace.Uid,
@@ -259,10 +373,108 @@ func (srv RulerSrv) RoutePostNameRulesConfig(c *models.ReqContext, ruleGroupConf
 		srv.manager.RemoveByRuleUID(c.OrgId, uid)
 	}
 
-	return response.JSON(http.StatusAccepted, util.DynMap{"message": "rule group updated successfully"})
+	return nil
+}
+
+func (srv RulerSrv) RoutePauseRuleGroup(c *models.ReqContext) response.Response {
+	return srv.setRuleGroupPaused(c, true)
+}
+
+func (srv RulerSrv) RouteResumeRuleGroup(c *models.ReqContext) response.Response {
+	return srv.setRuleGroupPaused(c, false)
+}
+
+// setRuleGroupPaused pauses or resumes the rules of the group, or only the ones with the UIDs of the request.
+// The rules keep their UID, version history and state. Provisioned rules can be paused too: the provisioning
+// files do not set the paused state, so it is kept when they are provisioned again.
+func (srv RulerSrv) setRuleGroupPaused(c *models.ReqContext, paused bool) response.Response {
+	action := "resume"
+	if paused {
+		action = "pause"
+	}
+
+	namespaceTitle := c.Params(":Namespace")
+	namespace, err := srv.store.GetNamespaceByTitle(namespaceTitle, c.SignedInUser.OrgId, c.SignedInUser, true)
+	if err != nil {
+		return toNamespaceErrorResponse(err)
+	}
+	ruleGroup := c.Params(":Groupname")
+	q := ngmodels.ListRuleGroupAlertRulesQuery{
+		OrgID:        c.SignedInUser.OrgId,
+		NamespaceUID: namespace.Uid,
+		RuleGroup:    ruleGroup,
+	}
+	if err := srv.store.GetRuleGroupAlertRules(&q); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get group alert rules")
+	}
+	if len(q.Result) == 0 {
+		return ErrResp(http.StatusNotFound, ngmodels.ErrRuleGroupNamespaceNotFound, "failed to %s rule group", action)
+	}
+
+	rules := q.Result
+	if uids := c.QueryStrings("ruleUID"); len(uids) > 0 {
+		byUID := make(map[string]*ngmodels.AlertRule, len(q.Result))
+		for _, r := range q.Result {
+			byUID[r.UID] = r
+		}
+		rules = make([]*ngmodels.AlertRule, 0, len(uids))
+		for _, uid := range uids {
+			r, ok := byUID[uid]
+			if !ok {
+				return ErrResp(http.StatusNotFound, ngmodels.ErrAlertRuleNotFound, "failed to %s alert rule %s", action, uid)
+			}
+			rules = append(rules, r)
+		}
+	}
+
+	upsertRules := make([]store.UpsertRule, 0, len(rules))
+	for _, r := range rules {
+		if r.IsPaused == paused {
+			continue
+		}
+		updated := *r
+		updated.IsPaused = paused
+		upsertRules = append(upsertRules, store.UpsertRule{Existing: r, New: updated})
+	}
+	if err := srv.store.UpsertAlertRules(upsertRules); err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to %s rule group", action)
+	}
+
+	return response.JSON(http.StatusAccepted, util.DynMap{"message": fmt.Sprintf("rule group %sd", action)})
+}
+
+// getRuleProvenances returns the provenance of the provisioned alert rules of the organization by UID.
+func (srv RulerSrv) getRuleProvenances(orgID int64) (map[string]ngmodels.Provenance, error) {
+	q := ngmodels.GetProvenancesQuery{OrgID: orgID, RecordType: ngmodels.ProvenanceRecordAlertRule}
+	if err := srv.provenanceStore.GetProvenances(&q); err != nil {
+		return nil, err
+	}
+	return q.Result, nil
+}
+
+// checkNotProvisioned returns the error response to send if any of the alert rules is provisioned.
+func (srv RulerSrv) checkNotProvisioned(orgID int64, uids []string) response.Response {
+	provenances, err := srv.getRuleProvenances(orgID)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get the provenance of the alert rules")
+	}
+	for _, uid := range uids {
+		if provenance, ok := provenances[uid]; ok {
+			return ErrResp(http.StatusBadRequest, fmt.Errorf("%w: alert rule %s is provisioned from %s", ngmodels.ErrCannotChangeProvisionedResource, uid, provenance), "")
+		}
+	}
+	return nil
+}
+
+func ruleUIDs(rules []*ngmodels.AlertRule) []string {
+	uids := make([]string, 0, len(rules))
+	for _, r := range rules {
+		uids = append(uids, r.UID)
+	}
+	return uids
 }
 
-func toGettableExtendedRuleNode(r ngmodels.AlertRule, namespaceID int64) apimodels.GettableExtendedRuleNode {
+func toGettableExtendedRuleNode(r ngmodels.AlertRule, namespaceID int64, provenance ngmodels.Provenance) apimodels.GettableExtendedRuleNode {
 	gettableExtendedRuleNode := apimodels.GettableExtendedRuleNode{
 		GrafanaManagedAlert: &apimodels.GettableGrafanaRule{
 			ID:              r.ID,
@@ -279,6 +491,8 @@ func toGettableExtendedRuleNode(r ngmodels.AlertRule, namespaceID int64) apimode
 			RuleGroup:       r.RuleGroup,
 			NoDataState:     apimodels.NoDataState(r.NoDataState),
 			ExecErrState:    apimodels.ExecutionErrorState(r.ExecErrState),
+			Provenance:      apimodels.Provenance(provenance),
+			IsPaused:        r.IsPaused,
 		},
 	}
 	gettableExtendedRuleNode.ApiRuleNode = &apimodels.ApiRuleNode{
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -13,6 +14,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/datasources"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb"
 	"github.com/grafana/grafana/pkg/util"
@@ -80,6 +83,57 @@ func (srv TestingApiSrv) RouteTestRuleConfig(c *models.ReqContext, body apimodel
 	)
 }
 
+func (srv TestingApiSrv) RouteBacktestConfig(c *models.ReqContext, body apimodels.BacktestConfig) response.Response {
+	if body.Rule.Type() != apimodels.GrafanaManagedRule {
+		return ErrResp(http.StatusBadRequest, errors.New("only Grafana managed alert rules can be backtested"), "")
+	}
+
+	r := body.Rule.GrafanaManagedAlert
+	alertRule := &ngmodels.AlertRule{
+		OrgID:           c.SignedInUser.OrgId,
+		Title:           r.Title,
+		Condition:       r.Condition,
+		Data:            r.Data,
+		UID:             r.UID,
+		IntervalSeconds: int64(time.Duration(body.Interval).Seconds()),
+		NoDataState:     ngmodels.NoDataState(r.NoDataState),
+		ExecErrState:    ngmodels.ExecutionErrorState(r.ExecErrState),
+	}
+	if body.Rule.ApiRuleNode != nil {
+		alertRule.For = time.Duration(body.Rule.For)
+		alertRule.Labels = body.Rule.Labels
+		alertRule.Annotations = body.Rule.Annotations
+	}
+	if !alertRule.NoDataState.IsValid() {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("invalid no data state %q", alertRule.NoDataState), "")
+	}
+	if !alertRule.ExecErrState.IsValid() {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("invalid execution error state %q", alertRule.ExecErrState), "")
+	}
+
+	cond := ngmodels.Condition{
+		Condition: alertRule.Condition,
+		OrgID:     alertRule.OrgID,
+		Data:      alertRule.Data,
+	}
+	if err := validateCondition(cond, c.SignedInUser, c.SkipCache, srv.DatasourceCache); err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid condition")
+	}
+
+	evaluator := eval.Evaluator{Cfg: srv.Cfg, Log: srv.log}
+	evaluate := func(condition *ngmodels.Condition, now time.Time) (eval.Results, error) {
+		return evaluator.ConditionEval(condition, now, srv.DataService)
+	}
+	result, err := schedule.Backtest(srv.log, alertRule, body.From, body.To, evaluate, srv.Cfg.UnifiedAlerting.ResendDelay, srv.Cfg.AppURL)
+	if err != nil {
+		if errors.Is(err, schedule.ErrInvalidBacktest) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to backtest the alert rule")
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
 func (srv TestingApiSrv) RouteEvalQueries(c *models.ReqContext, cmd apimodels.EvalQueriesPayload) response.Response {
 	now := cmd.Now
 	if now.IsZero() {
@@ -99,6 +99,36 @@ func (r *ForkedRuler) RouteGetRulesConfig(ctx *models.ReqContext) response.Respo
 	}
 }
 
+func (r *ForkedRuler) RouteImportPrometheusRules(ctx *models.ReqContext) response.Response {
+	t, err := backendType(ctx, r.DatasourceCache)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+	switch t {
+	case apimodels.GrafanaBackend:
+		return r.GrafanaRuler.RouteImportPrometheusRules(ctx)
+	case apimodels.LoTexRulerBackend:
+		return r.LotexRuler.RouteImportPrometheusRules(ctx)
+	default:
+		return ErrResp(400, fmt.Errorf("unexpected backend type (%v)", t), "")
+	}
+}
+
+func (r *ForkedRuler) RoutePauseRuleGroup(ctx *models.ReqContext) response.Response {
+	t, err := backendType(ctx, r.DatasourceCache)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+	switch t {
+	case apimodels.GrafanaBackend:
+		return r.GrafanaRuler.RoutePauseRuleGroup(ctx)
+	case apimodels.LoTexRulerBackend:
+		return r.LotexRuler.RoutePauseRuleGroup(ctx)
+	default:
+		return ErrResp(400, fmt.Errorf("unexpected backend type (%v)", t), "")
+	}
+}
+
 func (r *ForkedRuler) RoutePostNameRulesConfig(ctx *models.ReqContext, conf apimodels.PostableRuleGroupConfig) response.Response {
 	backendType, err := backendType(ctx, r.DatasourceCache)
 	if err != nil {
@@ -119,3 +149,18 @@ func (r *ForkedRuler) RoutePostNameRulesConfig(ctx *models.ReqContext, conf apim
 		return ErrResp(400, fmt.Errorf("unexpected backend type (%v)", backendType), "")
 	}
 }
+
+func (r *ForkedRuler) RouteResumeRuleGroup(ctx *models.ReqContext) response.Response {
+	t, err := backendType(ctx, r.DatasourceCache)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+	switch t {
+	case apimodels.GrafanaBackend:
+		return r.GrafanaRuler.RouteResumeRuleGroup(ctx)
+	case apimodels.LoTexRulerBackend:
+		return r.LotexRuler.RouteResumeRuleGroup(ctx)
+	default:
+		return ErrResp(400, fmt.Errorf("unexpected backend type (%v)", t), "")
+	}
+}
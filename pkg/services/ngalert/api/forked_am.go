@@ -146,3 +146,48 @@ func (am *ForkedAMSvc) RoutePostAMAlerts(ctx *models.ReqContext, body apimodels.
 
 	return s.RoutePostAMAlerts(ctx, body)
 }
+
+func (am *ForkedAMSvc) RouteGetTemplates(ctx *models.ReqContext) response.Response {
+	s, err := am.getService(ctx)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+
+	return s.RouteGetTemplates(ctx)
+}
+
+func (am *ForkedAMSvc) RouteGetTemplate(ctx *models.ReqContext) response.Response {
+	s, err := am.getService(ctx)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+
+	return s.RouteGetTemplate(ctx)
+}
+
+func (am *ForkedAMSvc) RoutePutTemplate(ctx *models.ReqContext, body apimodels.PostableNotificationTemplate) response.Response {
+	s, err := am.getService(ctx)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+
+	return s.RoutePutTemplate(ctx, body)
+}
+
+func (am *ForkedAMSvc) RouteDeleteTemplate(ctx *models.ReqContext) response.Response {
+	s, err := am.getService(ctx)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+
+	return s.RouteDeleteTemplate(ctx)
+}
+
+func (am *ForkedAMSvc) RoutePostTemplatePreview(ctx *models.ReqContext, body apimodels.TemplatePreviewConfig) response.Response {
+	s, err := am.getService(ctx)
+	if err != nil {
+		return ErrResp(400, err, "")
+	}
+
+	return s.RoutePostTemplatePreview(ctx, body)
+}
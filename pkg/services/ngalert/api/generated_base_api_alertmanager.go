@@ -24,14 +24,19 @@ type AlertmanagerApiService interface {
 	RouteCreateSilence(*models.ReqContext, apimodels.PostableSilence) response.Response
 	RouteDeleteAlertingConfig(*models.ReqContext) response.Response
 	RouteDeleteSilence(*models.ReqContext) response.Response
+	RouteDeleteTemplate(*models.ReqContext) response.Response
 	RouteGetAMAlertGroups(*models.ReqContext) response.Response
 	RouteGetAMAlerts(*models.ReqContext) response.Response
 	RouteGetAMStatus(*models.ReqContext) response.Response
 	RouteGetAlertingConfig(*models.ReqContext) response.Response
 	RouteGetSilence(*models.ReqContext) response.Response
 	RouteGetSilences(*models.ReqContext) response.Response
+	RouteGetTemplate(*models.ReqContext) response.Response
+	RouteGetTemplates(*models.ReqContext) response.Response
 	RoutePostAMAlerts(*models.ReqContext, apimodels.PostableAlerts) response.Response
 	RoutePostAlertingConfig(*models.ReqContext, apimodels.PostableUserConfig) response.Response
+	RoutePostTemplatePreview(*models.ReqContext, apimodels.TemplatePreviewConfig) response.Response
+	RoutePutTemplate(*models.ReqContext, apimodels.PostableNotificationTemplate) response.Response
 }
 
 func (api *API) RegisterAlertmanagerApiEndpoints(srv AlertmanagerApiService, m *metrics.Metrics) {
@@ -64,6 +69,15 @@ func (api *API) RegisterAlertmanagerApiEndpoints(srv AlertmanagerApiService, m *
 				m,
 			),
 		)
+		group.Delete(
+			toMacaronPath("/api/alertmanager/{Recipient}/config/api/v1/templates/{Name}"),
+			metrics.Instrument(
+				http.MethodDelete,
+				"/api/alertmanager/{Recipient}/config/api/v1/templates/{Name}",
+				srv.RouteDeleteTemplate,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/alertmanager/{Recipient}/api/v2/alerts/groups"),
 			metrics.Instrument(
@@ -118,6 +132,24 @@ func (api *API) RegisterAlertmanagerApiEndpoints(srv AlertmanagerApiService, m *
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/alertmanager/{Recipient}/config/api/v1/templates/{Name}"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/alertmanager/{Recipient}/config/api/v1/templates/{Name}",
+				srv.RouteGetTemplate,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/alertmanager/{Recipient}/config/api/v1/templates"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/alertmanager/{Recipient}/config/api/v1/templates",
+				srv.RouteGetTemplates,
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/alertmanager/{Recipient}/api/v2/alerts"),
 			binding.Bind(apimodels.PostableAlerts{}),
@@ -138,5 +170,25 @@ func (api *API) RegisterAlertmanagerApiEndpoints(srv AlertmanagerApiService, m *
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/alertmanager/{Recipient}/config/api/v1/templates/preview"),
+			binding.Bind(apimodels.TemplatePreviewConfig{}),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/alertmanager/{Recipient}/config/api/v1/templates/preview",
+				srv.RoutePostTemplatePreview,
+				m,
+			),
+		)
+		group.Put(
+			toMacaronPath("/api/alertmanager/{Recipient}/config/api/v1/templates/{Name}"),
+			binding.Bind(apimodels.PostableNotificationTemplate{}),
+			metrics.Instrument(
+				http.MethodPut,
+				"/api/alertmanager/{Recipient}/config/api/v1/templates/{Name}",
+				srv.RoutePutTemplate,
+				m,
+			),
+		)
 	}, middleware.ReqSignedIn)
 }
@@ -26,7 +26,10 @@ type RulerApiService interface {
 	RouteGetNamespaceRulesConfig(*models.ReqContext) response.Response
 	RouteGetRulegGroupConfig(*models.ReqContext) response.Response
 	RouteGetRulesConfig(*models.ReqContext) response.Response
+	RouteImportPrometheusRules(*models.ReqContext) response.Response
+	RoutePauseRuleGroup(*models.ReqContext) response.Response
 	RoutePostNameRulesConfig(*models.ReqContext, apimodels.PostableRuleGroupConfig) response.Response
+	RouteResumeRuleGroup(*models.ReqContext) response.Response
 }
 
 func (api *API) RegisterRulerApiEndpoints(srv RulerApiService, m *metrics.Metrics) {
@@ -76,6 +79,24 @@ func (api *API) RegisterRulerApiEndpoints(srv RulerApiService, m *metrics.Metric
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/ruler/{Recipient}/api/v1/import/prometheus/{Namespace}"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/{Recipient}/api/v1/import/prometheus/{Namespace}",
+				srv.RouteImportPrometheusRules,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/ruler/{Recipient}/api/v1/rules/{Namespace}/{Groupname}/pause"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/{Recipient}/api/v1/rules/{Namespace}/{Groupname}/pause",
+				srv.RoutePauseRuleGroup,
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/ruler/{Recipient}/api/v1/rules/{Namespace}"),
 			binding.Bind(apimodels.PostableRuleGroupConfig{}),
@@ -86,5 +107,14 @@ func (api *API) RegisterRulerApiEndpoints(srv RulerApiService, m *metrics.Metric
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/ruler/{Recipient}/api/v1/rules/{Namespace}/{Groupname}/resume"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/{Recipient}/api/v1/rules/{Namespace}/{Groupname}/resume",
+				srv.RouteResumeRuleGroup,
+				m,
+			),
+		)
 	}, middleware.ReqSignedIn)
 }
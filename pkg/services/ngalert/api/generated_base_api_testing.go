@@ -21,6 +21,7 @@ import (
 )
 
 type TestingApiService interface {
+	RouteBacktestConfig(*models.ReqContext, apimodels.BacktestConfig) response.Response
 	RouteEvalQueries(*models.ReqContext, apimodels.EvalQueriesPayload) response.Response
 	RouteTestReceiverConfig(*models.ReqContext, apimodels.ExtendedReceiver) response.Response
 	RouteTestRuleConfig(*models.ReqContext, apimodels.TestRulePayload) response.Response
@@ -28,6 +29,16 @@ type TestingApiService interface {
 
 func (api *API) RegisterTestingApiEndpoints(srv TestingApiService, m *metrics.Metrics) {
 	api.RouteRegister.Group("", func(group routing.RouteRegister) {
+		group.Post(
+			toMacaronPath("/api/v1/rule/backtest"),
+			binding.Bind(apimodels.BacktestConfig{}),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/rule/backtest",
+				srv.RouteBacktestConfig,
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/v1/eval"),
 			binding.Bind(apimodels.EvalQueriesPayload{}),
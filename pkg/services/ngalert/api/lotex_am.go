@@ -192,3 +192,25 @@ func (am *LotexAM) RoutePostAMAlerts(ctx *models.ReqContext, alerts apimodels.Po
 		nil,
 	)
 }
+
+// the notification templates of the Alertmanager-compatible data sources are only managed with their configuration
+
+func (am *LotexAM) RouteGetTemplates(ctx *models.ReqContext) response.Response {
+	return NotImplementedResp
+}
+
+func (am *LotexAM) RouteGetTemplate(ctx *models.ReqContext) response.Response {
+	return NotImplementedResp
+}
+
+func (am *LotexAM) RoutePutTemplate(ctx *models.ReqContext, body apimodels.PostableNotificationTemplate) response.Response {
+	return NotImplementedResp
+}
+
+func (am *LotexAM) RouteDeleteTemplate(ctx *models.ReqContext) response.Response {
+	return NotImplementedResp
+}
+
+func (am *LotexAM) RoutePostTemplatePreview(ctx *models.ReqContext, body apimodels.TemplatePreviewConfig) response.Response {
+	return NotImplementedResp
+}
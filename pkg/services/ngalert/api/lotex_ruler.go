@@ -149,6 +149,21 @@ func (r *LotexRuler) RoutePostNameRulesConfig(ctx *models.ReqContext, conf apimo
 	return r.withReq(ctx, http.MethodPost, u, bytes.NewBuffer(yml), jsonExtractor(nil), nil)
 }
 
+func (r *LotexRuler) RouteImportPrometheusRules(ctx *models.ReqContext) response.Response {
+	// the rule files are imported as Grafana managed rules, the Cortex and Loki rulers accept them as they are
+	return NotImplementedResp
+}
+
+func (r *LotexRuler) RoutePauseRuleGroup(ctx *models.ReqContext) response.Response {
+	// the Cortex and Loki rulers cannot pause rules
+	return NotImplementedResp
+}
+
+func (r *LotexRuler) RouteResumeRuleGroup(ctx *models.ReqContext) response.Response {
+	// the Cortex and Loki rulers cannot pause rules
+	return NotImplementedResp
+}
+
 func (r *LotexRuler) getPrefix(ctx *models.ReqContext) (string, error) {
 	ds, err := r.DataProxy.DatasourceCache.GetDatasource(ctx.ParamsInt64("Recipient"), ctx.SignedInUser, ctx.SkipCache)
 	if err != nil {
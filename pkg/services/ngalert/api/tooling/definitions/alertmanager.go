@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/go-openapi/strfmt"
 	"github.com/pkg/errors"
 	amv2 "github.com/prometheus/alertmanager/api/v2/models"
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/timeinterval"
 	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
@@ -105,6 +107,49 @@ import (
 //       200: Ack
 //       400: ValidationError
 
+// swagger:route GET /api/alertmanager/{Recipient}/config/api/v1/templates alertmanager RouteGetTemplates
+//
+// gets the notification templates
+//
+//     Responses:
+//       200: NotificationTemplates
+//       400: ValidationError
+
+// swagger:route GET /api/alertmanager/{Recipient}/config/api/v1/templates/{Name} alertmanager RouteGetTemplate
+//
+// gets a notification template
+//
+//     Responses:
+//       200: NotificationTemplate
+//       404: NotFound
+
+// swagger:route PUT /api/alertmanager/{Recipient}/config/api/v1/templates/{Name} alertmanager RoutePutTemplate
+//
+// creates or updates a notification template
+//
+//     Responses:
+//       202: Ack
+//       400: ValidationError
+//       409: ValidationError
+
+// swagger:route DELETE /api/alertmanager/{Recipient}/config/api/v1/templates/{Name} alertmanager RouteDeleteTemplate
+//
+// deletes a notification template
+//
+//     Responses:
+//       200: Ack
+//       400: ValidationError
+//       404: NotFound
+//       409: ValidationError
+
+// swagger:route POST /api/alertmanager/{Recipient}/config/api/v1/templates/preview alertmanager RoutePostTemplatePreview
+//
+// renders the templates of a notification template for a group of alerts
+//
+//     Responses:
+//       200: TemplatePreviews
+//       400: ValidationError
+
 // swagger:parameters RouteCreateSilence
 type CreateSilenceParams struct {
 	// in:body
@@ -123,6 +168,81 @@ type GetSilencesParams struct {
 	Filter []string `json:"filter"`
 }
 
+// swagger:parameters RouteGetTemplate RoutePutTemplate RouteDeleteTemplate
+type TemplateParams struct {
+	// in:path
+	Name string
+}
+
+// swagger:parameters RouteDeleteTemplate
+type DeleteTemplateParams struct {
+	// Version is the version of the configuration the template is deleted from, the template is
+	// not deleted if the configuration has changed since.
+	// in:query
+	// required:false
+	Version int64 `json:"version"`
+}
+
+// swagger:parameters RoutePutTemplate
+type PutTemplateParams struct {
+	// in:body
+	Body PostableNotificationTemplate
+}
+
+// swagger:parameters RoutePostTemplatePreview
+type TemplatePreviewParams struct {
+	// in:body
+	Body TemplatePreviewConfig
+}
+
+// NotificationTemplate is a template file of the Alertmanager configuration. The templates
+// it defines can be used in the settings of the contact points.
+// swagger:model
+type NotificationTemplate struct {
+	Name     string `json:"name"`
+	Template string `json:"template"`
+	// Version is the version of the Alertmanager configuration the template is part of.
+	Version int64 `json:"version"`
+}
+
+// swagger:model
+type NotificationTemplates []NotificationTemplate
+
+// swagger:model
+type PostableNotificationTemplate struct {
+	Template string `json:"template"`
+	// Version is the version of the Alertmanager configuration the template is based on, the template
+	// is not saved if the configuration has changed since. The version is not checked when it is missing.
+	Version int64 `json:"version,omitempty"`
+}
+
+// swagger:model
+type TemplatePreviewConfig struct {
+	// Name is the name of the notification template that is replaced by the previewed one.
+	Name string `json:"name,omitempty"`
+	// Template is the content of the previewed notification template, its templates are rendered.
+	Template string `json:"template"`
+	// Alerts are the alerts of the group the templates are rendered for. The templates are
+	// rendered for a sample alert when there are none.
+	Alerts []amv2.PostableAlert `json:"alerts,omitempty"`
+	// Live renders the templates for the alerts of the Alertmanager instead, optionally
+	// selected with Filter like in the alerts API.
+	Live   bool     `json:"live,omitempty"`
+	Filter []string `json:"filter,omitempty"`
+}
+
+// TemplatePreview is a template rendered for a group of alerts, or the error it failed with.
+type TemplatePreview struct {
+	Name  string `json:"name"`
+	Text  string `json:"text"`
+	Error string `json:"error,omitempty"`
+}
+
+// swagger:model
+type TemplatePreviews struct {
+	Previews []TemplatePreview `json:"previews"`
+}
+
 // swagger:model
 type GettableStatus struct {
 	// cluster
@@ -543,10 +663,80 @@ func (c *GettableApiAlertingConfig) validate() error {
 
 // Config is the top-level configuration for Alertmanager's config files.
 type Config struct {
-	Global       *config.GlobalConfig  `yaml:"global,omitempty" json:"global,omitempty"`
-	Route        *config.Route         `yaml:"route,omitempty" json:"route,omitempty"`
-	InhibitRules []*config.InhibitRule `yaml:"inhibit_rules,omitempty" json:"inhibit_rules,omitempty"`
-	Templates    []string              `yaml:"templates" json:"templates"`
+	Global            *config.GlobalConfig  `yaml:"global,omitempty" json:"global,omitempty"`
+	Route             *config.Route         `yaml:"route,omitempty" json:"route,omitempty"`
+	InhibitRules      []*config.InhibitRule `yaml:"inhibit_rules,omitempty" json:"inhibit_rules,omitempty"`
+	MuteTimeIntervals []MuteTimeInterval    `yaml:"mute_time_intervals,omitempty" json:"mute_time_intervals,omitempty"`
+	Templates         []string              `yaml:"templates" json:"templates"`
+}
+
+// MuteTimeInterval is a named set of time intervals during which the notifications of the
+// routes referencing it are muted. It has the same fields as the Alertmanager type, and each
+// time interval can also be in the timezone of a location.
+type MuteTimeInterval struct {
+	Name          string         `yaml:"name" json:"name"`
+	TimeIntervals []TimeInterval `yaml:"time_intervals" json:"time_intervals"`
+}
+
+// MarshalJSON implements json.Marshaler. The time intervals are only marshaled to YAML by the
+// Alertmanager, so they are encoded in JSON with the same fields as in YAML.
+func (mt MuteTimeInterval) MarshalJSON() ([]byte, error) {
+	type plain MuteTimeInterval
+	b, err := yaml.Marshal((plain)(mt))
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. JSON being valid YAML, the validation of the time
+// intervals done when they are unmarshaled from YAML is reused.
+func (mt *MuteTimeInterval) UnmarshalJSON(b []byte) error {
+	type plain MuteTimeInterval
+	return yaml.Unmarshal(b, (*plain)(mt))
+}
+
+// TimeInterval is a time interval of the Alertmanager, in the timezone of its location.
+type TimeInterval struct {
+	timeinterval.TimeInterval `yaml:",inline"`
+	// Location is the timezone the time interval is in, UTC if it is not set.
+	Location *Location `yaml:"location,omitempty"`
+}
+
+// ContainsTime returns true if the time is within the time interval, in the timezone of its location.
+func (ti TimeInterval) ContainsTime(t time.Time) bool {
+	if ti.Location != nil {
+		return ti.TimeInterval.ContainsTime(t.In(ti.Location.Location))
+	}
+	return ti.TimeInterval.ContainsTime(t.UTC())
+}
+
+// Location is a timezone of the IANA Time Zone database, such as "Europe/Paris".
+type Location struct {
+	*time.Location
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *Location) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid location %q: %w", name, err)
+	}
+	l.Location = loc
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (l Location) MarshalYAML() (interface{}, error) {
+	return l.String(), nil
 }
 
 // Config is the entrypoint for the embedded Alertmanager config with the exception of receivers.
@@ -588,6 +778,9 @@ func (c *Config) UnmarshalJSON(b []byte) error {
 	if len(c.Route.Match) > 0 || len(c.Route.MatchRE) > 0 {
 		return fmt.Errorf("root route must not have any matchers")
 	}
+	if len(c.Route.MuteTimeIntervals) > 0 {
+		return fmt.Errorf("root route must not have any mute time intervals")
+	}
 
 	for _, r := range c.InhibitRules {
 		if err := r.UnmarshalYAML(noopUnmarshal); err != nil {
@@ -595,6 +788,33 @@ func (c *Config) UnmarshalJSON(b []byte) error {
 		}
 	}
 
+	tiNames := make(map[string]struct{}, len(c.MuteTimeIntervals))
+	for _, mt := range c.MuteTimeIntervals {
+		if mt.Name == "" {
+			return fmt.Errorf("missing name in mute time interval")
+		}
+		if _, ok := tiNames[mt.Name]; ok {
+			return fmt.Errorf("mute time interval %q is not unique", mt.Name)
+		}
+		tiNames[mt.Name] = struct{}{}
+	}
+
+	return checkTimeInterval(c.Route, tiNames)
+}
+
+// checkTimeInterval checks that the mute time intervals referenced by the routes are defined.
+func checkTimeInterval(r *config.Route, timeIntervals map[string]struct{}) error {
+	for _, sr := range r.Routes {
+		if err := checkTimeInterval(sr, timeIntervals); err != nil {
+			return err
+		}
+	}
+
+	for _, mt := range r.MuteTimeIntervals {
+		if _, ok := timeIntervals[mt]; !ok {
+			return fmt.Errorf("undefined time interval %q used in route", mt)
+		}
+	}
 	return nil
 }
 
@@ -710,6 +930,7 @@ type GettableGrafanaReceiver struct {
 	DisableResolveMessage bool             `json:"disableResolveMessage"`
 	Settings              *simplejson.Json `json:"settings"`
 	SecureFields          map[string]bool  `json:"secureFields"`
+	Provenance            Provenance       `json:"provenance,omitempty"`
 }
 
 type PostableGrafanaReceiver struct {
@@ -670,3 +670,41 @@ func Test_Marshaling_Validation(t *testing.T) {
 	expected := []model.LabelName{"alertname"}
 	require.Equal(t, expected, tmp.AlertmanagerConfig.Config.Route.GroupBy)
 }
+
+func Test_MuteTimeInterval_Marshaling(t *testing.T) {
+	cfg := `{
+		"route": {
+			"receiver": "graf",
+			"routes": [{"receiver": "graf", "mute_time_intervals": ["weekends"]}]
+		},
+		"mute_time_intervals": [{
+			"name": "weekends",
+			"time_intervals": [{"weekdays": ["saturday", "sunday"], "times": [{"start_time": "00:00", "end_time": "12:00"}], "location": "Europe/Paris"}]
+		}]
+	}`
+
+	var c Config
+	require.NoError(t, json.Unmarshal([]byte(cfg), &c))
+	require.Len(t, c.MuteTimeIntervals, 1)
+	require.Equal(t, "weekends", c.MuteTimeIntervals[0].Name)
+	require.Len(t, c.MuteTimeIntervals[0].TimeIntervals[0].Weekdays, 2)
+	require.Len(t, c.MuteTimeIntervals[0].TimeIntervals[0].Times, 1)
+	require.Equal(t, "Europe/Paris", c.MuteTimeIntervals[0].TimeIntervals[0].Location.String())
+
+	b, err := json.Marshal(c)
+	require.NoError(t, err)
+	var roundtrip Config
+	require.NoError(t, json.Unmarshal(b, &roundtrip))
+	require.Equal(t, c.MuteTimeIntervals, roundtrip.MuteTimeIntervals)
+
+	undefined := `{
+		"route": {
+			"receiver": "graf",
+			"routes": [{"receiver": "graf", "mute_time_intervals": ["nights"]}]
+		}
+	}`
+	require.EqualError(t, json.Unmarshal([]byte(undefined), &c), `undefined time interval "nights" used in route`)
+
+	var mt MuteTimeInterval
+	require.Error(t, json.Unmarshal([]byte(`{"name": "nights", "time_intervals": [{"location": "Nowhere/Nowhere"}]}`), &mt))
+}
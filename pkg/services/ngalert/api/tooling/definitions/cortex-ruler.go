@@ -64,6 +64,37 @@ import (
 //     Responses:
 //       202: Ack
 
+// swagger:route POST /api/ruler/{Recipient}/api/v1/rules/{Namespace}/{Groupname}/pause ruler RoutePauseRuleGroup
+//
+// Pauses the evaluation of the rules of a rule group, provisioned rules included
+//
+//     Responses:
+//       202: Ack
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:route POST /api/ruler/{Recipient}/api/v1/rules/{Namespace}/{Groupname}/resume ruler RouteResumeRuleGroup
+//
+// Resumes the evaluation of the rules of a rule group, provisioned rules included
+//
+//     Responses:
+//       202: Ack
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:route POST /api/ruler/{Recipient}/api/v1/import/prometheus/{Namespace} ruler RouteImportPrometheusRules
+//
+// Imports the alerting rules of a Prometheus rule file as Grafana managed rules, replacing the rule groups with the same names
+//
+//     Consumes:
+//     - application/yaml
+//     - application/json
+//
+//     Responses:
+//       202: Ack
+//       400: ValidationError
+//       404: NotFound
+
 // swagger:parameters RoutePostNameRulesConfig
 type NamespaceConfig struct {
 	// in:path
@@ -86,6 +117,31 @@ type PathRouleGroupConfig struct {
 	Groupname string
 }
 
+// swagger:parameters RoutePauseRuleGroup RouteResumeRuleGroup
+type PathPauseRuleGroupConfig struct {
+	// in: path
+	Namespace string
+	// in: path
+	Groupname string
+	// Only the rules with these UIDs, all the rules of the group when none is set
+	// in: query
+	// required: false
+	RuleUID []string `json:"ruleUID"`
+}
+
+// swagger:parameters RouteImportPrometheusRules
+type ImportPrometheusRulesConfig struct {
+	// in: path
+	Namespace string
+	// The UID of the Prometheus or Loki data source the rules are evaluated with
+	// in: query
+	// required: true
+	DatasourceUID string `json:"datasourceUID"`
+	// The rule file, in the format of Prometheus, or a single rule group
+	// in: body
+	Body string
+}
+
 // swagger:model
 type RuleGroupConfigResponse struct {
 	GettableRuleGroupConfig
@@ -279,18 +335,27 @@ func (n *GettableExtendedRuleNode) validate() error {
 type NoDataState string
 
 const (
-	Alerting NoDataState = "Alerting"
-	NoData   NoDataState = "NoData"
-	OK       NoDataState = "OK"
+	Alerting      NoDataState = "Alerting"
+	NoData        NoDataState = "NoData"
+	OK            NoDataState = "OK"
+	KeepLastState NoDataState = "KeepLastState"
 )
 
 // swagger:enum ExecutionErrorState
 type ExecutionErrorState string
 
 const (
-	AlertingErrState ExecutionErrorState = "Alerting"
+	AlertingErrState      ExecutionErrorState = "Alerting"
+	ErrorErrState         ExecutionErrorState = "Error"
+	OkErrState            ExecutionErrorState = "OK"
+	KeepLastStateErrState ExecutionErrorState = "KeepLastState"
 )
 
+// Provenance is where a resource comes from. The resources without provenance were created
+// through the API, the others are provisioned and cannot be changed through it.
+// swagger:enum Provenance
+type Provenance string
+
 // swagger:model
 type PostableGrafanaRule struct {
 	Title        string              `json:"title" yaml:"title"`
@@ -299,6 +364,9 @@ type PostableGrafanaRule struct {
 	UID          string              `json:"uid" yaml:"uid"`
 	NoDataState  NoDataState         `json:"no_data_state" yaml:"no_data_state"`
 	ExecErrState ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
+	// IsPaused pauses or resumes the evaluation of the alert rule, an existing rule
+	// keeps its current value when it is not set.
+	IsPaused *bool `json:"is_paused,omitempty" yaml:"is_paused,omitempty"`
 }
 
 // swagger:model
@@ -317,4 +385,6 @@ type GettableGrafanaRule struct {
 	RuleGroup       string              `json:"rule_group" yaml:"rule_group"`
 	NoDataState     NoDataState         `json:"no_data_state" yaml:"no_data_state"`
 	ExecErrState    ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
+	Provenance      Provenance          `json:"provenance,omitempty" yaml:"provenance,omitempty"`
+	IsPaused        bool                `json:"is_paused" yaml:"is_paused"`
 }
@@ -0,0 +1,69 @@
+package definitions
+
+import (
+	"time"
+)
+
+// swagger:route GET /api/v1/rules/history history RouteGetStateHistory
+//
+// gets the state transitions of the alert instances, from the most recent to the oldest
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: StateHistory
+//       400: ValidationError
+
+// swagger:parameters RouteGetStateHistory
+type StateHistoryParams struct {
+	// Only the transitions of the alert rule with this UID
+	// in: query
+	// required: false
+	RuleUID string `json:"ruleUID"`
+
+	// Only the transitions of the alert instances with these labels, in the form name=value
+	// in: query
+	// required: false
+	Labels []string `json:"labels"`
+
+	// Only the transitions evaluated after this time, in milliseconds since epoch
+	// in: query
+	// required: false
+	From int64 `json:"from"`
+
+	// Only the transitions evaluated before this time, in milliseconds since epoch
+	// in: query
+	// required: false
+	To int64 `json:"to"`
+
+	// Maximum number of transitions
+	// in: query
+	// required: false
+	// default: 100
+	Limit int `json:"limit"`
+}
+
+// swagger:model
+type StateHistory struct {
+	// required: true
+	Transitions []StateTransition `json:"transitions"`
+}
+
+// swagger:model
+type StateTransition struct {
+	// required: true
+	RuleUID string `json:"ruleUID"`
+	// required: true
+	Labels map[string]string `json:"labels"`
+	// required: true
+	PreviousState string `json:"previousState"`
+	// required: true
+	State string `json:"state"`
+	// required: false
+	EvaluationString string `json:"evaluationString,omitempty"`
+	// required: false
+	Error string `json:"error,omitempty"`
+	// required: true
+	EvaluatedAt time.Time `json:"evaluatedAt"`
+}
@@ -8,7 +8,9 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/promql"
 )
 
@@ -53,6 +55,20 @@ import (
 //     Responses:
 //       200: EvalQueriesResponse
 
+// swagger:route Post /api/v1/rule/backtest testing RouteBacktestConfig
+//
+// Backtest rule: replays the evaluation of a Grafana managed rule over a past time range
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: BacktestResult
+//       400: ValidationError
+
 // swagger:parameters RouteTestReceiverConfig
 type TestReceiverRequest struct {
 	// in:body
@@ -85,6 +101,46 @@ type EvalQueriesPayload struct {
 	Now  time.Time           `json:"now"`
 }
 
+// swagger:parameters RouteBacktestConfig
+type BacktestRequest struct {
+	// in:body
+	Body BacktestConfig
+}
+
+// swagger:model
+type BacktestConfig struct {
+	// The time range the alert rule is evaluated over, at every interval
+	// required: true
+	From time.Time `json:"from"`
+	// required: true
+	To time.Time `json:"to"`
+	// required: true
+	Interval model.Duration `json:"interval"`
+	// required: true
+	Rule PostableExtendedRuleNode `json:"rule"`
+}
+
+// swagger:model
+type BacktestResult struct {
+	// Evaluations is the number of evaluations of the alert rule
+	// required: true
+	Evaluations int `json:"evaluations"`
+	// Transitions are the state transitions of the alert instances, in the order they happened
+	// required: true
+	Transitions []StateTransition `json:"transitions"`
+	// Notifications are the alerts that would have been sent to the Alertmanager
+	// required: true
+	Notifications []BacktestNotification `json:"notifications"`
+}
+
+// swagger:model
+type BacktestNotification struct {
+	// required: true
+	Time time.Time `json:"time"`
+	// required: true
+	Alerts []amv2.PostableAlert `json:"alerts"`
+}
+
 func (p *TestRulePayload) UnmarshalJSON(b []byte) error {
 	type plain TestRulePayload
 	if err := json.Unmarshal(b, (*plain)(p)); err != nil {
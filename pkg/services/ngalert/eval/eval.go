@@ -4,6 +4,7 @@ package eval
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime/debug"
 	"sort"
@@ -21,7 +22,8 @@ import (
 	"github.com/grafana/grafana/pkg/expr"
 )
 
-const alertingEvaluationTimeout = 30 * time.Second
+// defaultEvaluationTimeout is the evaluation timeout when none is configured.
+const defaultEvaluationTimeout = 30 * time.Second
 
 type Evaluator struct {
 	Cfg *setting.Cfg
@@ -192,6 +194,10 @@ func executeCondition(ctx AlertExecCtx, c *models.Condition, now time.Time, data
 		}
 
 		if refID == c.Condition {
+			// the condition fails when its own execution, or the one of a node it depends on, has failed.
+			if res.Error != nil {
+				return ExecutionResults{Error: res.Error}
+			}
 			result.Results = res.Frames
 		}
 	}
@@ -421,14 +427,46 @@ func (evalResults Results) AsDataFrame() data.Frame {
 	return *frame
 }
 
-// ConditionEval executes conditions and evaluates the result.
-func (e *Evaluator) ConditionEval(condition *models.Condition, now time.Time, dataService *tsdb.Service) (Results, error) {
-	alertCtx, cancelFn := context.WithTimeout(context.Background(), alertingEvaluationTimeout)
-	defer cancelFn()
+// evaluationTimeout returns the maximum duration of an evaluation.
+func (e *Evaluator) evaluationTimeout() time.Duration {
+	if e.Cfg.UnifiedAlerting.EvaluationTimeout > 0 {
+		return e.Cfg.UnifiedAlerting.EvaluationTimeout
+	}
+	return defaultEvaluationTimeout
+}
 
-	alertExecCtx := AlertExecCtx{OrgID: condition.OrgID, Ctx: alertCtx, ExpressionsEnabled: e.Cfg.ExpressionsEnabled, Log: e.Log}
+// executeWithTimeout executes with a context that is canceled after the timeout, and returns the
+// results of the execution, or an error when the execution does not complete before the context is
+// done. The context is canceled when executeWithTimeout returns so that the queries still running
+// are stopped, the data sources that ignore the cancellation complete them in the background.
+func executeWithTimeout(ctx context.Context, timeout time.Duration, execute func(ctx context.Context) ExecutionResults) ExecutionResults {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resultCh := make(chan ExecutionResults, 1)
+	go func() {
+		resultCh <- execute(ctx)
+	}()
 
-	execResult := executeCondition(alertExecCtx, condition, now, dataService)
+	select {
+	case result := <-resultCh:
+		return result
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ExecutionResults{Error: fmt.Errorf("the evaluation did not complete within %s", timeout)}
+		}
+		return ExecutionResults{Error: ctx.Err()}
+	}
+}
+
+// ConditionEval executes conditions and evaluates the result. The result is an Error state when
+// the execution does not complete within the evaluation timeout.
+func (e *Evaluator) ConditionEval(condition *models.Condition, now time.Time, dataService *tsdb.Service) (Results, error) {
+	timeout := e.evaluationTimeout()
+	execResult := executeWithTimeout(context.Background(), timeout, func(ctx context.Context) ExecutionResults {
+		alertExecCtx := AlertExecCtx{OrgID: condition.OrgID, Ctx: ctx, ExpressionsEnabled: e.Cfg.ExpressionsEnabled, Log: e.Log}
+		return executeCondition(alertExecCtx, condition, now, dataService)
+	})
 
 	evalResults := evaluateExecutionResult(execResult, now)
 	return evalResults, nil
@@ -436,7 +474,7 @@ func (e *Evaluator) ConditionEval(condition *models.Condition, now time.Time, da
 
 // QueriesAndExpressionsEval executes queries and expressions and returns the result.
 func (e *Evaluator) QueriesAndExpressionsEval(orgID int64, data []models.AlertQuery, now time.Time, dataService *tsdb.Service) (*backend.QueryDataResponse, error) {
-	alertCtx, cancelFn := context.WithTimeout(context.Background(), alertingEvaluationTimeout)
+	alertCtx, cancelFn := context.WithTimeout(context.Background(), e.evaluationTimeout())
 	defer cancelFn()
 
 	alertExecCtx := AlertExecCtx{OrgID: orgID, Ctx: alertCtx, ExpressionsEnabled: e.Cfg.ExpressionsEnabled, Log: e.Log}
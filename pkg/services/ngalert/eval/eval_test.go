@@ -1,6 +1,7 @@
 package eval
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -294,3 +295,43 @@ func TestEvaluateExecutionResult(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteWithTimeout(t *testing.T) {
+	t.Run("the results are returned when the execution completes in time", func(t *testing.T) {
+		frames := []*data.Frame{data.NewFrame("", data.NewField("", nil, []*float64{ptr.Float64(1)}))}
+		res := executeWithTimeout(context.Background(), time.Second, func(context.Context) ExecutionResults {
+			return ExecutionResults{Results: frames}
+		})
+		require.NoError(t, res.Error)
+		require.Equal(t, data.Frames(frames), res.Results)
+	})
+
+	t.Run("the execution that does not complete in time is an Error state", func(t *testing.T) {
+		canceled := make(chan struct{})
+		res := executeWithTimeout(context.Background(), 10*time.Millisecond, func(ctx context.Context) ExecutionResults {
+			<-ctx.Done()
+			close(canceled)
+			return ExecutionResults{}
+		})
+		require.EqualError(t, res.Error, "the evaluation did not complete within 10ms")
+
+		results := evaluateExecutionResult(res, time.Time{})
+		require.Len(t, results, 1)
+		require.Equal(t, Error, results[0].State)
+
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("the context of the execution was not canceled")
+		}
+	})
+
+	t.Run("the context of the execution is canceled when it returns", func(t *testing.T) {
+		var execCtx context.Context
+		executeWithTimeout(context.Background(), time.Minute, func(ctx context.Context) ExecutionResults {
+			execCtx = ctx
+			return ExecutionResults{}
+		})
+		require.ErrorIs(t, execCtx.Err(), context.Canceled)
+	})
+}
@@ -36,6 +36,7 @@ type Metrics struct {
 	EvalFailures         *prometheus.CounterVec
 	EvalDuration         *prometheus.SummaryVec
 	GroupRules           *prometheus.GaugeVec
+	SchedulerMembers     prometheus.Gauge
 }
 
 func init() {
@@ -122,6 +123,12 @@ func NewMetrics(r prometheus.Registerer) *Metrics {
 			},
 			[]string{"user"},
 		),
+		SchedulerMembers: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: "grafana",
+			Subsystem: "alerting",
+			Name:      "scheduler_members",
+			Help:      "The number of Grafana instances the evaluation of the alert rules is sharded across.",
+		}),
 	}
 }
 
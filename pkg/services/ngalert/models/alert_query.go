@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/expr"
 )
 
@@ -169,6 +170,26 @@ func (aq *AlertQuery) GetIntervalDuration() (time.Duration, error) {
 	return time.Duration(intervalMs) * time.Millisecond, nil
 }
 
+// SetLoadedDimensions sets the labels of the alert instances that are currently
+// firing in the model of a threshold expression, so it can apply its recovery evaluator.
+// It does nothing for other queries.
+func (aq *AlertQuery) SetLoadedDimensions(dimensions []data.Labels) error {
+	isExpression, err := aq.IsExpression()
+	if err != nil || !isExpression {
+		return err
+	}
+	if aq.modelProps == nil {
+		if err := aq.setModelProps(); err != nil {
+			return err
+		}
+	}
+	if t, _ := aq.modelProps["type"].(string); t != expr.TypeThreshold.String() {
+		return nil
+	}
+	aq.modelProps["loadedDimensions"] = dimensions
+	return nil
+}
+
 // GetDatasource returns the query datasource identifier.
 func (aq *AlertQuery) GetDatasource() (string, error) {
 	return aq.DatasourceUID, nil
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -245,3 +246,31 @@ func TestAlertQueryMarshalling(t *testing.T) {
 		}
 	}
 }
+
+func TestAlertQuerySetLoadedDimensions(t *testing.T) {
+	dimensions := []data.Labels{{"host": "a"}}
+
+	t.Run("sets loaded dimensions on threshold expressions", func(t *testing.T) {
+		aq := AlertQuery{
+			RefID:         "B",
+			DatasourceUID: "-100",
+			Model:         json.RawMessage(`{"type": "threshold", "expression": "A"}`),
+		}
+		require.NoError(t, aq.SetLoadedDimensions(dimensions))
+		model, err := aq.GetModel()
+		require.NoError(t, err)
+		require.Contains(t, string(model), `"loadedDimensions":[{"host":"a"}]`)
+	})
+
+	t.Run("ignores other queries", func(t *testing.T) {
+		aq := AlertQuery{
+			RefID:         "B",
+			DatasourceUID: "-100",
+			Model:         json.RawMessage(`{"type": "math", "expression": "$A > 1"}`),
+		}
+		require.NoError(t, aq.SetLoadedDimensions(dimensions))
+		model, err := aq.GetModel()
+		require.NoError(t, err)
+		require.NotContains(t, string(model), "loadedDimensions")
+	})
+}
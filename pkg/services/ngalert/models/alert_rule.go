@@ -28,11 +28,21 @@ func (noDataState NoDataState) String() string {
 }
 
 const (
-	Alerting NoDataState = "Alerting"
-	NoData   NoDataState = "NoData"
-	OK       NoDataState = "OK"
+	Alerting      NoDataState = "Alerting"
+	NoData        NoDataState = "NoData"
+	OK            NoDataState = "OK"
+	KeepLastState NoDataState = "KeepLastState"
 )
 
+// IsValid checks that the value of NoDataState is a valid option.
+func (noDataState NoDataState) IsValid() bool {
+	switch noDataState {
+	case Alerting, NoData, OK, KeepLastState:
+		return true
+	}
+	return false
+}
+
 type ExecutionErrorState string
 
 func (executionErrorState ExecutionErrorState) String() string {
@@ -40,12 +50,32 @@ func (executionErrorState ExecutionErrorState) String() string {
 }
 
 const (
-	AlertingErrState ExecutionErrorState = "Alerting"
+	AlertingErrState      ExecutionErrorState = "Alerting"
+	ErrorErrState         ExecutionErrorState = "Error"
+	OkErrState            ExecutionErrorState = "OK"
+	KeepLastStateErrState ExecutionErrorState = "KeepLastState"
 )
 
+// IsValid checks that the value of ExecutionErrorState is a valid option.
+func (executionErrorState ExecutionErrorState) IsValid() bool {
+	switch executionErrorState {
+	case AlertingErrState, ErrorErrState, OkErrState, KeepLastStateErrState:
+		return true
+	}
+	return false
+}
+
 const (
 	RuleUIDLabel      = "__alert_rule_uid__"
 	NamespaceUIDLabel = "__alert_rule_namespace_uid__"
+
+	// ErrorAlertName is the name of the alerts sent for the alert instances in the Error state,
+	// so that they can be routed to a dedicated notification.
+	ErrorAlertName = "DatasourceError"
+	// RuleNameLabel is the label with the title of the alert rule of an alert in the Error state.
+	RuleNameLabel = "rulename"
+	// ErrorAnnotation is the annotation with the error message of an alert in the Error state.
+	ErrorAnnotation = "Error"
 )
 
 // AlertRule is the model for alert rules in unified alerting.
@@ -68,6 +98,8 @@ type AlertRule struct {
 	For         time.Duration
 	Annotations map[string]string
 	Labels      map[string]string
+	// IsPaused excludes the alert rule from the evaluations, its state is kept until it is resumed.
+	IsPaused bool
 }
 
 // AlertRuleKey is the alert definition identifier
@@ -121,6 +153,7 @@ type AlertRuleVersion struct {
 	For         time.Duration
 	Annotations map[string]string
 	Labels      map[string]string
+	IsPaused    bool
 }
 
 // GetAlertRuleByUIDQuery is the query for retrieving/deleting an alert rule by UID and organisation ID.
@@ -4,7 +4,8 @@ const AlertConfigurationVersion = 1
 
 // AlertConfiguration represents a single version of the Alerting Engine Configuration.
 type AlertConfiguration struct {
-	ID int64 `xorm:"pk autoincr 'id'"`
+	ID    int64 `xorm:"pk autoincr 'id'"`
+	OrgID int64 `xorm:"org_id"`
 
 	AlertmanagerConfiguration string
 	ConfigurationVersion      string
@@ -14,14 +15,19 @@ type AlertConfiguration struct {
 
 // GetLatestAlertmanagerConfigurationQuery is the query to get the latest alertmanager configuration.
 type GetLatestAlertmanagerConfigurationQuery struct {
+	OrgID  int64
 	Result *AlertConfiguration
 }
 
 // SaveAlertmanagerConfigurationCmd is the command to save an alertmanager configuration.
 type SaveAlertmanagerConfigurationCmd struct {
+	OrgID                     int64
 	AlertmanagerConfiguration string
 	ConfigurationVersion      string
 	Default                   bool
+	// LatestID is the ID of the configuration this one is based on. When set, the configuration
+	// is only saved if it is still the latest configuration of the organization.
+	LatestID int64
 }
 
 type DeleteAlertmanagerConfigurationCmd struct {
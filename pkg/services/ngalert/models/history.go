@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// AlertStateTransition is a change of the state of an alert instance.
+type AlertStateTransition struct {
+	ID               int64             `xorm:"pk autoincr 'id'" json:"id"`
+	RuleOrgID        int64             `xorm:"rule_org_id" json:"ruleOrgId"`
+	RuleUID          string            `xorm:"rule_uid" json:"ruleUid"`
+	Labels           InstanceLabels    `json:"labels"`
+	LabelsHash       string            `json:"labelsHash"`
+	PreviousState    InstanceStateType `json:"previousState"`
+	State            InstanceStateType `json:"state"`
+	EvaluationString string            `json:"evaluationString"`
+	ErrorMessage     string            `json:"errorMessage,omitempty"`
+	EvaluatedAt      time.Time         `json:"evaluatedAt"`
+}
+
+// SaveAlertStateTransitionsCommand is the command for saving state transitions of alert instances.
+type SaveAlertStateTransitionsCommand struct {
+	Transitions []AlertStateTransition
+}
+
+// ListAlertStateHistoryQuery is the query for listing the state transitions of the alert instances
+// of an organization, from the most recent to the oldest.
+type ListAlertStateHistoryQuery struct {
+	RuleOrgID int64
+	// RuleUID restricts the transitions to the ones of the alert rule.
+	RuleUID string
+	// Labels restricts the transitions to the ones of the alert instances having all these labels.
+	Labels InstanceLabels
+	// From and To restrict the transitions to the ones evaluated in this time range, when they are set.
+	From time.Time
+	To   time.Time
+	// Limit is the maximum number of transitions to return, when it is set.
+	Limit int
+
+	Result []*AlertStateTransition
+}
+
+// DeleteAlertStateHistoryCommand is the command for deleting the state transitions
+// evaluated before a time.
+type DeleteAlertStateHistoryCommand struct {
+	Before time.Time
+
+	// Result is the number of deleted transitions.
+	Result int64
+}
@@ -0,0 +1,57 @@
+package models
+
+import "errors"
+
+// ErrCannotChangeProvisionedResource is an error for a change of a provisioned resource through the API.
+var ErrCannotChangeProvisionedResource = errors.New("cannot change a provisioned resource")
+
+// Provenance is where an alerting resource comes from. The resources that are provisioned
+// cannot be changed through the API.
+type Provenance string
+
+const (
+	// ProvenanceNone is the provenance of the resources created through the API.
+	ProvenanceNone Provenance = ""
+	// ProvenanceFile is the provenance of the resources provisioned from files.
+	ProvenanceFile Provenance = "file"
+)
+
+// The types of the resources whose provenance is recorded.
+const (
+	ProvenanceRecordAlertRule          = "alertRule"
+	ProvenanceRecordContactPoint       = "contactPoint"
+	ProvenanceRecordNotificationPolicy = "notificationPolicy"
+	ProvenanceRecordMuteTiming         = "muteTiming"
+)
+
+// NotificationPolicyRecordKey is the key of the provenance of the notification policy tree,
+// as there is one per organization.
+const NotificationPolicyRecordKey = "policies"
+
+// ProvenanceRecord is the provenance of an alerting resource.
+type ProvenanceRecord struct {
+	ID         int64      `xorm:"pk autoincr 'id'"`
+	OrgID      int64      `xorm:"org_id"`
+	RecordKey  string     `xorm:"record_key"`
+	RecordType string     `xorm:"record_type"`
+	Provenance Provenance `xorm:"provenance"`
+}
+
+// GetProvenancesQuery is the query for the provenance of the resources of a type in an organization.
+type GetProvenancesQuery struct {
+	OrgID      int64
+	RecordType string
+
+	// Result maps the keys of the resources to their provenance. The resources created through the
+	// API are not in it.
+	Result map[string]Provenance
+}
+
+// SetProvenanceCommand is the command for setting the provenance of a resource.
+// Setting ProvenanceNone deletes the provenance of the resource.
+type SetProvenanceCommand struct {
+	OrgID      int64
+	RecordType string
+	RecordKey  string
+	Provenance Provenance
+}
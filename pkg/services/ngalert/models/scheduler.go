@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// SchedulerMember is a Grafana instance that takes part in the evaluation of the alert rules
+// when the sharding of the scheduler is enabled.
+type SchedulerMember struct {
+	ID        int64  `xorm:"pk autoincr 'id'"`
+	MemberID  string `xorm:"member_id"`
+	Heartbeat int64  `xorm:"heartbeat"`
+}
+
+// SchedulerMemberHeartbeatCommand is the command for recording that a scheduler member is alive.
+type SchedulerMemberHeartbeatCommand struct {
+	MemberID  string
+	Heartbeat time.Time
+}
+
+// ListSchedulerMembersQuery is the query for listing the scheduler members
+// that sent a heartbeat since a time.
+type ListSchedulerMembersQuery struct {
+	Since time.Time
+
+	Result []string
+}
+
+// DeleteSchedulerMembersCommand is the command for deleting a scheduler member,
+// or the scheduler members that did not send a heartbeat since a time.
+type DeleteSchedulerMembersCommand struct {
+	MemberID string
+	Before   time.Time
+}
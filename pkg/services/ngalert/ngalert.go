@@ -2,6 +2,9 @@ package ngalert
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/grafana/grafana/pkg/services/quota"
@@ -22,21 +25,19 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	alertingProvisioning "github.com/grafana/grafana/pkg/services/provisioning/alerting"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb"
+	"github.com/grafana/grafana/pkg/util"
 )
 
 const (
 	maxAttempts int64 = 3
-	// scheduler interval
-	// changing this value is discouraged
-	// because this could cause existing alert definition
-	// with intervals that are not exactly divided by this number
-	// not to be evaluated
-	baseIntervalSeconds = 10
-	// default alert definiiton interval
-	defaultIntervalSeconds int64 = 6 * baseIntervalSeconds
+	// the default alert definition interval is this number of scheduler intervals
+	defaultIntervalFactor int64 = 6
+	// defaultBaseInterval is the scheduler interval when none is configured.
+	defaultBaseInterval = 10 * time.Second
 )
 
 // AlertNG is the service for evaluating the condition of an alert definition.
@@ -52,7 +53,12 @@ type AlertNG struct {
 	Log             log.Logger
 	schedule        schedule.ScheduleService
 	stateManager    *state.Manager
-	Alertmanager    *notifier.Alertmanager
+	historian       *state.Historian
+	provisioner     *alertingProvisioning.AlertingProvisioner
+	sharding        *schedule.DBSharding
+
+	// Alerting notification services
+	MultiOrgAlertmanager *notifier.MultiOrgAlertmanager
 }
 
 func init() {
@@ -62,48 +68,73 @@ func init() {
 // Init initializes the AlertingService.
 func (ng *AlertNG) Init() error {
 	ng.Log = log.New("ngalert")
-	baseInterval := baseIntervalSeconds * time.Second
+	// changing the scheduler interval is discouraged because the alert definitions
+	// with intervals that are not exactly divided by it are not evaluated
+	baseInterval := ng.Cfg.UnifiedAlerting.BaseInterval
+	if baseInterval <= 0 {
+		baseInterval = defaultBaseInterval
+	}
 
 	store := &store.DBstore{
 		BaseInterval:           baseInterval,
-		DefaultIntervalSeconds: defaultIntervalSeconds,
+		DefaultIntervalSeconds: defaultIntervalFactor * int64(baseInterval.Seconds()),
 		SQLStore:               ng.SQLStore,
 		Logger:                 ng.Log,
 	}
 
 	var err error
-	ng.Alertmanager, err = notifier.New(ng.Cfg, store, ng.Metrics)
+	ng.MultiOrgAlertmanager, err = notifier.NewMultiOrgAlertmanager(ng.Cfg, store, store, ng.Metrics)
 	if err != nil {
 		return err
 	}
 
+	// Let's make sure we're able to complete an initial sync of Alertmanagers before we start the alerting components.
+	if err := ng.MultiOrgAlertmanager.LoadAndSyncAlertmanagersForOrgs(context.Background()); err != nil {
+		return fmt.Errorf("failed to initialize alerting because the Alertmanagers of the organizations failed to warm up: %w", err)
+	}
+
 	schedCfg := schedule.SchedulerCfg{
-		C:             clock.New(),
-		BaseInterval:  baseInterval,
-		Logger:        ng.Log,
-		MaxAttempts:   maxAttempts,
-		Evaluator:     eval.Evaluator{Cfg: ng.Cfg, Log: ng.Log},
-		InstanceStore: store,
-		RuleStore:     store,
-		Notifier:      ng.Alertmanager,
-		Metrics:       ng.Metrics,
+		C:                        clock.New(),
+		BaseInterval:             baseInterval,
+		Logger:                   ng.Log,
+		MaxAttempts:              maxAttempts,
+		Evaluator:                eval.Evaluator{Cfg: ng.Cfg, Log: ng.Log},
+		InstanceStore:            store,
+		RuleStore:                store,
+		Notifier:                 ng.MultiOrgAlertmanager,
+		Metrics:                  ng.Metrics,
+		MaxConcurrentEvaluations: ng.Cfg.UnifiedAlerting.MaxConcurrentEvaluations,
+	}
+	if ng.Cfg.UnifiedAlerting.SchedulerShardingEnabled {
+		ng.sharding = schedule.NewDBSharding(schedulerMemberID(), ng.Cfg.UnifiedAlerting.SchedulerShardingHeartbeatInterval, store, schedCfg.C, ng.Log, ng.Metrics)
+		schedCfg.Sharding = ng.sharding
 	}
-	ng.stateManager = state.NewManager(ng.Log, ng.Metrics, store, store)
+	ng.historian = state.NewHistorian(ng.Log, store, ng.Cfg.UnifiedAlerting.StateHistoryRetention, ng.Cfg.UnifiedAlerting.StateHistoryAnnotations)
+	ng.stateManager = state.NewManager(ng.Log, ng.Metrics, ng.Cfg.UnifiedAlerting.ResendDelay, store, store, ng.historian)
 	ng.schedule = schedule.NewScheduler(schedCfg, ng.DataService, ng.Cfg.AppURL, ng.stateManager)
 
+	ng.provisioner = alertingProvisioning.New(filepath.Join(ng.Cfg.ProvisioningPath, "alerting"), ng.SQLStore, store, store, store, ng.MultiOrgAlertmanager, ng.stateManager)
+	// a provisioning failure does not prevent Grafana from starting, the files are provisioned
+	// again by PollChanges.
+	if err := ng.provisioner.Provision(); err != nil {
+		ng.Log.Error("failed to provision alerting", "err", err)
+	}
+
 	api := api.API{
-		Cfg:             ng.Cfg,
-		DatasourceCache: ng.DatasourceCache,
-		RouteRegister:   ng.RouteRegister,
-		DataService:     ng.DataService,
-		Schedule:        ng.schedule,
-		DataProxy:       ng.DataProxy,
-		QuotaService:    ng.QuotaService,
-		InstanceStore:   store,
-		RuleStore:       store,
-		AlertingStore:   store,
-		Alertmanager:    ng.Alertmanager,
-		StateManager:    ng.stateManager,
+		Cfg:                  ng.Cfg,
+		DatasourceCache:      ng.DatasourceCache,
+		RouteRegister:        ng.RouteRegister,
+		DataService:          ng.DataService,
+		Schedule:             ng.schedule,
+		DataProxy:            ng.DataProxy,
+		QuotaService:         ng.QuotaService,
+		InstanceStore:        store,
+		RuleStore:            store,
+		AlertingStore:        store,
+		HistoryStore:         store,
+		ProvisioningStore:    store,
+		StateManager:         ng.stateManager,
+		MultiOrgAlertmanager: ng.MultiOrgAlertmanager,
 	}
 	api.RegisterAPIEndpoints(ng.Metrics)
 
@@ -113,18 +144,44 @@ func (ng *AlertNG) Init() error {
 // Run starts the scheduler.
 func (ng *AlertNG) Run(ctx context.Context) error {
 	ng.Log.Debug("ngalert starting")
-	ng.stateManager.Warm()
+	if ng.sharding != nil {
+		// the states of the alert rules are loaded when they are first evaluated by this instance
+		ng.sharding.Heartbeat()
+	} else {
+		ng.stateManager.Warm()
+	}
 
 	children, subCtx := errgroup.WithContext(ctx)
+	if ng.sharding != nil {
+		children.Go(func() error {
+			return ng.sharding.Run(subCtx)
+		})
+	}
 	children.Go(func() error {
 		return ng.schedule.Ticker(subCtx)
 	})
 	children.Go(func() error {
-		return ng.Alertmanager.Run(subCtx)
+		return ng.MultiOrgAlertmanager.Run(subCtx)
+	})
+	children.Go(func() error {
+		return ng.historian.Run(subCtx)
+	})
+	children.Go(func() error {
+		ng.provisioner.PollChanges(subCtx)
+		return nil
 	})
 	return children.Wait()
 }
 
+// schedulerMemberID returns a unique identifier of this instance of Grafana among the scheduler members.
+func schedulerMemberID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "grafana"
+	}
+	return fmt.Sprintf("%s-%s", hostname, util.GenerateShortUID())
+}
+
 // IsDisabled returns true if the alerting service is disable for this instance.
 func (ng *AlertNG) IsDisabled() bool {
 	if ng.Cfg == nil {
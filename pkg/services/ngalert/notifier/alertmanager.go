@@ -9,20 +9,25 @@ import (
 	"fmt"
 	"net/url"
 	"path/filepath"
+	"strconv"
 	"sync"
 	"time"
 
 	gokit_log "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/cluster"
 	"github.com/prometheus/alertmanager/dispatch"
 	"github.com/prometheus/alertmanager/inhibit"
 	"github.com/prometheus/alertmanager/nflog"
 	"github.com/prometheus/alertmanager/nflog/nflogpb"
 	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/provider"
 	"github.com/prometheus/alertmanager/provider/mem"
 	"github.com/prometheus/alertmanager/silence"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/components/securejsondata"
@@ -77,6 +82,7 @@ const (
 type Alertmanager struct {
 	logger      log.Logger
 	gokitLogger gokit_log.Logger
+	orgID       int64
 
 	Settings *setting.Cfg       `inject:""`
 	SQLStore *sqlstore.SQLStore `inject:""`
@@ -90,6 +96,10 @@ type Alertmanager struct {
 
 	dispatcher *dispatch.Dispatcher
 	inhibitor  *inhibit.Inhibitor
+	// dispatcherStarted and inhibitorStarted are closed once the Run of the dispatcher and the inhibitor
+	// has started, they ignore Stop before that.
+	dispatcherStarted chan struct{}
+	inhibitorStarted  chan struct{}
 	// wg is for dispatcher, inhibitor, silences and notifications
 	// Across configuration changes dispatcher and inhibitor are completely replaced, however, silences, notification log and alerts remain the same.
 	// stopc is used to let silences and notifications know we are done.
@@ -101,21 +111,40 @@ type Alertmanager struct {
 
 	stageMetrics      *notify.Metrics
 	dispatcherMetrics *dispatch.DispatcherMetrics
+	// registerer records the metrics of the components, they are unregistered when the Alertmanager is stopped.
+	registerer *unregisterer
+
+	// peer is the member of the cluster the silences and the notification log are replicated with.
+	peer        ClusterPeer
+	peerTimeout time.Duration
+	// clusterStates are the states of the silences and the notification log added to the peer.
+	clusterStates []*removableState
 
 	reloadConfigMtx sync.RWMutex
 	config          []byte
+	// activeConfig is true when the configuration is not the default one,
+	// and is counted in the active configurations metric.
+	activeConfig bool
 }
 
-func New(cfg *setting.Cfg, store store.AlertingStore, m *metrics.Metrics) (*Alertmanager, error) {
+// New creates the Alertmanager of an organization. Its configuration, silences and notification
+// log are separate from the ones of the other organizations.
+func New(orgID int64, cfg *setting.Cfg, store store.AlertingStore, m *metrics.Metrics, peer ClusterPeer) (*Alertmanager, error) {
+	// The metrics of the Alertmanager components are registered once per organization.
+	r := newUnregisterer(prometheus.WrapRegistererWith(prometheus.Labels{"org": strconv.FormatInt(orgID, 10)}, m.Registerer))
 	am := &Alertmanager{
+		registerer:        r,
 		Settings:          cfg,
 		stopc:             make(chan struct{}),
-		logger:            log.New("alertmanager"),
-		marker:            types.NewMarker(m.Registerer),
-		stageMetrics:      notify.NewMetrics(m.Registerer),
-		dispatcherMetrics: dispatch.NewDispatcherMetrics(m.Registerer),
+		logger:            log.New("alertmanager", "org", orgID),
+		orgID:             orgID,
+		marker:            types.NewMarker(r),
+		stageMetrics:      notify.NewMetrics(r),
+		dispatcherMetrics: dispatch.NewDispatcherMetrics(r),
 		Store:             store,
 		Metrics:           m,
+		peer:              peer,
+		peerTimeout:       cfg.UnifiedAlerting.HAPeerTimeout,
 	}
 
 	am.gokitLogger = gokit_log.NewLogfmtLogger(logging.NewWrapper(am.logger))
@@ -131,15 +160,20 @@ func New(cfg *setting.Cfg, store store.AlertingStore, m *metrics.Metrics) (*Aler
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize the notification log component of alerting: %w", err)
 	}
+	c := am.addClusterState(fmt.Sprintf("notificationlog:%d", orgID), am.notificationLog)
+	am.notificationLog.SetBroadcast(c.Broadcast)
+
 	// Initialize silences
 	am.silences, err = silence.New(silence.Options{
-		Metrics:      m.Registerer,
+		Metrics:      r,
 		SnapshotFile: filepath.Join(am.WorkingDirPath(), "silences"),
 		Retention:    retentionNotificationsAndSilences,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize the silencing component of alerting: %w", err)
 	}
+	c = am.addClusterState(fmt.Sprintf("silences:%d", orgID), am.silences)
+	am.silences.SetBroadcast(c.Broadcast)
 
 	am.wg.Add(1)
 	go func() {
@@ -156,44 +190,69 @@ func New(cfg *setting.Cfg, store store.AlertingStore, m *metrics.Metrics) (*Aler
 	return am, nil
 }
 
-func (am *Alertmanager) Run(ctx context.Context) error {
-	// Make sure dispatcher starts. We can tolerate future reload failures.
-	if err := am.SyncAndApplyConfigFromDatabase(); err != nil {
-		am.logger.Error("unable to sync configuration", "err", err)
-	}
+func (am *Alertmanager) StopAndWait() error {
+	am.reloadConfigMtx.Lock()
+	am.stopDispatcherAndInhibitor()
+	am.setActiveConfig(false)
+	am.reloadConfigMtx.Unlock()
 
-	for {
-		select {
-		case <-ctx.Done():
-			return am.StopAndWait()
-		case <-time.After(pollInterval):
-			if err := am.SyncAndApplyConfigFromDatabase(); err != nil {
-				am.logger.Error("unable to sync configuration", "err", err)
-			}
-		}
+	am.alerts.Close()
+
+	close(am.stopc)
+
+	am.wg.Wait()
+
+	// The Alertmanager of the organization may be created again, with the same metrics.
+	am.registerer.UnregisterAll()
+	return nil
+}
+
+// addClusterState adds a state of the Alertmanager to the peer, and returns the channel its
+// changes are broadcast to.
+func (am *Alertmanager) addClusterState(key string, state cluster.State) cluster.ClusterChannel {
+	s := &removableState{state: state}
+	am.clusterStates = append(am.clusterStates, s)
+	return am.peer.AddState(key, s, am.registerer)
+}
+
+// removeClusterStates stops replicating the silences and the notification log of the Alertmanager
+// with the other members of the cluster. It is called once the Alertmanager of an organization
+// that no longer exists is stopped.
+func (am *Alertmanager) removeClusterStates() {
+	for _, s := range am.clusterStates {
+		s.remove()
 	}
 }
 
-func (am *Alertmanager) StopAndWait() error {
+// stopDispatcherAndInhibitor stops the dispatcher and the inhibitor, and waits for the dispatcher to be done.
+// As they ignore Stop until their Run has started, it waits for them to be started first.
+// It must be called with reloadConfigMtx held.
+func (am *Alertmanager) stopDispatcherAndInhibitor() {
 	if am.dispatcher != nil {
+		<-am.dispatcherStarted
 		am.dispatcher.Stop()
 	}
 
 	if am.inhibitor != nil {
+		<-am.inhibitorStarted
 		am.inhibitor.Stop()
 	}
-
-	am.alerts.Close()
-
-	close(am.stopc)
-
-	am.wg.Wait()
-	return nil
 }
 
 // SaveAndApplyConfig saves the configuration the database and applies the configuration to the Alertmanager.
 // It rollbacks the save if we fail to apply the configuration.
 func (am *Alertmanager) SaveAndApplyConfig(cfg *apimodels.PostableUserConfig) error {
+	return am.saveAndApplyConfig(cfg, 0)
+}
+
+// SaveAndApplyConfigVersion saves and applies the configuration like SaveAndApplyConfig, if the latest
+// configuration of the organization is still the one with the ID latestID it is based on.
+// It returns store.ErrAlertmanagerConfigurationChanged otherwise.
+func (am *Alertmanager) SaveAndApplyConfigVersion(cfg *apimodels.PostableUserConfig, latestID int64) error {
+	return am.saveAndApplyConfig(cfg, latestID)
+}
+
+func (am *Alertmanager) saveAndApplyConfig(cfg *apimodels.PostableUserConfig, latestID int64) error {
 	rawConfig, err := json.Marshal(&cfg)
 	if err != nil {
 		return fmt.Errorf("failed to serialize to the Alertmanager configuration: %w", err)
@@ -203,8 +262,10 @@ func (am *Alertmanager) SaveAndApplyConfig(cfg *apimodels.PostableUserConfig) er
 	defer am.reloadConfigMtx.Unlock()
 
 	cmd := &ngmodels.SaveAlertmanagerConfigurationCmd{
+		OrgID:                     am.orgID,
 		AlertmanagerConfiguration: string(rawConfig),
 		ConfigurationVersion:      fmt.Sprintf("v%d", ngmodels.AlertConfigurationVersion),
+		LatestID:                  latestID,
 	}
 
 	err = am.Store.SaveAlertmanagerConfigurationWithCallback(cmd, func() error {
@@ -216,7 +277,7 @@ func (am *Alertmanager) SaveAndApplyConfig(cfg *apimodels.PostableUserConfig) er
 	if err != nil {
 		return err
 	}
-	am.Metrics.ActiveConfigurations.Set(1)
+	am.setActiveConfig(true)
 
 	return nil
 }
@@ -228,13 +289,14 @@ func (am *Alertmanager) SyncAndApplyConfigFromDatabase() error {
 	defer am.reloadConfigMtx.Unlock()
 
 	// First, let's get the configuration we need from the database.
-	q := &ngmodels.GetLatestAlertmanagerConfigurationQuery{}
+	q := &ngmodels.GetLatestAlertmanagerConfigurationQuery{OrgID: am.orgID}
 	if err := am.Store.GetLatestAlertmanagerConfiguration(q); err != nil {
 		// If there's no configuration in the database, let's use the default configuration.
 		if errors.Is(err, store.ErrNoAlertmanagerConfiguration) {
 			// First, let's save it to the database. We don't need to use a transaction here as we'll always succeed.
 			am.logger.Info("no Alertmanager configuration found, saving and applying a default")
 			savecmd := &ngmodels.SaveAlertmanagerConfigurationCmd{
+				OrgID:                     am.orgID,
 				AlertmanagerConfiguration: alertmanagerDefaultConfiguration,
 				Default:                   true,
 				ConfigurationVersion:      fmt.Sprintf("v%d", ngmodels.AlertConfigurationVersion),
@@ -243,7 +305,7 @@ func (am *Alertmanager) SyncAndApplyConfigFromDatabase() error {
 				return err
 			}
 
-			q.Result = &ngmodels.AlertConfiguration{AlertmanagerConfiguration: alertmanagerDefaultConfiguration, Default: true}
+			q.Result = &ngmodels.AlertConfiguration{OrgID: am.orgID, AlertmanagerConfiguration: alertmanagerDefaultConfiguration, Default: true}
 		} else {
 			return fmt.Errorf("unable to get Alertmanager configuration from the database: %w", err)
 		}
@@ -258,15 +320,25 @@ func (am *Alertmanager) SyncAndApplyConfigFromDatabase() error {
 		return fmt.Errorf("unable to reload configuration: %w", err)
 	}
 
-	if q.Result.Default {
-		am.Metrics.ActiveConfigurations.Set(0)
-	} else {
-		am.Metrics.ActiveConfigurations.Set(1)
-	}
+	am.setActiveConfig(!q.Result.Default)
 
 	return nil
 }
 
+// setActiveConfig updates the active configurations metric, that counts the organizations
+// whose configuration is not the default one. It must be called with reloadConfigMtx held.
+func (am *Alertmanager) setActiveConfig(active bool) {
+	if am.activeConfig == active {
+		return
+	}
+	am.activeConfig = active
+	if active {
+		am.Metrics.ActiveConfigurations.Inc()
+	} else {
+		am.Metrics.ActiveConfigurations.Dec()
+	}
+}
+
 // applyConfig applies a new configuration by re-initializing all components using the configuration provided.
 // It is not safe to call concurrently.
 func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig []byte) error {
@@ -288,7 +360,7 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 	if cfg.TemplateFiles == nil {
 		cfg.TemplateFiles = map[string]string{}
 	}
-	cfg.TemplateFiles["__default__.tmpl"] = channels.DefaultTemplateString
+	cfg.TemplateFiles[defaultTemplateName] = channels.DefaultTemplateString
 
 	// next, we need to make sure we persist the templates to disk.
 	paths, templatesChanged, err := PersistTemplates(cfg, am.WorkingDirPath())
@@ -321,25 +393,26 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 	// Now, let's put together our notification pipeline
 	routingStage := make(notify.RoutingStage, len(integrationsMap))
 
-	if am.inhibitor != nil {
-		am.inhibitor.Stop()
-	}
-	if am.dispatcher != nil {
-		am.dispatcher.Stop()
-	}
+	am.stopDispatcherAndInhibitor()
 
-	am.inhibitor = inhibit.NewInhibitor(am.alerts, cfg.AlertmanagerConfig.InhibitRules, am.marker, am.gokitLogger)
+	inhibitorAlerts := newStartedAlerts(am.alerts)
+	am.inhibitorStarted = inhibitorAlerts.started
+	am.inhibitor = inhibit.NewInhibitor(inhibitorAlerts, cfg.AlertmanagerConfig.InhibitRules, am.marker, am.gokitLogger)
 	am.silencer = silence.NewSilencer(am.silences, am.marker, am.gokitLogger)
 
+	meshStage := notify.NewGossipSettleStage(am.peer)
 	inhibitionStage := notify.NewMuteStage(am.inhibitor)
 	silencingStage := notify.NewMuteStage(am.silencer)
+	timeMuteStage := newTimeMuteStage(cfg.AlertmanagerConfig.MuteTimeIntervals)
 	for name := range integrationsMap {
-		stage := am.createReceiverStage(name, integrationsMap[name], waitFunc, am.notificationLog)
-		routingStage[name] = notify.MultiStage{silencingStage, inhibitionStage, stage}
+		stage := am.createReceiverStage(name, integrationsMap[name], am.waitFunc, am.notificationLog)
+		routingStage[name] = notify.MultiStage{meshStage, silencingStage, timeMuteStage, inhibitionStage, stage}
 	}
 
 	am.route = dispatch.NewRoute(cfg.AlertmanagerConfig.Route, nil)
-	am.dispatcher = dispatch.NewDispatcher(am.alerts, am.route, routingStage, am.marker, timeoutFunc, am.gokitLogger, am.dispatcherMetrics)
+	dispatcherAlerts := newStartedAlerts(am.alerts)
+	am.dispatcherStarted = dispatcherAlerts.started
+	am.dispatcher = dispatch.NewDispatcher(dispatcherAlerts, am.route, routingStage, am.marker, am.timeoutFunc, am.gokitLogger, am.dispatcherMetrics)
 
 	am.wg.Add(1)
 	go func() {
@@ -357,8 +430,68 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 	return nil
 }
 
+// unregisterer is a prometheus.Registerer that records the collectors registered with it,
+// so that all of them can be unregistered at once.
+type unregisterer struct {
+	prometheus.Registerer
+
+	mtx        sync.Mutex
+	collectors []prometheus.Collector
+}
+
+func newUnregisterer(r prometheus.Registerer) *unregisterer {
+	return &unregisterer{Registerer: r}
+}
+
+func (r *unregisterer) Register(c prometheus.Collector) error {
+	if err := r.Registerer.Register(c); err != nil {
+		return err
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.collectors = append(r.collectors, c)
+	return nil
+}
+
+func (r *unregisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// UnregisterAll unregisters all the collectors registered with r.
+func (r *unregisterer) UnregisterAll() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, c := range r.collectors {
+		r.Registerer.Unregister(c)
+	}
+	r.collectors = nil
+}
+
+// startedAlerts are the alerts of the Alertmanager as seen by its dispatcher or inhibitor. They
+// subscribe to the alerts once their Run has started, at which point started is closed.
+type startedAlerts struct {
+	provider.Alerts
+	once    sync.Once
+	started chan struct{}
+}
+
+func newStartedAlerts(alerts provider.Alerts) *startedAlerts {
+	return &startedAlerts{Alerts: alerts, started: make(chan struct{})}
+}
+
+func (a *startedAlerts) Subscribe() provider.AlertIterator {
+	a.once.Do(func() { close(a.started) })
+	return a.Alerts.Subscribe()
+}
+
+// WorkingDirPath returns the directory where the templates, silences and notification log
+// of the Alertmanager of the organization are stored.
 func (am *Alertmanager) WorkingDirPath() string {
-	return filepath.Join(am.Settings.DataPath, workingDir)
+	return filepath.Join(am.Settings.DataPath, workingDir, strconv.FormatInt(am.orgID, 10))
 }
 
 // buildIntegrationsMap builds a map of name to the list of Grafana integration notifiers off of a list of receiver config.
@@ -566,14 +699,56 @@ func (am *Alertmanager) createReceiverStage(name string, integrations []notify.I
 	return fs
 }
 
-func waitFunc() time.Duration {
-	return setting.AlertingNotificationTimeout
+// timeMuteStage removes the alerts from the pipeline when their route is within one of its
+// mute time intervals. Unlike notify.TimeMuteStage, the time intervals can be in the timezone of a location.
+type timeMuteStage struct {
+	muteTimes map[string][]apimodels.TimeInterval
+}
+
+func newTimeMuteStage(intervals []apimodels.MuteTimeInterval) *timeMuteStage {
+	muteTimes := make(map[string][]apimodels.TimeInterval, len(intervals))
+	for _, mt := range intervals {
+		muteTimes[mt.Name] = mt.TimeIntervals
+	}
+	return &timeMuteStage{muteTimes: muteTimes}
+}
+
+// Exec implements the notify.Stage interface.
+func (tms *timeMuteStage) Exec(ctx context.Context, l gokit_log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+	names, ok := notify.MuteTimeIntervalNames(ctx)
+	if !ok {
+		return ctx, alerts, nil
+	}
+	now, ok := notify.Now(ctx)
+	if !ok {
+		return ctx, alerts, errors.New("missing now timestamp")
+	}
+
+	for _, name := range names {
+		intervals, ok := tms.muteTimes[name]
+		if !ok {
+			return ctx, alerts, fmt.Errorf("mute time interval %s does not exist in the configuration", name)
+		}
+		for _, ti := range intervals {
+			if ti.ContainsTime(now) {
+				_ = level.Debug(l).Log("msg", "notifications not sent, route is within mute time interval", "interval", name)
+				return ctx, nil, nil
+			}
+		}
+	}
+	return ctx, alerts, nil
+}
+
+// waitFunc returns how long the notifications wait before being sent, so that the members of the
+// cluster notify one after the other and the notification log prevents duplicate notifications.
+func (am *Alertmanager) waitFunc() time.Duration {
+	return time.Duration(am.peer.Position()) * am.peerTimeout
 }
 
-func timeoutFunc(d time.Duration) time.Duration {
+func (am *Alertmanager) timeoutFunc(d time.Duration) time.Duration {
 	//TODO: What does MinTimeout means here?
 	if d < notify.MinTimeout {
 		d = notify.MinTimeout
 	}
-	return d + waitFunc()
+	return d + am.waitFunc()
 }
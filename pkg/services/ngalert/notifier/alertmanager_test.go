@@ -2,6 +2,7 @@ package notifier
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"os"
@@ -14,6 +15,7 @@ import (
 	gokit_log "github.com/go-kit/kit/log"
 	"github.com/go-openapi/strfmt"
 	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/notify"
 	"github.com/prometheus/alertmanager/provider/mem"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/client_golang/prometheus"
@@ -23,6 +25,7 @@ import (
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/logging"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/setting"
@@ -47,7 +50,7 @@ func setupAMTest(t *testing.T) *Alertmanager {
 		Logger:                 log.New("alertmanager-test"),
 	}
 
-	am, err := New(cfg, store, m)
+	am, err := New(1, cfg, store, m, NilPeer{})
 	require.NoError(t, err)
 	return am
 }
@@ -58,6 +61,41 @@ func TestAlertmanager_ShouldUseDefaultConfigurationWhenNoConfiguration(t *testin
 	require.NotNil(t, am.config)
 }
 
+func TestAlertmanager_SaveAndApplyConfigVersion(t *testing.T) {
+	am := setupAMTest(t)
+	require.NoError(t, am.SyncAndApplyConfigFromDatabase())
+	q := &ngmodels.GetLatestAlertmanagerConfigurationQuery{OrgID: 1}
+	require.NoError(t, am.Store.GetLatestAlertmanagerConfiguration(q))
+	cfg, err := DefaultConfiguration()
+	require.NoError(t, err)
+
+	require.NoError(t, am.SaveAndApplyConfigVersion(cfg, q.Result.ID))
+	require.ErrorIs(t, am.SaveAndApplyConfigVersion(cfg, q.Result.ID), store.ErrAlertmanagerConfigurationChanged, "the configuration is not the latest one anymore")
+}
+
+func TestAlertmanager_StopAndWait(t *testing.T) {
+	t.Run("without configuration", func(t *testing.T) {
+		am := setupAMTest(t)
+		require.NoError(t, am.StopAndWait())
+	})
+
+	t.Run("right after the configuration is applied", func(t *testing.T) {
+		am := setupAMTest(t)
+		require.NoError(t, am.SyncAndApplyConfigFromDatabase())
+
+		done := make(chan error)
+		go func() {
+			done <- am.StopAndWait()
+		}()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the Alertmanager was not stopped")
+		}
+	})
+}
+
 func TestPutAlert(t *testing.T) {
 	am := setupAMTest(t)
 
@@ -310,3 +348,68 @@ func TestPutAlert(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeMuteStage(t *testing.T) {
+	var intervals []apimodels.MuteTimeInterval
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"name": "weekends", "time_intervals": [{"weekdays": ["saturday", "sunday"]}]},
+		{"name": "paris-nights", "time_intervals": [{"times": [{"start_time": "00:00", "end_time": "06:00"}], "location": "Europe/Paris"}]}
+	]`), &intervals))
+	stage := newTimeMuteStage(intervals)
+	alerts := []*types.Alert{{Alert: model.Alert{Labels: model.LabelSet{"alertname": "test"}}}}
+
+	testCases := []struct {
+		desc      string
+		intervals []string
+		now       time.Time
+		muted     bool
+	}{
+		{
+			desc:      "alerts are muted within the time interval",
+			intervals: []string{"weekends"},
+			now:       time.Date(2021, time.October, 16, 12, 0, 0, 0, time.UTC), // a Saturday
+			muted:     true,
+		},
+		{
+			desc:      "alerts are not muted outside of the time interval",
+			intervals: []string{"weekends"},
+			now:       time.Date(2021, time.October, 18, 12, 0, 0, 0, time.UTC), // a Monday
+		},
+		{
+			desc:      "the time interval is in the timezone of its location",
+			intervals: []string{"paris-nights"},
+			now:       time.Date(2021, time.October, 18, 23, 30, 0, 0, time.UTC), // 01:30 in Paris
+			muted:     true,
+		},
+		{
+			desc:      "alerts are muted if any of the time intervals of the route contains the time",
+			intervals: []string{"weekends", "paris-nights"},
+			now:       time.Date(2021, time.October, 18, 2, 0, 0, 0, time.UTC),
+			muted:     true,
+		},
+		{
+			desc:      "alerts are not muted in the timezone of the location",
+			intervals: []string{"paris-nights"},
+			now:       time.Date(2021, time.October, 18, 5, 30, 0, 0, time.UTC), // 07:30 in Paris
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx := notify.WithNow(notify.WithMuteTimeIntervals(context.Background(), tc.intervals), tc.now)
+			_, res, err := stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+			require.NoError(t, err)
+			if tc.muted {
+				require.Empty(t, res)
+			} else {
+				require.Equal(t, alerts, res)
+			}
+		})
+	}
+
+	t.Run("an undefined time interval is an error", func(t *testing.T) {
+		ctx := notify.WithNow(notify.WithMuteTimeIntervals(context.Background(), []string{"holidays"}), time.Now())
+		_, _, err := stage.Exec(ctx, gokit_log.NewNopLogger(), alerts...)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,72 @@
+package notifier
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/cluster"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// settleTimeout is for how long the notifications wait for the gossip to settle after the
+	// Alertmanagers start, before they are sent anyway.
+	settleTimeout = 30 * time.Second
+	// leaveTimeout is for how long the other members of the cluster are notified that this one is leaving.
+	leaveTimeout = 10 * time.Second
+)
+
+// ClusterPeer is the member of the gossip cluster the silences and the notification log
+// of the Alertmanagers are replicated with.
+type ClusterPeer interface {
+	AddState(string, cluster.State, prometheus.Registerer) cluster.ClusterChannel
+	Position() int
+	WaitReady(context.Context) error
+}
+
+// NilPeer is the ClusterPeer of an Alertmanager that runs without clustering.
+type NilPeer struct{}
+
+func (NilPeer) Position() int                   { return 0 }
+func (NilPeer) WaitReady(context.Context) error { return nil }
+func (NilPeer) AddState(string, cluster.State, prometheus.Registerer) cluster.ClusterChannel {
+	return NilChannel{}
+}
+
+// NilChannel is the ClusterChannel of an Alertmanager that runs without clustering.
+type NilChannel struct{}
+
+func (NilChannel) Broadcast([]byte) {}
+
+// removableState is a state of the gossip cluster that can be removed, as the peer has no way to
+// remove its states. Once removed, it has nothing to replicate and ignores the data of the other
+// members, so that the Alertmanager it belonged to can be released.
+type removableState struct {
+	mtx   sync.RWMutex
+	state cluster.State
+}
+
+func (s *removableState) MarshalBinary() ([]byte, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.state == nil {
+		return nil, nil
+	}
+	return s.state.MarshalBinary()
+}
+
+func (s *removableState) Merge(b []byte) error {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.state == nil {
+		return nil
+	}
+	return s.state.Merge(b)
+}
+
+func (s *removableState) remove() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.state = nil
+}
@@ -0,0 +1,132 @@
+// +build integration
+
+package notifier
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// freeAddr returns a local address with a port that is free for both TCP and UDP, as memberlist uses both.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	for {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := l.Addr().String()
+		require.NoError(t, l.Close())
+
+		pc, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			continue
+		}
+		require.NoError(t, pc.Close())
+		return addr
+	}
+}
+
+func TestMultiOrgAlertmanager_Clustering(t *testing.T) {
+	configStore := &store.DBstore{
+		BaseInterval:           10 * time.Second,
+		DefaultIntervalSeconds: 60,
+		SQLStore:               sqlstore.InitTestDB(t),
+		Logger:                 log.New("alertmanager-cluster-test"),
+	}
+	orgStore := &fakeOrgStore{orgs: []int64{1, 2}}
+	addrs := []string{freeAddr(t), freeAddr(t)}
+
+	// Each instance of Grafana runs the Alertmanagers of all the organizations,
+	// with its own data directory.
+	instances := make([]*MultiOrgAlertmanager, 0, len(addrs))
+	for _, addr := range addrs {
+		dir, err := ioutil.TempDir("", "")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, os.RemoveAll(dir))
+		})
+
+		cfg := &setting.Cfg{
+			DataPath: dir,
+			UnifiedAlerting: setting.UnifiedAlertingSettings{
+				HAListenAddr:       addr,
+				HAAdvertiseAddr:    addr,
+				HAPeers:            addrs,
+				HAPeerTimeout:      15 * time.Second,
+				HAGossipInterval:   50 * time.Millisecond,
+				HAPushPullInterval: time.Second,
+			},
+		}
+		mam, err := NewMultiOrgAlertmanager(cfg, configStore, orgStore, metrics.NewMetrics(prometheus.NewRegistry()))
+		require.NoError(t, err)
+		t.Cleanup(mam.StopAndWait)
+		require.NoError(t, mam.LoadAndSyncAlertmanagersForOrgs(context.Background()))
+		instances = append(instances, mam)
+	}
+
+	positions := map[int]struct{}{}
+	for _, mam := range instances {
+		require.Eventually(t, func() bool {
+			return mam.peer.(interface{ ClusterSize() int }).ClusterSize() == len(instances)
+		}, 10*time.Second, 50*time.Millisecond, "the instances should join the same cluster")
+		positions[mam.peer.Position()] = struct{}{}
+	}
+	require.Len(t, positions, len(instances), "each instance should notify at a different position")
+
+	am1, err := instances[0].AlertmanagerFor(1)
+	require.NoError(t, err)
+	am2, err := instances[1].AlertmanagerFor(1)
+	require.NoError(t, err)
+
+	startsAt := strfmt.DateTime(time.Now())
+	endsAt := strfmt.DateTime(time.Now().Add(time.Hour))
+	createdBy, comment := "test", "replicated"
+	name, value, isRegex := "alertname", "test", false
+	silenceID, err := am1.CreateSilence(&apimodels.PostableSilence{
+		Silence: amv2.Silence{
+			StartsAt:  &startsAt,
+			EndsAt:    &endsAt,
+			CreatedBy: &createdBy,
+			Comment:   &comment,
+			Matchers:  amv2.Matchers{{Name: &name, Value: &value, IsRegex: &isRegex}},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("silences are replicated to the other instances", func(t *testing.T) {
+		require.Eventually(t, func() bool {
+			_, err := am2.GetSilence(silenceID)
+			return err == nil
+		}, 10*time.Second, 50*time.Millisecond)
+	})
+
+	t.Run("silences are only replicated to the Alertmanager of the same organization", func(t *testing.T) {
+		other, err := instances[1].AlertmanagerFor(2)
+		require.NoError(t, err)
+		_, err = other.GetSilence(silenceID)
+		require.ErrorIs(t, err, ErrSilenceNotFound)
+	})
+
+	t.Run("expired silences are replicated to the other instances", func(t *testing.T) {
+		require.NoError(t, am2.DeleteSilence(silenceID))
+		require.Eventually(t, func() bool {
+			s, err := am1.GetSilence(silenceID)
+			return err == nil && *s.Status.State == amv2.SilenceStatusStateExpired
+		}, 10*time.Second, 50*time.Millisecond)
+	})
+}
@@ -82,3 +82,8 @@ func Load(rawConfig []byte) (*api.PostableUserConfig, error) {
 
 	return cfg, nil
 }
+
+// DefaultConfiguration returns the configuration the Alertmanager of an organization starts with.
+func DefaultConfiguration() (*api.PostableUserConfig, error) {
+	return Load([]byte(alertmanagerDefaultConfiguration))
+}
@@ -0,0 +1,267 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	gokit_log "github.com/go-kit/kit/log"
+	"github.com/prometheus/alertmanager/cluster"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/logging"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var (
+	// ErrNoAlertmanagerForOrg is returned when there is no Alertmanager for an organization.
+	ErrNoAlertmanagerForOrg = fmt.Errorf("Alertmanager does not exist for this organization")
+)
+
+// MultiOrgAlertmanager manages an Alertmanager for each organization. Alertmanagers are created
+// when organizations appear and stopped when they disappear.
+type MultiOrgAlertmanager struct {
+	alertmanagersMtx sync.RWMutex
+	alertmanagers    map[int64]*Alertmanager
+
+	settings *setting.Cfg
+	logger   log.Logger
+
+	configStore store.AlertingStore
+	orgStore    store.OrgStore
+
+	metrics *metrics.Metrics
+
+	// peer is shared by the Alertmanagers of all the organizations, their silences and
+	// notification logs are replicated under a key per organization.
+	peer         ClusterPeer
+	settleCancel context.CancelFunc
+}
+
+// NewMultiOrgAlertmanager creates a MultiOrgAlertmanager without any Alertmanager, they are
+// created by LoadAndSyncAlertmanagersForOrgs. When peers are configured, it joins the
+// gossip cluster of the Alertmanagers of the other instances of Grafana.
+func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore store.AlertingStore, orgStore store.OrgStore, m *metrics.Metrics) (*MultiOrgAlertmanager, error) {
+	moa := &MultiOrgAlertmanager{
+		settings:      cfg,
+		logger:        log.New("multiorg.alertmanager"),
+		alertmanagers: map[int64]*Alertmanager{},
+		configStore:   configStore,
+		orgStore:      orgStore,
+		metrics:       m,
+		peer:          NilPeer{},
+		settleCancel:  func() {},
+	}
+
+	ua := cfg.UnifiedAlerting
+	if len(ua.HAPeers) == 0 {
+		return moa, nil
+	}
+
+	clusterLogger := gokit_log.NewLogfmtLogger(logging.NewWrapper(log.New("multiorg.alertmanager.cluster")))
+	peer, err := cluster.Create(
+		clusterLogger,
+		m.Registerer,
+		ua.HAListenAddr,
+		ua.HAAdvertiseAddr,
+		ua.HAPeers,
+		true,
+		ua.HAPushPullInterval,
+		ua.HAGossipInterval,
+		cluster.DefaultTcpTimeout,
+		cluster.DefaultProbeTimeout,
+		cluster.DefaultProbeInterval,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize the gossip cluster of the Alertmanager: %w", err)
+	}
+
+	// The other members may not be up yet, they are retried in the background.
+	if err := peer.Join(cluster.DefaultReconnectInterval, cluster.DefaultReconnectTimeout); err != nil {
+		moa.logger.Error("unable to join the gossip cluster of the Alertmanager", "err", err)
+	}
+
+	var ctx context.Context
+	ctx, moa.settleCancel = context.WithTimeout(context.Background(), settleTimeout)
+	go peer.Settle(ctx, ua.HAGossipInterval*10)
+	moa.peer = peer
+
+	return moa, nil
+}
+
+// Run synchronizes the Alertmanagers with the organizations and their configurations
+// periodically, until the context is done.
+func (moa *MultiOrgAlertmanager) Run(ctx context.Context) error {
+	moa.logger.Info("starting MultiOrg Alertmanager")
+
+	for {
+		select {
+		case <-ctx.Done():
+			moa.StopAndWait()
+			return nil
+		case <-time.After(pollInterval):
+			if err := moa.LoadAndSyncAlertmanagersForOrgs(ctx); err != nil {
+				moa.logger.Error("error while synchronizing Alertmanager orgs", "err", err)
+			}
+		}
+	}
+}
+
+// LoadAndSyncAlertmanagersForOrgs loads the organizations from the database
+// and synchronizes the Alertmanagers with them.
+func (moa *MultiOrgAlertmanager) LoadAndSyncAlertmanagersForOrgs(ctx context.Context) error {
+	moa.logger.Debug("synchronizing Alertmanagers for orgs")
+	orgIDs, err := moa.orgStore.GetOrgs(ctx)
+	if err != nil {
+		return err
+	}
+
+	moa.SyncAlertmanagersForOrgs(orgIDs)
+	moa.logger.Debug("done synchronizing Alertmanagers for orgs")
+	return nil
+}
+
+// SyncAlertmanagersForOrgs creates the missing Alertmanagers of the organizations, applies the
+// latest configuration of each of them, and stops the Alertmanagers of the organizations that
+// no longer exist. The silences and notification log of these organizations are removed, and
+// no longer replicated with the other members of the cluster.
+func (moa *MultiOrgAlertmanager) SyncAlertmanagersForOrgs(orgIDs []int64) {
+	orgsFound := make(map[int64]struct{}, len(orgIDs))
+	toSync := make([]*Alertmanager, 0, len(orgIDs))
+	toStop := make(map[int64]*Alertmanager)
+
+	// The main organization is the one with the smallest ID.
+	var mainOrgID int64
+	for _, orgID := range orgIDs {
+		if mainOrgID == 0 || orgID < mainOrgID {
+			mainOrgID = orgID
+		}
+	}
+
+	moa.alertmanagersMtx.Lock()
+	for _, orgID := range orgIDs {
+		orgsFound[orgID] = struct{}{}
+
+		am, found := moa.alertmanagers[orgID]
+		if !found {
+			if orgID == mainOrgID {
+				moa.migrateLegacyFiles(orgID)
+			}
+			var err error
+			am, err = New(orgID, moa.settings, moa.configStore, moa.metrics, moa.peer)
+			if err != nil {
+				moa.logger.Error("unable to create Alertmanager for org", "org", orgID, "err", err)
+				continue
+			}
+			moa.alertmanagers[orgID] = am
+		}
+		toSync = append(toSync, am)
+	}
+
+	for orgID, am := range moa.alertmanagers {
+		if _, exists := orgsFound[orgID]; !exists {
+			toStop[orgID] = am
+			delete(moa.alertmanagers, orgID)
+		}
+	}
+	moa.alertmanagersMtx.Unlock()
+
+	// The configurations are applied and the Alertmanagers are stopped without holding the lock,
+	// so that the Alertmanagers of the other organizations remain available.
+	for _, am := range toSync {
+		if err := am.SyncAndApplyConfigFromDatabase(); err != nil {
+			am.logger.Error("failed to apply Alertmanager config for org", "org", am.orgID, "err", err)
+		}
+	}
+
+	for orgID, am := range toStop {
+		moa.logger.Info("stopping Alertmanager", "org", orgID)
+		if err := am.StopAndWait(); err != nil {
+			moa.logger.Error("failed to stop Alertmanager", "org", orgID, "err", err)
+		}
+		am.removeClusterStates()
+		if err := os.RemoveAll(am.WorkingDirPath()); err != nil {
+			moa.logger.Error("failed to remove the working directory of the Alertmanager", "org", orgID, "err", err)
+		}
+	}
+}
+
+// migrateLegacyFiles moves the silences and the notification log of the Alertmanager shared by all
+// the organizations, from before each organization had its own, to the working directory of the
+// Alertmanager of the main organization. The main organization also kept the shared configuration.
+// The files are not moved if the Alertmanager of the main organization already has its own.
+func (moa *MultiOrgAlertmanager) migrateLegacyFiles(mainOrgID int64) {
+	legacyDir := filepath.Join(moa.settings.DataPath, workingDir)
+	orgDir := filepath.Join(legacyDir, strconv.FormatInt(mainOrgID, 10))
+	for _, name := range []string{"silences", "notifications"} {
+		legacyPath := filepath.Join(legacyDir, name)
+		if _, err := os.Stat(legacyPath); err != nil {
+			if !os.IsNotExist(err) {
+				moa.logger.Error("unable to check the legacy Alertmanager file", "file", legacyPath, "err", err)
+			}
+			continue
+		}
+
+		orgPath := filepath.Join(orgDir, name)
+		if _, err := os.Stat(orgPath); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(orgDir, 0750); err != nil {
+			moa.logger.Error("unable to create the working directory of the Alertmanager", "org", mainOrgID, "err", err)
+			return
+		}
+		if err := os.Rename(legacyPath, orgPath); err != nil {
+			moa.logger.Error("unable to move the legacy Alertmanager file", "file", legacyPath, "org", mainOrgID, "err", err)
+			continue
+		}
+		moa.logger.Info("moved the legacy Alertmanager file to the main organization", "file", legacyPath, "org", mainOrgID)
+	}
+}
+
+// StopAndWait stops all the Alertmanagers, and leaves the gossip cluster.
+func (moa *MultiOrgAlertmanager) StopAndWait() {
+	moa.alertmanagersMtx.Lock()
+	defer moa.alertmanagersMtx.Unlock()
+
+	for orgID, am := range moa.alertmanagers {
+		if err := am.StopAndWait(); err != nil {
+			moa.logger.Error("failed to stop Alertmanager", "org", orgID, "err", err)
+		}
+	}
+
+	if p, ok := moa.peer.(*cluster.Peer); ok {
+		moa.settleCancel()
+		if err := p.Leave(leaveTimeout); err != nil {
+			moa.logger.Warn("unable to leave the gossip cluster of the Alertmanager", "err", err)
+		}
+	}
+}
+
+// AlertmanagerFor returns the Alertmanager of the organization.
+// It returns ErrNoAlertmanagerForOrg if the organization has none.
+func (moa *MultiOrgAlertmanager) AlertmanagerFor(orgID int64) (*Alertmanager, error) {
+	moa.alertmanagersMtx.RLock()
+	defer moa.alertmanagersMtx.RUnlock()
+
+	am, found := moa.alertmanagers[orgID]
+	if !found {
+		return nil, ErrNoAlertmanagerForOrg
+	}
+	return am, nil
+}
+
+// PutAlerts sends the alerts to the Alertmanager of the organization.
+func (moa *MultiOrgAlertmanager) PutAlerts(orgID int64, alerts apimodels.PostableAlerts) error {
+	am, err := moa.AlertmanagerFor(orgID)
+	if err != nil {
+		return err
+	}
+	return am.PutAlerts(alerts)
+}
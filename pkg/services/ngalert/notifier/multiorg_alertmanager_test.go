@@ -0,0 +1,228 @@
+package notifier
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/cluster"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeOrgStore struct {
+	orgs []int64
+}
+
+func (f *fakeOrgStore) GetOrgs(_ context.Context) ([]int64, error) {
+	return f.orgs, nil
+}
+
+// fakePeer is a ClusterPeer that records the states added to it.
+type fakePeer struct {
+	NilPeer
+	mtx    sync.Mutex
+	states map[string]cluster.State
+}
+
+func (p *fakePeer) AddState(key string, s cluster.State, r prometheus.Registerer) cluster.ClusterChannel {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.states[key] = s
+	return p.NilPeer.AddState(key, s, r)
+}
+
+func (p *fakePeer) state(key string) cluster.State {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.states[key]
+}
+
+func TestMultiOrgAlertmanager_SyncAlertmanagersForOrgs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, os.RemoveAll(dir))
+	})
+
+	configStore := &store.DBstore{
+		BaseInterval:           10 * time.Second,
+		DefaultIntervalSeconds: 60,
+		SQLStore:               sqlstore.InitTestDB(t),
+		Logger:                 log.New("multiorg-alertmanager-test"),
+	}
+	orgStore := &fakeOrgStore{orgs: []int64{1, 2, 3}}
+	reg := prometheus.NewRegistry()
+	m := metrics.NewMetrics(reg)
+	mam, err := NewMultiOrgAlertmanager(&setting.Cfg{DataPath: dir}, configStore, orgStore, m)
+	require.NoError(t, err)
+	t.Cleanup(mam.StopAndWait)
+	peer := &fakePeer{states: map[string]cluster.State{}}
+	mam.peer = peer
+
+	ctx := context.Background()
+
+	// Ensure that one Alertmanager is created per org, each with its own configuration and directory.
+	{
+		require.NoError(t, mam.LoadAndSyncAlertmanagersForOrgs(ctx))
+		require.Len(t, mam.alertmanagers, 3)
+		for _, orgID := range orgStore.orgs {
+			am, err := mam.AlertmanagerFor(orgID)
+			require.NoError(t, err)
+			require.NotNil(t, am.config)
+			require.DirExists(t, am.WorkingDirPath())
+
+			q := &ngmodels.GetLatestAlertmanagerConfigurationQuery{OrgID: orgID}
+			require.NoError(t, configStore.GetLatestAlertmanagerConfiguration(q))
+			require.Equal(t, orgID, q.Result.OrgID)
+			require.True(t, q.Result.Default)
+		}
+	}
+	// When an org is removed, its Alertmanager is stopped and its files are removed.
+	{
+		am, err := mam.AlertmanagerFor(3)
+		require.NoError(t, err)
+		startsAt := strfmt.DateTime(time.Now())
+		endsAt := strfmt.DateTime(time.Now().Add(time.Hour))
+		createdBy, comment := "test", "removed with the org"
+		name, value, isRegex := "alertname", "test", false
+		_, err = am.CreateSilence(&apimodels.PostableSilence{
+			Silence: amv2.Silence{
+				StartsAt:  &startsAt,
+				EndsAt:    &endsAt,
+				CreatedBy: &createdBy,
+				Comment:   &comment,
+				Matchers:  amv2.Matchers{{Name: &name, Value: &value, IsRegex: &isRegex}},
+			},
+		})
+		require.NoError(t, err)
+		silences, err := peer.state("silences:3").MarshalBinary()
+		require.NoError(t, err)
+		require.NotEmpty(t, silences)
+
+		orgStore.orgs = []int64{1, 2}
+		require.NoError(t, mam.LoadAndSyncAlertmanagersForOrgs(ctx))
+
+		// its silences and notification log are no longer replicated
+		for _, key := range []string{"silences:3", "notificationlog:3"} {
+			b, err := peer.state(key).MarshalBinary()
+			require.NoError(t, err)
+			require.Empty(t, b, key)
+		}
+		require.NoError(t, peer.state("silences:3").Merge(silences))
+		b, err := peer.state("silences:3").MarshalBinary()
+		require.NoError(t, err)
+		require.Empty(t, b, "the silences of the other members are ignored")
+
+		require.Len(t, mam.alertmanagers, 2)
+		require.NoDirExists(t, am.WorkingDirPath())
+		_, err = mam.AlertmanagerFor(3)
+		require.ErrorIs(t, err, ErrNoAlertmanagerForOrg)
+		require.Zero(t, countOrgMetrics(t, reg, "3"))
+		require.NotZero(t, countOrgMetrics(t, reg, "2"))
+	}
+	// When an org is added, it gets an Alertmanager too.
+	{
+		orgStore.orgs = []int64{1, 2, 4}
+		require.NoError(t, mam.LoadAndSyncAlertmanagersForOrgs(ctx))
+		require.Len(t, mam.alertmanagers, 3)
+		_, err := mam.AlertmanagerFor(4)
+		require.NoError(t, err)
+	}
+	// When a removed org is added back, its Alertmanager registers its metrics again.
+	{
+		orgStore.orgs = []int64{1, 2, 3, 4}
+		require.NoError(t, mam.LoadAndSyncAlertmanagersForOrgs(ctx))
+		require.Len(t, mam.alertmanagers, 4)
+		require.NotZero(t, countOrgMetrics(t, reg, "3"))
+	}
+}
+
+// countOrgMetrics returns the number of metrics of the Alertmanager of the organization.
+func countOrgMetrics(t *testing.T, g prometheus.Gatherer, orgID string) int {
+	t.Helper()
+	mfs, err := g.Gather()
+	require.NoError(t, err)
+	count := 0
+	for _, mf := range mfs {
+		for _, metric := range mf.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "org" && label.GetValue() == orgID {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+func TestMultiOrgAlertmanager_MigrateLegacyFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, os.RemoveAll(dir))
+	})
+
+	// The silences of the Alertmanager shared by all the organizations.
+	legacySilences, err := silence.New(silence.Options{})
+	require.NoError(t, err)
+	now := time.Now()
+	_, err = legacySilences.Set(&silencepb.Silence{
+		Matchers:  []*silencepb.Matcher{{Type: silencepb.Matcher_EQUAL, Name: "alertname", Pattern: "test"}},
+		StartsAt:  now,
+		EndsAt:    now.Add(time.Hour),
+		CreatedBy: "test",
+		Comment:   "legacy silence",
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, workingDir), 0750))
+	f, err := os.Create(filepath.Join(dir, workingDir, "silences"))
+	require.NoError(t, err)
+	_, err = legacySilences.Snapshot(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	configStore := &store.DBstore{
+		BaseInterval:           10 * time.Second,
+		DefaultIntervalSeconds: 60,
+		SQLStore:               sqlstore.InitTestDB(t),
+		Logger:                 log.New("multiorg-alertmanager-test"),
+	}
+	orgStore := &fakeOrgStore{orgs: []int64{2, 1}}
+	m := metrics.NewMetrics(prometheus.NewRegistry())
+	mam, err := NewMultiOrgAlertmanager(&setting.Cfg{DataPath: dir}, configStore, orgStore, m)
+	require.NoError(t, err)
+	t.Cleanup(mam.StopAndWait)
+	require.NoError(t, mam.LoadAndSyncAlertmanagersForOrgs(context.Background()))
+
+	// The silences are moved to the main organization.
+	require.NoFileExists(t, filepath.Join(dir, workingDir, "silences"))
+	am, err := mam.AlertmanagerFor(1)
+	require.NoError(t, err)
+	silences, err := am.ListSilences(nil)
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	require.Equal(t, "legacy silence", *silences[0].Comment)
+
+	am, err = mam.AlertmanagerFor(2)
+	require.NoError(t, err)
+	silences, err = am.ListSilences(nil)
+	require.NoError(t, err)
+	require.Empty(t, silences)
+}
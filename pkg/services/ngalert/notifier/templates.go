@@ -0,0 +1,252 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	tmpltext "text/template"
+	"time"
+
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier/channels"
+)
+
+const (
+	// defaultTemplateName is the name of the template file of the default templates,
+	// which cannot be changed.
+	defaultTemplateName = "__default__.tmpl"
+	// previewReceiverName is the receiver of the alert groups the templates are previewed for.
+	previewReceiverName = "preview"
+)
+
+// ErrInvalidTemplate is returned when a template file cannot be stored or rendered.
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// ValidateTemplate checks that the template file can be stored in the Alertmanager
+// configuration with this name, and that its templates parse.
+func ValidateTemplate(name, content string) error {
+	if err := validateTemplateName(name); err != nil {
+		return err
+	}
+	if _, err := parseTemplate(name, content); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+	return nil
+}
+
+// validateTemplateName checks that the name can be the name of a template file of the Alertmanager,
+// which are written to its working directory.
+func validateTemplateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: the name is missing", ErrInvalidTemplate)
+	}
+	if name != filepath.Base(filepath.Clean(name)) || name == defaultTemplateName {
+		return fmt.Errorf("%w: the name %q is not valid", ErrInvalidTemplate, name)
+	}
+	return nil
+}
+
+// templateCallRegexp matches the templates called by the settings of a contact point.
+var templateCallRegexp = regexp.MustCompile(`{{-?\s*template\s+"([^"]+)"`)
+
+// ReceiversUsingTemplate returns the names of the contact points of the configuration that use
+// the templates defined in the template file, and not in one of its other template files.
+func ReceiversUsingTemplate(cfg *apimodels.PostableUserConfig, name string) ([]string, error) {
+	defined := map[string]struct{}{}
+	for n, content := range cfg.TemplateFiles {
+		if n == name {
+			continue
+		}
+		parsed, err := parseTemplate(n, content)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+		}
+		for _, t := range parsed.Templates() {
+			defined[t.Name()] = struct{}{}
+		}
+	}
+	parsed, err := parseTemplate(name, cfg.TemplateFiles[name])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+	removed := map[string]struct{}{}
+	for _, t := range parsed.Templates() {
+		if _, ok := defined[t.Name()]; !ok && t.Name() != name {
+			removed[t.Name()] = struct{}{}
+		}
+	}
+
+	var receivers []string
+	for _, r := range cfg.AlertmanagerConfig.Receivers {
+		for _, gr := range r.GrafanaManagedReceivers {
+			if gr.Settings != nil && usesTemplates(gr.Settings.Interface(), removed) {
+				receivers = append(receivers, r.Name)
+				break
+			}
+		}
+	}
+	return receivers, nil
+}
+
+// usesTemplates tells whether one of the strings of the settings calls one of the templates.
+func usesTemplates(settings interface{}, templates map[string]struct{}) bool {
+	switch v := settings.(type) {
+	case string:
+		for _, m := range templateCallRegexp.FindAllStringSubmatch(v, -1) {
+			if _, ok := templates[m[1]]; ok {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, value := range v {
+			if usesTemplates(value, templates) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if usesTemplates(value, templates) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseTemplate parses a template file with the functions of the Alertmanager templates.
+func parseTemplate(name, content string) (*tmpltext.Template, error) {
+	return tmpltext.New(name).Option("missingkey=zero").Funcs(tmpltext.FuncMap(template.DefaultFuncs)).Parse(content)
+}
+
+// PreviewTemplates renders the templates defined in a template file for a group of alerts, as
+// the contact points would. The template file replaces the one with the same name in the template
+// files the Alertmanager is configured with, so it can use their templates. The templates are
+// rendered for a sample alert when there are no alerts. The error is returned when the template
+// files do not parse, the errors of the templates that fail to render are in the previews.
+func (am *Alertmanager) PreviewTemplates(templateFiles map[string]string, name, content string, alerts []amv2.PostableAlert) ([]apimodels.TemplatePreview, error) {
+	if name == "" {
+		name = previewReceiverName
+	}
+	if err := validateTemplateName(name); err != nil {
+		return nil, err
+	}
+	parsed, err := parseTemplate(name, content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+
+	files := make(map[string]string, len(templateFiles)+2)
+	for n, c := range templateFiles {
+		files[n] = c
+	}
+	files[name] = content
+	files[defaultTemplateName] = channels.DefaultTemplateString
+
+	dir, err := ioutil.TempDir("", "grafana-templates")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			am.logger.Warn("failed to remove the template files of the preview", "dir", dir, "err", err)
+		}
+	}()
+	paths, _, err := PersistTemplates(&apimodels.PostableUserConfig{TemplateFiles: files}, dir)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.FromGlobs(paths...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTemplate, err)
+	}
+	externalURL, err := url.Parse(am.Settings.AppURL)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.ExternalURL = externalURL
+
+	if len(alerts) == 0 {
+		alerts = []amv2.PostableAlert{sampleAlert()}
+	}
+	previewAlerts := make([]*types.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		previewAlerts = append(previewAlerts, toPreviewAlert(a))
+	}
+
+	ctx := notify.WithReceiverName(context.Background(), previewReceiverName)
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{})
+
+	var tmplErr error
+	tmplText, _ := channels.TmplText(ctx, tmpl, previewAlerts, am.logger, &tmplErr)
+
+	previews := make([]apimodels.TemplatePreview, 0, len(parsed.Templates()))
+	for _, t := range parsed.Templates() {
+		// the text outside of the defined templates is not used by the contact points
+		if t.Name() == name {
+			continue
+		}
+		tmplErr = nil
+		preview := apimodels.TemplatePreview{
+			Name: t.Name(),
+			Text: tmplText(fmt.Sprintf(`{{ template %q . }}`, t.Name())),
+		}
+		if tmplErr != nil {
+			preview.Error = tmplErr.Error()
+		}
+		previews = append(previews, preview)
+	}
+	sort.Slice(previews, func(i, j int) bool { return previews[i].Name < previews[j].Name })
+	return previews, nil
+}
+
+// sampleAlert is the alert the templates are previewed for when no alerts are given.
+func sampleAlert() amv2.PostableAlert {
+	return amv2.PostableAlert{
+		Alert: amv2.Alert{
+			Labels: amv2.LabelSet{
+				"alertname": "TestAlert",
+				"instance":  "Grafana",
+			},
+		},
+		Annotations: amv2.LabelSet{
+			"summary":          "Notification test",
+			"__value_string__": "[ metric='foo' labels={instance=bar} value=10 ]",
+		},
+	}
+}
+
+func toPreviewAlert(a amv2.PostableAlert) *types.Alert {
+	now := time.Now()
+	alert := &types.Alert{
+		Alert: model.Alert{
+			Labels:       model.LabelSet{},
+			Annotations:  model.LabelSet{},
+			StartsAt:     time.Time(a.StartsAt),
+			EndsAt:       time.Time(a.EndsAt),
+			GeneratorURL: a.GeneratorURL.String(),
+		},
+		UpdatedAt: now,
+	}
+	for k, v := range a.Labels {
+		alert.Labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+	for k, v := range a.Annotations {
+		alert.Annotations[model.LabelName(k)] = model.LabelValue(v)
+	}
+	if alert.StartsAt.IsZero() {
+		alert.StartsAt = now
+	}
+	return alert
+}
@@ -0,0 +1,112 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	require.NoError(t, ValidateTemplate("slack", `{{ define "slack.title" }}{{ .Status | toUpper }}{{ end }}`))
+
+	for name, content := range map[string]string{
+		"":                  `{{ define "title" }}{{ end }}`,
+		"../slack":          `{{ define "title" }}{{ end }}`,
+		defaultTemplateName: `{{ define "title" }}{{ end }}`,
+		"unclosed":          `{{ define "title" }}`,
+		"unknown function":  `{{ define "title" }}{{ .Status | unknown }}{{ end }}`,
+	} {
+		require.ErrorIs(t, ValidateTemplate(name, content), ErrInvalidTemplate, name)
+	}
+}
+
+func TestPreviewTemplates(t *testing.T) {
+	am := setupAMTest(t)
+	am.Settings.AppURL = "http://localhost:3000/"
+
+	templateFiles := map[string]string{
+		"common": `{{ define "common.labels" }}{{ range .CommonLabels.SortedPairs }}{{ .Name }}={{ .Value }} {{ end }}{{ end }}`,
+		"slack":  `{{ define "slack.title" }}stored{{ end }}`,
+	}
+
+	t.Run("the templates of the template file are rendered for the alerts", func(t *testing.T) {
+		content := `{{ define "slack.title" }}[{{ .Status | toUpper }}] {{ template "common.labels" . }}{{ end }}
+{{ define "slack.text" }}{{ range .Alerts }}{{ .Annotations.summary }} {{ .SilenceURL }}{{ end }}{{ end }}`
+		previews, err := am.PreviewTemplates(templateFiles, "slack", content, []amv2.PostableAlert{{
+			Alert: amv2.Alert{
+				Labels:       amv2.LabelSet{"alertname": "HighCPU", "instance": "web-1"},
+				GeneratorURL: strfmt.URI("http://localhost:3000/alerting/list"),
+			},
+			Annotations: amv2.LabelSet{"summary": "CPU is high"},
+		}})
+		require.NoError(t, err)
+		require.Equal(t, []apimodels.TemplatePreview{
+			{Name: "slack.text", Text: "CPU is high http://localhost:3000/alerting/silence/new?alertmanager=grafana&matchers=alertname%3DHighCPU%2Cinstance%3Dweb-1"},
+			{Name: "slack.title", Text: "[FIRING] alertname=HighCPU instance=web-1 "},
+		}, previews)
+	})
+
+	t.Run("the templates are rendered for a sample alert when there are no alerts", func(t *testing.T) {
+		previews, err := am.PreviewTemplates(templateFiles, "", `{{ define "preview.title" }}{{ .CommonLabels.alertname }}{{ end }}`, nil)
+		require.NoError(t, err)
+		require.Equal(t, []apimodels.TemplatePreview{{Name: "preview.title", Text: "TestAlert"}}, previews)
+	})
+
+	t.Run("the templates that fail to render have an error", func(t *testing.T) {
+		previews, err := am.PreviewTemplates(templateFiles, "", `{{ define "missing" }}{{ template "unknown" . }}{{ end }}`, nil)
+		require.NoError(t, err)
+		require.Len(t, previews, 1)
+		require.Equal(t, "missing", previews[0].Name)
+		require.NotEmpty(t, previews[0].Error)
+	})
+
+	t.Run("invalid template files are rejected", func(t *testing.T) {
+		_, err := am.PreviewTemplates(templateFiles, "slack", `{{ define "slack.title" }}`, nil)
+		require.ErrorIs(t, err, ErrInvalidTemplate)
+	})
+}
+
+func TestReceiversUsingTemplate(t *testing.T) {
+	cfg, err := Load([]byte(`{
+		"template_files": {
+			"slack": "{{ define \"slack.title\" }}{{ .Status }}{{ end }}{{ define \"slack.text\" }}{{ end }}",
+			"email": "{{ define \"email.subject\" }}{{ end }}{{ define \"slack.text\" }}{{ end }}"
+		},
+		"alertmanager_config": {
+			"route": {"receiver": "slack"},
+			"receivers": [{
+				"name": "slack",
+				"grafana_managed_receiver_configs": [{
+					"uid": "", "name": "slack", "type": "slack", "disableResolveMessage": false,
+					"settings": {"title": "{{ template \"slack.title\" . }}", "text": "{{ template \"slack.text\" . }}"},
+					"secureSettings": {}
+				}]
+			}, {
+				"name": "email",
+				"grafana_managed_receiver_configs": [{
+					"uid": "", "name": "email", "type": "email", "disableResolveMessage": false,
+					"settings": {"addresses": "me@example.com", "subject": "{{- template \"email.subject\" . }}"},
+					"secureSettings": {}
+				}]
+			}]
+		}
+	}`))
+	require.NoError(t, err)
+
+	receivers, err := ReceiversUsingTemplate(cfg, "slack")
+	require.NoError(t, err)
+	require.Equal(t, []string{"slack"}, receivers)
+
+	receivers, err = ReceiversUsingTemplate(cfg, "email")
+	require.NoError(t, err)
+	require.Equal(t, []string{"email"}, receivers, "slack.text is still defined by the other template file")
+
+	cfg.TemplateFiles["unused"] = `{{ define "unused" }}{{ end }}`
+	receivers, err = ReceiversUsingTemplate(cfg, "unused")
+	require.NoError(t, err)
+	require.Empty(t, receivers)
+}
@@ -0,0 +1,266 @@
+// Package prom converts the alerting rules of Prometheus, Cortex and Loki rule files to
+// Grafana managed alert rules.
+package prom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/expr"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	// defaultInterval is the evaluation interval of the rule groups without one, like in Prometheus.
+	defaultInterval = model.Duration(time.Minute)
+	// queryTimeRange is how far back the queries of the converted alert rules look.
+	queryTimeRange = 10 * time.Minute
+
+	queryRefID     = "A"
+	reduceRefID    = "B"
+	conditionRefID = "C"
+)
+
+// ErrInvalidRuleFile is returned when a rule file cannot be converted.
+var ErrInvalidRuleFile = errors.New("invalid rule file")
+
+// Datasource is the data source the queries of the converted alert rules are sent to.
+type Datasource struct {
+	UID string
+	// Type is the type of the data source, "prometheus" or "loki".
+	Type string
+}
+
+// ParseRuleGroups parses the rule groups of a rule file, in the format of the rule files of
+// Prometheus, or a single rule group, as accepted by the Cortex and Loki ruler APIs.
+func ParseRuleGroups(b []byte) ([]apimodels.PostableRuleGroupConfig, error) {
+	var file struct {
+		Groups []apimodels.PostableRuleGroupConfig `yaml:"groups"`
+	}
+	if err := yaml.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRuleFile, err)
+	}
+	if file.Groups == nil {
+		var group apimodels.PostableRuleGroupConfig
+		if err := yaml.Unmarshal(b, &group); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRuleFile, err)
+		}
+		file.Groups = append(file.Groups, group)
+	}
+
+	names := make(map[string]struct{}, len(file.Groups))
+	for _, g := range file.Groups {
+		if g.Name == "" {
+			return nil, fmt.Errorf("%w: a rule group has no name", ErrInvalidRuleFile)
+		}
+		if _, ok := names[g.Name]; ok {
+			return nil, fmt.Errorf("%w: rule group %q is defined more than once", ErrInvalidRuleFile, g.Name)
+		}
+		names[g.Name] = struct{}{}
+
+		for i, r := range g.Rules {
+			if r.ApiRuleNode == nil || r.Expr == "" {
+				return nil, fmt.Errorf("%w: rule %d of group %q has no expression", ErrInvalidRuleFile, i, g.Name)
+			}
+			if (r.Alert == "") == (r.Record == "") {
+				return nil, fmt.Errorf("%w: rule %d of group %q must be either an alerting or a recording rule", ErrInvalidRuleFile, i, g.Name)
+			}
+		}
+	}
+	return file.Groups, nil
+}
+
+// ConvertRuleGroups converts the alerting rules of the rule groups to Grafana managed alert rules.
+// Recording rules are not supported by Grafana and are skipped. The titles of the alert rules
+// being unique, the alerts with the same name are numbered.
+func ConvertRuleGroups(groups []apimodels.PostableRuleGroupConfig, ds Datasource) ([]apimodels.PostableRuleGroupConfig, error) {
+	if ds.Type != "prometheus" && ds.Type != "loki" {
+		return nil, fmt.Errorf("unsupported data source type %q, it must be prometheus or loki", ds.Type)
+	}
+
+	titles := make(map[string]int)
+	result := make([]apimodels.PostableRuleGroupConfig, 0, len(groups))
+	for _, g := range groups {
+		converted := apimodels.PostableRuleGroupConfig{
+			Name:     g.Name,
+			Interval: g.Interval,
+		}
+		if converted.Interval == 0 {
+			converted.Interval = defaultInterval
+		}
+
+		for _, r := range g.Rules {
+			if r.ApiRuleNode == nil || r.Alert == "" {
+				continue
+			}
+			title := r.Alert
+			if titles[r.Alert]++; titles[r.Alert] > 1 {
+				title = fmt.Sprintf("%s (%d)", r.Alert, titles[r.Alert])
+			}
+
+			rule, err := convertRule(r.ApiRuleNode, title, ds)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert alert rule %q of group %q: %w", r.Alert, g.Name, err)
+			}
+			converted.Rules = append(converted.Rules, rule)
+		}
+		if len(converted.Rules) > 0 {
+			result = append(result, converted)
+		}
+	}
+	return result, nil
+}
+
+// KeepRuleUIDs sets the UIDs of the converted alert rules of the group to the ones of the existing
+// alert rules of the group with the same titles, so that importing a rule file again updates these
+// alert rules instead of conflicting with their titles.
+func KeepRuleUIDs(group apimodels.PostableRuleGroupConfig, existing []*ngmodels.AlertRule) {
+	uids := make(map[string]string, len(existing))
+	for _, r := range existing {
+		uids[r.Title] = r.UID
+	}
+	for _, r := range group.Rules {
+		if r.GrafanaManagedAlert == nil {
+			continue
+		}
+		if uid, ok := uids[r.GrafanaManagedAlert.Title]; ok {
+			r.GrafanaManagedAlert.UID = uid
+		}
+	}
+}
+
+// convertRule converts an alerting rule to a query of the data source, reduced to its last value
+// and compared with the threshold of the expression of the rule. When the threshold cannot be
+// extracted from the expression, the alert instances are firing when the query returns a value,
+// like in Prometheus. Returning no value is therefore the normal state of the rule, and the
+// alert instances are not changed when the query fails.
+func convertRule(r *apimodels.ApiRuleNode, title string, ds Datasource) (apimodels.PostableExtendedRuleNode, error) {
+	query, evaluator := splitThreshold(r.Expr)
+	reducer := "last"
+	if evaluator == nil {
+		reducer = "count"
+		evaluator = &expr.ThresholdEvaluator{Type: "gt", Params: []float64{0}}
+	}
+
+	model := map[string]interface{}{
+		"refId":         queryRefID,
+		"expr":          query,
+		"intervalMs":    1000,
+		"maxDataPoints": 43200,
+	}
+	if ds.Type == "prometheus" {
+		model["instant"] = true
+		model["range"] = false
+	}
+
+	data := make([]ngmodels.AlertQuery, 0, 3)
+	for _, q := range []struct {
+		refID         string
+		datasourceUID string
+		model         map[string]interface{}
+	}{
+		{queryRefID, ds.UID, model},
+		{reduceRefID, expr.DatasourceUID, map[string]interface{}{
+			"refId":      reduceRefID,
+			"type":       "reduce",
+			"expression": queryRefID,
+			"reducer":    reducer,
+		}},
+		{conditionRefID, expr.DatasourceUID, map[string]interface{}{
+			"refId":      conditionRefID,
+			"type":       "threshold",
+			"expression": reduceRefID,
+			"evaluator":  evaluator,
+		}},
+	} {
+		b, err := json.Marshal(q.model)
+		if err != nil {
+			return apimodels.PostableExtendedRuleNode{}, err
+		}
+		data = append(data, ngmodels.AlertQuery{
+			RefID:         q.refID,
+			DatasourceUID: q.datasourceUID,
+			Model:         b,
+			RelativeTimeRange: ngmodels.RelativeTimeRange{
+				From: ngmodels.Duration(queryTimeRange),
+			},
+		})
+	}
+
+	return apimodels.PostableExtendedRuleNode{
+		ApiRuleNode: &apimodels.ApiRuleNode{
+			For:         r.For,
+			Labels:      r.Labels,
+			Annotations: r.Annotations,
+		},
+		GrafanaManagedAlert: &apimodels.PostableGrafanaRule{
+			Title:        title,
+			Condition:    conditionRefID,
+			Data:         data,
+			NoDataState:  apimodels.OK,
+			ExecErrState: apimodels.KeepLastStateErrState,
+		},
+	}, nil
+}
+
+// splitThreshold splits an expression comparing a query with a number, such as `up < 1`, into
+// the query and the threshold evaluator of the comparison. The evaluator is nil if the expression
+// is not such a comparison, or cannot be parsed as PromQL like most LogQL expressions.
+func splitThreshold(e string) (string, *expr.ThresholdEvaluator) {
+	node, err := parser.ParseExpr(e)
+	if err != nil {
+		return e, nil
+	}
+	binary, ok := unwrapParens(node).(*parser.BinaryExpr)
+	if !ok || binary.ReturnBool {
+		return e, nil
+	}
+
+	op := binary.Op
+	query, threshold := binary.LHS, unwrapParens(binary.RHS)
+	if n, ok := unwrapParens(binary.LHS).(*parser.NumberLiteral); ok {
+		// the number is compared with the query, `1 > up` is `up < 1`
+		query, threshold = binary.RHS, n
+		switch op {
+		case parser.GTR:
+			op = parser.LSS
+		case parser.LSS:
+			op = parser.GTR
+		}
+	}
+	n, ok := threshold.(*parser.NumberLiteral)
+	if !ok || query.Type() != parser.ValueTypeVector {
+		return e, nil
+	}
+
+	var evaluator expr.ThresholdEvaluator
+	switch op {
+	case parser.GTR:
+		evaluator = expr.ThresholdEvaluator{Type: "gt", Params: []float64{n.Val}}
+	case parser.LSS:
+		evaluator = expr.ThresholdEvaluator{Type: "lt", Params: []float64{n.Val}}
+	default:
+		// the threshold expression has no equivalent of the other comparisons
+		return e, nil
+	}
+
+	pos := query.PositionRange()
+	return e[pos.Start:pos.End], &evaluator
+}
+
+func unwrapParens(node parser.Expr) parser.Expr {
+	for {
+		p, ok := node.(*parser.ParenExpr)
+		if !ok {
+			return node
+		}
+		node = p.Expr
+	}
+}
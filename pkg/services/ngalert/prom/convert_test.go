@@ -0,0 +1,185 @@
+package prom
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const ruleFile = `
+groups:
+  - name: node
+    interval: 30s
+    rules:
+      - record: instance:node_cpu:rate5m
+        expr: rate(node_cpu_seconds_total[5m])
+      - alert: HighCPU
+        expr: (instance:node_cpu:rate5m > 0.9)
+        for: 5m
+        labels:
+          severity: warning
+        annotations:
+          summary: "{{ $labels.instance }} is busy"
+      - alert: HighCPU
+        expr: 0.95 < instance:node_cpu:rate5m
+        labels:
+          severity: critical
+  - name: targets
+    rules:
+      - alert: TargetDown
+        expr: up == 0
+`
+
+func TestParseRuleGroups(t *testing.T) {
+	t.Run("a rule file", func(t *testing.T) {
+		groups, err := ParseRuleGroups([]byte(ruleFile))
+		require.NoError(t, err)
+		require.Len(t, groups, 2)
+		require.Equal(t, "node", groups[0].Name)
+		require.Equal(t, model.Duration(30*time.Second), groups[0].Interval)
+		require.Len(t, groups[0].Rules, 3)
+		require.Equal(t, "HighCPU", groups[0].Rules[1].Alert)
+		require.Equal(t, model.Duration(5*time.Minute), groups[0].Rules[1].For)
+		require.Equal(t, "warning", groups[0].Rules[1].Labels["severity"])
+	})
+
+	t.Run("a single rule group", func(t *testing.T) {
+		groups, err := ParseRuleGroups([]byte(`
+name: targets
+rules:
+  - alert: TargetDown
+    expr: up == 0
+`))
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		require.Equal(t, "targets", groups[0].Name)
+	})
+
+	t.Run("invalid rule files", func(t *testing.T) {
+		for _, f := range []string{
+			"groups: [",
+			"groups: [{rules: [{alert: A, expr: up}]}]",
+			"groups: [{name: a, rules: []}, {name: a, rules: []}]",
+			"groups: [{name: a, rules: [{alert: A}]}]",
+			"groups: [{name: a, rules: [{alert: A, record: a, expr: up}]}]",
+		} {
+			_, err := ParseRuleGroups([]byte(f))
+			require.ErrorIs(t, err, ErrInvalidRuleFile, f)
+		}
+	})
+}
+
+func TestConvertRuleGroups(t *testing.T) {
+	groups, err := ParseRuleGroups([]byte(ruleFile))
+	require.NoError(t, err)
+
+	converted, err := ConvertRuleGroups(groups, Datasource{UID: "prom", Type: "prometheus"})
+	require.NoError(t, err)
+	require.Len(t, converted, 2)
+
+	node := converted[0]
+	require.Equal(t, model.Duration(30*time.Second), node.Interval)
+	require.Len(t, node.Rules, 2, "the recording rules are skipped")
+	require.Equal(t, model.Duration(time.Minute), converted[1].Interval, "the default interval is the one of Prometheus")
+
+	type queries struct {
+		expr      string
+		reducer   string
+		evaluator expr.ThresholdEvaluator
+	}
+	queriesOf := func(r apimodels.PostableExtendedRuleNode) queries {
+		t.Helper()
+		data := r.GrafanaManagedAlert.Data
+		require.Len(t, data, 3)
+		require.Equal(t, "prom", data[0].DatasourceUID)
+		require.Equal(t, expr.DatasourceUID, data[1].DatasourceUID)
+		require.Equal(t, expr.DatasourceUID, data[2].DatasourceUID)
+		require.Equal(t, data[2].RefID, r.GrafanaManagedAlert.Condition)
+
+		var q struct {
+			Expr string `json:"expr"`
+		}
+		require.NoError(t, json.Unmarshal(data[0].Model, &q))
+		var reduce struct {
+			Reducer string `json:"reducer"`
+		}
+		require.NoError(t, json.Unmarshal(data[1].Model, &reduce))
+		var threshold struct {
+			Evaluator expr.ThresholdEvaluator `json:"evaluator"`
+		}
+		require.NoError(t, json.Unmarshal(data[2].Model, &threshold))
+		return queries{q.Expr, reduce.Reducer, threshold.Evaluator}
+	}
+
+	t.Run("the threshold of a comparison is extracted from the expression", func(t *testing.T) {
+		r := node.Rules[0]
+		require.Equal(t, "HighCPU", r.GrafanaManagedAlert.Title)
+		require.Equal(t, model.Duration(5*time.Minute), r.For)
+		require.Equal(t, map[string]string{"severity": "warning"}, r.Labels)
+		require.Equal(t, "{{ $labels.instance }} is busy", r.Annotations["summary"])
+		require.Equal(t, apimodels.OK, r.GrafanaManagedAlert.NoDataState)
+		require.Equal(t, queries{"instance:node_cpu:rate5m", "last", expr.ThresholdEvaluator{Type: "gt", Params: []float64{0.9}}}, queriesOf(r))
+	})
+
+	t.Run("the comparison is reversed when the number is first", func(t *testing.T) {
+		r := node.Rules[1]
+		require.Equal(t, "HighCPU (2)", r.GrafanaManagedAlert.Title, "the titles of the alert rules are unique")
+		require.Equal(t, queries{"instance:node_cpu:rate5m", "last", expr.ThresholdEvaluator{Type: "gt", Params: []float64{0.95}}}, queriesOf(r))
+	})
+
+	t.Run("the alert rule fires when the query returns a value if there is no threshold", func(t *testing.T) {
+		r := converted[1].Rules[0]
+		require.Equal(t, queries{"up == 0", "count", expr.ThresholdEvaluator{Type: "gt", Params: []float64{0}}}, queriesOf(r))
+	})
+
+	t.Run("only Prometheus and Loki data sources are supported", func(t *testing.T) {
+		_, err := ConvertRuleGroups(groups, Datasource{UID: "graphite", Type: "graphite"})
+		require.Error(t, err)
+	})
+}
+
+func TestSplitThreshold(t *testing.T) {
+	testCases := []struct {
+		expr      string
+		query     string
+		evaluator *expr.ThresholdEvaluator
+	}{
+		{`up < 1`, `up`, &expr.ThresholdEvaluator{Type: "lt", Params: []float64{1}}},
+		{`sum by (job) (rate(http_requests_total{code=~"5.."}[5m])) > 10`, `sum by (job) (rate(http_requests_total{code=~"5.."}[5m]))`, &expr.ThresholdEvaluator{Type: "gt", Params: []float64{10}}},
+		{`((up)) > (2)`, `((up))`, &expr.ThresholdEvaluator{Type: "gt", Params: []float64{2}}},
+		{`up >= 1`, `up >= 1`, nil},
+		{`up > bool 1`, `up > bool 1`, nil},
+		{`up > on(job) other`, `up > on(job) other`, nil},
+		{`absent(up)`, `absent(up)`, nil},
+		{`sum(rate({app="foo"} |= "error" [5m])) > 10`, `sum(rate({app="foo"} |= "error" [5m])) > 10`, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			query, evaluator := splitThreshold(tc.expr)
+			require.Equal(t, tc.query, query)
+			require.Equal(t, tc.evaluator, evaluator)
+		})
+	}
+}
+
+func TestKeepRuleUIDs(t *testing.T) {
+	groups, err := ParseRuleGroups([]byte(ruleFile))
+	require.NoError(t, err)
+	converted, err := ConvertRuleGroups(groups, Datasource{UID: "prom", Type: "prometheus"})
+	require.NoError(t, err)
+
+	node := converted[0]
+	KeepRuleUIDs(node, []*ngmodels.AlertRule{
+		{UID: "high-cpu", Title: "HighCPU"},
+		{UID: "removed", Title: "Removed"},
+	})
+	require.Equal(t, "high-cpu", node.Rules[0].GrafanaManagedAlert.UID, "the existing alert rule with the same title is updated")
+	require.Empty(t, node.Rules[1].GrafanaManagedAlert.UID, "a new alert rule is created")
+}
@@ -0,0 +1,103 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+// maxBacktestEvaluations is the maximum number of evaluations of an alert rule in a backtest,
+// a week at an interval of one minute.
+const maxBacktestEvaluations = 7 * 24 * 60
+
+// ErrInvalidBacktest is returned when the time range or the interval of a backtest are invalid.
+var ErrInvalidBacktest = errors.New("invalid backtest")
+
+// EvalFunc evaluates the condition of an alert rule at the given time.
+type EvalFunc func(condition *models.Condition, now time.Time) (eval.Results, error)
+
+// Backtest replays the evaluation of the alert rule at its interval over the time range, with the
+// same state transitions as the scheduler, including the pending period of the rule. The states are
+// neither saved nor sent to the Alertmanager: the result has the state transitions of the alert
+// instances, and the alerts that would have been sent to the Alertmanager at each evaluation, the
+// firing ones being sent again after the resend delay.
+func Backtest(logger log.Logger, alertRule *models.AlertRule, from, to time.Time, evaluate EvalFunc, resendDelay time.Duration, appURL string) (*apimodels.BacktestResult, error) {
+	interval := time.Duration(alertRule.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		return nil, fmt.Errorf("%w: the interval must be positive", ErrInvalidBacktest)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidBacktest)
+	}
+	if evaluations := int64(to.Sub(from)/interval) + 1; evaluations > maxBacktestEvaluations {
+		return nil, fmt.Errorf("%w: %d evaluations, the maximum is %d", ErrInvalidBacktest, evaluations, maxBacktestEvaluations)
+	}
+
+	// the metrics of the state cache are not registered, so that they are not mixed with the ones
+	// of the alert rules that are actually evaluated
+	history := &backtestHistory{}
+	stateManager := state.NewManager(logger, metrics.NewMetrics(nil), resendDelay, nil, nil, history)
+	defer stateManager.Close()
+
+	result := &apimodels.BacktestResult{
+		Transitions:   []apimodels.StateTransition{},
+		Notifications: []apimodels.BacktestNotification{},
+	}
+	for now := from; !now.After(to); now = now.Add(interval) {
+		dimensions := firingDimensions(stateManager.GetStatesForRuleUID(alertRule.OrgID, alertRule.UID))
+		for i := range alertRule.Data {
+			if err := alertRule.Data[i].SetLoadedDimensions(dimensions); err != nil {
+				return nil, err
+			}
+		}
+
+		condition := models.Condition{
+			Condition: alertRule.Condition,
+			OrgID:     alertRule.OrgID,
+			Data:      alertRule.Data,
+		}
+		results, err := evaluate(&condition, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate the alert rule at %s: %w", now, err)
+		}
+		result.Evaluations++
+
+		processedStates := stateManager.ProcessEvalResults(alertRule, results)
+		alerts := fromAlertStateToPostableAlerts(logger, processedStates, stateManager, appURL, now)
+		if len(alerts.PostableAlerts) > 0 {
+			result.Notifications = append(result.Notifications, apimodels.BacktestNotification{
+				Time:   now,
+				Alerts: alerts.PostableAlerts,
+			})
+		}
+	}
+
+	for _, t := range history.transitions {
+		result.Transitions = append(result.Transitions, apimodels.StateTransition{
+			RuleUID:          t.RuleUID,
+			Labels:           t.Labels,
+			PreviousState:    string(t.PreviousState),
+			State:            string(t.State),
+			EvaluationString: t.EvaluationString,
+			Error:            t.ErrorMessage,
+			EvaluatedAt:      t.EvaluatedAt,
+		})
+	}
+	return result, nil
+}
+
+// backtestHistory keeps the state transitions of a backtest in memory.
+type backtestHistory struct {
+	transitions []models.AlertStateTransition
+}
+
+func (h *backtestHistory) RecordStates(_ *models.AlertRule, transitions []state.StateTransition) {
+	h.transitions = append(h.transitions, state.AlertStateTransitions(transitions)...)
+}
@@ -0,0 +1,84 @@
+package schedule_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
+)
+
+func TestBacktest(t *testing.T) {
+	from := time.Date(2021, time.October, 1, 0, 0, 0, 0, time.UTC)
+	alertRule := &models.AlertRule{
+		OrgID:           1,
+		UID:             "backtest",
+		Title:           "backtest",
+		Condition:       "A",
+		IntervalSeconds: 60,
+		For:             2 * time.Minute,
+		NoDataState:     models.NoData,
+		ExecErrState:    models.AlertingErrState,
+	}
+
+	// the condition is firing from the 2nd to the 6th minute
+	evaluate := func(_ *models.Condition, now time.Time) (eval.Results, error) {
+		state := eval.Normal
+		if minute := now.Sub(from) / time.Minute; minute >= 2 && minute <= 6 {
+			state = eval.Alerting
+		}
+		return eval.Results{{Instance: data.Labels{"instance": "a"}, State: state, EvaluatedAt: now}}, nil
+	}
+
+	t.Run("the alert rule is evaluated at its interval, with the pending period", func(t *testing.T) {
+		result, err := schedule.Backtest(log.New("test"), alertRule, from, from.Add(8*time.Minute), evaluate, time.Minute, "http://localhost:3000")
+		require.NoError(t, err)
+		require.Equal(t, 9, result.Evaluations)
+
+		type transition struct {
+			at              time.Duration
+			previous, state string
+		}
+		var transitions []transition
+		for _, tr := range result.Transitions {
+			require.Equal(t, "a", tr.Labels["instance"])
+			transitions = append(transitions, transition{tr.EvaluatedAt.Sub(from), tr.PreviousState, tr.State})
+		}
+		require.Equal(t, []transition{
+			{2 * time.Minute, "Normal", "Pending"},
+			{5 * time.Minute, "Pending", "Alerting"},
+			{7 * time.Minute, "Alerting", "Normal"},
+		}, transitions)
+
+		// the firing alert is sent again after the resend delay
+		require.Len(t, result.Notifications, 2)
+		require.Equal(t, from.Add(5*time.Minute), result.Notifications[0].Time)
+		require.Equal(t, from.Add(6*time.Minute), result.Notifications[1].Time)
+		require.Len(t, result.Notifications[0].Alerts, 1)
+		require.Equal(t, "a", result.Notifications[0].Alerts[0].Labels["instance"])
+	})
+
+	t.Run("the time range must be valid", func(t *testing.T) {
+		_, err := schedule.Backtest(log.New("test"), alertRule, from, from.Add(-time.Minute), evaluate, time.Minute, "")
+		require.ErrorIs(t, err, schedule.ErrInvalidBacktest)
+	})
+
+	t.Run("the number of evaluations is limited", func(t *testing.T) {
+		_, err := schedule.Backtest(log.New("test"), alertRule, from, from.Add(30*24*time.Hour), evaluate, time.Minute, "")
+		require.ErrorIs(t, err, schedule.ErrInvalidBacktest)
+	})
+
+	t.Run("an evaluation error stops the backtest", func(t *testing.T) {
+		failing := func(*models.Condition, time.Time) (eval.Results, error) {
+			return nil, errors.New("failed")
+		}
+		_, err := schedule.Backtest(log.New("test"), alertRule, from, from.Add(time.Hour), failing, time.Minute, "")
+		require.Error(t, err)
+	})
+}
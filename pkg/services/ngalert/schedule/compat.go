@@ -10,16 +10,23 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/prometheus/alertmanager/api/v2/models"
+	prometheusModel "github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 )
 
 func FromAlertStateToPostableAlerts(logger log.Logger, firingStates []*state.State, stateManager *state.Manager, appURL string) apimodels.PostableAlerts {
+	return fromAlertStateToPostableAlerts(logger, firingStates, stateManager, appURL, time.Now())
+}
+
+// fromAlertStateToPostableAlerts converts the states that need to be sent to alerts,
+// and records that they were sent at ts.
+func fromAlertStateToPostableAlerts(logger log.Logger, firingStates []*state.State, stateManager *state.Manager, appURL string, ts time.Time) apimodels.PostableAlerts {
 	alerts := apimodels.PostableAlerts{PostableAlerts: make([]models.PostableAlert, 0, len(firingStates))}
 	var sentAlerts []*state.State
-	ts := time.Now()
 
 	u, err := url.Parse(appURL)
 	if err != nil {
@@ -36,6 +43,15 @@ func FromAlertStateToPostableAlerts(logger log.Logger, firingStates []*state.Sta
 				nA["__value_string__"] = alertState.Results[0].EvaluationString
 			}
 
+			if alertState.State == eval.Error {
+				// the errors are sent as a distinct alert so that they can be routed to a dedicated notification
+				nL[ngModels.RuleNameLabel] = nL[prometheusModel.AlertNameLabel]
+				nL[prometheusModel.AlertNameLabel] = ngModels.ErrorAlertName
+				if alertState.Error != nil {
+					nA[ngModels.ErrorAnnotation] = alertState.Error.Error()
+				}
+			}
+
 			genURL := appURL
 			if uid := nL[ngModels.RuleUIDLabel]; len(uid) > 0 && u != nil {
 				oldPath := u.Path
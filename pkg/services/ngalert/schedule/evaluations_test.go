@@ -0,0 +1,39 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+)
+
+func TestAcquireEvaluation(t *testing.T) {
+	sch := &schedule{evaluations: semaphore.NewWeighted(1)}
+	require.NoError(t, sch.acquireEvaluation(context.Background(), nil))
+
+	t.Run("the wait stops when the alert rule routine is stopped", func(t *testing.T) {
+		stopCh := make(chan struct{})
+		done := make(chan error)
+		go func() {
+			done <- sch.acquireEvaluation(context.Background(), stopCh)
+		}()
+
+		select {
+		case stopCh <- struct{}{}:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the routine could not be stopped")
+		}
+		require.ErrorIs(t, <-done, errRuleRoutineStopped)
+	})
+
+	t.Run("the wait stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, sch.acquireEvaluation(ctx, make(chan struct{})), context.Canceled)
+	})
+
+	sch.evaluations.Release(1)
+	require.True(t, sch.evaluations.TryAcquire(1), "the evaluations that were stopped do not hold a slot")
+}
@@ -2,14 +2,17 @@ package schedule
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/alerting"
@@ -35,9 +38,10 @@ type ScheduleService interface {
 	overrideCfg(cfg SchedulerCfg)
 }
 
-// Notifier handles the delivery of alert notifications to the end user
+// Notifier handles the delivery of alert notifications to the end user.
+// The alerts of each organization are delivered by the Alertmanager of the organization.
 type Notifier interface {
-	PutAlerts(alerts apimodels.PostableAlerts) error
+	PutAlerts(orgID int64, alerts apimodels.PostableAlerts) error
 }
 
 type schedule struct {
@@ -79,6 +83,14 @@ type schedule struct {
 
 	notifier Notifier
 	metrics  *metrics.Metrics
+
+	// sharding decides which alert rules are evaluated by this instance,
+	// all the alert rules are evaluated when it is nil.
+	sharding RuleSharding
+
+	// evaluations limits the number of alert rules evaluated at the same time,
+	// they are not limited when it is nil.
+	evaluations *semaphore.Weighted
 }
 
 // SchedulerCfg is the scheduler configuration.
@@ -94,6 +106,10 @@ type SchedulerCfg struct {
 	InstanceStore   store.InstanceStore
 	Notifier        Notifier
 	Metrics         *metrics.Metrics
+	Sharding        RuleSharding
+	// MaxConcurrentEvaluations is the maximum number of alert rules evaluated at the same time,
+	// 0 does not limit them.
+	MaxConcurrentEvaluations int
 }
 
 // NewScheduler returns a new schedule.
@@ -116,6 +132,10 @@ func NewScheduler(cfg SchedulerCfg, dataService *tsdb.Service, appURL string, st
 		metrics:         cfg.Metrics,
 		appURL:          appURL,
 		stateManager:    stateManager,
+		sharding:        cfg.Sharding,
+	}
+	if cfg.MaxConcurrentEvaluations > 0 {
+		sch.evaluations = semaphore.NewWeighted(int64(cfg.MaxConcurrentEvaluations))
 	}
 	return &sch
 }
@@ -166,6 +186,8 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 	sch.log.Debug("alert rule routine started", "key", key)
 
 	evalRunning := false
+	// stopped is set when the routine is stopped while the evaluation waits for the others
+	stopped := false
 	var attempt int64
 	var alertRule *models.AlertRule
 	for {
@@ -176,6 +198,15 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 			}
 
 			evaluate := func(attempt int64) error {
+				// wait for the evaluations of other alert rules to complete
+				// so that the data sources are not queried by all of them at once
+				if sch.evaluations != nil {
+					if err := sch.acquireEvaluation(grafanaCtx, stopCh); err != nil {
+						return err
+					}
+					defer sch.evaluations.Release(1)
+				}
+
 				start := timeNow()
 
 				// fetch latest alert rule version
@@ -186,10 +217,22 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 						sch.log.Error("failed to fetch alert rule", "key", key)
 						return err
 					}
+					if alertRule == nil && sch.sharding != nil {
+						// the evaluation of the alert rule may have been moved from another instance
+						sch.stateManager.WarmRule(q.Result)
+					}
 					alertRule = q.Result
 					sch.log.Debug("new alert rule version fetched", "title", alertRule.Title, "key", key, "version", alertRule.Version)
 				}
 
+				dimensions := firingDimensions(sch.stateManager.GetStatesForRuleUID(alertRule.OrgID, alertRule.UID))
+				for i := range alertRule.Data {
+					if err := alertRule.Data[i].SetLoadedDimensions(dimensions); err != nil {
+						sch.log.Error("failed to set loaded dimensions", "key", key, "refID", alertRule.Data[i].RefID, "error", err)
+						return err
+					}
+				}
+
 				condition := models.Condition{
 					Condition: alertRule.Condition,
 					OrgID:     alertRule.OrgID,
@@ -216,7 +259,7 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 				sch.saveAlertStates(processedStates)
 				alerts := FromAlertStateToPostableAlerts(sch.log, processedStates, sch.stateManager, sch.appURL)
 				sch.log.Debug("sending alerts to notifier", "count", len(alerts.PostableAlerts), "alerts", alerts.PostableAlerts)
-				err = sch.sendAlerts(alerts)
+				err = sch.sendAlerts(alertRule.OrgID, alerts)
 				if err != nil {
 					sch.log.Error("failed to put alerts in the notifier", "count", len(alerts.PostableAlerts), "err", err)
 				}
@@ -232,11 +275,17 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 
 				for attempt = 0; attempt < sch.maxAttempts; attempt++ {
 					err := evaluate(attempt)
-					if err == nil {
+					if err == nil || errors.Is(err, errRuleRoutineStopped) || grafanaCtx.Err() != nil {
+						stopped = errors.Is(err, errRuleRoutineStopped)
 						break
 					}
 				}
 			}()
+			if stopped {
+				sch.stopApplied(key)
+				sch.log.Debug("stopping alert rule routine", "key", key)
+				return nil
+			}
 		case <-stopCh:
 			sch.stopApplied(key)
 			sch.log.Debug("stopping alert rule routine", "key", key)
@@ -248,6 +297,33 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key models.AlertRul
 	}
 }
 
+// errRuleRoutineStopped is returned when the routine of an alert rule is stopped while it waits to evaluate it.
+var errRuleRoutineStopped = errors.New("alert rule routine stopped")
+
+// acquireEvaluation waits until the alert rule can be evaluated without exceeding the maximum number
+// of concurrent evaluations. It returns errRuleRoutineStopped when the routine of the alert rule is
+// stopped in the meantime, and the error of the context when it is done.
+func (sch *schedule) acquireEvaluation(ctx context.Context, stopCh <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- sch.evaluations.Acquire(ctx, 1)
+	}()
+
+	select {
+	case err := <-acquired:
+		return err
+	case <-stopCh:
+		cancel()
+		if err := <-acquired; err == nil {
+			sch.evaluations.Release(1)
+		}
+		return errRuleRoutineStopped
+	}
+}
+
 func (sch *schedule) Ticker(grafanaCtx context.Context) error {
 	dispatcherGroup, ctx := errgroup.WithContext(grafanaCtx)
 	for {
@@ -270,6 +346,11 @@ func (sch *schedule) Ticker(grafanaCtx context.Context) error {
 			readyToRun := make([]readyToRunItem, 0)
 			for _, item := range alertRules {
 				key := item.GetKey()
+				if sch.sharding != nil && !sch.sharding.Owns(key) {
+					// the alert rule is evaluated by another instance,
+					// its routine is stopped below if it was evaluated by this one
+					continue
+				}
 				itemVersion := item.Version
 				newRoutine := !sch.registry.exists(key)
 				ruleInfo := sch.registry.getOrCreateInfo(key, itemVersion)
@@ -310,7 +391,8 @@ func (sch *schedule) Ticker(grafanaCtx context.Context) error {
 				})
 			}
 
-			// unregister and stop routines of the deleted alert rules
+			// unregister and stop routines of the deleted alert rules,
+			// and of the alert rules now evaluated by another instance
 			for key := range registeredDefinitions {
 				ruleInfo, err := sch.registry.get(key)
 				if err != nil {
@@ -319,6 +401,9 @@ func (sch *schedule) Ticker(grafanaCtx context.Context) error {
 				}
 				ruleInfo.stopCh <- struct{}{}
 				sch.registry.del(key)
+				if sch.sharding != nil {
+					sch.stateManager.RemoveByRuleUID(key.OrgID, key.UID)
+				}
 			}
 		case <-grafanaCtx.Done():
 			waitErr := dispatcherGroup.Wait()
@@ -338,8 +423,19 @@ func (sch *schedule) Ticker(grafanaCtx context.Context) error {
 	}
 }
 
-func (sch *schedule) sendAlerts(alerts apimodels.PostableAlerts) error {
-	return sch.notifier.PutAlerts(alerts)
+// firingDimensions returns the labels of the states that are pending or alerting.
+func firingDimensions(states []*state.State) []data.Labels {
+	dimensions := make([]data.Labels, 0, len(states))
+	for _, s := range states {
+		if s.State == eval.Alerting || s.State == eval.Pending {
+			dimensions = append(dimensions, s.Labels)
+		}
+	}
+	return dimensions
+}
+
+func (sch *schedule) sendAlerts(orgID int64, alerts apimodels.PostableAlerts) error {
+	return sch.notifier.PutAlerts(orgID, alerts)
 }
 
 func (sch *schedule) saveAlertStates(states []*state.State) {
@@ -107,7 +107,7 @@ func TestWarmStateCache(t *testing.T) {
 		InstanceStore: dbstore,
 		Metrics:       metrics.NewMetrics(prometheus.NewRegistry()),
 	}
-	st := state.NewManager(schedCfg.Logger, nilMetrics, dbstore, dbstore)
+	st := state.NewManager(schedCfg.Logger, nilMetrics, time.Minute, dbstore, dbstore, nil)
 	st.Warm()
 
 	t.Run("instance cache has expected entries", func(t *testing.T) {
@@ -152,7 +152,7 @@ func TestAlertingTicker(t *testing.T) {
 		Logger:        log.New("ngalert schedule test"),
 		Metrics:       metrics.NewMetrics(prometheus.NewRegistry()),
 	}
-	st := state.NewManager(schedCfg.Logger, nilMetrics, dbstore, dbstore)
+	st := state.NewManager(schedCfg.Logger, nilMetrics, time.Minute, dbstore, dbstore, nil)
 	sched := schedule.NewScheduler(schedCfg, nil, "http://localhost", st)
 
 	ctx := context.Background()
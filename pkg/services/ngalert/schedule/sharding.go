@@ -0,0 +1,168 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+// missedHeartbeats is the number of heartbeats a scheduler member can miss
+// before it is not considered a member anymore.
+const missedHeartbeats = 3
+
+// RuleSharding decides which alert rules are evaluated by this instance of Grafana.
+type RuleSharding interface {
+	Owns(key models.AlertRuleKey) bool
+}
+
+// DBSharding shards the alert rules across the instances of Grafana that share the database.
+// Each instance records a heartbeat in the database, and the alert rules are assigned to the
+// live instances using rendezvous hashing, so that only the alert rules of an instance that
+// joins or leaves are moved when the membership changes.
+type DBSharding struct {
+	memberID          string
+	heartbeatInterval time.Duration
+
+	store   store.SchedulerStore
+	clock   clock.Clock
+	log     log.Logger
+	metrics *metrics.Metrics
+
+	mu      sync.RWMutex
+	members []string
+}
+
+// NewDBSharding returns a new DBSharding for the scheduler member.
+func NewDBSharding(memberID string, heartbeatInterval time.Duration, store store.SchedulerStore, c clock.Clock, logger log.Logger, m *metrics.Metrics) *DBSharding {
+	return &DBSharding{
+		memberID:          memberID,
+		heartbeatInterval: heartbeatInterval,
+		store:             store,
+		clock:             c,
+		log:               logger.New("member", memberID),
+		metrics:           m,
+		members:           []string{memberID},
+	}
+}
+
+// Run records the heartbeat of the member until the context is done,
+// and then removes the member so that its alert rules are moved to the other members.
+func (s *DBSharding) Run(ctx context.Context) error {
+	ticker := s.clock.Ticker(s.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.store.DeleteSchedulerMembers(&models.DeleteSchedulerMembersCommand{MemberID: s.memberID}); err != nil {
+				s.log.Error("failed to leave the scheduler members", "err", err)
+			}
+			return nil
+		case <-ticker.C:
+			s.Heartbeat()
+		}
+	}
+}
+
+// Heartbeat records that the member is alive and refreshes the list of members.
+func (s *DBSharding) Heartbeat() {
+	now := s.clock.Now()
+	if err := s.store.SchedulerMemberHeartbeat(&models.SchedulerMemberHeartbeatCommand{MemberID: s.memberID, Heartbeat: now}); err != nil {
+		s.log.Error("failed to record the heartbeat of the scheduler member", "err", err)
+	}
+
+	deadline := now.Add(-missedHeartbeats * s.heartbeatInterval)
+	if err := s.store.DeleteSchedulerMembers(&models.DeleteSchedulerMembersCommand{Before: deadline}); err != nil {
+		s.log.Error("failed to delete the dead scheduler members", "err", err)
+	}
+
+	query := &models.ListSchedulerMembersQuery{Since: deadline}
+	if err := s.store.ListSchedulerMembers(query); err != nil {
+		// the alert rules keep being sharded across the last known members
+		s.log.Error("failed to list the scheduler members", "err", err)
+		return
+	}
+	s.setMembers(query.Result)
+}
+
+func (s *DBSharding) setMembers(members []string) {
+	found := false
+	for _, m := range members {
+		if m == s.memberID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		members = append(members, s.memberID)
+	}
+	sort.Strings(members)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !equalMembers(s.members, members) {
+		s.log.Info("scheduler members changed, the alert rules are rebalanced", "members", members)
+	}
+	s.members = members
+	s.metrics.SchedulerMembers.Set(float64(len(members)))
+}
+
+// Owns returns true if the alert rule is evaluated by this member.
+func (s *DBSharding) Owns(key models.AlertRuleKey) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return ownerOf(s.members, key) == s.memberID
+}
+
+// ownerOf returns the member with the highest weight for the alert rule.
+func ownerOf(members []string, key models.AlertRuleKey) string {
+	var (
+		owner     string
+		maxWeight uint64
+	)
+	for _, m := range members {
+		weight := memberWeight(m, key)
+		if owner == "" || weight > maxWeight || (weight == maxWeight && m < owner) {
+			owner = m
+			maxWeight = weight
+		}
+	}
+	return owner
+}
+
+func memberWeight(member string, key models.AlertRuleKey) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(fmt.Sprintf("%d\x00%s\x00%s", key.OrgID, key.UID, member)))
+	// FNV does not spread similar inputs well enough, the weight is finalized
+	// like in MurmurHash3 so that the alert rules are evenly spread across the members.
+	w := h.Sum64()
+	w ^= w >> 33
+	w *= 0xff51afd7ed558ccd
+	w ^= w >> 33
+	w *= 0xc4ceb9fe1a85ec53
+	w ^= w >> 33
+	return w
+}
+
+func equalMembers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
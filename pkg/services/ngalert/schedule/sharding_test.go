@@ -0,0 +1,122 @@
+package schedule_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
+)
+
+type fakeSchedulerStore struct {
+	mu      sync.Mutex
+	members map[string]time.Time
+}
+
+func (f *fakeSchedulerStore) SchedulerMemberHeartbeat(cmd *models.SchedulerMemberHeartbeatCommand) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.members[cmd.MemberID] = cmd.Heartbeat
+	return nil
+}
+
+func (f *fakeSchedulerStore) ListSchedulerMembers(query *models.ListSchedulerMembersQuery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	query.Result = nil
+	for m, heartbeat := range f.members {
+		if !heartbeat.Before(query.Since) {
+			query.Result = append(query.Result, m)
+		}
+	}
+	return nil
+}
+
+func (f *fakeSchedulerStore) DeleteSchedulerMembers(cmd *models.DeleteSchedulerMembersCommand) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for m, heartbeat := range f.members {
+		if m == cmd.MemberID || (cmd.MemberID == "" && heartbeat.Before(cmd.Before)) {
+			delete(f.members, m)
+		}
+	}
+	return nil
+}
+
+func TestDBSharding(t *testing.T) {
+	const heartbeatInterval = 10 * time.Second
+	store := &fakeSchedulerStore{members: make(map[string]time.Time)}
+	mockedClock := clock.NewMock()
+
+	newMember := func(id string) *schedule.DBSharding {
+		return schedule.NewDBSharding(id, heartbeatInterval, store, mockedClock, log.New("test"), nilMetrics)
+	}
+	a, b, c := newMember("a"), newMember("b"), newMember("c")
+
+	keys := make([]models.AlertRuleKey, 0, 300)
+	for i := 0; i < 300; i++ {
+		keys = append(keys, models.AlertRuleKey{OrgID: int64(i%3 + 1), UID: fmt.Sprintf("rule-%d", i)})
+	}
+
+	owners := func(members ...*schedule.DBSharding) map[models.AlertRuleKey]int {
+		result := make(map[models.AlertRuleKey]int, len(keys))
+		for _, key := range keys {
+			owned := 0
+			for i, m := range members {
+				if m.Owns(key) {
+					result[key] = i
+					owned++
+				}
+			}
+			require.Equal(t, 1, owned, "alert rule %v should be owned by exactly one member", key)
+		}
+		return result
+	}
+
+	t.Run("a member owns all the alert rules until it knows the other members", func(t *testing.T) {
+		for _, key := range keys {
+			require.True(t, a.Owns(key))
+		}
+	})
+
+	var before map[models.AlertRuleKey]int
+	t.Run("the alert rules are spread across the members", func(t *testing.T) {
+		for _, m := range []*schedule.DBSharding{a, b, c} {
+			m.Heartbeat()
+		}
+		for _, m := range []*schedule.DBSharding{a, b, c} {
+			m.Heartbeat()
+		}
+
+		before = owners(a, b, c)
+		counts := make([]int, 3)
+		for _, i := range before {
+			counts[i]++
+		}
+		for i, count := range counts {
+			require.Greater(t, count, 50, "member %d owns too few alert rules", i)
+		}
+	})
+
+	t.Run("only the alert rules of a dead member are moved", func(t *testing.T) {
+		mockedClock.Add(2 * heartbeatInterval)
+		a.Heartbeat()
+		b.Heartbeat()
+		mockedClock.Add(2 * heartbeatInterval)
+		a.Heartbeat()
+		b.Heartbeat()
+
+		after := owners(a, b)
+		for key, owner := range before {
+			if owner != 2 {
+				require.Equal(t, owner, after[key], "alert rule %v should not have moved", key)
+			}
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+const (
+	dashboardUIDAnnotation = "__dashboardUid__"
+	panelIDAnnotation      = "__panelId__"
+
+	// historyCleanupInterval is how often the transitions older than the retention are deleted.
+	historyCleanupInterval = time.Hour
+	// historyQueueSize is the number of evaluations whose transitions can wait to be recorded.
+	historyQueueSize = 1000
+)
+
+// StateTransition is the change of state of an alert instance after an evaluation.
+type StateTransition struct {
+	State         *State
+	PreviousState eval.State
+}
+
+// StateHistorian records the state transitions of the alert instances after an evaluation.
+type StateHistorian interface {
+	RecordStates(alertRule *ngModels.AlertRule, transitions []StateTransition)
+}
+
+// Historian records the state transitions of the alert instances and deletes them
+// once they are older than the retention. The transitions are recorded in the background
+// by Run, so that the evaluations never wait for them to be saved.
+type Historian struct {
+	log         log.Logger
+	store       store.HistoryStore
+	retention   time.Duration
+	annotations bool
+	queue       chan historyEntry
+}
+
+// historyEntry is the state transitions of the instances of an alert rule after an evaluation.
+type historyEntry struct {
+	ruleUID     string
+	orgID       int64
+	title       string
+	annotations map[string]string
+	transitions []ngModels.AlertStateTransition
+}
+
+// NewHistorian creates a Historian. A retention of 0 keeps the transitions forever, and
+// annotations enables the creation of an annotation for each transition.
+func NewHistorian(logger log.Logger, historyStore store.HistoryStore, retention time.Duration, annotations bool) *Historian {
+	return &Historian{
+		log:         logger,
+		store:       historyStore,
+		retention:   retention,
+		annotations: annotations,
+		queue:       make(chan historyEntry, historyQueueSize),
+	}
+}
+
+// RecordStates queues the state transitions of the instances of the alert rule to be saved by Run.
+// It never blocks: the transitions are dropped when too many are already waiting to be saved.
+func (h *Historian) RecordStates(alertRule *ngModels.AlertRule, transitions []StateTransition) {
+	entry := historyEntry{
+		ruleUID:     alertRule.UID,
+		orgID:       alertRule.OrgID,
+		title:       alertRule.Title,
+		annotations: alertRule.Annotations,
+		transitions: AlertStateTransitions(transitions),
+	}
+	select {
+	case h.queue <- entry:
+	default:
+		h.log.Warn("too many state transitions waiting to be saved, dropping them", "uid", alertRule.UID, "orgId", alertRule.OrgID, "count", len(transitions))
+	}
+}
+
+// AlertStateTransitions returns the state transitions as they are saved. They do not refer to the
+// states, which keep changing with the next evaluations.
+func AlertStateTransitions(transitions []StateTransition) []ngModels.AlertStateTransition {
+	entries := make([]ngModels.AlertStateTransition, 0, len(transitions))
+	for _, t := range transitions {
+		entry := ngModels.AlertStateTransition{
+			RuleOrgID:     t.State.OrgID,
+			RuleUID:       t.State.AlertRuleUID,
+			Labels:        ngModels.InstanceLabels(t.State.Labels.Copy()),
+			PreviousState: ngModels.InstanceStateType(t.PreviousState.String()),
+			State:         ngModels.InstanceStateType(t.State.State.String()),
+			EvaluatedAt:   t.State.LastEvaluationTime,
+		}
+		if len(t.State.Results) > 0 {
+			entry.EvaluationString = t.State.Results[len(t.State.Results)-1].EvaluationString
+		}
+		if t.State.Error != nil {
+			entry.ErrorMessage = t.State.Error.Error()
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// record saves the state transitions, and creates their annotations.
+func (h *Historian) record(entry historyEntry) {
+	defer func() {
+		if r := recover(); r != nil {
+			h.log.Error("failed to record state transitions", "uid", entry.ruleUID, "orgId", entry.orgID, "err", r)
+		}
+	}()
+
+	cmd := &ngModels.SaveAlertStateTransitionsCommand{Transitions: entry.transitions}
+	if err := h.store.SaveAlertStateTransitions(cmd); err != nil {
+		h.log.Error("failed to save state transitions", "uid", entry.ruleUID, "orgId", entry.orgID, "count", len(entry.transitions), "err", err)
+	}
+
+	if h.annotations {
+		h.createAnnotations(entry)
+	}
+}
+
+// createAnnotations creates an annotation for each state transition. The annotations are
+// added to the panel of the alert rule, if the rule has one, or to the organization otherwise.
+func (h *Historian) createAnnotations(entry historyEntry) {
+	var dashboardID, panelID int64
+	if dashboardUID := entry.annotations[dashboardUIDAnnotation]; dashboardUID != "" {
+		query := &models.GetDashboardQuery{Uid: dashboardUID, OrgId: entry.orgID}
+		if err := bus.Dispatch(query); err != nil {
+			h.log.Error("failed to get the dashboard of the alert rule, the annotations are added to the organization", "uid", entry.ruleUID, "dashboardUid", dashboardUID, "err", err)
+		} else {
+			dashboardID = query.Result.Id
+			panelID, _ = strconv.ParseInt(entry.annotations[panelIDAnnotation], 10, 64)
+		}
+	}
+
+	repo := annotations.GetRepository()
+	for _, t := range entry.transitions {
+		labels := data.Labels(t.Labels)
+		annotationData := simplejson.New()
+		if t.EvaluationString != "" {
+			annotationData.Set("evaluationString", t.EvaluationString)
+		}
+		if t.ErrorMessage != "" {
+			annotationData.Set("error", t.ErrorMessage)
+		}
+		item := &annotations.Item{
+			OrgId:       entry.orgID,
+			DashboardId: dashboardID,
+			PanelId:     panelID,
+			Text:        fmt.Sprintf("%s %s: %s", entry.title, labels.String(), t.State),
+			PrevState:   string(t.PreviousState),
+			NewState:    string(t.State),
+			Epoch:       t.EvaluatedAt.UnixNano() / int64(time.Millisecond),
+			Data:        annotationData,
+		}
+		if err := repo.Save(item); err != nil {
+			h.log.Error("failed to save annotation for state transition", "uid", entry.ruleUID, "orgId", entry.orgID, "labels", labels.String(), "err", err)
+		}
+	}
+}
+
+// Run saves the queued state transitions, and deletes the transitions older than the retention
+// periodically, until the context is done. The transitions still queued are saved before it returns.
+func (h *Historian) Run(ctx context.Context) error {
+	var cleanup <-chan time.Time
+	if h.retention > 0 {
+		h.cleanup()
+		ticker := time.NewTicker(historyCleanupInterval)
+		defer ticker.Stop()
+		cleanup = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case entry := <-h.queue:
+					h.record(entry)
+				default:
+					return nil
+				}
+			}
+		case entry := <-h.queue:
+			h.record(entry)
+		case <-cleanup:
+			h.cleanup()
+		}
+	}
+}
+
+func (h *Historian) cleanup() {
+	cmd := &ngModels.DeleteAlertStateHistoryCommand{Before: time.Now().Add(-h.retention)}
+	if err := h.store.DeleteAlertStateHistory(cmd); err != nil {
+		h.log.Error("failed to delete old state transitions", "err", err)
+		return
+	}
+	h.log.Debug("deleted old state transitions", "count", cmd.Result)
+}
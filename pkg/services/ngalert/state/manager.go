@@ -21,17 +21,22 @@ type Manager struct {
 
 	ruleStore     store.RuleStore
 	instanceStore store.InstanceStore
+	historian     StateHistorian
 }
 
-func NewManager(logger log.Logger, metrics *metrics.Metrics, ruleStore store.RuleStore, instanceStore store.InstanceStore) *Manager {
+// NewManager creates a state manager. The alert instances that are still firing are sent again
+// to the notifier after the resend delay. The state transitions are recorded by the historian,
+// unless it is nil.
+func NewManager(logger log.Logger, metrics *metrics.Metrics, resendDelay time.Duration, ruleStore store.RuleStore, instanceStore store.InstanceStore, historian StateHistorian) *Manager {
 	manager := &Manager{
 		cache:         newCache(logger, metrics),
 		quit:          make(chan struct{}),
-		ResendDelay:   1 * time.Minute, // TODO: make this configurable
+		ResendDelay:   resendDelay,
 		log:           logger,
 		metrics:       metrics,
 		ruleStore:     ruleStore,
 		instanceStore: instanceStore,
+		historian:     historian,
 	}
 	go manager.recordMetrics()
 	return manager
@@ -80,24 +85,7 @@ func (st *Manager) Warm() {
 				continue
 			}
 
-			lbs := map[string]string(entry.Labels)
-			cacheId, err := entry.Labels.StringKey()
-			if err != nil {
-				st.log.Error("error getting cacheId for entry", "msg", err.Error())
-			}
-			stateForEntry := &State{
-				AlertRuleUID:       entry.RuleUID,
-				OrgID:              entry.RuleOrgID,
-				CacheId:            cacheId,
-				Labels:             lbs,
-				State:              translateInstanceState(entry.CurrentState),
-				Results:            []Evaluation{},
-				StartsAt:           entry.CurrentStateSince,
-				EndsAt:             entry.CurrentStateEnd,
-				LastEvaluationTime: entry.LastEvalTime,
-				Annotations:        ruleForEntry.Annotations,
-			}
-			states = append(states, stateForEntry)
+			states = append(states, st.stateFromInstance(entry, ruleForEntry))
 		}
 	}
 
@@ -106,6 +94,47 @@ func (st *Manager) Warm() {
 	}
 }
 
+// WarmRule loads the states of an alert rule from the database, unless the cache
+// already holds states for the alert rule.
+// It is used when the evaluation of an alert rule moves to this instance of Grafana.
+func (st *Manager) WarmRule(alertRule *ngModels.AlertRule) {
+	if len(st.GetStatesForRuleUID(alertRule.OrgID, alertRule.UID)) > 0 {
+		return
+	}
+
+	cmd := ngModels.ListAlertInstancesQuery{
+		RuleOrgID: alertRule.OrgID,
+		RuleUID:   alertRule.UID,
+	}
+	if err := st.instanceStore.ListAlertInstances(&cmd); err != nil {
+		st.log.Error("unable to fetch previous state", "uid", alertRule.UID, "msg", err.Error())
+		return
+	}
+
+	for _, entry := range cmd.Result {
+		st.set(st.stateFromInstance(entry, alertRule))
+	}
+}
+
+func (st *Manager) stateFromInstance(entry *ngModels.ListAlertInstancesQueryResult, alertRule *ngModels.AlertRule) *State {
+	cacheId, err := entry.Labels.StringKey()
+	if err != nil {
+		st.log.Error("error getting cacheId for entry", "msg", err.Error())
+	}
+	return &State{
+		AlertRuleUID:       entry.RuleUID,
+		OrgID:              entry.RuleOrgID,
+		CacheId:            cacheId,
+		Labels:             map[string]string(entry.Labels),
+		State:              translateInstanceState(entry.CurrentState),
+		Results:            []Evaluation{},
+		StartsAt:           entry.CurrentStateSince,
+		EndsAt:             entry.CurrentStateEnd,
+		LastEvaluationTime: entry.LastEvalTime,
+		Annotations:        alertRule.Annotations,
+	}
+}
+
 func (st *Manager) getOrCreate(alertRule *ngModels.AlertRule, result eval.Result) *State {
 	return st.cache.getOrCreate(alertRule, result)
 }
@@ -131,18 +160,41 @@ func (st *Manager) RemoveByRuleUID(orgID int64, ruleUID string) {
 func (st *Manager) ProcessEvalResults(alertRule *ngModels.AlertRule, results eval.Results) []*State {
 	st.log.Debug("state manager processing evaluation results", "uid", alertRule.UID, "resultCount", len(results))
 	var states []*State
+	var transitions []StateTransition
 	for _, result := range results {
-		s := st.setNextState(alertRule, result)
+		if keepsLastState(alertRule, result) {
+			// The result is not about any instance in particular, so all the instances of the rule keep their state.
+			if existing := st.GetStatesForRuleUID(alertRule.OrgID, alertRule.UID); len(existing) > 0 {
+				for _, s := range existing {
+					st.updateState(alertRule, s, result)
+				}
+				states = append(states, existing...)
+				continue
+			}
+		}
+		s, previous := st.setNextState(alertRule, result)
 		states = append(states, s)
+		if s.State != previous {
+			transitions = append(transitions, StateTransition{State: s, PreviousState: previous})
+		}
+	}
+	if st.historian != nil && len(transitions) > 0 {
+		st.historian.RecordStates(alertRule, transitions)
 	}
 	st.log.Debug("returning changed states to scheduler", "count", len(states))
 	return states
 }
 
-//Set the current state based on evaluation results
-func (st *Manager) setNextState(alertRule *ngModels.AlertRule, result eval.Result) *State {
+//Set the current state based on evaluation results, and return it with the previous state
+func (st *Manager) setNextState(alertRule *ngModels.AlertRule, result eval.Result) (*State, eval.State) {
 	currentState := st.getOrCreate(alertRule, result)
+	previousState := currentState.State
+	st.updateState(alertRule, currentState, result)
+	return currentState, previousState
+}
 
+// updateState applies the evaluation result to the state and saves it in the cache.
+func (st *Manager) updateState(alertRule *ngModels.AlertRule, currentState *State, result eval.Result) {
 	currentState.LastEvaluationTime = result.EvaluatedAt
 	currentState.EvaluationDuration = result.EvaluationDuration
 	currentState.Results = append(currentState.Results, Evaluation{
@@ -166,7 +218,16 @@ func (st *Manager) setNextState(alertRule *ngModels.AlertRule, result eval.Resul
 	}
 
 	st.set(currentState)
-	return currentState
+}
+
+// keepsLastState returns true if the result is an error or no data without any labels, and the
+// alert rule is configured to keep the last state of its instances in this case.
+func keepsLastState(alertRule *ngModels.AlertRule, result eval.Result) bool {
+	if len(result.Instance) > 0 {
+		return false
+	}
+	return (result.State == eval.Error && alertRule.ExecErrState == ngModels.KeepLastStateErrState) ||
+		(result.State == eval.NoData && alertRule.NoDataState == ngModels.KeepLastState)
 }
 
 func (st *Manager) GetAll(orgID int64) []*State {
@@ -202,11 +263,15 @@ func (st *Manager) Put(states []*State) {
 }
 
 func translateInstanceState(state ngModels.InstanceStateType) eval.State {
-	switch {
-	case state == ngModels.InstanceStateFiring:
+	switch state {
+	case ngModels.InstanceStateFiring:
 		return eval.Alerting
-	case state == ngModels.InstanceStateNormal:
+	case ngModels.InstanceStateNormal:
 		return eval.Normal
+	case ngModels.InstanceStatePending:
+		return eval.Pending
+	case ngModels.InstanceStateNoData:
+		return eval.NoData
 	default:
 		return eval.Error
 	}
@@ -1,6 +1,8 @@
 package state_test
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -774,6 +776,198 @@ func TestProcessEvalResults(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "pending -> error when result is Error and ExecErrState is Error",
+			alertRule: &models.AlertRule{
+				OrgID:           1,
+				Title:           "test_title",
+				UID:             "test_alert_rule_uid_2",
+				NamespaceUID:    "test_namespace_uid",
+				Annotations:     map[string]string{"annotation": "test"},
+				Labels:          map[string]string{"label": "test"},
+				IntervalSeconds: 10,
+				For:             1 * time.Minute,
+				ExecErrState:    models.ErrorErrState,
+			},
+			evalResults: []eval.Results{
+				{
+					eval.Result{
+						Instance:           data.Labels{"instance_label": "test"},
+						State:              eval.Alerting,
+						EvaluatedAt:        evaluationTime,
+						EvaluationDuration: evaluationDuration,
+					},
+				},
+				{
+					eval.Result{
+						Instance:           data.Labels{"instance_label": "test"},
+						State:              eval.Error,
+						Error:              errors.New("test error"),
+						EvaluatedAt:        evaluationTime.Add(10 * time.Second),
+						EvaluationDuration: evaluationDuration,
+					},
+				},
+			},
+			expectedStates: map[string]*state.State{
+				`[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`: {
+					AlertRuleUID: "test_alert_rule_uid_2",
+					OrgID:        1,
+					CacheId:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					Labels: data.Labels{
+						"__alert_rule_namespace_uid__": "test_namespace_uid",
+						"__alert_rule_uid__":           "test_alert_rule_uid_2",
+						"alertname":                    "test_title",
+						"label":                        "test",
+						"instance_label":               "test",
+					},
+					State: eval.Error,
+					Error: errors.New("test error"),
+					Results: []state.Evaluation{
+						{
+							EvaluationTime:  evaluationTime,
+							EvaluationState: eval.Alerting,
+						},
+						{
+							EvaluationTime:  evaluationTime.Add(10 * time.Second),
+							EvaluationState: eval.Error,
+						},
+					},
+					StartsAt:           evaluationTime.Add(10 * time.Second),
+					EndsAt:             evaluationTime.Add(10 * time.Second).Add(1 * time.Minute),
+					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
+					EvaluationDuration: evaluationDuration,
+					Annotations:        map[string]string{"annotation": "test"},
+				},
+			},
+		},
+		{
+			desc: "pending -> normal when result is Error and ExecErrState is OK",
+			alertRule: &models.AlertRule{
+				OrgID:           1,
+				Title:           "test_title",
+				UID:             "test_alert_rule_uid_2",
+				NamespaceUID:    "test_namespace_uid",
+				Annotations:     map[string]string{"annotation": "test"},
+				Labels:          map[string]string{"label": "test"},
+				IntervalSeconds: 10,
+				For:             1 * time.Minute,
+				ExecErrState:    models.OkErrState,
+			},
+			evalResults: []eval.Results{
+				{
+					eval.Result{
+						Instance:           data.Labels{"instance_label": "test"},
+						State:              eval.Alerting,
+						EvaluatedAt:        evaluationTime,
+						EvaluationDuration: evaluationDuration,
+					},
+				},
+				{
+					eval.Result{
+						Instance:           data.Labels{"instance_label": "test"},
+						State:              eval.Error,
+						Error:              errors.New("test error"),
+						EvaluatedAt:        evaluationTime.Add(10 * time.Second),
+						EvaluationDuration: evaluationDuration,
+					},
+				},
+			},
+			expectedStates: map[string]*state.State{
+				`[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`: {
+					AlertRuleUID: "test_alert_rule_uid_2",
+					OrgID:        1,
+					CacheId:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					Labels: data.Labels{
+						"__alert_rule_namespace_uid__": "test_namespace_uid",
+						"__alert_rule_uid__":           "test_alert_rule_uid_2",
+						"alertname":                    "test_title",
+						"label":                        "test",
+						"instance_label":               "test",
+					},
+					State: eval.Normal,
+					Error: errors.New("test error"),
+					Results: []state.Evaluation{
+						{
+							EvaluationTime:  evaluationTime,
+							EvaluationState: eval.Alerting,
+						},
+						{
+							EvaluationTime:  evaluationTime.Add(10 * time.Second),
+							EvaluationState: eval.Error,
+						},
+					},
+					StartsAt:           evaluationTime.Add(10 * time.Second),
+					EndsAt:             evaluationTime.Add(10 * time.Second),
+					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
+					EvaluationDuration: evaluationDuration,
+					Annotations:        map[string]string{"annotation": "test"},
+				},
+			},
+		},
+		{
+			desc: "pending -> pending when result is Error and ExecErrState is KeepLastState",
+			alertRule: &models.AlertRule{
+				OrgID:           1,
+				Title:           "test_title",
+				UID:             "test_alert_rule_uid_2",
+				NamespaceUID:    "test_namespace_uid",
+				Annotations:     map[string]string{"annotation": "test"},
+				Labels:          map[string]string{"label": "test"},
+				IntervalSeconds: 10,
+				For:             1 * time.Minute,
+				ExecErrState:    models.KeepLastStateErrState,
+			},
+			evalResults: []eval.Results{
+				{
+					eval.Result{
+						Instance:           data.Labels{"instance_label": "test"},
+						State:              eval.Alerting,
+						EvaluatedAt:        evaluationTime,
+						EvaluationDuration: evaluationDuration,
+					},
+				},
+				{
+					eval.Result{
+						Instance:           data.Labels{"instance_label": "test"},
+						State:              eval.Error,
+						Error:              errors.New("test error"),
+						EvaluatedAt:        evaluationTime.Add(10 * time.Second),
+						EvaluationDuration: evaluationDuration,
+					},
+				},
+			},
+			expectedStates: map[string]*state.State{
+				`[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`: {
+					AlertRuleUID: "test_alert_rule_uid_2",
+					OrgID:        1,
+					CacheId:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					Labels: data.Labels{
+						"__alert_rule_namespace_uid__": "test_namespace_uid",
+						"__alert_rule_uid__":           "test_alert_rule_uid_2",
+						"alertname":                    "test_title",
+						"label":                        "test",
+						"instance_label":               "test",
+					},
+					State: eval.Pending,
+					Error: errors.New("test error"),
+					Results: []state.Evaluation{
+						{
+							EvaluationTime:  evaluationTime,
+							EvaluationState: eval.Alerting,
+						},
+						{
+							EvaluationTime:  evaluationTime.Add(10 * time.Second),
+							EvaluationState: eval.Error,
+						},
+					},
+					StartsAt:           evaluationTime,
+					EndsAt:             evaluationTime.Add(1 * time.Minute),
+					LastEvaluationTime: evaluationTime.Add(10 * time.Second),
+					EvaluationDuration: evaluationDuration,
+					Annotations:        map[string]string{"annotation": "test"},
+				},
+			},
+		},
 		{
 			desc: "template is correctly expanded",
 			alertRule: &models.AlertRule{
@@ -826,7 +1020,7 @@ func TestProcessEvalResults(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		st := state.NewManager(log.New("test_state_manager"), nilMetrics, nil, nil)
+		st := state.NewManager(log.New("test_state_manager"), nilMetrics, time.Minute, nil, nil, nil)
 		t.Run(tc.desc, func(t *testing.T) {
 			for _, res := range tc.evalResults {
 				_ = st.ProcessEvalResults(tc.alertRule, res)
@@ -839,3 +1033,113 @@ func TestProcessEvalResults(t *testing.T) {
 		})
 	}
 }
+
+type fakeHistoryStore struct {
+	transitions []models.AlertStateTransition
+	// release blocks the saves until it is closed, unless it is nil.
+	release chan struct{}
+}
+
+func (f *fakeHistoryStore) SaveAlertStateTransitions(cmd *models.SaveAlertStateTransitionsCommand) error {
+	if f.release != nil {
+		<-f.release
+	}
+	f.transitions = append(f.transitions, cmd.Transitions...)
+	return nil
+}
+
+func (f *fakeHistoryStore) ListAlertStateHistory(_ *models.ListAlertStateHistoryQuery) error {
+	return nil
+}
+
+func (f *fakeHistoryStore) DeleteAlertStateHistory(_ *models.DeleteAlertStateHistoryCommand) error {
+	return nil
+}
+
+func TestProcessEvalResultsRecordsTransitions(t *testing.T) {
+	evaluationTime := time.Unix(1000, 0)
+	alertRule := &models.AlertRule{
+		OrgID:           1,
+		Title:           "test_title",
+		UID:             "test_alert_rule_uid",
+		NamespaceUID:    "test_namespace_uid",
+		IntervalSeconds: 10,
+		NoDataState:     models.NoData,
+	}
+	result := func(s eval.State, offset time.Duration) eval.Results {
+		return eval.Results{{
+			Instance:         data.Labels{"instance": "a"},
+			State:            s,
+			EvaluatedAt:      evaluationTime.Add(offset),
+			EvaluationString: s.String(),
+		}}
+	}
+
+	// the saves are blocked until the evaluations are done, which must not wait for them.
+	historyStore := &fakeHistoryStore{release: make(chan struct{})}
+	historian := state.NewHistorian(log.New("test_historian"), historyStore, 0, false)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = historian.Run(ctx)
+	}()
+
+	st := state.NewManager(log.New("test_state_manager"), nilMetrics, time.Minute, nil, nil, historian)
+	_ = st.ProcessEvalResults(alertRule, result(eval.Normal, 0))
+	_ = st.ProcessEvalResults(alertRule, result(eval.Alerting, 10*time.Second))
+	_ = st.ProcessEvalResults(alertRule, result(eval.Alerting, 20*time.Second))
+	_ = st.ProcessEvalResults(alertRule, result(eval.NoData, 30*time.Second))
+
+	// the queued transitions are saved before Run returns.
+	close(historyStore.release)
+	cancel()
+	<-done
+
+	require.Len(t, historyStore.transitions, 2)
+	require.Equal(t, models.InstanceStateNormal, historyStore.transitions[0].PreviousState)
+	require.Equal(t, models.InstanceStateFiring, historyStore.transitions[0].State)
+	require.Equal(t, evaluationTime.Add(10*time.Second), historyStore.transitions[0].EvaluatedAt)
+	require.Equal(t, "Alerting", historyStore.transitions[0].EvaluationString)
+	require.Equal(t, "a", historyStore.transitions[0].Labels["instance"])
+	require.Equal(t, models.InstanceStateFiring, historyStore.transitions[1].PreviousState)
+	require.Equal(t, models.InstanceStateNoData, historyStore.transitions[1].State)
+}
+
+func TestProcessEvalResultsKeepLastState(t *testing.T) {
+	evaluationTime := time.Unix(1000, 0)
+	alertRule := &models.AlertRule{
+		OrgID:           1,
+		Title:           "test_title",
+		UID:             "test_alert_rule_uid",
+		NamespaceUID:    "test_namespace_uid",
+		IntervalSeconds: 10,
+		NoDataState:     models.KeepLastState,
+		ExecErrState:    models.KeepLastStateErrState,
+	}
+
+	st := state.NewManager(log.New("test_state_manager"), nilMetrics, time.Minute, nil, nil, nil)
+	_ = st.ProcessEvalResults(alertRule, eval.Results{
+		{Instance: data.Labels{"instance": "a"}, State: eval.Alerting, EvaluatedAt: evaluationTime},
+		{Instance: data.Labels{"instance": "b"}, State: eval.Normal, EvaluatedAt: evaluationTime},
+	})
+
+	for i, result := range []eval.Result{
+		{State: eval.Error, Error: errors.New("test error"), EvaluatedAt: evaluationTime.Add(10 * time.Second)},
+		{State: eval.NoData, EvaluatedAt: evaluationTime.Add(20 * time.Second)},
+	} {
+		states := st.ProcessEvalResults(alertRule, eval.Results{result})
+		require.Len(t, states, 2)
+		require.Len(t, st.GetStatesForRuleUID(alertRule.OrgID, alertRule.UID), 2, "no instance should be created for the result without labels")
+
+		expected := map[string]eval.State{"a": eval.Alerting, "b": eval.Normal}
+		for _, s := range states {
+			require.Equal(t, expected[s.Labels["instance"]], s.State)
+			require.Equal(t, result.EvaluatedAt, s.LastEvaluationTime)
+			require.Len(t, s.Results, i+2)
+			if s.State == eval.Alerting {
+				require.Equal(t, result.EvaluatedAt.Add(20*time.Second), s.EndsAt, "the firing instance should not be resolved")
+			}
+		}
+	}
+}
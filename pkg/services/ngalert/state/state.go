@@ -63,17 +63,37 @@ func (a *State) resultAlerting(alertRule *ngModels.AlertRule, result eval.Result
 
 func (a *State) resultError(alertRule *ngModels.AlertRule, result eval.Result) {
 	a.Error = result.Error
-	if a.StartsAt.IsZero() {
-		a.StartsAt = result.EvaluatedAt
-	}
-	a.setEndsAt(alertRule, result)
 
-	if alertRule.ExecErrState == ngModels.AlertingErrState {
+	switch alertRule.ExecErrState {
+	case ngModels.AlertingErrState:
+		if a.StartsAt.IsZero() {
+			a.StartsAt = result.EvaluatedAt
+		}
+		a.setEndsAt(alertRule, result)
 		a.State = eval.Alerting
+	case ngModels.ErrorErrState:
+		if a.State != eval.Error {
+			a.StartsAt = result.EvaluatedAt
+		}
+		a.setEndsAt(alertRule, result)
+		a.State = eval.Error
+	case ngModels.OkErrState:
+		if a.State != eval.Normal {
+			a.EndsAt = result.EvaluatedAt
+			a.StartsAt = result.EvaluatedAt
+		}
+		a.State = eval.Normal
+	case ngModels.KeepLastStateErrState:
+		a.keepLastState(alertRule, result)
 	}
 }
 
 func (a *State) resultNoData(alertRule *ngModels.AlertRule, result eval.Result) {
+	if alertRule.NoDataState == ngModels.KeepLastState {
+		a.keepLastState(alertRule, result)
+		return
+	}
+
 	if a.StartsAt.IsZero() {
 		a.StartsAt = result.EvaluatedAt
 	}
@@ -89,8 +109,16 @@ func (a *State) resultNoData(alertRule *ngModels.AlertRule, result eval.Result)
 	}
 }
 
+// keepLastState leaves the state unchanged, but extends the alert if it is firing so that
+// it is not resolved until the rule can be evaluated again.
+func (a *State) keepLastState(alertRule *ngModels.AlertRule, result eval.Result) {
+	if a.State == eval.Alerting || a.State == eval.Error {
+		a.setEndsAt(alertRule, result)
+	}
+}
+
 func (a *State) NeedsSending(resendDelay time.Duration) bool {
-	if a.State != eval.Alerting {
+	if a.State != eval.Alerting && a.State != eval.Error {
 		return false
 	}
 
@@ -49,6 +49,16 @@ func TestNeedsSending(t *testing.T) {
 				LastSentAt:         evaluationTime.Add(-1 * time.Minute),
 			},
 		},
+		{
+			name:        "state: error and LastSentAt before LastEvaluationTime + ResendDelay",
+			resendDelay: 1 * time.Minute,
+			expected:    true,
+			testState: &State{
+				State:              eval.Error,
+				LastEvaluationTime: evaluationTime,
+				LastSentAt:         evaluationTime.Add(-2 * time.Minute),
+			},
+		},
 		{
 			name:        "state: pending",
 			resendDelay: 1 * time.Minute,
@@ -28,11 +28,16 @@ type UpdateRuleGroupCmd struct {
 	OrgID           int64
 	NamespaceUID    string
 	RuleGroupConfig apimodels.PostableRuleGroupConfig
+	// Provenance is recorded for all the rules of the group.
+	Provenance ngmodels.Provenance
 }
 
 type UpsertRule struct {
 	Existing *ngmodels.AlertRule
 	New      ngmodels.AlertRule
+	// Provenance is recorded for the rule when it is set. A provisioned rule that does not exist
+	// yet is created with the UID it is given.
+	Provenance ngmodels.Provenance
 }
 
 // Store is the interface for persisting alert rules and instances
@@ -84,7 +89,8 @@ func (st DBstore) DeleteAlertRuleByUID(orgID int64, ruleUID string) error {
 		if err != nil {
 			return err
 		}
-		return nil
+
+		return setProvenance(sess, orgID, ngmodels.ProvenanceRecordAlertRule, ruleUID, ngmodels.ProvenanceNone)
 	})
 }
 
@@ -115,6 +121,12 @@ func (st DBstore) DeleteNamespaceAlertRules(orgID int64, namespaceUID string) ([
 			return err
 		}
 
+		for _, uid := range ruleUIDs {
+			if err := setProvenance(sess, orgID, ngmodels.ProvenanceRecordAlertRule, uid, ngmodels.ProvenanceNone); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 	return ruleUIDs, err
@@ -152,6 +164,12 @@ func (st DBstore) DeleteRuleGroupAlertRules(orgID int64, namespaceUID string, ru
 			return err
 		}
 
+		for _, uid := range ruleUIDs {
+			if err := setProvenance(sess, orgID, ngmodels.ProvenanceRecordAlertRule, uid, ngmodels.ProvenanceNone); err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 
@@ -191,23 +209,26 @@ func (st DBstore) UpsertAlertRules(rules []UpsertRule) error {
 			if r.Existing == nil && r.New.UID != "" {
 				// check by UID
 				existingAlertRule, err := getAlertRuleByUID(sess, r.New.UID, r.New.OrgID)
-				if err != nil {
-					if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
-						return fmt.Errorf("failed to get alert rule %s: %w", r.New.UID, err)
-					}
+				switch {
+				case err == nil:
+					r.Existing = existingAlertRule
+				case !errors.Is(err, ngmodels.ErrAlertRuleNotFound):
 					return err
+				case r.Provenance == ngmodels.ProvenanceNone:
+					return fmt.Errorf("failed to get alert rule %s: %w", r.New.UID, err)
 				}
-				r.Existing = existingAlertRule
 			}
 
 			var parentVersion int64
 			switch r.Existing {
 			case nil: // new rule
-				uid, err := GenerateNewAlertRuleUID(sess, r.New.OrgID, r.New.Title)
-				if err != nil {
-					return fmt.Errorf("failed to generate UID for alert rule %q: %w", r.New.Title, err)
+				if r.New.UID == "" {
+					uid, err := GenerateNewAlertRuleUID(sess, r.New.OrgID, r.New.Title)
+					if err != nil {
+						return fmt.Errorf("failed to generate UID for alert rule %q: %w", r.New.Title, err)
+					}
+					r.New.UID = uid
 				}
-				r.New.UID = uid
 
 				if r.New.IntervalSeconds == 0 {
 					r.New.IntervalSeconds = st.DefaultIntervalSeconds
@@ -296,7 +317,14 @@ func (st DBstore) UpsertAlertRules(rules []UpsertRule) error {
 				For:              r.New.For,
 				Annotations:      r.New.Annotations,
 				Labels:           r.New.Labels,
+				IsPaused:         r.New.IsPaused,
 			})
+
+			if r.Provenance != ngmodels.ProvenanceNone {
+				if err := setProvenance(sess, r.New.OrgID, ngmodels.ProvenanceRecordAlertRule, r.New.UID, r.Provenance); err != nil {
+					return err
+				}
+			}
 		}
 
 		if len(newRules) > 0 {
@@ -396,8 +424,9 @@ func (st DBstore) GetNamespaceByUID(UID string, orgID int64, user *models.Signed
 func (st DBstore) GetAlertRulesForScheduling(query *ngmodels.ListAlertRulesQuery) error {
 	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
 		alerts := make([]*ngmodels.AlertRule, 0)
-		q := "SELECT uid, org_id, interval_seconds, version FROM alert_rule"
-		if err := sess.SQL(q).Find(&alerts); err != nil {
+		// the paused alert rules are not scheduled, so the routines of the ones that were are stopped
+		q := "SELECT uid, org_id, interval_seconds, version FROM alert_rule WHERE is_paused = ?"
+		if err := sess.SQL(q, false).Find(&alerts); err != nil {
 			return err
 		}
 
@@ -454,6 +483,14 @@ func (st DBstore) validateAlertRule(alertRule ngmodels.AlertRule) error {
 		return fmt.Errorf("%w: no organisation is found", ngmodels.ErrAlertRuleFailedValidation)
 	}
 
+	if !alertRule.NoDataState.IsValid() {
+		return fmt.Errorf("%w: invalid no data state %q", ngmodels.ErrAlertRuleFailedValidation, alertRule.NoDataState)
+	}
+
+	if !alertRule.ExecErrState.IsValid() {
+		return fmt.Errorf("%w: invalid execution error state %q", ngmodels.ErrAlertRuleFailedValidation, alertRule.ExecErrState)
+	}
+
 	return nil
 }
 
@@ -495,6 +532,15 @@ func (st DBstore) UpdateRuleGroup(cmd UpdateRuleGroupCmd) error {
 				ExecErrState:    ngmodels.ExecutionErrorState(r.GrafanaManagedAlert.ExecErrState),
 			}
 
+			existingGroupRule, exists := existingGroupRulesUIDs[r.GrafanaManagedAlert.UID]
+			switch {
+			case r.GrafanaManagedAlert.IsPaused != nil:
+				new.IsPaused = *r.GrafanaManagedAlert.IsPaused
+			case exists:
+				// the rule remains paused when the payload does not say otherwise
+				new.IsPaused = existingGroupRule.IsPaused
+			}
+
 			if r.ApiRuleNode != nil {
 				new.For = time.Duration(r.ApiRuleNode.For)
 				new.Annotations = r.ApiRuleNode.Annotations
@@ -502,10 +548,11 @@ func (st DBstore) UpdateRuleGroup(cmd UpdateRuleGroupCmd) error {
 			}
 
 			upsertRule := UpsertRule{
-				New: new,
+				New:        new,
+				Provenance: cmd.Provenance,
 			}
 
-			if existingGroupRule, ok := existingGroupRulesUIDs[r.GrafanaManagedAlert.UID]; ok {
+			if exists {
 				upsertRule.Existing = &existingGroupRule
 				// remove the rule from existingGroupRulesUIDs
 				delete(existingGroupRulesUIDs, r.GrafanaManagedAlert.UID)
@@ -0,0 +1,88 @@
+// +build integration
+
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/registry"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+)
+
+func TestPausedAlertRules(t *testing.T) {
+	dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	t.Cleanup(registry.ClearOverrides)
+
+	rule := tests.CreateTestAlertRule(t, dbstore, 60)
+	other := tests.CreateTestAlertRule(t, dbstore, 60)
+
+	scheduled := func() []string {
+		q := models.ListAlertRulesQuery{}
+		require.NoError(t, dbstore.GetAlertRulesForScheduling(&q))
+		uids := make([]string, 0, len(q.Result))
+		for _, r := range q.Result {
+			uids = append(uids, r.UID)
+		}
+		return uids
+	}
+	get := func() *models.AlertRule {
+		q := models.GetAlertRuleByUIDQuery{OrgID: rule.OrgID, UID: rule.UID}
+		require.NoError(t, dbstore.GetAlertRuleByUID(&q))
+		return q.Result
+	}
+	updateGroup := func(paused *bool) {
+		current := get()
+		require.NoError(t, dbstore.UpdateRuleGroup(store.UpdateRuleGroupCmd{
+			OrgID:        current.OrgID,
+			NamespaceUID: current.NamespaceUID,
+			RuleGroupConfig: apimodels.PostableRuleGroupConfig{
+				Name:     current.RuleGroup,
+				Interval: model.Duration(time.Duration(current.IntervalSeconds) * time.Second),
+				Rules: []apimodels.PostableExtendedRuleNode{{
+					GrafanaManagedAlert: &apimodels.PostableGrafanaRule{
+						UID:       current.UID,
+						Title:     current.Title,
+						Condition: current.Condition,
+						Data:      current.Data,
+						IsPaused:  paused,
+					},
+				}},
+			},
+		}))
+	}
+
+	require.ElementsMatch(t, []string{rule.UID, other.UID}, scheduled())
+
+	t.Run("paused rules are versioned and not scheduled", func(t *testing.T) {
+		paused := true
+		updateGroup(&paused)
+
+		current := get()
+		require.True(t, current.IsPaused)
+		require.Equal(t, rule.Version+1, current.Version)
+		require.Equal(t, []string{other.UID}, scheduled())
+	})
+
+	t.Run("rules remain paused when an update does not say otherwise", func(t *testing.T) {
+		updateGroup(nil)
+		require.True(t, get().IsPaused)
+		require.Equal(t, []string{other.UID}, scheduled())
+	})
+
+	t.Run("resumed rules are scheduled again with the same UID", func(t *testing.T) {
+		current := get()
+		resumed := *current
+		resumed.IsPaused = false
+		require.NoError(t, dbstore.UpsertAlertRules([]store.UpsertRule{{Existing: current, New: resumed}}))
+
+		require.False(t, get().IsPaused)
+		require.ElementsMatch(t, []string{rule.UID, other.UID}, scheduled())
+	})
+}
@@ -11,15 +11,18 @@ import (
 var (
 	// ErrNoAlertmanagerConfiguration is an error for when no alertmanager configuration is found.
 	ErrNoAlertmanagerConfiguration = fmt.Errorf("could not find an Alertmanager configuration")
+	// ErrAlertmanagerConfigurationChanged is an error for when the latest alertmanager configuration
+	// is not the one the saved configuration is based on.
+	ErrAlertmanagerConfigurationChanged = fmt.Errorf("the Alertmanager configuration has changed")
 )
 
-// GetLatestAlertmanagerConfiguration returns the lastest version of the alertmanager configuration.
+// GetLatestAlertmanagerConfiguration returns the lastest version of the alertmanager configuration of the organization.
 // It returns ErrNoAlertmanagerConfiguration if no configuration is found.
 func (st *DBstore) GetLatestAlertmanagerConfiguration(query *models.GetLatestAlertmanagerConfigurationQuery) error {
 	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
 		c := &models.AlertConfiguration{}
 		// The ID is already an auto incremental column, using the ID as an order should guarantee the latest.
-		ok, err := sess.Desc("id").Limit(1).Get(c)
+		ok, err := sess.Where("org_id = ?", query.OrgID).Desc("id").Limit(1).Get(c)
 		if err != nil {
 			return err
 		}
@@ -42,9 +45,22 @@ type SaveCallback func() error
 
 // SaveAlertmanagerConfigurationWithCallback creates an alertmanager configuration version and then executes a callback.
 // If the callback results in error in rollsback the transaction.
+// It returns ErrAlertmanagerConfigurationChanged if the latest configuration is not the one of cmd.LatestID.
 func (st DBstore) SaveAlertmanagerConfigurationWithCallback(cmd *models.SaveAlertmanagerConfigurationCmd, callback SaveCallback) error {
 	return st.SQLStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		if cmd.LatestID != 0 {
+			latest := &models.AlertConfiguration{}
+			ok, err := sess.Where("org_id = ?", cmd.OrgID).Desc("id").Limit(1).Cols("id").Get(latest)
+			if err != nil {
+				return err
+			}
+			if !ok || latest.ID != cmd.LatestID {
+				return ErrAlertmanagerConfigurationChanged
+			}
+		}
+
 		config := models.AlertConfiguration{
+			OrgID:                     cmd.OrgID,
 			AlertmanagerConfiguration: cmd.AlertmanagerConfiguration,
 			ConfigurationVersion:      cmd.ConfigurationVersion,
 			Default:                   cmd.Default,
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// HistoryStore is the storage of the state transitions of the alert instances.
+type HistoryStore interface {
+	SaveAlertStateTransitions(cmd *models.SaveAlertStateTransitionsCommand) error
+	ListAlertStateHistory(query *models.ListAlertStateHistoryQuery) error
+	DeleteAlertStateHistory(cmd *models.DeleteAlertStateHistoryCommand) error
+}
+
+// SaveAlertStateTransitions is a handler for saving the state transitions of alert instances.
+func (st DBstore) SaveAlertStateTransitions(cmd *models.SaveAlertStateTransitionsCommand) error {
+	if len(cmd.Transitions) == 0 {
+		return nil
+	}
+	return st.SQLStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		for _, t := range cmd.Transitions {
+			labels, labelsHash, err := t.Labels.StringAndHash()
+			if err != nil {
+				return err
+			}
+			if _, err := sess.Exec(`INSERT INTO alert_state_history
+				(rule_org_id, rule_uid, labels, labels_hash, previous_state, state, evaluation_string, error_message, evaluated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				t.RuleOrgID, t.RuleUID, labels, labelsHash, t.PreviousState, t.State, t.EvaluationString, t.ErrorMessage, t.EvaluatedAt.Unix()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// historyPageSize is the number of transitions read at once when they are filtered by labels.
+const historyPageSize = 500
+
+// ListAlertStateHistory is a handler for retrieving the state transitions of the alert instances
+// of an organisation based on various filters.
+func (st DBstore) ListAlertStateHistory(query *models.ListAlertStateHistoryQuery) error {
+	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		s := strings.Builder{}
+		params := make([]interface{}, 0)
+
+		addToQuery := func(stmt string, p ...interface{}) {
+			s.WriteString(stmt)
+			params = append(params, p...)
+		}
+
+		addToQuery("SELECT * FROM alert_state_history WHERE rule_org_id = ?", query.RuleOrgID)
+
+		if query.RuleUID != "" {
+			addToQuery(" AND rule_uid = ?", query.RuleUID)
+		}
+
+		if !query.From.IsZero() {
+			addToQuery(" AND evaluated_at >= ?", query.From.Unix())
+		}
+
+		if !query.To.IsZero() {
+			addToQuery(" AND evaluated_at <= ?", query.To.Unix())
+		}
+
+		if len(query.Labels) == 0 {
+			addToQuery(" ORDER BY evaluated_at DESC, id DESC")
+			if query.Limit > 0 {
+				addToQuery(" " + st.SQLStore.Dialect.Limit(int64(query.Limit)))
+			}
+
+			transitions := make([]*models.AlertStateTransition, 0)
+			if err := sess.SQL(s.String(), params...).Find(&transitions); err != nil {
+				return err
+			}
+			query.Result = transitions
+			return nil
+		}
+
+		// The labels are stored as JSON, so the transitions are filtered by labels after they are fetched.
+		// They are read in pages, each one following the last transition of the previous one, until
+		// enough transitions have the labels.
+		filtered := make([]*models.AlertStateTransition, 0)
+		var last *models.AlertStateTransition
+		for {
+			pageQuery := s.String()
+			pageParams := append(make([]interface{}, 0, len(params)+3), params...)
+			if last != nil {
+				pageQuery += " AND (evaluated_at < ? OR (evaluated_at = ? AND id < ?))"
+				pageParams = append(pageParams, last.EvaluatedAt.Unix(), last.EvaluatedAt.Unix(), last.ID)
+			}
+			pageQuery += " ORDER BY evaluated_at DESC, id DESC " + st.SQLStore.Dialect.Limit(historyPageSize)
+
+			page := make([]*models.AlertStateTransition, 0, historyPageSize)
+			if err := sess.SQL(pageQuery, pageParams...).Find(&page); err != nil {
+				return err
+			}
+			for _, t := range page {
+				if hasLabels(t.Labels, query.Labels) {
+					filtered = append(filtered, t)
+					if query.Limit > 0 && len(filtered) == query.Limit {
+						query.Result = filtered
+						return nil
+					}
+				}
+			}
+			if len(page) < historyPageSize {
+				query.Result = filtered
+				return nil
+			}
+			last = page[len(page)-1]
+		}
+	})
+}
+
+// DeleteAlertStateHistory is a handler for deleting the state transitions evaluated before a time.
+func (st DBstore) DeleteAlertStateHistory(cmd *models.DeleteAlertStateHistoryCommand) error {
+	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("DELETE FROM alert_state_history WHERE evaluated_at < ?", cmd.Before.Unix())
+		if err != nil {
+			return err
+		}
+		cmd.Result, err = res.RowsAffected()
+		return err
+	})
+}
+
+// hasLabels returns true if labels contains all the labels of subset.
+func hasLabels(labels, subset models.InstanceLabels) bool {
+	for k, v := range subset {
+		if value, ok := labels[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+// +build integration
+
+package store_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertStateHistoryOperations(t *testing.T) {
+	dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	t.Cleanup(registry.ClearOverrides)
+
+	alertRule1 := tests.CreateTestAlertRule(t, dbstore, 60)
+	alertRule2 := tests.CreateTestAlertRule(t, dbstore, 60)
+	orgID := alertRule1.OrgID
+
+	now := time.Unix(1000, 0)
+	saveCmd := &models.SaveAlertStateTransitionsCommand{
+		Transitions: []models.AlertStateTransition{
+			{
+				RuleOrgID:        orgID,
+				RuleUID:          alertRule1.UID,
+				Labels:           models.InstanceLabels{"host": "a", "dc": "x"},
+				PreviousState:    models.InstanceStateNormal,
+				State:            models.InstanceStateFiring,
+				EvaluationString: "[ var='A' labels={host=a} value=10 ]",
+				EvaluatedAt:      now,
+			},
+			{
+				RuleOrgID:     orgID,
+				RuleUID:       alertRule1.UID,
+				Labels:        models.InstanceLabels{"host": "b", "dc": "x"},
+				PreviousState: models.InstanceStateNormal,
+				State:         models.InstanceStateFiring,
+				EvaluatedAt:   now.Add(time.Minute),
+			},
+			{
+				RuleOrgID:     orgID,
+				RuleUID:       alertRule1.UID,
+				Labels:        models.InstanceLabels{"host": "a", "dc": "x"},
+				PreviousState: models.InstanceStateFiring,
+				State:         models.InstanceStateNormal,
+				EvaluatedAt:   now.Add(2 * time.Minute),
+			},
+			{
+				RuleOrgID:     orgID,
+				RuleUID:       alertRule2.UID,
+				Labels:        models.InstanceLabels{"host": "a"},
+				PreviousState: models.InstanceStateNormal,
+				State:         models.InstanceStateNoData,
+				ErrorMessage:  "no data",
+				EvaluatedAt:   now.Add(3 * time.Minute),
+			},
+		},
+	}
+	require.NoError(t, dbstore.SaveAlertStateTransitions(saveCmd))
+
+	t.Run("can list the transitions of the organization from the most recent", func(t *testing.T) {
+		query := &models.ListAlertStateHistoryQuery{RuleOrgID: orgID}
+		require.NoError(t, dbstore.ListAlertStateHistory(query))
+		require.Len(t, query.Result, 4)
+		require.Equal(t, alertRule2.UID, query.Result[0].RuleUID)
+		require.Equal(t, models.InstanceStateNoData, query.Result[0].State)
+		require.Equal(t, "no data", query.Result[0].ErrorMessage)
+		require.Equal(t, now.Unix(), query.Result[3].EvaluatedAt.Unix())
+		require.Equal(t, models.InstanceLabels{"host": "a", "dc": "x"}, query.Result[3].Labels)
+		require.Equal(t, "[ var='A' labels={host=a} value=10 ]", query.Result[3].EvaluationString)
+	})
+
+	t.Run("can filter the transitions by rule, labels and time range", func(t *testing.T) {
+		query := &models.ListAlertStateHistoryQuery{
+			RuleOrgID: orgID,
+			RuleUID:   alertRule1.UID,
+			Labels:    models.InstanceLabels{"host": "a"},
+		}
+		require.NoError(t, dbstore.ListAlertStateHistory(query))
+		require.Len(t, query.Result, 2)
+		require.Equal(t, models.InstanceStateNormal, query.Result[0].State)
+		require.Equal(t, models.InstanceStateFiring, query.Result[1].State)
+
+		query = &models.ListAlertStateHistoryQuery{
+			RuleOrgID: orgID,
+			From:      now.Add(time.Minute),
+			To:        now.Add(2 * time.Minute),
+		}
+		require.NoError(t, dbstore.ListAlertStateHistory(query))
+		require.Len(t, query.Result, 2)
+
+		query = &models.ListAlertStateHistoryQuery{
+			RuleOrgID: orgID,
+			Labels:    models.InstanceLabels{"dc": "x"},
+			Limit:     1,
+		}
+		require.NoError(t, dbstore.ListAlertStateHistory(query))
+		require.Len(t, query.Result, 1)
+		require.Equal(t, now.Add(2*time.Minute).Unix(), query.Result[0].EvaluatedAt.Unix())
+	})
+
+	t.Run("reads the transitions by pages when they are filtered by labels", func(t *testing.T) {
+		// more transitions than several pages, with the same evaluation times across the pages
+		alertRule := tests.CreateTestAlertRule(t, dbstore, 60)
+		cmd := &models.SaveAlertStateTransitionsCommand{}
+		for i := 0; i < 1200; i++ {
+			host := "other"
+			if i%3 == 0 {
+				host = "c"
+			}
+			cmd.Transitions = append(cmd.Transitions, models.AlertStateTransition{
+				RuleOrgID:     orgID,
+				RuleUID:       alertRule.UID,
+				Labels:        models.InstanceLabels{"host": host, "index": strconv.Itoa(i)},
+				PreviousState: models.InstanceStateNormal,
+				State:         models.InstanceStateFiring,
+				EvaluatedAt:   now.Add(time.Duration(i/7) * time.Second),
+			})
+		}
+		require.NoError(t, dbstore.SaveAlertStateTransitions(cmd))
+
+		query := &models.ListAlertStateHistoryQuery{
+			RuleOrgID: orgID,
+			RuleUID:   alertRule.UID,
+			Labels:    models.InstanceLabels{"host": "c"},
+			Limit:     250,
+		}
+		require.NoError(t, dbstore.ListAlertStateHistory(query))
+		require.Len(t, query.Result, 250)
+		for i, r := range query.Result {
+			require.Equal(t, strconv.Itoa(1197-3*i), r.Labels["index"], "the most recent transitions are listed first")
+		}
+
+		query = &models.ListAlertStateHistoryQuery{
+			RuleOrgID: orgID,
+			RuleUID:   alertRule.UID,
+			Labels:    models.InstanceLabels{"host": "c"},
+		}
+		require.NoError(t, dbstore.ListAlertStateHistory(query))
+		require.Len(t, query.Result, 400, "the transitions of all the pages are listed once")
+		require.Equal(t, "0", query.Result[399].Labels["index"])
+
+		_, err := dbstore.SQLStore.NewSession(context.Background()).Exec("DELETE FROM alert_state_history WHERE rule_uid = ?", alertRule.UID)
+		require.NoError(t, err)
+	})
+
+	t.Run("does not list the transitions of other organizations", func(t *testing.T) {
+		query := &models.ListAlertStateHistoryQuery{RuleOrgID: orgID + 1}
+		require.NoError(t, dbstore.ListAlertStateHistory(query))
+		require.Len(t, query.Result, 0)
+	})
+
+	t.Run("can delete the transitions older than a time", func(t *testing.T) {
+		cmd := &models.DeleteAlertStateHistoryCommand{Before: now.Add(2 * time.Minute)}
+		require.NoError(t, dbstore.DeleteAlertStateHistory(cmd))
+		require.Equal(t, int64(2), cmd.Result)
+
+		query := &models.ListAlertStateHistoryQuery{RuleOrgID: orgID}
+		require.NoError(t, dbstore.ListAlertStateHistory(query))
+		require.Len(t, query.Result, 2)
+	})
+}
@@ -0,0 +1,28 @@
+package store
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// OrgStore is the database interface used to list the organizations.
+type OrgStore interface {
+	GetOrgs(ctx context.Context) ([]int64, error)
+}
+
+// GetOrgs returns the IDs of all the organizations.
+func (st DBstore) GetOrgs(ctx context.Context) ([]int64, error) {
+	orgs := make([]int64, 0)
+	err := st.SQLStore.WithDbSession(ctx, func(sess *sqlstore.DBSession) error {
+		q := "SELECT id FROM org"
+		if err := sess.SQL(q).Find(&orgs); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
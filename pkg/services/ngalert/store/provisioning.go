@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// ProvisioningStore is the storage of the provenance of the alerting resources.
+type ProvisioningStore interface {
+	GetProvenances(query *models.GetProvenancesQuery) error
+	SetProvenance(cmd *models.SetProvenanceCommand) error
+}
+
+// GetProvenances is a handler for retrieving the provenance of the resources of a type in an organization.
+func (st DBstore) GetProvenances(query *models.GetProvenancesQuery) error {
+	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		records := make([]*models.ProvenanceRecord, 0)
+		if err := sess.Table("provenance_type").Where("org_id = ? AND record_type = ?", query.OrgID, query.RecordType).Find(&records); err != nil {
+			return err
+		}
+
+		query.Result = make(map[string]models.Provenance, len(records))
+		for _, r := range records {
+			query.Result[r.RecordKey] = r.Provenance
+		}
+		return nil
+	})
+}
+
+// SetProvenance is a handler for setting the provenance of a resource.
+func (st DBstore) SetProvenance(cmd *models.SetProvenanceCommand) error {
+	return st.SQLStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		return setProvenance(sess, cmd.OrgID, cmd.RecordType, cmd.RecordKey, cmd.Provenance)
+	})
+}
+
+func setProvenance(sess *sqlstore.DBSession, orgID int64, recordType, recordKey string, provenance models.Provenance) error {
+	if _, err := sess.Exec("DELETE FROM provenance_type WHERE org_id = ? AND record_type = ? AND record_key = ?", orgID, recordType, recordKey); err != nil {
+		return err
+	}
+	if provenance == models.ProvenanceNone {
+		return nil
+	}
+	_, err := sess.Table("provenance_type").Insert(&models.ProvenanceRecord{
+		OrgID:      orgID,
+		RecordType: recordType,
+		RecordKey:  recordKey,
+		Provenance: provenance,
+	})
+	return err
+}
@@ -0,0 +1,113 @@
+// +build integration
+
+package store_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/registry"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvisioningStore(t *testing.T) {
+	dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	t.Cleanup(registry.ClearOverrides)
+
+	t.Run("can set, get and clear the provenance of resources", func(t *testing.T) {
+		require.NoError(t, dbstore.SetProvenance(&models.SetProvenanceCommand{
+			OrgID:      1,
+			RecordType: models.ProvenanceRecordContactPoint,
+			RecordKey:  "email",
+			Provenance: models.ProvenanceFile,
+		}))
+		// setting it again does not violate the uniqueness of the record
+		require.NoError(t, dbstore.SetProvenance(&models.SetProvenanceCommand{
+			OrgID:      1,
+			RecordType: models.ProvenanceRecordContactPoint,
+			RecordKey:  "email",
+			Provenance: models.ProvenanceFile,
+		}))
+
+		q := models.GetProvenancesQuery{OrgID: 1, RecordType: models.ProvenanceRecordContactPoint}
+		require.NoError(t, dbstore.GetProvenances(&q))
+		require.Equal(t, map[string]models.Provenance{"email": models.ProvenanceFile}, q.Result)
+
+		q = models.GetProvenancesQuery{OrgID: 2, RecordType: models.ProvenanceRecordContactPoint}
+		require.NoError(t, dbstore.GetProvenances(&q))
+		require.Empty(t, q.Result)
+
+		require.NoError(t, dbstore.SetProvenance(&models.SetProvenanceCommand{
+			OrgID:      1,
+			RecordType: models.ProvenanceRecordContactPoint,
+			RecordKey:  "email",
+			Provenance: models.ProvenanceNone,
+		}))
+		q = models.GetProvenancesQuery{OrgID: 1, RecordType: models.ProvenanceRecordContactPoint}
+		require.NoError(t, dbstore.GetProvenances(&q))
+		require.Empty(t, q.Result)
+	})
+
+	t.Run("deleting a provisioned alert rule clears its provenance", func(t *testing.T) {
+		rule := tests.CreateTestAlertRule(t, dbstore, 60)
+		require.NoError(t, dbstore.SetProvenance(&models.SetProvenanceCommand{
+			OrgID:      rule.OrgID,
+			RecordType: models.ProvenanceRecordAlertRule,
+			RecordKey:  rule.UID,
+			Provenance: models.ProvenanceFile,
+		}))
+
+		require.NoError(t, dbstore.DeleteAlertRuleByUID(rule.OrgID, rule.UID))
+
+		q := models.GetProvenancesQuery{OrgID: rule.OrgID, RecordType: models.ProvenanceRecordAlertRule}
+		require.NoError(t, dbstore.GetProvenances(&q))
+		require.NotContains(t, q.Result, rule.UID)
+	})
+
+	t.Run("provisioned rules are created with their UID and their provenance is recorded", func(t *testing.T) {
+		err := dbstore.UpdateRuleGroup(store.UpdateRuleGroupCmd{
+			OrgID:        1,
+			NamespaceUID: "namespace",
+			RuleGroupConfig: apimodels.PostableRuleGroupConfig{
+				Name:     "provisioned",
+				Interval: model.Duration(time.Minute),
+				Rules: []apimodels.PostableExtendedRuleNode{
+					{
+						GrafanaManagedAlert: &apimodels.PostableGrafanaRule{
+							UID:          "provisioned_rule",
+							Title:        "provisioned rule",
+							Condition:    "A",
+							NoDataState:  apimodels.NoData,
+							ExecErrState: apimodels.AlertingErrState,
+							Data: []models.AlertQuery{
+								{
+									RefID:         "A",
+									DatasourceUID: "-100",
+									Model:         json.RawMessage(`{"type":"math","expression":"2 + 2 > 1"}`),
+								},
+							},
+						},
+					},
+				},
+			},
+			Provenance: models.ProvenanceFile,
+		})
+		require.NoError(t, err)
+
+		rq := models.GetAlertRuleByUIDQuery{OrgID: 1, UID: "provisioned_rule"}
+		require.NoError(t, dbstore.GetAlertRuleByUID(&rq))
+		require.Equal(t, "provisioned rule", rq.Result.Title)
+
+		q := models.GetProvenancesQuery{OrgID: 1, RecordType: models.ProvenanceRecordAlertRule}
+		require.NoError(t, dbstore.GetProvenances(&q))
+		require.Equal(t, models.ProvenanceFile, q.Result["provisioned_rule"])
+	})
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// SchedulerStore is the storage of the members of a sharded scheduler.
+type SchedulerStore interface {
+	SchedulerMemberHeartbeat(cmd *models.SchedulerMemberHeartbeatCommand) error
+	ListSchedulerMembers(query *models.ListSchedulerMembersQuery) error
+	DeleteSchedulerMembers(cmd *models.DeleteSchedulerMembersCommand) error
+}
+
+// SchedulerMemberHeartbeat is a handler for recording the heartbeat of a scheduler member.
+func (st DBstore) SchedulerMemberHeartbeat(cmd *models.SchedulerMemberHeartbeatCommand) error {
+	return st.SQLStore.WithTransactionalDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		res, err := sess.Exec("UPDATE alert_scheduler_member SET heartbeat = ? WHERE member_id = ?", cmd.Heartbeat.Unix(), cmd.MemberID)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err != nil || affected > 0 {
+			return err
+		}
+		_, err = sess.Table("alert_scheduler_member").Insert(&models.SchedulerMember{
+			MemberID:  cmd.MemberID,
+			Heartbeat: cmd.Heartbeat.Unix(),
+		})
+		return err
+	})
+}
+
+// ListSchedulerMembers is a handler for retrieving the scheduler members that are alive.
+func (st DBstore) ListSchedulerMembers(query *models.ListSchedulerMembersQuery) error {
+	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		members := make([]*models.SchedulerMember, 0)
+		if err := sess.Table("alert_scheduler_member").Where("heartbeat >= ?", query.Since.Unix()).Asc("member_id").Find(&members); err != nil {
+			return err
+		}
+
+		query.Result = make([]string, 0, len(members))
+		for _, m := range members {
+			query.Result = append(query.Result, m.MemberID)
+		}
+		return nil
+	})
+}
+
+// DeleteSchedulerMembers is a handler for deleting a scheduler member, or the scheduler members
+// that are not alive anymore.
+func (st DBstore) DeleteSchedulerMembers(cmd *models.DeleteSchedulerMembersCommand) error {
+	return st.SQLStore.WithDbSession(context.Background(), func(sess *sqlstore.DBSession) error {
+		if cmd.MemberID != "" {
+			_, err := sess.Exec("DELETE FROM alert_scheduler_member WHERE member_id = ?", cmd.MemberID)
+			return err
+		}
+		_, err := sess.Exec("DELETE FROM alert_scheduler_member WHERE heartbeat < ?", cmd.Before.Unix())
+		return err
+	})
+}
@@ -0,0 +1,45 @@
+// +build integration
+
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana/pkg/registry"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerMemberOperations(t *testing.T) {
+	dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	t.Cleanup(registry.ClearOverrides)
+
+	now := time.Unix(1000, 0)
+	list := func(since time.Time) []string {
+		query := &models.ListSchedulerMembersQuery{Since: since}
+		require.NoError(t, dbstore.ListSchedulerMembers(query))
+		return query.Result
+	}
+
+	t.Run("heartbeats add or update the members", func(t *testing.T) {
+		require.NoError(t, dbstore.SchedulerMemberHeartbeat(&models.SchedulerMemberHeartbeatCommand{MemberID: "b", Heartbeat: now}))
+		require.NoError(t, dbstore.SchedulerMemberHeartbeat(&models.SchedulerMemberHeartbeatCommand{MemberID: "a", Heartbeat: now}))
+		require.Equal(t, []string{"a", "b"}, list(now))
+
+		require.NoError(t, dbstore.SchedulerMemberHeartbeat(&models.SchedulerMemberHeartbeatCommand{MemberID: "a", Heartbeat: now.Add(time.Minute)}))
+		require.Equal(t, []string{"a"}, list(now.Add(time.Second)))
+	})
+
+	t.Run("dead members are deleted", func(t *testing.T) {
+		require.NoError(t, dbstore.DeleteSchedulerMembers(&models.DeleteSchedulerMembersCommand{Before: now.Add(time.Second)}))
+		require.Equal(t, []string{"a"}, list(time.Unix(0, 0)))
+	})
+
+	t.Run("a member is deleted when it leaves", func(t *testing.T) {
+		require.NoError(t, dbstore.DeleteSchedulerMembers(&models.DeleteSchedulerMembersCommand{MemberID: "a"}))
+		require.Empty(t, list(time.Unix(0, 0)))
+	})
+}
@@ -32,6 +32,7 @@ func SetupTestEnv(t *testing.T, baseIntervalSeconds int64) *store.DBstore {
 	// AlertNG is disabled by default and only if it's enabled
 	// its database migrations run and the relative database tables are created
 	cfg.FeatureToggles = map[string]bool{"ngalert": true}
+	cfg.UnifiedAlerting.BaseInterval = time.Duration(baseIntervalSeconds) * time.Second
 
 	ng := overrideAlertNGInRegistry(t, cfg)
 	ng.SQLStore = sqlstore.InitTestDB(t)
@@ -0,0 +1,570 @@
+package alerting
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// pollingInterval is the interval at which the provisioning files are checked for changes.
+const pollingInterval = 10 * time.Second
+
+// StateRemover removes the state of the alert instances of a rule.
+type StateRemover interface {
+	RemoveByRuleUID(orgID int64, ruleUID string)
+}
+
+// AlertingProvisioner is responsible for provisioning the alert rules, contact points, notification
+// policies and mute timings of unified alerting.
+type AlertingProvisioner struct {
+	log             log.Logger
+	cfgProvider     *configReader
+	path            string
+	sqlStore        *sqlstore.SQLStore
+	ruleStore       store.RuleStore
+	amStore         store.AlertingStore
+	provenanceStore store.ProvisioningStore
+	mam             *notifier.MultiOrgAlertmanager
+	stateRemover    StateRemover
+	checksum        string
+}
+
+// New returns a new AlertingProvisioner for the files of the configuration directory.
+func New(configDirectory string, sqlStore *sqlstore.SQLStore, ruleStore store.RuleStore, amStore store.AlertingStore,
+	provenanceStore store.ProvisioningStore, mam *notifier.MultiOrgAlertmanager, stateRemover StateRemover) *AlertingProvisioner {
+	logger := log.New("provisioning.alerting")
+	return &AlertingProvisioner{
+		log:             logger,
+		cfgProvider:     &configReader{log: logger},
+		path:            configDirectory,
+		sqlStore:        sqlStore,
+		ruleStore:       ruleStore,
+		amStore:         amStore,
+		provenanceStore: provenanceStore,
+		mam:             mam,
+		stateRemover:    stateRemover,
+	}
+}
+
+// Provision applies the provisioning files.
+func (ap *AlertingProvisioner) Provision() error {
+	checksum, err := ap.filesChecksum()
+	if err != nil {
+		return err
+	}
+
+	configs, err := ap.cfgProvider.readConfig(ap.path)
+	if err != nil {
+		return err
+	}
+
+	if err := ap.applyRules(configs); err != nil {
+		return err
+	}
+
+	if err := ap.applyAlertmanagerChanges(configs); err != nil {
+		return err
+	}
+
+	ap.checksum = checksum
+	return nil
+}
+
+// PollChanges applies the provisioning files again whenever they change, until the context is done.
+func (ap *AlertingProvisioner) PollChanges(ctx context.Context) {
+	ticker := time.NewTicker(pollingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			checksum, err := ap.filesChecksum()
+			if err != nil {
+				ap.log.Error("failed to read the alerting provisioning files", "path", ap.path, "err", err)
+				continue
+			}
+			if checksum == ap.checksum {
+				continue
+			}
+			ap.log.Info("alerting provisioning files changed, provisioning them again", "path", ap.path)
+			if err := ap.Provision(); err != nil {
+				ap.log.Error("failed to provision alerting", "path", ap.path, "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// filesChecksum returns a checksum of the names and contents of the provisioning files.
+func (ap *AlertingProvisioner) filesChecksum() (string, error) {
+	files, err := ioutil.ReadDir(ap.path)
+	if err != nil {
+		// a missing directory has nothing to provision, like an empty one
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because the path comes from ps.Cfg.ProvisioningPath
+		content, err := ioutil.ReadFile(filepath.Join(ap.path, file.Name()))
+		if err != nil {
+			return "", err
+		}
+		_, _ = h.Write([]byte(file.Name()))
+		_, _ = h.Write(content)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// applyRules deletes the rules of deleteRules, and provisions the rule groups. The rules of the
+// groups that are no longer in the files are not deleted, they must be listed in deleteRules.
+func (ap *AlertingProvisioner) applyRules(configs []*alertingAsConfig) error {
+	for _, cfg := range configs {
+		for _, r := range cfg.DeleteRules {
+			ap.log.Info("deleting alert rule", "org", r.OrgID, "uid", r.UID)
+			if err := ap.ruleStore.DeleteAlertRuleByUID(r.OrgID, r.UID); err != nil {
+				return fmt.Errorf("failed to delete alert rule %s: %w", r.UID, err)
+			}
+			ap.stateRemover.RemoveByRuleUID(r.OrgID, r.UID)
+		}
+	}
+
+	for _, cfg := range configs {
+		for _, g := range cfg.Groups {
+			if err := ap.applyRuleGroup(g); err != nil {
+				return fmt.Errorf("failed to provision rule group %q of folder %q: %w", g.Name, g.Folder, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (ap *AlertingProvisioner) applyRuleGroup(g *ruleGroupFromConfig) error {
+	folder, err := ap.getOrCreateFolder(g.OrgID, g.Folder)
+	if err != nil {
+		return err
+	}
+
+	q := ngmodels.ListRuleGroupAlertRulesQuery{OrgID: g.OrgID, NamespaceUID: folder.Uid, RuleGroup: g.Name}
+	if err := ap.ruleStore.GetRuleGroupAlertRules(&q); err != nil {
+		return err
+	}
+	pq := ngmodels.GetProvenancesQuery{OrgID: g.OrgID, RecordType: ngmodels.ProvenanceRecordAlertRule}
+	if err := ap.provenanceStore.GetProvenances(&pq); err != nil {
+		return err
+	}
+	if !ruleGroupChanged(q.Result, pq.Result, g) {
+		ap.log.Debug("rule group is up to date", "org", g.OrgID, "folder", g.Folder, "group", g.Name)
+		return nil
+	}
+
+	ap.log.Info("provisioning rule group", "org", g.OrgID, "folder", g.Folder, "group", g.Name)
+	if err := ap.ruleStore.UpdateRuleGroup(store.UpdateRuleGroupCmd{
+		OrgID:           g.OrgID,
+		NamespaceUID:    folder.Uid,
+		RuleGroupConfig: g.toPostableRuleGroupConfig(),
+		Provenance:      ngmodels.ProvenanceFile,
+	}); err != nil {
+		return err
+	}
+
+	for _, r := range q.Result {
+		ap.stateRemover.RemoveByRuleUID(g.OrgID, r.UID)
+	}
+	for _, r := range g.Rules {
+		ap.stateRemover.RemoveByRuleUID(g.OrgID, r.UID)
+	}
+	return nil
+}
+
+func (ap *AlertingProvisioner) getOrCreateFolder(orgID int64, title string) (*models.Folder, error) {
+	user := &models.SignedInUser{OrgId: orgID, OrgRole: models.ROLE_ADMIN}
+	s := dashboards.NewFolderService(orgID, user, ap.sqlStore)
+	folder, err := s.GetFolderByTitle(title)
+	if errors.Is(err, models.ErrFolderNotFound) {
+		ap.log.Info("creating folder for the provisioned rule groups", "org", orgID, "folder", title)
+		return s.CreateFolder(title, "")
+	}
+	return folder, err
+}
+
+func (g *ruleGroupFromConfig) toPostableRuleGroupConfig() apimodels.PostableRuleGroupConfig {
+	cfg := apimodels.PostableRuleGroupConfig{
+		Name:     g.Name,
+		Interval: model.Duration(g.Interval),
+	}
+	for _, r := range g.Rules {
+		cfg.Rules = append(cfg.Rules, apimodels.PostableExtendedRuleNode{
+			ApiRuleNode: &apimodels.ApiRuleNode{
+				For:         model.Duration(r.For),
+				Labels:      r.Labels,
+				Annotations: r.Annotations,
+			},
+			GrafanaManagedAlert: &apimodels.PostableGrafanaRule{
+				Title:        r.Title,
+				Condition:    r.Condition,
+				Data:         r.Data,
+				UID:          r.UID,
+				NoDataState:  apimodels.NoDataState(r.NoDataState),
+				ExecErrState: apimodels.ExecutionErrorState(r.ExecErrState),
+			},
+		})
+	}
+	return cfg
+}
+
+// ruleGroupChanged reports whether the provisioned rule group differs from the existing one,
+// so that the versions of the rules are not bumped every time the files are provisioned.
+func ruleGroupChanged(existing []*ngmodels.AlertRule, provenances map[string]ngmodels.Provenance, g *ruleGroupFromConfig) bool {
+	if len(existing) != len(g.Rules) {
+		return true
+	}
+
+	existingByUID := make(map[string]*ngmodels.AlertRule, len(existing))
+	for _, r := range existing {
+		existingByUID[r.UID] = r
+	}
+
+	for _, r := range g.Rules {
+		e, ok := existingByUID[r.UID]
+		if !ok || provenances[r.UID] != ngmodels.ProvenanceFile {
+			return true
+		}
+		if e.Title != r.Title ||
+			e.Condition != r.Condition ||
+			e.IntervalSeconds != int64(g.Interval.Seconds()) ||
+			e.NoDataState != r.NoDataState ||
+			e.ExecErrState != r.ExecErrState ||
+			e.For != r.For ||
+			!sameJSON(e.Data, preSaveQueries(r.Data)) ||
+			!sameStringMap(e.Annotations, r.Annotations) ||
+			!sameStringMap(e.Labels, r.Labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// preSaveQueries returns the queries with the default properties the store sets when saving them.
+func preSaveQueries(queries []ngmodels.AlertQuery) []ngmodels.AlertQuery {
+	res := make([]ngmodels.AlertQuery, 0, len(queries))
+	for _, q := range queries {
+		q := ngmodels.AlertQuery{
+			RefID:             q.RefID,
+			QueryType:         q.QueryType,
+			RelativeTimeRange: q.RelativeTimeRange,
+			DatasourceUID:     q.DatasourceUID,
+			Model:             append(json.RawMessage(nil), q.Model...),
+		}
+		if err := q.PreSave(); err != nil {
+			// the store fails to save it anyway
+			return queries
+		}
+		res = append(res, q)
+	}
+	return res
+}
+
+// alertmanagerChanges are the provisioned changes to the Alertmanager configuration of an organization.
+type alertmanagerChanges struct {
+	contactPoints       []*contactPointFromConfig
+	deleteContactPoints []string
+	policy              *config.Route
+	resetPolicy         bool
+	muteTimes           []apimodels.MuteTimeInterval
+	deleteMuteTimes     []string
+}
+
+func (ap *AlertingProvisioner) applyAlertmanagerChanges(configs []*alertingAsConfig) error {
+	changes := make(map[int64]*alertmanagerChanges)
+	forOrg := func(orgID int64) *alertmanagerChanges {
+		if _, ok := changes[orgID]; !ok {
+			changes[orgID] = &alertmanagerChanges{}
+		}
+		return changes[orgID]
+	}
+
+	for _, cfg := range configs {
+		for _, cp := range cfg.ContactPoints {
+			c := forOrg(cp.OrgID)
+			c.contactPoints = append(c.contactPoints, cp)
+		}
+		for _, cp := range cfg.DeleteContactPoints {
+			c := forOrg(cp.OrgID)
+			c.deleteContactPoints = append(c.deleteContactPoints, cp.Name)
+		}
+		for _, p := range cfg.Policies {
+			forOrg(p.OrgID).policy = p.Route
+		}
+		for _, orgID := range cfg.ResetPolicies {
+			forOrg(orgID).resetPolicy = true
+		}
+		for _, mt := range cfg.MuteTimes {
+			c := forOrg(mt.OrgID)
+			c.muteTimes = append(c.muteTimes, mt.MuteTime)
+		}
+		for _, mt := range cfg.DeleteMuteTimes {
+			c := forOrg(mt.OrgID)
+			c.deleteMuteTimes = append(c.deleteMuteTimes, mt.Name)
+		}
+	}
+
+	orgIDs := make([]int64, 0, len(changes))
+	for orgID := range changes {
+		orgIDs = append(orgIDs, orgID)
+	}
+	sort.Slice(orgIDs, func(i, j int) bool { return orgIDs[i] < orgIDs[j] })
+
+	for _, orgID := range orgIDs {
+		if err := ap.applyOrgAlertmanagerChanges(orgID, changes[orgID]); err != nil {
+			return fmt.Errorf("failed to provision the Alertmanager configuration of organization %d: %w", orgID, err)
+		}
+	}
+	return nil
+}
+
+func (ap *AlertingProvisioner) applyOrgAlertmanagerChanges(orgID int64, changes *alertmanagerChanges) error {
+	var cfg *apimodels.PostableUserConfig
+	q := ngmodels.GetLatestAlertmanagerConfigurationQuery{OrgID: orgID}
+	err := ap.amStore.GetLatestAlertmanagerConfiguration(&q)
+	switch {
+	case errors.Is(err, store.ErrNoAlertmanagerConfiguration):
+		cfg, err = notifier.DefaultConfiguration()
+	case err == nil:
+		cfg, err = notifier.Load([]byte(q.Result.AlertmanagerConfiguration))
+	}
+	if err != nil {
+		return err
+	}
+
+	// the secure settings are encrypted again when the configuration is saved
+	for _, r := range cfg.AlertmanagerConfig.Receivers {
+		for _, gr := range r.PostableGrafanaReceivers.GrafanaManagedReceivers {
+			for k := range gr.SecureSettings {
+				v, err := gr.GetDecryptedSecret(k)
+				if err != nil {
+					return fmt.Errorf("failed to decrypt stored secure setting %s: %w", k, err)
+				}
+				gr.SecureSettings[k] = v
+			}
+		}
+	}
+
+	before, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := mergeAlertmanagerConfig(cfg, changes); err != nil {
+		return err
+	}
+	after, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	if string(before) != string(after) {
+		// loading the merged configuration validates it like the configurations posted to the API
+		cfg, err = notifier.Load(after)
+		if err != nil {
+			return err
+		}
+		if err := cfg.ProcessConfig(); err != nil {
+			return err
+		}
+		am, err := ap.mam.AlertmanagerFor(orgID)
+		if err != nil {
+			return err
+		}
+		ap.log.Info("provisioning Alertmanager configuration", "org", orgID)
+		if err := am.SaveAndApplyConfig(cfg); err != nil {
+			return err
+		}
+	}
+
+	return ap.setAlertmanagerProvenances(orgID, cfg, changes)
+}
+
+// setAlertmanagerProvenances records the provenance of the provisioned resources, and clears the
+// provenance of the provisioned resources that no longer exist.
+func (ap *AlertingProvisioner) setAlertmanagerProvenances(orgID int64, cfg *apimodels.PostableUserConfig, changes *alertmanagerChanges) error {
+	set := func(recordType, recordKey string, provenance ngmodels.Provenance) error {
+		return ap.provenanceStore.SetProvenance(&ngmodels.SetProvenanceCommand{
+			OrgID:      orgID,
+			RecordType: recordType,
+			RecordKey:  recordKey,
+			Provenance: provenance,
+		})
+	}
+
+	integrations := cfg.GetGrafanaReceiverMap()
+	q := ngmodels.GetProvenancesQuery{OrgID: orgID, RecordType: ngmodels.ProvenanceRecordContactPoint}
+	if err := ap.provenanceStore.GetProvenances(&q); err != nil {
+		return err
+	}
+	for uid := range q.Result {
+		if _, ok := integrations[uid]; !ok {
+			if err := set(ngmodels.ProvenanceRecordContactPoint, uid, ngmodels.ProvenanceNone); err != nil {
+				return err
+			}
+		}
+	}
+	for _, cp := range changes.contactPoints {
+		for _, r := range cp.Receivers {
+			if err := set(ngmodels.ProvenanceRecordContactPoint, r.UID, ngmodels.ProvenanceFile); err != nil {
+				return err
+			}
+		}
+	}
+
+	if changes.policy != nil {
+		if err := set(ngmodels.ProvenanceRecordNotificationPolicy, ngmodels.NotificationPolicyRecordKey, ngmodels.ProvenanceFile); err != nil {
+			return err
+		}
+	} else if changes.resetPolicy {
+		if err := set(ngmodels.ProvenanceRecordNotificationPolicy, ngmodels.NotificationPolicyRecordKey, ngmodels.ProvenanceNone); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range changes.deleteMuteTimes {
+		if err := set(ngmodels.ProvenanceRecordMuteTiming, name, ngmodels.ProvenanceNone); err != nil {
+			return err
+		}
+	}
+	for _, mt := range changes.muteTimes {
+		if err := set(ngmodels.ProvenanceRecordMuteTiming, mt.Name, ngmodels.ProvenanceFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeAlertmanagerConfig applies the provisioned changes to the Alertmanager configuration. The deletions
+// are applied first, so that a resource can be deleted and provisioned again in the same run.
+func mergeAlertmanagerConfig(cfg *apimodels.PostableUserConfig, changes *alertmanagerChanges) error {
+	amCfg := &cfg.AlertmanagerConfig
+
+	for _, name := range changes.deleteContactPoints {
+		for i, r := range amCfg.Receivers {
+			if r.Name == name {
+				amCfg.Receivers = append(amCfg.Receivers[:i], amCfg.Receivers[i+1:]...)
+				break
+			}
+		}
+	}
+
+	for _, cp := range changes.contactPoints {
+		receiver := &apimodels.PostableApiReceiver{
+			Receiver: config.Receiver{Name: cp.Name},
+		}
+		for _, r := range cp.Receivers {
+			receiver.PostableGrafanaReceivers.GrafanaManagedReceivers = append(receiver.PostableGrafanaReceivers.GrafanaManagedReceivers, &apimodels.PostableGrafanaReceiver{
+				UID:                   r.UID,
+				Name:                  cp.Name,
+				Type:                  r.Type,
+				DisableResolveMessage: r.DisableResolveMessage,
+				Settings:              r.SettingsToJSON(),
+				SecureSettings:        r.SecureSettings,
+			})
+		}
+
+		replaced := false
+		for i, existing := range amCfg.Receivers {
+			if existing.Name == cp.Name {
+				amCfg.Receivers[i] = receiver
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			amCfg.Receivers = append(amCfg.Receivers, receiver)
+		}
+	}
+
+	if changes.policy != nil {
+		amCfg.Route = changes.policy
+	} else if changes.resetPolicy {
+		defaultCfg, err := notifier.DefaultConfiguration()
+		if err != nil {
+			return err
+		}
+		amCfg.Route = defaultCfg.AlertmanagerConfig.Route
+	}
+
+	for _, name := range changes.deleteMuteTimes {
+		for i, mt := range amCfg.MuteTimeIntervals {
+			if mt.Name == name {
+				amCfg.MuteTimeIntervals = append(amCfg.MuteTimeIntervals[:i], amCfg.MuteTimeIntervals[i+1:]...)
+				break
+			}
+		}
+	}
+
+	for _, mt := range changes.muteTimes {
+		replaced := false
+		for i, existing := range amCfg.MuteTimeIntervals {
+			if existing.Name == mt.Name {
+				amCfg.MuteTimeIntervals[i] = mt
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			amCfg.MuteTimeIntervals = append(amCfg.MuteTimeIntervals, mt)
+		}
+	}
+
+	return nil
+}
+
+func sameJSON(a, b interface{}) bool {
+	aj, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bj, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(aj) == string(bj)
+}
+
+func sameStringMap(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
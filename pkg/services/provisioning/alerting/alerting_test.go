@@ -0,0 +1,166 @@
+package alerting
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
+)
+
+func TestMergeAlertmanagerConfig(t *testing.T) {
+	weekends := apimodels.MuteTimeInterval{
+		Name:          "weekends",
+		TimeIntervals: []apimodels.TimeInterval{{TimeInterval: timeinterval.TimeInterval{Weekdays: []timeinterval.WeekdayRange{{InclusiveRange: timeinterval.InclusiveRange{Begin: 6, End: 6}}}}}},
+	}
+
+	t.Run("contact points are added or replaced by name", func(t *testing.T) {
+		cfg, err := notifier.DefaultConfiguration()
+		require.NoError(t, err)
+
+		err = mergeAlertmanagerConfig(cfg, &alertmanagerChanges{
+			contactPoints: []*contactPointFromConfig{
+				{Name: "grafana-default-email", Receivers: []*receiverFromConfig{{UID: "email", Type: "email", Settings: map[string]interface{}{"addresses": "ops@example.com"}}}},
+				{Name: "ops", Receivers: []*receiverFromConfig{{UID: "slack", Type: "slack", SecureSettings: map[string]string{"url": "secret"}}}},
+			},
+		})
+		require.NoError(t, err)
+
+		receivers := cfg.AlertmanagerConfig.Receivers
+		require.Len(t, receivers, 2)
+		require.Equal(t, "grafana-default-email", receivers[0].Name)
+		require.Len(t, receivers[0].GrafanaManagedReceivers, 1)
+		require.Equal(t, "email", receivers[0].GrafanaManagedReceivers[0].UID)
+		require.Equal(t, "ops@example.com", receivers[0].GrafanaManagedReceivers[0].Settings.Get("addresses").MustString())
+		require.Equal(t, "ops", receivers[1].Name)
+		require.Equal(t, "ops", receivers[1].GrafanaManagedReceivers[0].Name)
+		require.Equal(t, map[string]string{"url": "secret"}, receivers[1].GrafanaManagedReceivers[0].SecureSettings)
+	})
+
+	t.Run("deletions are applied before the provisioned resources", func(t *testing.T) {
+		cfg, err := notifier.DefaultConfiguration()
+		require.NoError(t, err)
+		cfg.AlertmanagerConfig.MuteTimeIntervals = []apimodels.MuteTimeInterval{weekends}
+
+		err = mergeAlertmanagerConfig(cfg, &alertmanagerChanges{
+			contactPoints:       []*contactPointFromConfig{{Name: "ops", Receivers: []*receiverFromConfig{{UID: "slack", Type: "slack"}}}},
+			deleteContactPoints: []string{"grafana-default-email", "ops"},
+			deleteMuteTimes:     []string{"weekends"},
+		})
+		require.NoError(t, err)
+
+		require.Len(t, cfg.AlertmanagerConfig.Receivers, 1)
+		require.Equal(t, "ops", cfg.AlertmanagerConfig.Receivers[0].Name)
+		require.Empty(t, cfg.AlertmanagerConfig.MuteTimeIntervals)
+	})
+
+	t.Run("policies are replaced or reset", func(t *testing.T) {
+		cfg, err := notifier.DefaultConfiguration()
+		require.NoError(t, err)
+
+		err = mergeAlertmanagerConfig(cfg, &alertmanagerChanges{
+			policy:    &config.Route{Receiver: "ops", Routes: []*config.Route{{Receiver: "ops", MuteTimeIntervals: []string{"weekends"}}}},
+			muteTimes: []apimodels.MuteTimeInterval{weekends},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "ops", cfg.AlertmanagerConfig.Route.Receiver)
+		require.Equal(t, []apimodels.MuteTimeInterval{weekends}, cfg.AlertmanagerConfig.MuteTimeIntervals)
+
+		err = mergeAlertmanagerConfig(cfg, &alertmanagerChanges{resetPolicy: true})
+		require.NoError(t, err)
+		require.Equal(t, "grafana-default-email", cfg.AlertmanagerConfig.Route.Receiver)
+		require.Empty(t, cfg.AlertmanagerConfig.Route.Routes)
+	})
+}
+
+func TestRuleGroupChanged(t *testing.T) {
+	group := &ruleGroupFromConfig{
+		Name:     "my_group",
+		Interval: time.Minute,
+		Rules: []*ruleFromConfig{
+			{
+				UID:       "my_rule",
+				Title:     "my_rule",
+				Condition: "A",
+				Data: []ngmodels.AlertQuery{{
+					RefID:             "A",
+					DatasourceUID:     "prometheus",
+					RelativeTimeRange: ngmodels.RelativeTimeRange{From: ngmodels.Duration(10 * time.Minute)},
+					Model:             []byte(`{"expr":"up"}`),
+				}},
+				NoDataState:  ngmodels.NoData,
+				ExecErrState: ngmodels.AlertingErrState,
+				For:          time.Minute,
+				Labels:       map[string]string{"team": "ops"},
+			},
+		},
+	}
+
+	existing := func() []*ngmodels.AlertRule {
+		r := group.Rules[0]
+		return []*ngmodels.AlertRule{{
+			UID:             r.UID,
+			Title:           r.Title,
+			Condition:       r.Condition,
+			Data:            preSaveQueries(r.Data),
+			IntervalSeconds: 60,
+			RuleGroup:       group.Name,
+			NoDataState:     r.NoDataState,
+			ExecErrState:    r.ExecErrState,
+			For:             r.For,
+			Labels:          map[string]string{"team": "ops"},
+		}}
+	}
+	provisioned := map[string]ngmodels.Provenance{"my_rule": ngmodels.ProvenanceFile}
+
+	require.False(t, ruleGroupChanged(existing(), provisioned, group))
+
+	require.True(t, ruleGroupChanged(existing(), map[string]ngmodels.Provenance{}, group), "the rules that are not provisioned yet are provisioned")
+	require.True(t, ruleGroupChanged(nil, provisioned, group), "missing rules are created")
+
+	rules := existing()
+	rules[0].Title = "changed"
+	require.True(t, ruleGroupChanged(rules, provisioned, group))
+
+	rules = existing()
+	rules[0].Data[0].Model = []byte(`{"expr":"down"}`)
+	require.True(t, ruleGroupChanged(rules, provisioned, group))
+
+	rules = existing()
+	rules[0].IntervalSeconds = 120
+	require.True(t, ruleGroupChanged(rules, provisioned, group))
+}
+
+func TestFilesChecksum(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("a missing directory has nothing to provision", func(t *testing.T) {
+		ap := &AlertingProvisioner{path: filepath.Join(dir, "missing")}
+		checksum, err := ap.filesChecksum()
+		require.NoError(t, err)
+		require.Empty(t, checksum)
+	})
+
+	t.Run("the files are part of the checksum", func(t *testing.T) {
+		ap := &AlertingProvisioner{path: dir}
+		empty, err := ap.filesChecksum()
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "rules.yaml"), []byte("apiVersion: 1"), 0600))
+		checksum, err := ap.filesChecksum()
+		require.NoError(t, err)
+		require.NotEqual(t, empty, checksum)
+	})
+
+	t.Run("the directory cannot be read", func(t *testing.T) {
+		ap := &AlertingProvisioner{path: filepath.Join(dir, "rules.yaml")}
+		_, err := ap.filesChecksum()
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,266 @@
+package alerting
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type configReader struct {
+	log log.Logger
+}
+
+func (cr *configReader) readConfig(path string) ([]*alertingAsConfig, error) {
+	var configs []*alertingAsConfig
+	cr.log.Debug("Looking for alerting provisioning files", "path", path)
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Can't read alerting provisioning files from directory", "path", path, "error", err)
+		return configs, nil
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml") {
+			cr.log.Debug("Parsing alerting provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parseAlertingConfig(path, file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", file.Name(), err)
+			}
+
+			if cfg != nil {
+				configs = append(configs, cfg)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating alerting provisioning files")
+	if err := validateRequiredFields(configs); err != nil {
+		return nil, err
+	}
+
+	if err := checkOrgIDs(configs); err != nil {
+		return nil, err
+	}
+
+	if err := validateUniqueness(configs); err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+func (cr *configReader) parseAlertingConfig(path string, file os.FileInfo) (*alertingAsConfig, error) {
+	filename, _ := filepath.Abs(filepath.Join(path, file.Name()))
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *alertingAsConfigV1
+	if err := yaml.Unmarshal(yamlFile, &cfg); err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToAlertingFromConfig()
+}
+
+// checkOrgIDs defaults the organization of the provisioned resources to the main one, and checks
+// that the organizations of the provisioned resources exist.
+func checkOrgIDs(configs []*alertingAsConfig) error {
+	orgIDs := make(map[int64]struct{})
+	fix := func(orgID *int64) {
+		if *orgID < 1 {
+			*orgID = 1
+		}
+		orgIDs[*orgID] = struct{}{}
+	}
+
+	for _, cfg := range configs {
+		for _, g := range cfg.Groups {
+			fix(&g.OrgID)
+		}
+		for _, r := range cfg.DeleteRules {
+			fix(&r.OrgID)
+		}
+		for _, cp := range cfg.ContactPoints {
+			fix(&cp.OrgID)
+		}
+		for _, cp := range cfg.DeleteContactPoints {
+			fix(&cp.OrgID)
+		}
+		for _, p := range cfg.Policies {
+			fix(&p.OrgID)
+		}
+		for i := range cfg.ResetPolicies {
+			fix(&cfg.ResetPolicies[i])
+		}
+		for _, mt := range cfg.MuteTimes {
+			fix(&mt.OrgID)
+		}
+		for _, mt := range cfg.DeleteMuteTimes {
+			fix(&mt.OrgID)
+		}
+	}
+
+	for orgID := range orgIDs {
+		if err := utils.CheckOrgExists(orgID); err != nil {
+			return fmt.Errorf("failed to provision alerting resources of organization %d: %w", orgID, err)
+		}
+	}
+	return nil
+}
+
+func validateRequiredFields(configs []*alertingAsConfig) error {
+	var errStrings []string
+	for _, cfg := range configs {
+		for i, g := range cfg.Groups {
+			if g.Name == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Rule group %d in configuration doesn't contain required field name", i+1))
+			}
+			if g.Folder == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Rule group %d in configuration doesn't contain required field folder", i+1))
+			}
+			for j, r := range g.Rules {
+				if r.UID == "" {
+					errStrings = append(errStrings, fmt.Sprintf("Alert rule %d of rule group %q in configuration doesn't contain required field uid", j+1, g.Name))
+				} else if !util.IsValidShortUID(r.UID) {
+					errStrings = append(errStrings, fmt.Sprintf("Alert rule %d of rule group %q in configuration has an invalid uid %q", j+1, g.Name, r.UID))
+				}
+				if r.Title == "" {
+					errStrings = append(errStrings, fmt.Sprintf("Alert rule %d of rule group %q in configuration doesn't contain required field title", j+1, g.Name))
+				}
+				if r.Condition == "" {
+					errStrings = append(errStrings, fmt.Sprintf("Alert rule %d of rule group %q in configuration doesn't contain required field condition", j+1, g.Name))
+				}
+			}
+		}
+
+		for i, r := range cfg.DeleteRules {
+			if r.UID == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Deleted alert rule %d in configuration doesn't contain required field uid", i+1))
+			}
+		}
+
+		for i, cp := range cfg.ContactPoints {
+			if cp.Name == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Contact point %d in configuration doesn't contain required field name", i+1))
+			}
+			if len(cp.Receivers) == 0 {
+				errStrings = append(errStrings, fmt.Sprintf("Contact point %d in configuration doesn't contain any receiver", i+1))
+			}
+			for j, r := range cp.Receivers {
+				if r.UID == "" {
+					errStrings = append(errStrings, fmt.Sprintf("Receiver %d of contact point %q in configuration doesn't contain required field uid", j+1, cp.Name))
+				} else if !util.IsValidShortUID(r.UID) {
+					errStrings = append(errStrings, fmt.Sprintf("Receiver %d of contact point %q in configuration has an invalid uid %q", j+1, cp.Name, r.UID))
+				}
+				if r.Type == "" {
+					errStrings = append(errStrings, fmt.Sprintf("Receiver %d of contact point %q in configuration doesn't contain required field type", j+1, cp.Name))
+				}
+			}
+		}
+
+		for i, cp := range cfg.DeleteContactPoints {
+			if cp.Name == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Deleted contact point %d in configuration doesn't contain required field name", i+1))
+			}
+		}
+
+		for i, p := range cfg.Policies {
+			if p.Route.Receiver == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Notification policy %d in configuration doesn't contain required field receiver", i+1))
+			}
+		}
+
+		for i, mt := range cfg.MuteTimes {
+			if mt.MuteTime.Name == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Mute timing %d in configuration doesn't contain required field name", i+1))
+			}
+		}
+
+		for i, mt := range cfg.DeleteMuteTimes {
+			if mt.Name == "" {
+				errStrings = append(errStrings, fmt.Sprintf("Deleted mute timing %d in configuration doesn't contain required field name", i+1))
+			}
+		}
+	}
+
+	if len(errStrings) != 0 {
+		return fmt.Errorf(strings.Join(errStrings, "\n"))
+	}
+	return nil
+}
+
+// validateUniqueness checks that the provisioned resources are defined only once across all the files.
+func validateUniqueness(configs []*alertingAsConfig) error {
+	type key struct {
+		orgID int64
+		name  string
+	}
+	groups := make(map[key]struct{})
+	rules := make(map[key]struct{})
+	contactPoints := make(map[key]struct{})
+	receivers := make(map[key]struct{})
+	policies := make(map[int64]struct{})
+	muteTimes := make(map[key]struct{})
+
+	for _, cfg := range configs {
+		for _, g := range cfg.Groups {
+			k := key{g.OrgID, g.Folder + "/" + g.Name}
+			if _, ok := groups[k]; ok {
+				return fmt.Errorf("rule group %q of folder %q is provisioned more than once", g.Name, g.Folder)
+			}
+			groups[k] = struct{}{}
+			for _, r := range g.Rules {
+				k := key{g.OrgID, r.UID}
+				if _, ok := rules[k]; ok {
+					return fmt.Errorf("alert rule %q is provisioned more than once", r.UID)
+				}
+				rules[k] = struct{}{}
+			}
+		}
+
+		for _, cp := range cfg.ContactPoints {
+			k := key{cp.OrgID, cp.Name}
+			if _, ok := contactPoints[k]; ok {
+				return fmt.Errorf("contact point %q is provisioned more than once", cp.Name)
+			}
+			contactPoints[k] = struct{}{}
+			for _, r := range cp.Receivers {
+				k := key{cp.OrgID, r.UID}
+				if _, ok := receivers[k]; ok {
+					return fmt.Errorf("receiver %q is provisioned more than once", r.UID)
+				}
+				receivers[k] = struct{}{}
+			}
+		}
+
+		for _, p := range cfg.Policies {
+			if _, ok := policies[p.OrgID]; ok {
+				return fmt.Errorf("notification policies of organization %d are provisioned more than once", p.OrgID)
+			}
+			policies[p.OrgID] = struct{}{}
+		}
+
+		for _, mt := range cfg.MuteTimes {
+			k := key{mt.OrgID, mt.MuteTime.Name}
+			if _, ok := muteTimes[k]; ok {
+				return fmt.Errorf("mute timing %q is provisioned more than once", mt.MuteTime.Name)
+			}
+			muteTimes[k] = struct{}{}
+		}
+	}
+	return nil
+}
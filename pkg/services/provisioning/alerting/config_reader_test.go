@@ -0,0 +1,127 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/models"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+var (
+	correctProperties = "./testdata/test-configs/correct-properties"
+	noRequiredFields  = "./testdata/test-configs/no-required-fields"
+	duplicatedRules   = "./testdata/test-configs/duplicated-rules"
+	brokenYaml        = "./testdata/test-configs/broken-yaml"
+	unknownOrg        = "./testdata/test-configs/unknown-org"
+	emptyFolder       = "./testdata/test-configs/empty_folder"
+)
+
+func TestAlertingAsConfig(t *testing.T) {
+	sqlstore.InitTestDB(t)
+	for i := 1; i <= 2; i++ {
+		orgCommand := models.CreateOrgCommand{Name: fmt.Sprintf("Main Org. %v", i)}
+		require.NoError(t, sqlstore.CreateOrg(&orgCommand))
+	}
+	cr := &configReader{log: log.New("test logger")}
+
+	t.Run("can read correct properties", func(t *testing.T) {
+		_ = os.Setenv("TEST_DATASOURCE_UID", "prometheus")
+		_ = os.Setenv("TEST_SLACK_TOKEN", "secret")
+		cfgs, err := cr.readConfig(correctProperties)
+		_ = os.Unsetenv("TEST_DATASOURCE_UID")
+		_ = os.Unsetenv("TEST_SLACK_TOKEN")
+		require.NoError(t, err)
+		require.Len(t, cfgs, 1)
+		cfg := cfgs[0]
+
+		require.Len(t, cfg.Groups, 1)
+		g := cfg.Groups[0]
+		require.Equal(t, int64(1), g.OrgID)
+		require.Equal(t, "my_group", g.Name)
+		require.Equal(t, "my_folder", g.Folder)
+		require.Equal(t, time.Minute, g.Interval)
+		require.Len(t, g.Rules, 1)
+		r := g.Rules[0]
+		require.Equal(t, "my_rule_1", r.UID)
+		require.Equal(t, "my_first_rule", r.Title)
+		require.Equal(t, "B", r.Condition)
+		require.Equal(t, ngmodels.OK, r.NoDataState)
+		require.Equal(t, ngmodels.AlertingErrState, r.ExecErrState)
+		require.Equal(t, 5*time.Minute, r.For)
+		require.Equal(t, map[string]string{"summary": "the target is up"}, r.Annotations)
+		require.Equal(t, map[string]string{"team": "ops"}, r.Labels)
+		require.Len(t, r.Data, 2)
+		require.Equal(t, "prometheus", r.Data[0].DatasourceUID)
+		require.Equal(t, ngmodels.Duration(10*time.Minute), r.Data[0].RelativeTimeRange.From)
+		var model map[string]interface{}
+		require.NoError(t, json.Unmarshal(r.Data[1].Model, &model))
+		require.Equal(t, "$A > 0", model["expression"])
+
+		require.Len(t, cfg.DeleteRules, 1)
+		require.Equal(t, int64(2), cfg.DeleteRules[0].OrgID)
+		require.Equal(t, "my_old_rule", cfg.DeleteRules[0].UID)
+
+		require.Len(t, cfg.ContactPoints, 1)
+		cp := cfg.ContactPoints[0]
+		require.Equal(t, "ops", cp.Name)
+		require.Len(t, cp.Receivers, 2)
+		require.Equal(t, "ops_slack", cp.Receivers[1].UID)
+		require.Equal(t, "slack", cp.Receivers[1].Type)
+		require.True(t, cp.Receivers[1].DisableResolveMessage)
+		require.Equal(t, map[string]interface{}{"recipient": "#ops"}, cp.Receivers[1].Settings)
+		require.Equal(t, map[string]string{"url": "https://hooks.slack.com/services/secret"}, cp.Receivers[1].SecureSettings)
+		require.Len(t, cfg.DeleteContactPoints, 1)
+		require.Equal(t, "old_contact_point", cfg.DeleteContactPoints[0].Name)
+
+		require.Len(t, cfg.Policies, 1)
+		require.Equal(t, "ops", cfg.Policies[0].Route.Receiver)
+		require.Len(t, cfg.Policies[0].Route.Routes, 1)
+		require.Equal(t, []string{"weekends"}, cfg.Policies[0].Route.Routes[0].MuteTimeIntervals)
+		require.Equal(t, []int64{2}, cfg.ResetPolicies)
+
+		require.Len(t, cfg.MuteTimes, 1)
+		require.Equal(t, "weekends", cfg.MuteTimes[0].MuteTime.Name)
+		require.Len(t, cfg.MuteTimes[0].MuteTime.TimeIntervals, 1)
+		require.Equal(t, "Europe/Paris", cfg.MuteTimes[0].MuteTime.TimeIntervals[0].Location.String())
+		require.Len(t, cfg.DeleteMuteTimes, 1)
+		require.Equal(t, "nights", cfg.DeleteMuteTimes[0].Name)
+	})
+
+	t.Run("missing required fields should fail", func(t *testing.T) {
+		_, err := cr.readConfig(noRequiredFields)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "doesn't contain required field name")
+		require.Contains(t, err.Error(), "doesn't contain required field folder")
+		require.Contains(t, err.Error(), "doesn't contain required field uid")
+		require.Contains(t, err.Error(), "doesn't contain required field type")
+	})
+
+	t.Run("alert rules provisioned more than once should fail", func(t *testing.T) {
+		_, err := cr.readConfig(duplicatedRules)
+		require.EqualError(t, err, `alert rule "my_rule" is provisioned more than once`)
+	})
+
+	t.Run("broken yaml should return error", func(t *testing.T) {
+		_, err := cr.readConfig(brokenYaml)
+		require.Error(t, err)
+	})
+
+	t.Run("unknown organization should fail", func(t *testing.T) {
+		_, err := cr.readConfig(unknownOrg)
+		require.ErrorIs(t, err, models.ErrOrgNotFound)
+	})
+
+	t.Run("missing folder should not return error", func(t *testing.T) {
+		cfgs, err := cr.readConfig(emptyFolder)
+		require.NoError(t, err)
+		require.Len(t, cfgs, 0)
+	})
+}
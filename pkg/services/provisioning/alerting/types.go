@@ -0,0 +1,351 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+)
+
+// alertingAsConfig is normalized data object for alerting config data. Any config version should be mappable
+// to this type.
+type alertingAsConfig struct {
+	Groups              []*ruleGroupFromConfig
+	DeleteRules         []*deleteRuleConfig
+	ContactPoints       []*contactPointFromConfig
+	DeleteContactPoints []*deleteContactPointConfig
+	Policies            []*policyFromConfig
+	ResetPolicies       []int64
+	MuteTimes           []*muteTimeFromConfig
+	DeleteMuteTimes     []*deleteMuteTimeConfig
+}
+
+type ruleGroupFromConfig struct {
+	OrgID    int64
+	Name     string
+	Folder   string
+	Interval time.Duration
+	Rules    []*ruleFromConfig
+}
+
+type ruleFromConfig struct {
+	UID          string
+	Title        string
+	Condition    string
+	Data         []ngmodels.AlertQuery
+	NoDataState  ngmodels.NoDataState
+	ExecErrState ngmodels.ExecutionErrorState
+	For          time.Duration
+	Annotations  map[string]string
+	Labels       map[string]string
+}
+
+type deleteRuleConfig struct {
+	OrgID int64
+	UID   string
+}
+
+type contactPointFromConfig struct {
+	OrgID     int64
+	Name      string
+	Receivers []*receiverFromConfig
+}
+
+type receiverFromConfig struct {
+	UID                   string
+	Type                  string
+	DisableResolveMessage bool
+	Settings              map[string]interface{}
+	SecureSettings        map[string]string
+}
+
+type deleteContactPointConfig struct {
+	OrgID int64
+	Name  string
+}
+
+type policyFromConfig struct {
+	OrgID int64
+	Route *config.Route
+}
+
+type muteTimeFromConfig struct {
+	OrgID    int64
+	MuteTime apimodels.MuteTimeInterval
+}
+
+type deleteMuteTimeConfig struct {
+	OrgID int64
+	Name  string
+}
+
+// alertingAsConfigV1 is mapping for version 1 configs. This is mapped to its normalised version.
+type alertingAsConfigV1 struct {
+	APIVersion          values.Int64Value       `json:"apiVersion" yaml:"apiVersion"`
+	Groups              []*ruleGroupV1          `json:"groups" yaml:"groups"`
+	DeleteRules         []*deleteRuleV1         `json:"deleteRules" yaml:"deleteRules"`
+	ContactPoints       []*contactPointV1       `json:"contactPoints" yaml:"contactPoints"`
+	DeleteContactPoints []*deleteContactPointV1 `json:"deleteContactPoints" yaml:"deleteContactPoints"`
+	Policies            []*policyV1             `json:"policies" yaml:"policies"`
+	ResetPolicies       []values.Int64Value     `json:"resetPolicies" yaml:"resetPolicies"`
+	MuteTimes           []*muteTimeV1           `json:"muteTimes" yaml:"muteTimes"`
+	DeleteMuteTimes     []*deleteMuteTimeV1     `json:"deleteMuteTimes" yaml:"deleteMuteTimes"`
+}
+
+type ruleGroupV1 struct {
+	OrgID    values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Name     values.StringValue `json:"name" yaml:"name"`
+	Folder   values.StringValue `json:"folder" yaml:"folder"`
+	Interval values.StringValue `json:"interval" yaml:"interval"`
+	Rules    []*ruleV1          `json:"rules" yaml:"rules"`
+}
+
+type ruleV1 struct {
+	UID          values.StringValue    `json:"uid" yaml:"uid"`
+	Title        values.StringValue    `json:"title" yaml:"title"`
+	Condition    values.StringValue    `json:"condition" yaml:"condition"`
+	Data         []*queryV1            `json:"data" yaml:"data"`
+	NoDataState  values.StringValue    `json:"noDataState" yaml:"noDataState"`
+	ExecErrState values.StringValue    `json:"execErrState" yaml:"execErrState"`
+	For          values.StringValue    `json:"for" yaml:"for"`
+	Annotations  values.StringMapValue `json:"annotations" yaml:"annotations"`
+	Labels       values.StringMapValue `json:"labels" yaml:"labels"`
+}
+
+type queryV1 struct {
+	RefID             values.StringValue  `json:"refId" yaml:"refId"`
+	QueryType         values.StringValue  `json:"queryType" yaml:"queryType"`
+	RelativeTimeRange relativeTimeRangeV1 `json:"relativeTimeRange" yaml:"relativeTimeRange"`
+	DatasourceUID     values.StringValue  `json:"datasourceUid" yaml:"datasourceUid"`
+	Model             values.JSONValue    `json:"model" yaml:"model"`
+}
+
+// relativeTimeRangeV1 is the relative time range of a query in seconds.
+type relativeTimeRangeV1 struct {
+	From values.Int64Value `json:"from" yaml:"from"`
+	To   values.Int64Value `json:"to" yaml:"to"`
+}
+
+type deleteRuleV1 struct {
+	OrgID values.Int64Value  `json:"orgId" yaml:"orgId"`
+	UID   values.StringValue `json:"uid" yaml:"uid"`
+}
+
+type contactPointV1 struct {
+	OrgID     values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Name      values.StringValue `json:"name" yaml:"name"`
+	Receivers []*receiverV1      `json:"receivers" yaml:"receivers"`
+}
+
+type receiverV1 struct {
+	UID                   values.StringValue    `json:"uid" yaml:"uid"`
+	Type                  values.StringValue    `json:"type" yaml:"type"`
+	DisableResolveMessage values.BoolValue      `json:"disableResolveMessage" yaml:"disableResolveMessage"`
+	Settings              values.JSONValue      `json:"settings" yaml:"settings"`
+	SecureSettings        values.StringMapValue `json:"secureSettings" yaml:"secureSettings"`
+}
+
+type deleteContactPointV1 struct {
+	OrgID values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Name  values.StringValue `json:"name" yaml:"name"`
+}
+
+// policyV1 is the notification policy tree of an organization, in the same format as the
+// route of the Alertmanager configuration.
+type policyV1 struct {
+	OrgID values.Int64Value
+	Route config.Route
+}
+
+func (p *policyV1) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var org struct {
+		OrgID values.Int64Value `yaml:"orgId"`
+	}
+	if err := unmarshal(&org); err != nil {
+		return err
+	}
+	p.OrgID = org.OrgID
+	return unmarshal(&p.Route)
+}
+
+// muteTimeV1 is a mute timing of an organization, in the same format as the mute time intervals
+// of the Alertmanager configuration.
+type muteTimeV1 struct {
+	OrgID    values.Int64Value
+	MuteTime apimodels.MuteTimeInterval
+}
+
+func (m *muteTimeV1) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var org struct {
+		OrgID values.Int64Value `yaml:"orgId"`
+	}
+	if err := unmarshal(&org); err != nil {
+		return err
+	}
+	m.OrgID = org.OrgID
+	return unmarshal(&m.MuteTime)
+}
+
+type deleteMuteTimeV1 struct {
+	OrgID values.Int64Value  `json:"orgId" yaml:"orgId"`
+	Name  values.StringValue `json:"name" yaml:"name"`
+}
+
+func (receiver receiverFromConfig) SettingsToJSON() *simplejson.Json {
+	settings := simplejson.New()
+	for k, v := range receiver.Settings {
+		settings.Set(k, v)
+	}
+	return settings
+}
+
+// mapToAlertingFromConfig maps config syntax to normalized alertingAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *alertingAsConfigV1) mapToAlertingFromConfig() (*alertingAsConfig, error) {
+	r := &alertingAsConfig{}
+	if cfg == nil {
+		return r, nil
+	}
+
+	for _, group := range cfg.Groups {
+		interval, err := parseDuration(group.Interval.Value())
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval of rule group %q: %w", group.Name.Value(), err)
+		}
+		g := &ruleGroupFromConfig{
+			OrgID:    group.OrgID.Value(),
+			Name:     group.Name.Value(),
+			Folder:   group.Folder.Value(),
+			Interval: interval,
+		}
+		for _, rule := range group.Rules {
+			rr, err := rule.mapToRuleFromConfig()
+			if err != nil {
+				return nil, fmt.Errorf("invalid alert rule %q of rule group %q: %w", rule.Title.Value(), group.Name.Value(), err)
+			}
+			g.Rules = append(g.Rules, rr)
+		}
+		r.Groups = append(r.Groups, g)
+	}
+
+	for _, rule := range cfg.DeleteRules {
+		r.DeleteRules = append(r.DeleteRules, &deleteRuleConfig{
+			OrgID: rule.OrgID.Value(),
+			UID:   rule.UID.Value(),
+		})
+	}
+
+	for _, cp := range cfg.ContactPoints {
+		c := &contactPointFromConfig{
+			OrgID: cp.OrgID.Value(),
+			Name:  cp.Name.Value(),
+		}
+		for _, receiver := range cp.Receivers {
+			c.Receivers = append(c.Receivers, &receiverFromConfig{
+				UID:                   receiver.UID.Value(),
+				Type:                  receiver.Type.Value(),
+				DisableResolveMessage: receiver.DisableResolveMessage.Value(),
+				Settings:              receiver.Settings.Value(),
+				SecureSettings:        receiver.SecureSettings.Value(),
+			})
+		}
+		r.ContactPoints = append(r.ContactPoints, c)
+	}
+
+	for _, cp := range cfg.DeleteContactPoints {
+		r.DeleteContactPoints = append(r.DeleteContactPoints, &deleteContactPointConfig{
+			OrgID: cp.OrgID.Value(),
+			Name:  cp.Name.Value(),
+		})
+	}
+
+	for _, policy := range cfg.Policies {
+		route := policy.Route
+		r.Policies = append(r.Policies, &policyFromConfig{
+			OrgID: policy.OrgID.Value(),
+			Route: &route,
+		})
+	}
+
+	for _, orgID := range cfg.ResetPolicies {
+		r.ResetPolicies = append(r.ResetPolicies, orgID.Value())
+	}
+
+	for _, mt := range cfg.MuteTimes {
+		r.MuteTimes = append(r.MuteTimes, &muteTimeFromConfig{
+			OrgID:    mt.OrgID.Value(),
+			MuteTime: mt.MuteTime,
+		})
+	}
+
+	for _, mt := range cfg.DeleteMuteTimes {
+		r.DeleteMuteTimes = append(r.DeleteMuteTimes, &deleteMuteTimeConfig{
+			OrgID: mt.OrgID.Value(),
+			Name:  mt.Name.Value(),
+		})
+	}
+
+	return r, nil
+}
+
+func (rule *ruleV1) mapToRuleFromConfig() (*ruleFromConfig, error) {
+	forDuration, err := parseDuration(rule.For.Value())
+	if err != nil {
+		return nil, fmt.Errorf("invalid for: %w", err)
+	}
+
+	r := &ruleFromConfig{
+		UID:          rule.UID.Value(),
+		Title:        rule.Title.Value(),
+		Condition:    rule.Condition.Value(),
+		NoDataState:  ngmodels.NoDataState(rule.NoDataState.Value()),
+		ExecErrState: ngmodels.ExecutionErrorState(rule.ExecErrState.Value()),
+		For:          forDuration,
+		Annotations:  rule.Annotations.Value(),
+		Labels:       rule.Labels.Value(),
+	}
+	// the same defaults as the ones of the rule editor
+	if r.NoDataState == "" {
+		r.NoDataState = ngmodels.NoData
+	}
+	if r.ExecErrState == "" {
+		r.ExecErrState = ngmodels.AlertingErrState
+	}
+
+	for _, query := range rule.Data {
+		m, err := json.Marshal(query.Model.Value())
+		if err != nil {
+			return nil, fmt.Errorf("invalid model of query %q: %w", query.RefID.Value(), err)
+		}
+		r.Data = append(r.Data, ngmodels.AlertQuery{
+			RefID:     query.RefID.Value(),
+			QueryType: query.QueryType.Value(),
+			RelativeTimeRange: ngmodels.RelativeTimeRange{
+				From: ngmodels.Duration(time.Duration(query.RelativeTimeRange.From.Value()) * time.Second),
+				To:   ngmodels.Duration(time.Duration(query.RelativeTimeRange.To.Value()) * time.Second),
+			},
+			DatasourceUID: query.DatasourceUID.Value(),
+			Model:         m,
+		})
+	}
+	return r, nil
+}
+
+// parseDuration parses a duration in the Prometheus format, such as 1m or 30s. An empty duration is zero.
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := model.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(d), nil
+}
@@ -20,6 +20,7 @@ type alertRule struct {
 	RuleGroup       string
 	NoDataState     string
 	ExecErrState    string
+	IsPaused        bool
 	For             duration
 	Updated         time.Time
 	Annotations     map[string]string
@@ -42,6 +43,7 @@ type alertRuleVersion struct {
 	IntervalSeconds int64
 	NoDataState     string
 	ExecErrState    string
+	IsPaused        bool
 	// ideally this field should have been apimodels.ApiDuration
 	// but this is currently not possible because of circular dependencies
 	For         duration
@@ -66,6 +68,7 @@ func (a *alertRule) makeVersion() *alertRuleVersion {
 		IntervalSeconds: a.IntervalSeconds,
 		NoDataState:     a.NoDataState,
 		ExecErrState:    a.ExecErrState,
+		IsPaused:        a.IsPaused,
 		For:             a.For,
 		Annotations:     a.Annotations,
 		Labels:          map[string]string{},
@@ -105,6 +108,7 @@ func (m *migration) makeAlertRule(cond condition, da dashAlert, folderUID string
 		Version:         1,
 		NamespaceUid:    folderUID, // Folder already created, comes from env var.
 		RuleGroup:       da.Name,
+		IsPaused:        da.State == "paused",
 		For:             duration(da.For),
 		Updated:         time.Now().UTC(),
 		Annotations:     annotations,
@@ -200,7 +204,7 @@ func transNoData(s string) (string, error) {
 	case "alerting":
 		return "Alerting", nil
 	case "keep_state":
-		return "Alerting", nil
+		return "KeepLastState", nil
 	}
 	return "", fmt.Errorf("unrecognized No Data setting %v", s)
 }
@@ -210,7 +214,7 @@ func transExecErr(s string) (string, error) {
 	case "", "alerting":
 		return "Alerting", nil
 	case "keep_state":
-		return "Alerting", nil
+		return "KeepLastState", nil
 	}
 	return "", fmt.Errorf("unrecognized Execution Error setting %v", s)
 }
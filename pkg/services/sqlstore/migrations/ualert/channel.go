@@ -16,6 +16,7 @@ import (
 
 type notificationChannel struct {
 	ID                    int                           `xorm:"id"`
+	OrgID                 int64                         `xorm:"org_id"`
 	Uid                   string                        `xorm:"uid"`
 	Name                  string                        `xorm:"name"`
 	Type                  string                        `xorm:"type"`
@@ -25,9 +26,12 @@ type notificationChannel struct {
 	SecureSettings        securejsondata.SecureJsonData `xorm:"secure_settings"`
 }
 
-func (m *migration) getNotificationChannelMap() (map[interface{}]*notificationChannel, []*notificationChannel, error) {
+// getNotificationChannelMap returns, for each organization, the map of its channels
+// by UID and by ID, and its default channels.
+func (m *migration) getNotificationChannelMap() (map[int64]map[interface{}]*notificationChannel, map[int64][]*notificationChannel, error) {
 	q := `
 	SELECT id,
+		org_id,
 		uid,
 		name,
 		type,
@@ -48,18 +52,23 @@ func (m *migration) getNotificationChannelMap() (map[interface{}]*notificationCh
 		return nil, nil, nil
 	}
 
-	allChannelsMap := make(map[interface{}]*notificationChannel)
-	var defaultChannels []*notificationChannel
+	allChannelsMap := make(map[int64]map[interface{}]*notificationChannel)
+	defaultChannels := make(map[int64][]*notificationChannel)
 	for i, c := range allChannels {
+		orgChannels, ok := allChannelsMap[c.OrgID]
+		if !ok {
+			orgChannels = make(map[interface{}]*notificationChannel)
+			allChannelsMap[c.OrgID] = orgChannels
+		}
 		if c.Uid != "" {
-			allChannelsMap[c.Uid] = &allChannels[i]
+			orgChannels[c.Uid] = &allChannels[i]
 		}
 		if c.ID != 0 {
-			allChannelsMap[c.ID] = &allChannels[i]
+			orgChannels[c.ID] = &allChannels[i]
 		}
 		if c.IsDefault {
 			// TODO: verify that there will be only 1 default channel.
-			defaultChannels = append(defaultChannels, &allChannels[i])
+			defaultChannels[c.OrgID] = append(defaultChannels[c.OrgID], &allChannels[i])
 		}
 	}
 
@@ -16,6 +16,7 @@ type dashAlert struct {
 	Frequency   int64
 	For         time.Duration
 	State       string
+	Silenced    bool
 
 	Settings       json.RawMessage
 	ParsedSettings *dashAlertSettings
@@ -33,6 +34,7 @@ SELECT id,
 	frequency,
 	%s,
 	state,
+	silenced,
 	settings
 FROM
 	alert
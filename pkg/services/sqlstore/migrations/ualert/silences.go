@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -17,8 +19,15 @@ import (
 	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 )
 
+// silenceCreatedBy is the creator of the silences of the migration, which tells them apart from
+// the silences created by the users when the migration is reverted.
+const silenceCreatedBy = "Grafana Migration"
+
+// silenceDuration is how long the silences of the silenced dashboard alerts last.
+const silenceDuration = 365 * 24 * time.Hour // 1 year.
+
 func (m *migration) addSilence(da dashAlert, rule *alertRule) error {
-	if da.State != "paused" {
+	if !da.Silenced {
 		return nil
 	}
 
@@ -39,26 +48,91 @@ func (m *migration) addSilence(da dashAlert, rule *alertRule) error {
 				},
 			},
 			StartsAt:  time.Now(),
-			EndsAt:    time.Now().Add(365 * 20 * time.Hour), // 1 year.
-			CreatedBy: "Grafana Migration",
+			EndsAt:    time.Now().Add(silenceDuration),
+			CreatedBy: silenceCreatedBy,
 			Comment:   "Created during auto migration to unified alerting",
 		},
-		ExpiresAt: time.Now().Add(365 * 20 * time.Hour), // 1 year.
+		ExpiresAt: time.Now().Add(silenceDuration),
+	}
+
+	m.silences[da.OrgId] = append(m.silences[da.OrgId], s)
+	return nil
+}
+
+// writeSilencesFile adds the silences of the migration to the silences file of the organization.
+// The silences of a previous migration are replaced, and the ones created by the users are kept.
+func (m *migration) writeSilencesFile(orgID int64) error {
+	filename := silencesFileName(m.mg, orgID)
+	silences, err := readSilencesFile(filename)
+	if err != nil {
+		return err
 	}
+	return writeSilencesFile(filename, append(userSilences(silences), m.silences[orgID]...))
+}
 
-	m.silences = append(m.silences, s)
+// removeMigratedSilences removes the silences created by the migration from the silences files
+// of all the organizations.
+func removeMigratedSilences(mg *migrator.Migrator) error {
+	files, err := filepath.Glob(filepath.Join(mg.Cfg.DataPath, "alerting", "*", "silences"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		silences, err := readSilencesFile(f)
+		if err == nil {
+			err = writeSilencesFile(f, userSilences(silences))
+		}
+		if err != nil {
+			mg.Logger.Error("alert migration error: failed to remove the migrated silences", "file", f, "err", err)
+		}
+	}
 	return nil
 }
 
-func (m *migration) writeSilencesFile() error {
+// userSilences returns the silences that were not created by the migration.
+func userSilences(silences []*pb.MeshSilence) []*pb.MeshSilence {
+	result := make([]*pb.MeshSilence, 0, len(silences))
+	for _, s := range silences {
+		if s.Silence == nil || s.Silence.CreatedBy != silenceCreatedBy {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// readSilencesFile reads the silences of a silences file, which has none if it does not exist.
+func readSilencesFile(filename string) ([]*pb.MeshSilence, error) {
+	b, err := ioutil.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var silences []*pb.MeshSilence
+	r := bytes.NewReader(b)
+	for {
+		var s pb.MeshSilence
+		if _, err := pbutil.ReadDelimited(r, &s); err != nil {
+			if errors.Is(err, io.EOF) {
+				return silences, nil
+			}
+			return nil, err
+		}
+		silences = append(silences, &s)
+	}
+}
+
+func writeSilencesFile(filename string, silences []*pb.MeshSilence) error {
 	var buf bytes.Buffer
-	for _, e := range m.silences {
+	for _, e := range silences {
 		if _, err := pbutil.WriteDelimited(&buf, e); err != nil {
 			return err
 		}
 	}
 
-	f, err := openReplace(silencesFileName(m.mg))
+	f, err := openReplace(filename)
 	if err != nil {
 		return err
 	}
@@ -70,8 +144,9 @@ func (m *migration) writeSilencesFile() error {
 	return f.Close()
 }
 
-func silencesFileName(mg *migrator.Migrator) string {
-	return filepath.Join(mg.Cfg.DataPath, "alerting", "silences")
+// silencesFileName returns the path of the silences file of the Alertmanager of the organization.
+func silencesFileName(mg *migrator.Migrator, orgID int64) string {
+	return filepath.Join(mg.Cfg.DataPath, "alerting", strconv.FormatInt(orgID, 10), "silences")
 }
 
 // replaceFile wraps a file that is moved to another filename on closing.
@@ -92,6 +167,9 @@ func (f *replaceFile) Close() error {
 
 // openReplace opens a new temporary file that is moved to filename on closing.
 func openReplace(filename string) (*replaceFile, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0750); err != nil {
+		return nil, err
+	}
 	tmpFilename := fmt.Sprintf("%s.%x", filename, uint64(rand.Int63()))
 
 	f, err := os.Create(tmpFilename)
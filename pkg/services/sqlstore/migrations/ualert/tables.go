@@ -19,6 +19,15 @@ func AddTablesMigrations(mg *migrator.Migrator) {
 
 	// Create Alertmanager configurations
 	AddAlertmanagerConfigMigrations(mg)
+
+	// Create alert_state_history
+	AddAlertStateHistoryMigrations(mg)
+
+	// Create provenance_type
+	AddProvisioningMigrations(mg)
+
+	// Create alert_scheduler_member
+	AddSchedulerMemberMigrations(mg)
 }
 
 // AddAlertDefinitionMigrations should not be modified.
@@ -201,6 +210,9 @@ func AddAlertRuleMigrations(mg *migrator.Migrator, defaultIntervalSeconds int64)
 	mg.AddMigration("add index in alert_rule on org_id, namespase_uid and title columns", migrator.NewAddIndexMigration(alertRule, &migrator.Index{
 		Cols: []string{"org_id", "namespace_uid", "title"}, Type: migrator.UniqueIndex,
 	}))
+
+	// add is_paused column
+	mg.AddMigration("add column is_paused to alert_rule", migrator.NewAddColumnMigration(alertRule, &migrator.Column{Name: "is_paused", Type: migrator.DB_Bool, Nullable: false, Default: "0"}))
 }
 
 func AddAlertRuleVersionMigrations(mg *migrator.Migrator) {
@@ -244,6 +256,9 @@ func AddAlertRuleVersionMigrations(mg *migrator.Migrator) {
 
 	// add labels column
 	mg.AddMigration("add column labels to alert_rule_version", migrator.NewAddColumnMigration(alertRuleVersion, &migrator.Column{Name: "labels", Type: migrator.DB_Text, Nullable: true}))
+
+	// add is_paused column
+	mg.AddMigration("add column is_paused to alert_rule_version", migrator.NewAddColumnMigration(alertRuleVersion, &migrator.Column{Name: "is_paused", Type: migrator.DB_Bool, Nullable: false, Default: "0"}))
 }
 
 func AddAlertmanagerConfigMigrations(mg *migrator.Migrator) {
@@ -264,4 +279,81 @@ func AddAlertmanagerConfigMigrations(mg *migrator.Migrator) {
 
 	mg.AddMigration("alert alert_configuration alertmanager_configuration column from TEXT to MEDIUMTEXT if mysql", migrator.NewRawSQLMigration("").
 		Mysql("ALTER TABLE alert_configuration MODIFY alertmanager_configuration MEDIUMTEXT;"))
+
+	// Each organization has its own Alertmanager configuration.
+	mg.AddMigration("add column org_id in alert_configuration", migrator.NewAddColumnMigration(alertConfiguration, &migrator.Column{
+		Name: "org_id", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+	}))
+	// The existing configuration was shared by all the organizations, it is kept by the main one.
+	mg.AddMigration("set org_id of existing alert_configuration to the main organization", migrator.NewRawSQLMigration(
+		"UPDATE alert_configuration SET org_id = (SELECT COALESCE(MIN(id), 1) FROM org) WHERE org_id = 0;"))
+	mg.AddMigration("add index in alert_configuration table on org_id column", migrator.NewAddIndexMigration(alertConfiguration, &migrator.Index{
+		Cols: []string{"org_id"},
+	}))
+}
+
+func AddAlertStateHistoryMigrations(mg *migrator.Migrator) {
+	alertStateHistory := migrator.Table{
+		Name: "alert_state_history",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "rule_org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "labels", Type: migrator.DB_Text, Nullable: false},
+			{Name: "labels_hash", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "previous_state", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "state", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "evaluation_string", Type: migrator.DB_Text, Nullable: true},
+			{Name: "error_message", Type: migrator.DB_Text, Nullable: true},
+			{Name: "evaluated_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"rule_org_id", "rule_uid", "evaluated_at"}, Type: migrator.IndexType},
+			{Cols: []string{"rule_org_id", "evaluated_at"}, Type: migrator.IndexType},
+			{Cols: []string{"evaluated_at"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_state_history table", migrator.NewAddTableMigration(alertStateHistory))
+	mg.AddMigration("add index in alert_state_history on rule_org_id, rule_uid and evaluated_at columns", migrator.NewAddIndexMigration(alertStateHistory, alertStateHistory.Indices[0]))
+	mg.AddMigration("add index in alert_state_history on rule_org_id and evaluated_at columns", migrator.NewAddIndexMigration(alertStateHistory, alertStateHistory.Indices[1]))
+	mg.AddMigration("add index in alert_state_history on evaluated_at column", migrator.NewAddIndexMigration(alertStateHistory, alertStateHistory.Indices[2]))
+}
+
+func AddProvisioningMigrations(mg *migrator.Migrator) {
+	provenanceType := migrator.Table{
+		Name: "provenance_type",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "record_key", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "record_type", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "provenance", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"record_type", "record_key", "org_id"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create provenance_type table", migrator.NewAddTableMigration(provenanceType))
+	mg.AddMigration("add index to uniquify (record_key, record_type, org_id) columns", migrator.NewAddIndexMigration(provenanceType, provenanceType.Indices[0]))
+}
+
+func AddSchedulerMemberMigrations(mg *migrator.Migrator) {
+	schedulerMember := migrator.Table{
+		Name: "alert_scheduler_member",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "member_id", Type: migrator.DB_NVarchar, Length: 190, Nullable: false},
+			{Name: "heartbeat", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"member_id"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"heartbeat"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_scheduler_member table", migrator.NewAddTableMigration(schedulerMember))
+	mg.AddMigration("add unique index in alert_scheduler_member on member_id column", migrator.NewAddIndexMigration(schedulerMember, schedulerMember.Indices[0]))
+	mg.AddMigration("add index in alert_scheduler_member on heartbeat column", migrator.NewAddIndexMigration(schedulerMember, schedulerMember.Indices[1]))
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	pb "github.com/prometheus/alertmanager/silence/silencepb"
@@ -56,6 +57,7 @@ func AddDashAlertMigration(mg *migrator.Migrator) {
 		mg.AddMigration(migTitle, &migration{
 			seenChannelUIDs:  make(map[string]struct{}),
 			migratedChannels: make(map[*notificationChannel]struct{}),
+			silences:         make(map[int64][]*pb.MeshSilence),
 		})
 	case !ngEnabled && migrationRun:
 		// Remove the migration entry that creates unified alerting data. This is so when the feature
@@ -76,7 +78,8 @@ type migration struct {
 
 	seenChannelUIDs  map[string]struct{}
 	migratedChannels map[*notificationChannel]struct{}
-	silences         []*pb.MeshSilence
+	// silences are the silences to create for each organization.
+	silences map[int64][]*pb.MeshSilence
 }
 
 func (m *migration) SQL(dialect migrator.Dialect) string {
@@ -104,14 +107,23 @@ func (m *migration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 		return err
 	}
 
-	// allChannels: channelUID -> channelConfig
+	// allChannels: orgID -> channelUID -> channelConfig
 	allChannels, defaultChannels, err := m.getNotificationChannelMap()
 	if err != nil {
 		return err
 	}
 
-	amConfig := PostableUserConfig{}
-	amConfig.AlertmanagerConfig.Route = &Route{}
+	// Each organization has its own Alertmanager configuration.
+	amConfigs := make(map[int64]*PostableUserConfig)
+	amConfigFor := func(orgID int64) *PostableUserConfig {
+		amConfig, ok := amConfigs[orgID]
+		if !ok {
+			amConfig = &PostableUserConfig{}
+			amConfig.AlertmanagerConfig.Route = &Route{}
+			amConfigs[orgID] = amConfig
+		}
+		return amConfig
+	}
 
 	for _, da := range dashAlerts {
 		newCond, err := transConditions(*da.ParsedSettings, da.OrgId, dsIDMap)
@@ -214,7 +226,7 @@ func (m *migration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 			return err
 		}
 
-		if err := m.updateReceiverAndRoute(allChannels, defaultChannels, da, rule, &amConfig); err != nil {
+		if err := m.updateReceiverAndRoute(allChannels[da.OrgId], defaultChannels[da.OrgId], da, rule, amConfigFor(da.OrgId)); err != nil {
 			return err
 		}
 
@@ -244,24 +256,29 @@ func (m *migration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 		}
 	}
 
-	// Create a separate receiver for all the unmigrated channels.
-	err = m.updateDefaultAndUnmigratedChannels(&amConfig, allChannels, defaultChannels)
-	if err != nil {
-		return err
-	}
+	for orgID, orgChannels := range allChannels {
+		amConfig := amConfigFor(orgID)
+		// Create a separate receiver for all the unmigrated channels.
+		err = m.updateDefaultAndUnmigratedChannels(amConfig, orgChannels, defaultChannels[orgID])
+		if err != nil {
+			return err
+		}
 
-	if err := m.writeAlertmanagerConfig(&amConfig, allChannels); err != nil {
-		return err
+		if err := m.writeAlertmanagerConfig(orgID, amConfig, orgChannels); err != nil {
+			return err
+		}
 	}
 
-	if err := m.writeSilencesFile(); err != nil {
-		m.mg.Logger.Error("alert migration error: failed to write silence file", "err", err)
+	for orgID := range m.silences {
+		if err := m.writeSilencesFile(orgID); err != nil {
+			m.mg.Logger.Error("alert migration error: failed to write silence file", "org", orgID, "err", err)
+		}
 	}
 
 	return nil
 }
 
-func (m *migration) writeAlertmanagerConfig(amConfig *PostableUserConfig, allChannels map[interface{}]*notificationChannel) error {
+func (m *migration) writeAlertmanagerConfig(orgID int64, amConfig *PostableUserConfig, allChannels map[interface{}]*notificationChannel) error {
 	if len(allChannels) == 0 {
 		// No channels, hence don't require Alertmanager config.
 		m.mg.Logger.Info("alert migration: no notification channel found, skipping Alertmanager config")
@@ -278,6 +295,7 @@ func (m *migration) writeAlertmanagerConfig(amConfig *PostableUserConfig, allCha
 
 	// TODO: should we apply the config here? Because Alertmanager can take upto 1 min to pick it up.
 	_, err = m.sess.Insert(AlertConfiguration{
+		OrgID:                     orgID,
 		AlertmanagerConfiguration: string(rawAmConfig),
 		// Since we are migration for a snapshot of the code, it is always going to migrate to
 		// the v1 config.
@@ -291,7 +309,8 @@ func (m *migration) writeAlertmanagerConfig(amConfig *PostableUserConfig, allCha
 }
 
 type AlertConfiguration struct {
-	ID int64 `xorm:"pk autoincr 'id'"`
+	ID    int64 `xorm:"pk autoincr 'id'"`
+	OrgID int64 `xorm:"org_id"`
 
 	AlertmanagerConfiguration string
 	ConfigurationVersion      string
@@ -307,6 +326,10 @@ func (m *rmMigration) SQL(dialect migrator.Dialect) string {
 }
 
 func (m *rmMigration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
+	if err := restorePausedDashAlerts(sess); err != nil {
+		return err
+	}
+
 	_, err := sess.Exec("delete from alert_rule")
 	if err != nil {
 		return err
@@ -337,9 +360,52 @@ func (m *rmMigration) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
 		return err
 	}
 
-	if err := os.RemoveAll(silencesFileName(mg)); err != nil {
-		mg.Logger.Error("alert migration error: failed to remove silence file", "err", err)
+	_, err = sess.Exec("delete from alert_state_history")
+	if err != nil {
+		return err
+	}
+
+	_, err = sess.Exec("delete from provenance_type")
+	if err != nil {
+		return err
 	}
 
+	// the silences created by the users in unified alerting are kept
+	return removeMigratedSilences(mg)
+}
+
+// restorePausedDashAlerts pauses the dashboard alerts whose migrated alert rules are paused,
+// and resumes the ones whose alert rules were resumed, so that switching back to the
+// legacy alerting keeps the paused state like the migration does.
+func restorePausedDashAlerts(sess *xorm.Session) error {
+	rules := []struct {
+		Annotations string `xorm:"annotations"`
+		IsPaused    bool   `xorm:"is_paused"`
+	}{}
+	if err := sess.SQL("select annotations, is_paused from alert_rule").Find(&rules); err != nil {
+		return err
+	}
+
+	for _, r := range rules {
+		var annotations map[string]string
+		if err := json.Unmarshal([]byte(r.Annotations), &annotations); err != nil {
+			// the alert rule is not one of the migrated dashboard alerts
+			continue
+		}
+		alertID, err := strconv.ParseInt(annotations["__alertId__"], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if r.IsPaused {
+			_, err = sess.Exec("update alert set state = ? where id = ?", "paused", alertID)
+		} else {
+			// resumed alerts are evaluated again from the unknown state, as when they are resumed in the legacy alerting
+			_, err = sess.Exec("update alert set state = ? where id = ? and state = ?", "unknown", alertID, "paused")
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
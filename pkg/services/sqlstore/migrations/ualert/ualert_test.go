@@ -0,0 +1,188 @@
+package ualert_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	pb "github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/services/sqlstore/sqlutil"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const dashAlertSettings = `{
+	"conditions": [{
+		"evaluator": {"params": [3], "type": "gt"},
+		"operator": {"type": "and"},
+		"query": {"datasourceId": 1, "model": {"refId": "A"}, "params": ["A", "5m", "now"]},
+		"reducer": {"type": "avg"}
+	}],
+	"noDataState": "no_data",
+	"executionErrorState": "alerting",
+	"notifications": [{"uid": "slack"}]
+}`
+
+type migratedRule struct {
+	UID         string `xorm:"uid"`
+	Title       string `xorm:"title"`
+	IsPaused    bool   `xorm:"is_paused"`
+	Annotations string `xorm:"annotations"`
+}
+
+func TestDashAlertMigration(t *testing.T) {
+	testDB := sqlutil.SQLite3TestDB()
+	x, err := xorm.NewEngine(testDB.DriverName, testDB.ConnStr)
+	require.NoError(t, err)
+	require.NoError(t, migrator.NewDialect(x).CleanDB())
+
+	// the data path is kept between the migrations, like the silences files in it
+	dataPath := t.TempDir()
+	runMigrations := func(ngEnabled bool) {
+		t.Helper()
+		mg := migrator.NewMigrator(x, &setting.Cfg{
+			DataPath:       dataPath,
+			FeatureToggles: map[string]bool{"ngalert": ngEnabled},
+		})
+		migrations.AddMigrations(mg)
+		require.NoError(t, mg.Start())
+	}
+	runMigrations(false)
+
+	now := time.Now()
+	_, err = x.Exec(`INSERT INTO dashboard (id, version, slug, title, data, org_id, created, updated, uid, folder_id, is_folder, has_acl)
+		VALUES (1, 1, 'dash', 'dash', '{}', 1, ?, ?, 'dash', 0, ?, ?)`, now, now, false, false)
+	require.NoError(t, err)
+	_, err = x.Exec(`INSERT INTO data_source (id, org_id, version, type, name, access, url, basic_auth, is_default, created, updated, uid)
+		VALUES (1, 1, 1, 'prometheus', 'prometheus', 'proxy', 'http://localhost:9090', ?, ?, ?, ?, 'prom')`, false, true, now, now)
+	require.NoError(t, err)
+	_, err = x.Exec(`INSERT INTO alert_notification (id, org_id, name, type, settings, created, updated, uid, is_default, disable_resolve_message, send_reminder, secure_settings)
+		VALUES (1, 1, 'slack', 'slack', '{"url": "http://localhost"}', ?, ?, 'slack', ?, ?, ?, '{}')`, now, now, false, false, false)
+	require.NoError(t, err)
+	for _, a := range []struct {
+		id       int64
+		name     string
+		state    string
+		silenced bool
+	}{{1, "running", "ok", false}, {2, "paused", "paused", false}, {3, "silenced", "alerting", true}} {
+		_, err = x.Exec(`INSERT INTO alert (id, version, dashboard_id, panel_id, org_id, name, message, state, settings, frequency, handler, severity, silenced, execution_error, eval_data, eval_date, new_state_date, state_changes, created, updated, "for")
+			VALUES (?, 0, 1, ?, 1, ?, '', ?, ?, 60, 1, '', ?, '', '{}', ?, ?, 0, ?, ?, 0)`, a.id, a.id, a.name, a.state, dashAlertSettings, a.silenced, now, now, now, now)
+		require.NoError(t, err)
+	}
+
+	getRules := func() map[string]migratedRule {
+		t.Helper()
+		var rules []migratedRule
+		require.NoError(t, x.SQL("SELECT uid, title, is_paused, annotations FROM alert_rule").Find(&rules))
+		result := make(map[string]migratedRule, len(rules))
+		for _, r := range rules {
+			result[r.Title] = r
+		}
+		return result
+	}
+	// the silences file of the organization has a silence created by a user, which the migrations keep
+	silencesFile := filepath.Join(dataPath, "alerting", "1", "silences")
+	require.NoError(t, os.MkdirAll(filepath.Dir(silencesFile), 0750))
+	var buf bytes.Buffer
+	_, err = pbutil.WriteDelimited(&buf, &pb.MeshSilence{
+		Silence:   &pb.Silence{Id: "user", CreatedBy: "admin", Matchers: []*pb.Matcher{{Type: pb.Matcher_EQUAL, Name: "team", Pattern: "ops"}}},
+		ExpiresAt: now.Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(silencesFile, buf.Bytes(), 0600))
+	getSilences := func() []*pb.MeshSilence {
+		t.Helper()
+		b, err := ioutil.ReadFile(silencesFile)
+		require.NoError(t, err)
+		var silences []*pb.MeshSilence
+		r := bytes.NewReader(b)
+		for {
+			var s pb.MeshSilence
+			_, err := pbutil.ReadDelimited(r, &s)
+			if errors.Is(err, io.EOF) {
+				return silences
+			}
+			require.NoError(t, err)
+			silences = append(silences, &s)
+		}
+	}
+
+	getState := func(alertID int64) string {
+		t.Helper()
+		var state string
+		_, err := x.SQL("SELECT state FROM alert WHERE id = ?", alertID).Get(&state)
+		require.NoError(t, err)
+		return state
+	}
+
+	t.Run("the dashboard alerts are migrated when unified alerting is enabled", func(t *testing.T) {
+		runMigrations(true)
+
+		rules := getRules()
+		require.Len(t, rules, 3)
+		require.False(t, rules["running"].IsPaused)
+		require.True(t, rules["paused"].IsPaused, "the paused state of the dashboard alerts is kept")
+		require.False(t, rules["silenced"].IsPaused)
+
+		silences := getSilences()
+		require.Len(t, silences, 2, "a silence is created for the silenced dashboard alerts")
+		require.Equal(t, "user", silences[0].Silence.Id)
+		require.Equal(t, "Grafana Migration", silences[1].Silence.CreatedBy)
+		require.Equal(t, []*pb.Matcher{{Type: pb.Matcher_EQUAL, Name: "rule_uid", Pattern: rules["silenced"].UID}}, silences[1].Silence.Matchers)
+		require.True(t, silences[1].Silence.EndsAt.After(now))
+
+		var annotations map[string]string
+		require.NoError(t, json.Unmarshal([]byte(rules["paused"].Annotations), &annotations))
+		require.Equal(t, "2", annotations["__alertId__"])
+
+		var configs []string
+		require.NoError(t, x.SQL("SELECT alertmanager_configuration FROM alert_configuration WHERE org_id = 1").Find(&configs))
+		require.Len(t, configs, 1)
+		require.Contains(t, configs[0], `"name":"slack"`, "the notification channels are migrated to receivers")
+	})
+
+	t.Run("the migration is reverted when unified alerting is disabled", func(t *testing.T) {
+		_, err := x.Exec("UPDATE alert_rule SET is_paused = ? WHERE title = ?", true, "running")
+		require.NoError(t, err)
+		_, err = x.Exec("UPDATE alert_rule SET is_paused = ? WHERE title = ?", false, "paused")
+		require.NoError(t, err)
+
+		runMigrations(false)
+
+		require.Empty(t, getRules())
+		count, err := x.Table("alert_configuration").Count()
+		require.NoError(t, err)
+		require.Zero(t, count)
+		require.Equal(t, "paused", getState(1), "the alert rules paused in unified alerting are paused")
+		require.Equal(t, "unknown", getState(2), "the alert rules resumed in unified alerting are resumed")
+
+		silences := getSilences()
+		require.Len(t, silences, 1, "only the silences created by the migration are removed")
+		require.Equal(t, "user", silences[0].Silence.Id)
+	})
+
+	t.Run("the dashboard alerts are migrated again when unified alerting is enabled again", func(t *testing.T) {
+		runMigrations(true)
+
+		rules := getRules()
+		require.Len(t, rules, 3)
+		require.True(t, rules["running"].IsPaused)
+		require.False(t, rules["paused"].IsPaused)
+
+		silences := getSilences()
+		require.Len(t, silences, 2)
+		require.Equal(t, "user", silences[0].Silence.Id)
+		require.Equal(t, rules["silenced"].UID, silences[1].Silence.Matchers[0].Pattern)
+	})
+}
@@ -241,6 +241,7 @@ func DeleteOrg(cmd *models.DeleteOrgCommand) error {
 			"DELETE FROM org_user WHERE org_id = ?",
 			"DELETE FROM org WHERE id = ?",
 			"DELETE FROM temp_user WHERE org_id = ?",
+			"DELETE FROM alert_configuration WHERE org_id = ?",
 		}
 
 		for _, sql := range deletes {
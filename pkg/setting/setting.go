@@ -51,6 +51,10 @@ const (
 	authProxySyncTTL = 60
 )
 
+// defaultDataSourceHealthCheckTimeout is the timeout of the health checks of the data sources
+// when health_check_timeout is not set or is not greater than 0.
+const defaultDataSourceHealthCheckTimeout = 30 * time.Second
+
 // zoneInfo names environment variable for setting the path to look for the timezone database in go
 const zoneInfo = "ZONEINFO"
 
@@ -353,6 +357,10 @@ type Cfg struct {
 
 	// Data sources
 	DataSourceLimit int
+	// DataSourceHealthCheckInterval is the interval between the health checks of the data sources, 0 disables them.
+	DataSourceHealthCheckInterval time.Duration
+	// DataSourceHealthCheckTimeout is the maximum duration of the health check of a data source.
+	DataSourceHealthCheckTimeout time.Duration
 
 	// Snapshots
 	SnapshotPublicMode bool
@@ -382,6 +390,9 @@ type Cfg struct {
 	// ExpressionsEnabled specifies whether expressions are enabled.
 	ExpressionsEnabled bool
 
+	// Unified alerting
+	UnifiedAlerting UnifiedAlertingSettings
+
 	ImageUploadProvider string
 
 	// LiveMaxConnections is a maximum number of WebSocket connections to
@@ -904,6 +915,10 @@ func (cfg *Cfg) Load(args *CommandLineArgs) error {
 		return err
 	}
 
+	if err := cfg.readUnifiedAlertingSettings(iniFile); err != nil {
+		return err
+	}
+
 	explore := iniFile.Section("explore")
 	ExploreEnabled = explore.Key("enabled").MustBool(true)
 
@@ -1453,6 +1468,12 @@ func (cfg *Cfg) GetContentDeliveryURL(prefix string) string {
 func (cfg *Cfg) readDataSourcesSettings() {
 	datasources := cfg.Raw.Section("datasources")
 	cfg.DataSourceLimit = datasources.Key("datasource_limit").MustInt(5000)
+	cfg.DataSourceHealthCheckInterval = datasources.Key("health_check_interval").MustDuration(0)
+	cfg.DataSourceHealthCheckTimeout = datasources.Key("health_check_timeout").MustDuration(defaultDataSourceHealthCheckTimeout)
+	if cfg.DataSourceHealthCheckTimeout <= 0 {
+		// the health checks would always time out
+		cfg.DataSourceHealthCheckTimeout = defaultDataSourceHealthCheckTimeout
+	}
 }
 
 func GetAllowedOriginGlobs(originPatterns []string) ([]glob.Glob, error) {
@@ -381,6 +381,46 @@ func TestAuthDurationSettings(t *testing.T) {
 	require.Equal(t, maxLifetimeDurationTest, cfg.LoginMaxLifetime)
 }
 
+func TestUnifiedAlertingSchedulerSettings(t *testing.T) {
+	cfg := NewCfg()
+	require.NoError(t, cfg.readUnifiedAlertingSettings(ini.Empty()))
+	require.Equal(t, 10*time.Second, cfg.UnifiedAlerting.BaseInterval)
+	require.Equal(t, time.Minute, cfg.UnifiedAlerting.ResendDelay)
+	require.Equal(t, 30*time.Second, cfg.UnifiedAlerting.EvaluationTimeout)
+	require.Equal(t, 0, cfg.UnifiedAlerting.MaxConcurrentEvaluations)
+
+	f := ini.Empty()
+	sec, err := f.NewSection("unified_alerting")
+	require.NoError(t, err)
+	_, err = sec.NewKey("base_interval", "15s")
+	require.NoError(t, err)
+	_, err = sec.NewKey("resend_delay", "5m")
+	require.NoError(t, err)
+	_, err = sec.NewKey("evaluation_timeout", "10s")
+	require.NoError(t, err)
+	_, err = sec.NewKey("max_concurrent_evaluations", "20")
+	require.NoError(t, err)
+	require.NoError(t, cfg.readUnifiedAlertingSettings(f))
+	require.Equal(t, 15*time.Second, cfg.UnifiedAlerting.BaseInterval)
+	require.Equal(t, 5*time.Minute, cfg.UnifiedAlerting.ResendDelay)
+	require.Equal(t, 10*time.Second, cfg.UnifiedAlerting.EvaluationTimeout)
+	require.Equal(t, 20, cfg.UnifiedAlerting.MaxConcurrentEvaluations)
+
+	for key, value := range map[string]string{
+		"base_interval":              "1500ms",
+		"resend_delay":               "0s",
+		"evaluation_timeout":         "-1s",
+		"max_concurrent_evaluations": "-1",
+	} {
+		f := ini.Empty()
+		sec, err := f.NewSection("unified_alerting")
+		require.NoError(t, err)
+		_, err = sec.NewKey(key, value)
+		require.NoError(t, err)
+		require.Error(t, cfg.readUnifiedAlertingSettings(f), key)
+	}
+}
+
 func TestGetCDNPath(t *testing.T) {
 	var err error
 	cfg := NewCfg()
@@ -417,3 +457,25 @@ func TestGetCDNPathWithAlphaVersion(t *testing.T) {
 	require.Equal(t, "http://cdn.grafana.com/grafana-oss/pre-releases/v7.5.0-alpha.11124/", cfg.GetContentDeliveryURL("grafana-oss"))
 	require.Equal(t, "http://cdn.grafana.com/grafana/pre-releases/v7.5.0-alpha.11124/", cfg.GetContentDeliveryURL("grafana"))
 }
+
+func TestDataSourcesHealthCheckSettings(t *testing.T) {
+	cfg := NewCfg()
+	cfg.Raw = ini.Empty()
+	cfg.readDataSourcesSettings()
+	require.Equal(t, time.Duration(0), cfg.DataSourceHealthCheckInterval)
+	require.Equal(t, 30*time.Second, cfg.DataSourceHealthCheckTimeout)
+
+	for value, expected := range map[string]time.Duration{
+		"10s": 10 * time.Second,
+		"0":   30 * time.Second,
+		"-1s": 30 * time.Second,
+	} {
+		cfg.Raw = ini.Empty()
+		sec, err := cfg.Raw.NewSection("datasources")
+		require.NoError(t, err)
+		_, err = sec.NewKey("health_check_timeout", value)
+		require.NoError(t, err)
+		cfg.readDataSourcesSettings()
+		require.Equal(t, expected, cfg.DataSourceHealthCheckTimeout, value)
+	}
+}
@@ -0,0 +1,121 @@
+package setting
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/ini.v1"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// UnifiedAlertingSettings are the settings of the unified alerting, from the unified_alerting section.
+type UnifiedAlertingSettings struct {
+	// StateHistoryRetention is for how long the state transitions of the alert instances are kept.
+	// 0 keeps them forever.
+	StateHistoryRetention time.Duration
+	// StateHistoryAnnotations enables the creation of an annotation for each state transition.
+	StateHistoryAnnotations bool
+	// SchedulerShardingEnabled distributes the evaluation of the alert rules among the instances of Grafana
+	// sharing the database, instead of evaluating every rule on every instance.
+	SchedulerShardingEnabled bool
+	// SchedulerShardingHeartbeatInterval is the interval at which the instances announce they are alive.
+	SchedulerShardingHeartbeatInterval time.Duration
+	// HAListenAddr is the address the Alertmanager listens on for the gossip of the cluster.
+	HAListenAddr string
+	// HAAdvertiseAddr is the address advertised to the other members of the cluster, when it differs
+	// from the listen address.
+	HAAdvertiseAddr string
+	// HAPeers are the addresses of the initial members of the cluster. The Alertmanager
+	// runs without clustering when there are none.
+	HAPeers []string
+	// HAPeerTimeout is the time to wait between the notifications of two consecutive members of the cluster.
+	HAPeerTimeout time.Duration
+	// HAGossipInterval is the interval between the gossip messages of the cluster.
+	HAGossipInterval time.Duration
+	// HAPushPullInterval is the interval between the full synchronizations of the state with a member of the cluster.
+	HAPushPullInterval time.Duration
+	// BaseInterval is the tick of the scheduler, the interval of every alert rule must be a multiple of it.
+	BaseInterval time.Duration
+	// ResendDelay is the minimum time between two notifications of an alert instance that is still firing.
+	ResendDelay time.Duration
+	// EvaluationTimeout is the maximum duration of the evaluation of an alert rule, after which the
+	// alert rule is in the Error state.
+	EvaluationTimeout time.Duration
+	// MaxConcurrentEvaluations is the maximum number of alert rules evaluated at the same time by the scheduler.
+	// 0 does not limit them.
+	MaxConcurrentEvaluations int
+}
+
+func (cfg *Cfg) readUnifiedAlertingSettings(iniFile *ini.File) error {
+	ua := iniFile.Section("unified_alerting")
+
+	retention, err := gtime.ParseDuration(valueAsString(ua, "state_history_retention", "30d"))
+	if err != nil {
+		return fmt.Errorf("invalid state_history_retention in unified_alerting: %w", err)
+	}
+	heartbeatInterval, err := readPositiveDuration(ua, "scheduler_sharding_heartbeat_interval", "10s")
+	if err != nil {
+		return err
+	}
+	haPeerTimeout, err := readPositiveDuration(ua, "ha_peer_timeout", "15s")
+	if err != nil {
+		return err
+	}
+	haGossipInterval, err := readPositiveDuration(ua, "ha_gossip_interval", "200ms")
+	if err != nil {
+		return err
+	}
+	haPushPullInterval, err := readPositiveDuration(ua, "ha_push_pull_interval", "60s")
+	if err != nil {
+		return err
+	}
+	baseInterval, err := readPositiveDuration(ua, "base_interval", "10s")
+	if err != nil {
+		return err
+	}
+	if baseInterval%time.Second != 0 {
+		return fmt.Errorf("invalid base_interval in unified_alerting: it must be a multiple of a second")
+	}
+	resendDelay, err := readPositiveDuration(ua, "resend_delay", "1m")
+	if err != nil {
+		return err
+	}
+	evaluationTimeout, err := readPositiveDuration(ua, "evaluation_timeout", "30s")
+	if err != nil {
+		return err
+	}
+	maxConcurrentEvaluations := ua.Key("max_concurrent_evaluations").MustInt(0)
+	if maxConcurrentEvaluations < 0 {
+		return fmt.Errorf("invalid max_concurrent_evaluations in unified_alerting: it must not be negative")
+	}
+	cfg.UnifiedAlerting = UnifiedAlertingSettings{
+		StateHistoryRetention:              retention,
+		StateHistoryAnnotations:            ua.Key("state_history_annotations").MustBool(false),
+		SchedulerShardingEnabled:           ua.Key("scheduler_sharding_enabled").MustBool(false),
+		SchedulerShardingHeartbeatInterval: heartbeatInterval,
+		HAListenAddr:                       valueAsString(ua, "ha_listen_address", "0.0.0.0:9094"),
+		HAAdvertiseAddr:                    valueAsString(ua, "ha_advertise_address", ""),
+		HAPeers:                            util.SplitString(valueAsString(ua, "ha_peers", "")),
+		HAPeerTimeout:                      haPeerTimeout,
+		HAGossipInterval:                   haGossipInterval,
+		HAPushPullInterval:                 haPushPullInterval,
+		BaseInterval:                       baseInterval,
+		ResendDelay:                        resendDelay,
+		EvaluationTimeout:                  evaluationTimeout,
+		MaxConcurrentEvaluations:           maxConcurrentEvaluations,
+	}
+	return nil
+}
+
+func readPositiveDuration(section *ini.Section, keyName string, defaultValue string) (time.Duration, error) {
+	d, err := gtime.ParseDuration(valueAsString(section, keyName, defaultValue))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s in %s: %w", keyName, section.Name(), err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid %s in %s: it must be positive", keyName, section.Name())
+	}
+	return d, nil
+}
@@ -738,7 +738,8 @@ func TestDeleteFolderWithRules(t *testing.T) {
 								"namespace_id": 1,
 								"rule_group": "arulegroup",
 								"no_data_state": "NoData",
-								"exec_err_state": "Alerting"
+								"exec_err_state": "Alerting",
+								"is_paused": false
 							}
 						}
 					]
@@ -1198,7 +1199,8 @@ func TestAlertRuleCRUD(t *testing.T) {
 						  "namespace_id":1,
 						  "rule_group":"arulegroup",
 						  "no_data_state":"NoData",
-						  "exec_err_state":"Alerting"
+						  "exec_err_state":"Alerting",
+						  "is_paused":false
 					   }
 					},
 					{
@@ -1233,7 +1235,8 @@ func TestAlertRuleCRUD(t *testing.T) {
 						  "namespace_id":1,
 						  "rule_group":"arulegroup",
 						  "no_data_state":"Alerting",
-						  "exec_err_state":"Alerting"
+						  "exec_err_state":"Alerting",
+						  "is_paused":false
 					   }
 					}
 				 ]
@@ -1460,7 +1463,8 @@ func TestAlertRuleCRUD(t *testing.T) {
 		                  "namespace_id":1,
 		                  "rule_group":"arulegroup",
 		                  "no_data_state":"Alerting",
-		                  "exec_err_state":"Alerting"
+		                  "exec_err_state":"Alerting",
+		                  "is_paused":false
 		               }
 		            }
 		         ]
@@ -1584,7 +1588,8 @@ func TestAlertRuleCRUD(t *testing.T) {
 					  "namespace_id":1,
 					  "rule_group":"arulegroup",
 					  "no_data_state":"Alerting",
-					  "exec_err_state":"Alerting"
+					  "exec_err_state":"Alerting",
+					  "is_paused":false
 				       }
 				    }
 				 ]
@@ -1683,7 +1688,8 @@ func TestAlertRuleCRUD(t *testing.T) {
 					  "namespace_id":1,
 					  "rule_group":"arulegroup",
 					  "no_data_state":"Alerting",
-					  "exec_err_state":"Alerting"
+					  "exec_err_state":"Alerting",
+					  "is_paused":false
 				       }
 				    }
 				 ]
@@ -1791,6 +1797,57 @@ func TestAlertmanagerStatus(t *testing.T) {
 	}
 }
 
+func TestNotificationTemplates(t *testing.T) {
+	// Setup Grafana and its Database
+	dir, path := testinfra.CreateGrafDir(t, testinfra.GrafanaOpts{
+		EnableFeatureToggles: []string{"ngalert"},
+		DisableAnonymous:     true,
+	})
+	store := testinfra.SetUpDatabase(t, dir)
+	// override bus to get the GetSignedInUserQuery handler
+	store.Bus = bus.GetBus()
+	grafanaListedAddr := testinfra.StartGrafana(t, dir, path, store)
+	require.NoError(t, createUser(t, store, models.ROLE_EDITOR, "editor", "editor"))
+
+	templatesURL := fmt.Sprintf("http://editor:editor@%s/api/alertmanager/grafana/config/api/v1/templates", grafanaListedAddr)
+	doRequest := func(t *testing.T, method, url, body string, expStatusCode int) {
+		t.Helper()
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, resp.Body.Close())
+		})
+		require.Equal(t, expStatusCode, resp.StatusCode, getBody(t, resp.Body))
+	}
+	templateVersion := func(t *testing.T) int64 {
+		t.Helper()
+		resp := getRequest(t, templatesURL+"/slack", http.StatusOK)
+		var template apimodels.NotificationTemplate
+		require.NoError(t, json.Unmarshal([]byte(getBody(t, resp.Body)), &template))
+		return template.Version
+	}
+
+	doRequest(t, http.MethodPut, templatesURL+"/slack", `{"template": "{{ define \"slack.title\" }}v1{{ end }}"}`, http.StatusAccepted)
+	version := templateVersion(t)
+
+	t.Run("a template is updated when it is the latest version", func(t *testing.T) {
+		doRequest(t, http.MethodPut, templatesURL+"/slack", fmt.Sprintf(`{"template": "{{ define \"slack.title\" }}v2{{ end }}", "version": %d}`, version), http.StatusAccepted)
+	})
+
+	t.Run("a template is not updated nor deleted when the configuration has changed", func(t *testing.T) {
+		doRequest(t, http.MethodPut, templatesURL+"/slack", fmt.Sprintf(`{"template": "{{ define \"slack.title\" }}v3{{ end }}", "version": %d}`, version), http.StatusConflict)
+		doRequest(t, http.MethodDelete, fmt.Sprintf("%s/slack?version=%d", templatesURL, version), "", http.StatusConflict)
+	})
+
+	t.Run("a template is deleted when it is the latest version", func(t *testing.T) {
+		doRequest(t, http.MethodDelete, fmt.Sprintf("%s/slack?version=%d", templatesURL, templateVersion(t)), "", http.StatusOK)
+		getRequest(t, templatesURL+"/slack", http.StatusNotFound)
+	})
+}
+
 func TestQuota(t *testing.T) {
 	// Setup Grafana and its Database
 	dir, path := testinfra.CreateGrafDir(t, testinfra.GrafanaOpts{
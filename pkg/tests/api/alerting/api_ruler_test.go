@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/grafana/pkg/models"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/tests/testinfra"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
@@ -109,7 +110,8 @@ func TestAlertRulePermissions(t *testing.T) {
 						  "namespace_id":1,
 						  "rule_group":"arulegroup",
 						  "no_data_state":"NoData",
-						  "exec_err_state":"Alerting"
+						  "exec_err_state":"Alerting",
+						  "is_paused":false
 					   }
 					}
 				 ]
@@ -159,7 +161,8 @@ func TestAlertRulePermissions(t *testing.T) {
 						"namespace_id":2,
 						"rule_group":"arulegroup",
 						"no_data_state":"NoData",
-						"exec_err_state":"Alerting"
+						"exec_err_state":"Alerting",
+						"is_paused":false
 					 }
 				  }
 			   ]
@@ -231,7 +234,8 @@ func TestAlertRulePermissions(t *testing.T) {
 						  "namespace_id":1,
 						  "rule_group":"arulegroup",
 						  "no_data_state":"NoData",
-						  "exec_err_state":"Alerting"
+						  "exec_err_state":"Alerting",
+						  "is_paused":false
 					   }
 					}
 				 ]
@@ -418,3 +422,60 @@ func TestAlertRuleConflictingTitle(t *testing.T) {
 		require.JSONEq(t, `{"message":"rule group updated successfully"}`, string(b))
 	})
 }
+
+func TestImportPrometheusRules(t *testing.T) {
+	// Setup Grafana and its Database
+	dir, path := testinfra.CreateGrafDir(t, testinfra.GrafanaOpts{
+		EnableFeatureToggles: []string{"ngalert"},
+		DisableAnonymous:     true,
+	})
+	store := testinfra.SetUpDatabase(t, dir)
+	// override bus to get the GetSignedInUserQuery handler
+	store.Bus = bus.GetBus()
+	grafanaListedAddr := testinfra.StartGrafana(t, dir, path, store)
+
+	require.NoError(t, createUser(t, store, models.ROLE_ADMIN, "admin", "admin"))
+	_, err := createFolder(t, store, 0, "folder1")
+	require.NoError(t, err)
+	require.NoError(t, sqlstore.AddDataSource(&models.AddDataSourceCommand{
+		OrgId:  1,
+		Name:   "prometheus",
+		Type:   "prometheus",
+		Access: models.DS_ACCESS_PROXY,
+		Uid:    "prom",
+	}))
+
+	ruleFile := `
+groups:
+  - name: targets
+    rules:
+      - alert: TargetDown
+        expr: up == 0
+      - alert: TargetsMissing
+        expr: count(up) < 3
+`
+	u := fmt.Sprintf("http://admin:admin@%s/api/ruler/grafana/api/v1/import/prometheus/folder1?datasourceUID=prom", grafanaListedAddr)
+	ruleUIDs := func(t *testing.T) []string {
+		t.Helper()
+		resp := getRequest(t, fmt.Sprintf("http://admin:admin@%s/api/ruler/grafana/api/v1/rules/folder1", grafanaListedAddr), http.StatusAccepted)
+		var groups map[string][]apimodels.GettableRuleGroupConfig
+		require.NoError(t, json.Unmarshal([]byte(getBody(t, resp.Body)), &groups))
+		require.Len(t, groups["folder1"], 1)
+		uids := make([]string, 0, len(groups["folder1"][0].Rules))
+		for _, r := range groups["folder1"][0].Rules {
+			uids = append(uids, r.GrafanaManagedAlert.UID)
+		}
+		return uids
+	}
+
+	resp := postRequest(t, u, ruleFile, http.StatusAccepted)
+	require.JSONEq(t, `{"message":"rule groups imported successfully","groups":["targets"]}`, getBody(t, resp.Body))
+	imported := ruleUIDs(t)
+	require.Len(t, imported, 2)
+
+	t.Run("importing the same file again updates the alert rules", func(t *testing.T) {
+		resp := postRequest(t, u, ruleFile, http.StatusAccepted)
+		require.JSONEq(t, `{"message":"rule groups imported successfully","groups":["targets"]}`, getBody(t, resp.Body))
+		require.ElementsMatch(t, imported, ruleUIDs(t))
+	})
+}
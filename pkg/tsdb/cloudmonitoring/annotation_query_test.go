@@ -3,8 +3,7 @@ package cloudmonitoring
 import (
 	"testing"
 
-	"github.com/grafana/grafana/pkg/components/simplejson"
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,16 +13,14 @@ func TestExecutor_parseToAnnotations(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, d.TimeSeries, 3)
 
-	//nolint: staticcheck // plugins.DataPlugin deprecated
-	res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "annotationQuery"}
+	res := &backend.DataResponse{}
 	query := &cloudMonitoringTimeSeriesFilter{}
 
 	err = query.parseToAnnotations(res, d, "atitle {{metric.label.instance_name}} {{metric.value}}",
 		"atext {{resource.label.zone}}", "atag")
 	require.NoError(t, err)
 
-	decoded, err := res.Dataframes.Decoded()
-	require.NoError(t, err)
+	decoded := res.Frames
 	require.Len(t, decoded, 3)
 	assert.Equal(t, "title", decoded[0].Fields[1].Name)
 	assert.Equal(t, "tags", decoded[0].Fields[2].Name)
@@ -31,8 +28,7 @@ func TestExecutor_parseToAnnotations(t *testing.T) {
 }
 
 func TestCloudMonitoringExecutor_parseToAnnotations_emptyTimeSeries(t *testing.T) {
-	//nolint: staticcheck // plugins.DataPlugin deprecated
-	res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "annotationQuery"}
+	res := &backend.DataResponse{}
 	query := &cloudMonitoringTimeSeriesFilter{}
 
 	response := cloudMonitoringResponse{
@@ -42,14 +38,12 @@ func TestCloudMonitoringExecutor_parseToAnnotations_emptyTimeSeries(t *testing.T
 	err := query.parseToAnnotations(res, response, "atitle", "atext", "atag")
 	require.NoError(t, err)
 
-	decoded, err := res.Dataframes.Decoded()
-	require.NoError(t, err)
+	decoded := res.Frames
 	require.Len(t, decoded, 0)
 }
 
 func TestCloudMonitoringExecutor_parseToAnnotations_noPointsInSeries(t *testing.T) {
-	//nolint: staticcheck // plugins.DataPlugin deprecated
-	res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "annotationQuery"}
+	res := &backend.DataResponse{}
 	query := &cloudMonitoringTimeSeriesFilter{}
 
 	response := cloudMonitoringResponse{
@@ -61,6 +55,6 @@ func TestCloudMonitoringExecutor_parseToAnnotations_noPointsInSeries(t *testing.
 	err := query.parseToAnnotations(res, response, "atitle", "atext", "atag")
 	require.NoError(t, err)
 
-	decoded, _ := res.Dataframes.Decoded()
+	decoded := res.Frames
 	require.Len(t, decoded, 0)
 }
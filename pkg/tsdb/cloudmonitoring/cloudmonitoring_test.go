@@ -12,18 +12,17 @@ import (
 	"testing"
 	"time"
 
-	"github.com/grafana/grafana/pkg/components/simplejson"
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestCloudMonitoring(t *testing.T) {
-	executor := &Executor{}
+	service := &Service{}
 
 	t.Run("Parse migrated queries from frontend and build Google Cloud Monitoring API queries", func(t *testing.T) {
 		t.Run("and query has no aggregation set", func(t *testing.T) {
-			qes, err := executor.buildQueryExecutors(getBaseQuery())
+			qes, err := service.buildQueryExecutors(getBaseQuery(t))
 			require.NoError(t, err)
 			queries := getCloudMonitoringQueriesFromInterface(t, qes)
 
@@ -58,13 +57,13 @@ func TestCloudMonitoring(t *testing.T) {
 		})
 
 		t.Run("and query has filters", func(t *testing.T) {
-			query := getBaseQuery()
-			query.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+			query := getBaseQuery(t)
+			query.Queries[0].JSON = queryModel(t, map[string]interface{}{
 				"metricType": "a/metric/type",
 				"filters":    []interface{}{"key", "=", "value", "AND", "key2", "=", "value2", "AND", "resource.type", "=", "another/resource/type"},
 			})
 
-			qes, err := executor.buildQueryExecutors(query)
+			qes, err := service.buildQueryExecutors(query)
 			require.NoError(t, err)
 			queries := getCloudMonitoringQueriesFromInterface(t, qes)
 			assert.Equal(t, 1, len(queries))
@@ -89,14 +88,14 @@ func TestCloudMonitoring(t *testing.T) {
 
 		t.Run("and alignmentPeriod is set to grafana-auto", func(t *testing.T) {
 			t.Run("and IntervalMS is larger than 60000", func(t *testing.T) {
-				tsdbQuery := getBaseQuery()
-				tsdbQuery.Queries[0].IntervalMS = 1000000
-				tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+				tsdbQuery := getBaseQuery(t)
+				tsdbQuery.Queries[0].Interval = 1000000 * time.Millisecond
+				tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 					"alignmentPeriod": "grafana-auto",
 					"filters":         []interface{}{"key", "=", "value", "AND", "key2", "=", "value2"},
 				})
 
-				qes, err := executor.buildQueryExecutors(tsdbQuery)
+				qes, err := service.buildQueryExecutors(tsdbQuery)
 				require.NoError(t, err)
 				queries := getCloudMonitoringQueriesFromInterface(t, qes)
 				assert.Equal(t, `+1000s`, queries[0].Params["aggregation.alignmentPeriod"][0])
@@ -117,14 +116,14 @@ func TestCloudMonitoring(t *testing.T) {
 				verifyDeepLink(t, dl, expectedTimeSelection, expectedTimeSeriesFilter)
 			})
 			t.Run("and IntervalMS is less than 60000", func(t *testing.T) {
-				tsdbQuery := getBaseQuery()
-				tsdbQuery.Queries[0].IntervalMS = 30000
-				tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+				tsdbQuery := getBaseQuery(t)
+				tsdbQuery.Queries[0].Interval = 30000 * time.Millisecond
+				tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 					"alignmentPeriod": "grafana-auto",
 					"filters":         []interface{}{"key", "=", "value", "AND", "key2", "=", "value2"},
 				})
 
-				qes, err := executor.buildQueryExecutors(tsdbQuery)
+				qes, err := service.buildQueryExecutors(tsdbQuery)
 				require.NoError(t, err)
 				queries := getCloudMonitoringQueriesFromInterface(t, qes)
 				assert.Equal(t, `+60s`, queries[0].Params["aggregation.alignmentPeriod"][0])
@@ -148,60 +147,68 @@ func TestCloudMonitoring(t *testing.T) {
 
 		t.Run("and alignmentPeriod is set to cloud-monitoring-auto", func(t *testing.T) { // legacy
 			t.Run("and range is two hours", func(t *testing.T) {
-				tsdbQuery := getBaseQuery()
-				tsdbQuery.TimeRange.From = "1538033322461"
-				tsdbQuery.TimeRange.To = "1538040522461"
-				tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+				tsdbQuery := getBaseQuery(t)
+				tsdbQuery.Queries[0].TimeRange = backend.TimeRange{
+					From: time.Unix(0, 1538033322461*int64(time.Millisecond)),
+					To:   time.Unix(0, 1538040522461*int64(time.Millisecond)),
+				}
+				tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 					"target":          "target",
 					"alignmentPeriod": "cloud-monitoring-auto",
 				})
 
-				qes, err := executor.buildQueryExecutors(tsdbQuery)
+				qes, err := service.buildQueryExecutors(tsdbQuery)
 				require.NoError(t, err)
 				queries := getCloudMonitoringQueriesFromInterface(t, qes)
 				assert.Equal(t, `+60s`, queries[0].Params["aggregation.alignmentPeriod"][0])
 			})
 
 			t.Run("and range is 22 hours", func(t *testing.T) {
-				tsdbQuery := getBaseQuery()
-				tsdbQuery.TimeRange.From = "1538034524922"
-				tsdbQuery.TimeRange.To = "1538113724922"
-				tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+				tsdbQuery := getBaseQuery(t)
+				tsdbQuery.Queries[0].TimeRange = backend.TimeRange{
+					From: time.Unix(0, 1538034524922*int64(time.Millisecond)),
+					To:   time.Unix(0, 1538113724922*int64(time.Millisecond)),
+				}
+				tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 					"target":          "target",
 					"alignmentPeriod": "cloud-monitoring-auto",
 				})
 
-				qes, err := executor.buildQueryExecutors(tsdbQuery)
+				qes, err := service.buildQueryExecutors(tsdbQuery)
 				require.NoError(t, err)
 				queries := getCloudMonitoringQueriesFromInterface(t, qes)
 				assert.Equal(t, `+60s`, queries[0].Params["aggregation.alignmentPeriod"][0])
 			})
 
 			t.Run("and range is 23 hours", func(t *testing.T) {
-				tsdbQuery := getBaseQuery()
-				tsdbQuery.TimeRange.From = "1538034567985"
-				tsdbQuery.TimeRange.To = "1538117367985"
-				tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+				tsdbQuery := getBaseQuery(t)
+				tsdbQuery.Queries[0].TimeRange = backend.TimeRange{
+					From: time.Unix(0, 1538034567985*int64(time.Millisecond)),
+					To:   time.Unix(0, 1538117367985*int64(time.Millisecond)),
+				}
+				tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 					"target":          "target",
 					"alignmentPeriod": "cloud-monitoring-auto",
 				})
 
-				qes, err := executor.buildQueryExecutors(tsdbQuery)
+				qes, err := service.buildQueryExecutors(tsdbQuery)
 				require.NoError(t, err)
 				queries := getCloudMonitoringQueriesFromInterface(t, qes)
 				assert.Equal(t, `+300s`, queries[0].Params["aggregation.alignmentPeriod"][0])
 			})
 
 			t.Run("and range is 7 days", func(t *testing.T) {
-				tsdbQuery := getBaseQuery()
-				tsdbQuery.TimeRange.From = "1538036324073"
-				tsdbQuery.TimeRange.To = "1538641124073"
-				tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+				tsdbQuery := getBaseQuery(t)
+				tsdbQuery.Queries[0].TimeRange = backend.TimeRange{
+					From: time.Unix(0, 1538036324073*int64(time.Millisecond)),
+					To:   time.Unix(0, 1538641124073*int64(time.Millisecond)),
+				}
+				tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 					"target":          "target",
 					"alignmentPeriod": "cloud-monitoring-auto",
 				})
 
-				qes, err := executor.buildQueryExecutors(tsdbQuery)
+				qes, err := service.buildQueryExecutors(tsdbQuery)
 				require.NoError(t, err)
 				queries := getCloudMonitoringQueriesFromInterface(t, qes)
 				assert.Equal(t, `+3600s`, queries[0].Params["aggregation.alignmentPeriod"][0])
@@ -210,15 +217,17 @@ func TestCloudMonitoring(t *testing.T) {
 
 		t.Run("and alignmentPeriod is set to stackdriver-auto", func(t *testing.T) { // legacy
 			t.Run("and range is two hours", func(t *testing.T) {
-				tsdbQuery := getBaseQuery()
-				tsdbQuery.TimeRange.From = "1538033322461"
-				tsdbQuery.TimeRange.To = "1538040522461"
-				tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+				tsdbQuery := getBaseQuery(t)
+				tsdbQuery.Queries[0].TimeRange = backend.TimeRange{
+					From: time.Unix(0, 1538033322461*int64(time.Millisecond)),
+					To:   time.Unix(0, 1538040522461*int64(time.Millisecond)),
+				}
+				tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 					"target":          "target",
 					"alignmentPeriod": "stackdriver-auto",
 				})
 
-				qes, err := executor.buildQueryExecutors(tsdbQuery)
+				qes, err := service.buildQueryExecutors(tsdbQuery)
 				require.NoError(t, err)
 				queries := getCloudMonitoringQueriesFromInterface(t, qes)
 				assert.Equal(t, `+60s`, queries[0].Params["aggregation.alignmentPeriod"][0])
@@ -240,15 +249,17 @@ func TestCloudMonitoring(t *testing.T) {
 			})
 
 			t.Run("and range is 22 hours", func(t *testing.T) {
-				tsdbQuery := getBaseQuery()
-				tsdbQuery.TimeRange.From = "1538034524922"
-				tsdbQuery.TimeRange.To = "1538113724922"
-				tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+				tsdbQuery := getBaseQuery(t)
+				tsdbQuery.Queries[0].TimeRange = backend.TimeRange{
+					From: time.Unix(0, 1538034524922*int64(time.Millisecond)),
+					To:   time.Unix(0, 1538113724922*int64(time.Millisecond)),
+				}
+				tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 					"target":          "target",
 					"alignmentPeriod": "stackdriver-auto",
 				})
 
-				qes, err := executor.buildQueryExecutors(tsdbQuery)
+				qes, err := service.buildQueryExecutors(tsdbQuery)
 				require.NoError(t, err)
 				queries := getCloudMonitoringQueriesFromInterface(t, qes)
 				assert.Equal(t, `+60s`, queries[0].Params["aggregation.alignmentPeriod"][0])
@@ -270,15 +281,17 @@ func TestCloudMonitoring(t *testing.T) {
 			})
 
 			t.Run("and range is 23 hours", func(t *testing.T) {
-				tsdbQuery := getBaseQuery()
-				tsdbQuery.TimeRange.From = "1538034567985"
-				tsdbQuery.TimeRange.To = "1538117367985"
-				tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+				tsdbQuery := getBaseQuery(t)
+				tsdbQuery.Queries[0].TimeRange = backend.TimeRange{
+					From: time.Unix(0, 1538034567985*int64(time.Millisecond)),
+					To:   time.Unix(0, 1538117367985*int64(time.Millisecond)),
+				}
+				tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 					"target":          "target",
 					"alignmentPeriod": "stackdriver-auto",
 				})
 
-				qes, err := executor.buildQueryExecutors(tsdbQuery)
+				qes, err := service.buildQueryExecutors(tsdbQuery)
 				require.NoError(t, err)
 				queries := getCloudMonitoringQueriesFromInterface(t, qes)
 				assert.Equal(t, `+300s`, queries[0].Params["aggregation.alignmentPeriod"][0])
@@ -300,15 +313,17 @@ func TestCloudMonitoring(t *testing.T) {
 			})
 
 			t.Run("and range is 7 days", func(t *testing.T) {
-				tsdbQuery := getBaseQuery()
-				tsdbQuery.TimeRange.From = "1538036324073"
-				tsdbQuery.TimeRange.To = "1538641124073"
-				tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+				tsdbQuery := getBaseQuery(t)
+				tsdbQuery.Queries[0].TimeRange = backend.TimeRange{
+					From: time.Unix(0, 1538036324073*int64(time.Millisecond)),
+					To:   time.Unix(0, 1538641124073*int64(time.Millisecond)),
+				}
+				tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 					"target":          "target",
 					"alignmentPeriod": "stackdriver-auto",
 				})
 
-				qes, err := executor.buildQueryExecutors(tsdbQuery)
+				qes, err := service.buildQueryExecutors(tsdbQuery)
 				require.NoError(t, err)
 				queries := getCloudMonitoringQueriesFromInterface(t, qes)
 				assert.Equal(t, `+3600s`, queries[0].Params["aggregation.alignmentPeriod"][0])
@@ -332,13 +347,13 @@ func TestCloudMonitoring(t *testing.T) {
 
 		t.Run("and alignmentPeriod is set in frontend", func(t *testing.T) {
 			t.Run("and alignment period is within accepted range", func(t *testing.T) {
-				tsdbQuery := getBaseQuery()
-				tsdbQuery.Queries[0].IntervalMS = 1000
-				tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+				tsdbQuery := getBaseQuery(t)
+				tsdbQuery.Queries[0].Interval = 1000 * time.Millisecond
+				tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 					"alignmentPeriod": "+600s",
 				})
 
-				qes, err := executor.buildQueryExecutors(tsdbQuery)
+				qes, err := service.buildQueryExecutors(tsdbQuery)
 				require.NoError(t, err)
 				queries := getCloudMonitoringQueriesFromInterface(t, qes)
 				assert.Equal(t, `+600s`, queries[0].Params["aggregation.alignmentPeriod"][0])
@@ -361,14 +376,14 @@ func TestCloudMonitoring(t *testing.T) {
 		})
 
 		t.Run("and query has aggregation mean set", func(t *testing.T) {
-			tsdbQuery := getBaseQuery()
-			tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+			tsdbQuery := getBaseQuery(t)
+			tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 				"metricType":         "a/metric/type",
 				"crossSeriesReducer": "REDUCE_SUM",
 				"view":               "FULL",
 			})
 
-			qes, err := executor.buildQueryExecutors(tsdbQuery)
+			qes, err := service.buildQueryExecutors(tsdbQuery)
 			require.NoError(t, err)
 			queries := getCloudMonitoringQueriesFromInterface(t, qes)
 
@@ -404,15 +419,15 @@ func TestCloudMonitoring(t *testing.T) {
 		})
 
 		t.Run("and query has group bys", func(t *testing.T) {
-			tsdbQuery := getBaseQuery()
-			tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+			tsdbQuery := getBaseQuery(t)
+			tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 				"metricType":         "a/metric/type",
 				"crossSeriesReducer": "REDUCE_NONE",
 				"groupBys":           []interface{}{"metric.label.group1", "metric.label.group2"},
 				"view":               "FULL",
 			})
 
-			qes, err := executor.buildQueryExecutors(tsdbQuery)
+			qes, err := service.buildQueryExecutors(tsdbQuery)
 			require.NoError(t, err)
 			queries := getCloudMonitoringQueriesFromInterface(t, qes)
 
@@ -450,14 +465,14 @@ func TestCloudMonitoring(t *testing.T) {
 
 	t.Run("Parse queries from frontend and build Google Cloud Monitoring API queries", func(t *testing.T) {
 		fromStart := time.Date(2018, 3, 15, 13, 0, 0, 0, time.UTC).In(time.Local)
-		tsdbQuery := plugins.DataQuery{
-			TimeRange: &plugins.DataTimeRange{
-				From: fmt.Sprintf("%v", fromStart.Unix()*1000),
-				To:   fmt.Sprintf("%v", fromStart.Add(34*time.Minute).Unix()*1000),
-			},
-			Queries: []plugins.DataSubQuery{
+		tsdbQuery := &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{
 				{
-					Model: simplejson.NewFromAny(map[string]interface{}{
+					TimeRange: backend.TimeRange{
+						From: fromStart,
+						To:   fromStart.Add(34 * time.Minute),
+					},
+					JSON: queryModel(t, map[string]interface{}{
 						"queryType": metricQueryType,
 						"metricQuery": map[string]interface{}{
 							"metricType": "a/metric/type",
@@ -473,7 +488,7 @@ func TestCloudMonitoring(t *testing.T) {
 		}
 
 		t.Run("and query type is metrics", func(t *testing.T) {
-			qes, err := executor.buildQueryExecutors(tsdbQuery)
+			qes, err := service.buildQueryExecutors(tsdbQuery)
 			require.NoError(t, err)
 			queries := getCloudMonitoringQueriesFromInterface(t, qes)
 
@@ -509,7 +524,7 @@ func TestCloudMonitoring(t *testing.T) {
 			}
 			verifyDeepLink(t, dl, expectedTimeSelection, expectedTimeSeriesFilter)
 
-			tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+			tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 				"queryType": metricQueryType,
 				"metricQuery": map[string]interface{}{
 					"editorMode":  mqlEditorMode,
@@ -520,7 +535,7 @@ func TestCloudMonitoring(t *testing.T) {
 				"sloQuery": map[string]interface{}{},
 			})
 
-			qes, err = executor.buildQueryExecutors(tsdbQuery)
+			qes, err = service.buildQueryExecutors(tsdbQuery)
 			require.NoError(t, err)
 			tqueries := make([]*cloudMonitoringTimeSeriesQuery, 0)
 			for _, qi := range qes {
@@ -537,7 +552,7 @@ func TestCloudMonitoring(t *testing.T) {
 		})
 
 		t.Run("and query type is SLOs", func(t *testing.T) {
-			tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+			tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 				"queryType":   sloQueryType,
 				"metricQuery": map[string]interface{}{},
 				"sloQuery": map[string]interface{}{
@@ -551,7 +566,7 @@ func TestCloudMonitoring(t *testing.T) {
 				},
 			})
 
-			qes, err := executor.buildQueryExecutors(tsdbQuery)
+			qes, err := service.buildQueryExecutors(tsdbQuery)
 			require.NoError(t, err)
 			queries := getCloudMonitoringQueriesFromInterface(t, qes)
 
@@ -565,7 +580,7 @@ func TestCloudMonitoring(t *testing.T) {
 			assert.Equal(t, `aggregation.alignmentPeriod=%2B60s&aggregation.perSeriesAligner=ALIGN_MEAN&filter=select_slo_health%28%22projects%2Ftest-proj%2Fservices%2Ftest-service%2FserviceLevelObjectives%2Ftest-slo%22%29&interval.endTime=2018-03-15T13%3A34%3A00Z&interval.startTime=2018-03-15T13%3A00%3A00Z`, queries[0].Target)
 			assert.Equal(t, 5, len(queries[0].Params))
 
-			tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+			tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 				"queryType":   sloQueryType,
 				"metricQuery": map[string]interface{}{},
 				"sloQuery": map[string]interface{}{
@@ -579,7 +594,7 @@ func TestCloudMonitoring(t *testing.T) {
 				},
 			})
 
-			qes, err = executor.buildQueryExecutors(tsdbQuery)
+			qes, err = service.buildQueryExecutors(tsdbQuery)
 			require.NoError(t, err)
 			qqueries := getCloudMonitoringQueriesFromInterface(t, qes)
 			assert.Equal(t, "ALIGN_NEXT_OLDER", qqueries[0].Params["aggregation.perSeriesAligner"][0])
@@ -595,13 +610,11 @@ func TestCloudMonitoring(t *testing.T) {
 			require.NoError(t, err)
 			assert.Equal(t, 1, len(data.TimeSeries))
 
-			//nolint: staticcheck // plugins.DataPlugin deprecated
-			res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+			res := &backend.DataResponse{}
 			query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}}
 			err = query.parseResponse(res, data, "")
 			require.NoError(t, err)
-			frames, err := res.Dataframes.Decoded()
-			require.NoError(t, err)
+			frames := res.Frames
 			require.Len(t, frames, 1)
 			assert.Equal(t, "serviceruntime.googleapis.com/api/request_count", frames[0].Fields[1].Name)
 			assert.Equal(t, 3, frames[0].Fields[1].Len())
@@ -620,13 +633,11 @@ func TestCloudMonitoring(t *testing.T) {
 			data, err := loadTestFile("./test-data/2-series-response-no-agg.json")
 			require.NoError(t, err)
 			assert.Equal(t, 3, len(data.TimeSeries))
-			//nolint: staticcheck // plugins.DataPlugin deprecated
-			res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+			res := &backend.DataResponse{}
 			query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}}
 			err = query.parseResponse(res, data, "")
 			require.NoError(t, err)
-			frames, err := res.Dataframes.Decoded()
-			require.NoError(t, err)
+			frames := res.Frames
 
 			assert.Equal(t, 3, len(frames))
 			assert.Equal(t, "compute.googleapis.com/instance/cpu/usage_time collector-asia-east-1", frames[0].Fields[1].Name)
@@ -638,7 +649,7 @@ func TestCloudMonitoring(t *testing.T) {
 			assert.Equal(t, 9.7323568146676, frames[0].Fields[1].At(1))
 			assert.Equal(t, 9.7730520330369, frames[0].Fields[1].At(2))
 
-			labels := res.Meta.Get("labels").Interface().(map[string][]string)
+			labels := res.Frames[0].Meta.Custom.(map[string]interface{})["labels"].(map[string][]string)
 			require.NotNil(t, labels)
 			assert.Equal(t, 3, len(labels["metric.label.instance_name"]))
 			assert.Contains(t, labels["metric.label.instance_name"], "collector-asia-east-1")
@@ -658,15 +669,13 @@ func TestCloudMonitoring(t *testing.T) {
 			data, err := loadTestFile("./test-data/2-series-response-no-agg.json")
 			require.NoError(t, err)
 			assert.Equal(t, 3, len(data.TimeSeries))
-			//nolint: staticcheck // plugins.DataPlugin deprecated
-			res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+			res := &backend.DataResponse{}
 			query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}, GroupBys: []string{
 				"metric.label.instance_name", "resource.label.zone",
 			}}
 			err = query.parseResponse(res, data, "")
 			require.NoError(t, err)
-			frames, err := res.Dataframes.Decoded()
-			require.NoError(t, err)
+			frames := res.Frames
 
 			assert.Equal(t, 3, len(frames))
 			assert.Equal(t, "compute.googleapis.com/instance/cpu/usage_time collector-asia-east-1 asia-east1-a", frames[0].Fields[1].Name)
@@ -678,15 +687,13 @@ func TestCloudMonitoring(t *testing.T) {
 			data, err := loadTestFile("./test-data/2-series-response-no-agg.json")
 			require.NoError(t, err)
 			assert.Equal(t, 3, len(data.TimeSeries))
-			//nolint: staticcheck // plugins.DataPlugin deprecated
-			res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+			res := &backend.DataResponse{}
 
 			t.Run("and the alias pattern is for metric type, a metric label and a resource label", func(t *testing.T) {
 				query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}, AliasBy: "{{metric.type}} - {{metric.label.instance_name}} - {{resource.label.zone}}", GroupBys: []string{"metric.label.instance_name", "resource.label.zone"}}
 				err = query.parseResponse(res, data, "")
 				require.NoError(t, err)
-				frames, err := res.Dataframes.Decoded()
-				require.NoError(t, err)
+				frames := res.Frames
 
 				assert.Equal(t, 3, len(frames))
 				assert.Equal(t, "compute.googleapis.com/instance/cpu/usage_time - collector-asia-east-1 - asia-east1-a", frames[0].Fields[1].Name)
@@ -698,8 +705,7 @@ func TestCloudMonitoring(t *testing.T) {
 				query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}, AliasBy: "metric {{metric.name}} service {{metric.service}}", GroupBys: []string{"metric.label.instance_name", "resource.label.zone"}}
 				err = query.parseResponse(res, data, "")
 				require.NoError(t, err)
-				frames, err := res.Dataframes.Decoded()
-				require.NoError(t, err)
+				frames := res.Frames
 
 				assert.Equal(t, 3, len(frames))
 				assert.Equal(t, "metric instance/cpu/usage_time service compute", frames[0].Fields[1].Name)
@@ -712,13 +718,11 @@ func TestCloudMonitoring(t *testing.T) {
 			data, err := loadTestFile("./test-data/3-series-response-distribution-exponential.json")
 			require.NoError(t, err)
 			assert.Equal(t, 1, len(data.TimeSeries))
-			//nolint: staticcheck // plugins.DataPlugin deprecated
-			res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+			res := &backend.DataResponse{}
 			query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}, AliasBy: "{{bucket}}"}
 			err = query.parseResponse(res, data, "")
 			require.NoError(t, err)
-			frames, err := res.Dataframes.Decoded()
-			require.NoError(t, err)
+			frames := res.Frames
 			assert.Equal(t, 11, len(frames))
 			for i := 0; i < 11; i++ {
 				if i == 0 {
@@ -754,13 +758,11 @@ func TestCloudMonitoring(t *testing.T) {
 			data, err := loadTestFile("./test-data/4-series-response-distribution-explicit.json")
 			require.NoError(t, err)
 			assert.Equal(t, 1, len(data.TimeSeries))
-			//nolint: staticcheck // plugins.DataPlugin deprecated
-			res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+			res := &backend.DataResponse{}
 			query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}, AliasBy: "{{bucket}}"}
 			err = query.parseResponse(res, data, "")
 			require.NoError(t, err)
-			frames, err := res.Dataframes.Decoded()
-			require.NoError(t, err)
+			frames := res.Frames
 			assert.Equal(t, 33, len(frames))
 			for i := 0; i < 33; i++ {
 				if i == 0 {
@@ -789,14 +791,12 @@ func TestCloudMonitoring(t *testing.T) {
 			data, err := loadTestFile("./test-data/5-series-response-meta-data.json")
 			require.NoError(t, err)
 			assert.Equal(t, 3, len(data.TimeSeries))
-			//nolint: staticcheck // plugins.DataPlugin deprecated
-			res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+			res := &backend.DataResponse{}
 			query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}, AliasBy: "{{bucket}}"}
 			err = query.parseResponse(res, data, "")
 			require.NoError(t, err)
-			labels := res.Meta.Get("labels").Interface().(map[string][]string)
-			frames, err := res.Dataframes.Decoded()
-			require.NoError(t, err)
+			labels := res.Frames[0].Meta.Custom.(map[string]interface{})["labels"].(map[string][]string)
+			frames := res.Frames
 			assert.Equal(t, 3, len(frames))
 
 			assert.Equal(t, 5, len(labels["metadata.system_labels.test"]))
@@ -825,13 +825,11 @@ func TestCloudMonitoring(t *testing.T) {
 			assert.Equal(t, 3, len(data.TimeSeries))
 
 			t.Run("and systemlabel contains key with array of string", func(t *testing.T) {
-				//nolint: staticcheck // plugins.DataPlugin deprecated
-				res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+				res := &backend.DataResponse{}
 				query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}, AliasBy: "{{metadata.system_labels.test}}"}
 				err = query.parseResponse(res, data, "")
 				require.NoError(t, err)
-				frames, err := res.Dataframes.Decoded()
-				require.NoError(t, err)
+				frames := res.Frames
 				assert.Equal(t, 3, len(frames))
 				fmt.Println(frames[0].Fields[1].Name)
 				assert.Equal(t, "value1, value2", frames[0].Fields[1].Name)
@@ -840,13 +838,11 @@ func TestCloudMonitoring(t *testing.T) {
 			})
 
 			t.Run("and systemlabel contains key with array of string2", func(t *testing.T) {
-				//nolint: staticcheck // plugins.DataPlugin deprecated
-				res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+				res := &backend.DataResponse{}
 				query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}, AliasBy: "{{metadata.system_labels.test2}}"}
 				err = query.parseResponse(res, data, "")
 				require.NoError(t, err)
-				frames, err := res.Dataframes.Decoded()
-				require.NoError(t, err)
+				frames := res.Frames
 				assert.Equal(t, 3, len(frames))
 				assert.Equal(t, "testvalue", frames[2].Fields[1].Name)
 			})
@@ -858,8 +854,7 @@ func TestCloudMonitoring(t *testing.T) {
 			assert.Equal(t, 1, len(data.TimeSeries))
 
 			t.Run("and alias by is expanded", func(t *testing.T) {
-				//nolint: staticcheck // plugins.DataPlugin deprecated
-				res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+				res := &backend.DataResponse{}
 				query := &cloudMonitoringTimeSeriesFilter{
 					Params:      url.Values{},
 					ProjectName: "test-proj",
@@ -870,8 +865,7 @@ func TestCloudMonitoring(t *testing.T) {
 				}
 				err = query.parseResponse(res, data, "")
 				require.NoError(t, err)
-				frames, err := res.Dataframes.Decoded()
-				require.NoError(t, err)
+				frames := res.Frames
 				assert.Equal(t, "test-proj - test-service - test-slo - select_slo_compliance", frames[0].Fields[1].Name)
 			})
 		})
@@ -882,8 +876,7 @@ func TestCloudMonitoring(t *testing.T) {
 			assert.Equal(t, 1, len(data.TimeSeries))
 
 			t.Run("and alias by is expanded", func(t *testing.T) {
-				//nolint: staticcheck // plugins.DataPlugin deprecated
-				res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+				res := &backend.DataResponse{}
 				query := &cloudMonitoringTimeSeriesFilter{
 					Params:      url.Values{},
 					ProjectName: "test-proj",
@@ -893,8 +886,7 @@ func TestCloudMonitoring(t *testing.T) {
 				}
 				err = query.parseResponse(res, data, "")
 				require.NoError(t, err)
-				frames, err := res.Dataframes.Decoded()
-				require.NoError(t, err)
+				frames := res.Frames
 				assert.Equal(t, "select_slo_compliance(\"projects/test-proj/services/test-service/serviceLevelObjectives/test-slo\")", frames[0].Fields[1].Name)
 			})
 		})
@@ -904,13 +896,11 @@ func TestCloudMonitoring(t *testing.T) {
 				data, err := loadTestFile("./test-data/1-series-response-agg-one-metric.json")
 				require.NoError(t, err)
 				assert.Equal(t, 1, len(data.TimeSeries))
-				//nolint: staticcheck // plugins.DataPlugin deprecated
-				res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+				res := &backend.DataResponse{}
 				query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}}
 				err = query.parseResponse(res, data, "")
 				require.NoError(t, err)
-				frames, err := res.Dataframes.Decoded()
-				require.NoError(t, err)
+				frames := res.Frames
 				assert.Equal(t, "Bps", frames[0].Fields[1].Config.Unit)
 			})
 
@@ -918,13 +908,11 @@ func TestCloudMonitoring(t *testing.T) {
 				data, err := loadTestFile("./test-data/2-series-response-no-agg.json")
 				require.NoError(t, err)
 				assert.Equal(t, 3, len(data.TimeSeries))
-				//nolint: staticcheck // plugins.DataPlugin deprecated
-				res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+				res := &backend.DataResponse{}
 				query := &cloudMonitoringTimeSeriesFilter{Params: url.Values{}}
 				err = query.parseResponse(res, data, "")
 				require.NoError(t, err)
-				frames, err := res.Dataframes.Decoded()
-				require.NoError(t, err)
+				frames := res.Frames
 				assert.Equal(t, "", frames[0].Fields[1].Config.Unit)
 			})
 		})
@@ -937,21 +925,19 @@ func TestCloudMonitoring(t *testing.T) {
 
 			t.Run("and alias by is expanded", func(t *testing.T) {
 				fromStart := time.Date(2018, 3, 15, 13, 0, 0, 0, time.UTC).In(time.Local)
-				//nolint: staticcheck // plugins.DataPlugin deprecated
-				res := &plugins.DataQueryResult{Meta: simplejson.New(), RefID: "A"}
+				res := &backend.DataResponse{}
 				query := &cloudMonitoringTimeSeriesQuery{
 					ProjectName: "test-proj",
 					Query:       "test-query",
 					AliasBy:     "{{project}} - {{resource.label.zone}} - {{resource.label.instance_id}}",
-					timeRange: plugins.DataTimeRange{
-						From: fmt.Sprintf("%v", fromStart.Unix()*1000),
-						To:   fmt.Sprintf("%v", fromStart.Add(34*time.Minute).Unix()*1000),
+					timeRange: backend.TimeRange{
+						From: fromStart,
+						To:   fromStart.Add(34 * time.Minute),
 					},
 				}
 				err = query.parseResponse(res, data, "")
 				require.NoError(t, err)
-				frames, err := res.Dataframes.Decoded()
-				require.NoError(t, err)
+				frames := res.Frames
 				assert.Equal(t, "test-proj - asia-northeast1-c - 6724404429462225363", frames[0].Fields[1].Name)
 			})
 		})
@@ -1045,8 +1031,8 @@ func TestCloudMonitoring(t *testing.T) {
 	})
 
 	t.Run("and query preprocessor is not defined", func(t *testing.T) {
-		tsdbQuery := getBaseQuery()
-		tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+		tsdbQuery := getBaseQuery(t)
+		tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 			"metricType":         "a/metric/type",
 			"crossSeriesReducer": "REDUCE_MIN",
 			"perSeriesAligner":   "REDUCE_SUM",
@@ -1055,7 +1041,7 @@ func TestCloudMonitoring(t *testing.T) {
 			"view":               "FULL",
 		})
 
-		qes, err := executor.buildQueryExecutors(tsdbQuery)
+		qes, err := service.buildQueryExecutors(tsdbQuery)
 		require.NoError(t, err)
 		queries := getCloudMonitoringQueriesFromInterface(t, qes)
 
@@ -1072,8 +1058,8 @@ func TestCloudMonitoring(t *testing.T) {
 	})
 
 	t.Run("and query preprocessor is set to none", func(t *testing.T) {
-		tsdbQuery := getBaseQuery()
-		tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+		tsdbQuery := getBaseQuery(t)
+		tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 			"metricType":         "a/metric/type",
 			"crossSeriesReducer": "REDUCE_MIN",
 			"perSeriesAligner":   "REDUCE_SUM",
@@ -1083,7 +1069,7 @@ func TestCloudMonitoring(t *testing.T) {
 			"preprocessor":       "none",
 		})
 
-		qes, err := executor.buildQueryExecutors(tsdbQuery)
+		qes, err := service.buildQueryExecutors(tsdbQuery)
 		require.NoError(t, err)
 		queries := getCloudMonitoringQueriesFromInterface(t, qes)
 
@@ -1100,8 +1086,8 @@ func TestCloudMonitoring(t *testing.T) {
 	})
 
 	t.Run("and query preprocessor is set to rate and there's no group bys", func(t *testing.T) {
-		tsdbQuery := getBaseQuery()
-		tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+		tsdbQuery := getBaseQuery(t)
+		tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 			"metricType":         "a/metric/type",
 			"crossSeriesReducer": "REDUCE_SUM",
 			"perSeriesAligner":   "REDUCE_MIN",
@@ -1111,7 +1097,7 @@ func TestCloudMonitoring(t *testing.T) {
 			"preprocessor":       "rate",
 		})
 
-		qes, err := executor.buildQueryExecutors(tsdbQuery)
+		qes, err := service.buildQueryExecutors(tsdbQuery)
 		require.NoError(t, err)
 		queries := getCloudMonitoringQueriesFromInterface(t, qes)
 
@@ -1126,8 +1112,8 @@ func TestCloudMonitoring(t *testing.T) {
 	})
 
 	t.Run("and query preprocessor is set to rate and group bys exist", func(t *testing.T) {
-		tsdbQuery := getBaseQuery()
-		tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+		tsdbQuery := getBaseQuery(t)
+		tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 			"metricType":         "a/metric/type",
 			"crossSeriesReducer": "REDUCE_SUM",
 			"perSeriesAligner":   "REDUCE_MIN",
@@ -1137,7 +1123,7 @@ func TestCloudMonitoring(t *testing.T) {
 			"preprocessor":       "rate",
 		})
 
-		qes, err := executor.buildQueryExecutors(tsdbQuery)
+		qes, err := service.buildQueryExecutors(tsdbQuery)
 		require.NoError(t, err)
 		queries := getCloudMonitoringQueriesFromInterface(t, qes)
 
@@ -1154,8 +1140,8 @@ func TestCloudMonitoring(t *testing.T) {
 	})
 
 	t.Run("and query preprocessor is set to delta and there's no group bys", func(t *testing.T) {
-		tsdbQuery := getBaseQuery()
-		tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+		tsdbQuery := getBaseQuery(t)
+		tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 			"metricType":         "a/metric/type",
 			"crossSeriesReducer": "REDUCE_MIN",
 			"perSeriesAligner":   "REDUCE_SUM",
@@ -1165,7 +1151,7 @@ func TestCloudMonitoring(t *testing.T) {
 			"preprocessor":       "delta",
 		})
 
-		qes, err := executor.buildQueryExecutors(tsdbQuery)
+		qes, err := service.buildQueryExecutors(tsdbQuery)
 		require.NoError(t, err)
 		queries := getCloudMonitoringQueriesFromInterface(t, qes)
 
@@ -1180,8 +1166,8 @@ func TestCloudMonitoring(t *testing.T) {
 	})
 
 	t.Run("and query preprocessor is set to delta and group bys exist", func(t *testing.T) {
-		tsdbQuery := getBaseQuery()
-		tsdbQuery.Queries[0].Model = simplejson.NewFromAny(map[string]interface{}{
+		tsdbQuery := getBaseQuery(t)
+		tsdbQuery.Queries[0].JSON = queryModel(t, map[string]interface{}{
 			"metricType":         "a/metric/type",
 			"crossSeriesReducer": "REDUCE_MIN",
 			"perSeriesAligner":   "REDUCE_SUM",
@@ -1191,7 +1177,7 @@ func TestCloudMonitoring(t *testing.T) {
 			"preprocessor":       "delta",
 		})
 
-		qes, err := executor.buildQueryExecutors(tsdbQuery)
+		qes, err := service.buildQueryExecutors(tsdbQuery)
 		require.NoError(t, err)
 		queries := getCloudMonitoringQueriesFromInterface(t, qes)
 
@@ -1294,16 +1280,16 @@ func verifyDeepLink(t *testing.T, dl string, expectedTimeSelection map[string]st
 	}
 }
 
-func getBaseQuery() plugins.DataQuery {
+func getBaseQuery(t *testing.T) *backend.QueryDataRequest {
 	fromStart := time.Date(2018, 3, 15, 13, 0, 0, 0, time.UTC).In(time.Local)
-	query := plugins.DataQuery{
-		TimeRange: &plugins.DataTimeRange{
-			From: fmt.Sprintf("%v", fromStart.Unix()*1000),
-			To:   fmt.Sprintf("%v", fromStart.Add(34*time.Minute).Unix()*1000),
-		},
-		Queries: []plugins.DataSubQuery{
+	query := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{
 			{
-				Model: simplejson.NewFromAny(map[string]interface{}{
+				TimeRange: backend.TimeRange{
+					From: fromStart,
+					To:   fromStart.Add(34 * time.Minute),
+				},
+				JSON: queryModel(t, map[string]interface{}{
 					"metricType": "a/metric/type",
 					"view":       "FULL",
 					"aliasBy":    "testalias",
@@ -1315,3 +1301,11 @@ func getBaseQuery() plugins.DataQuery {
 	}
 	return query
 }
+
+func queryModel(t *testing.T, model map[string]interface{}) json.RawMessage {
+	t.Helper()
+
+	b, err := json.Marshal(model)
+	require.NoError(t, err)
+	return b
+}
@@ -2,10 +2,12 @@ package tsdb
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/adapters"
@@ -15,7 +17,7 @@ import (
 // nolint:staticcheck // plugins.DataQuery deprecated
 func dataPluginQueryAdapter(pluginID string, handler backend.QueryDataHandler, oAuthService *oauthtoken.Service) plugins.DataPluginFunc {
 	return plugins.DataPluginFunc(func(ctx context.Context, ds *models.DataSource, query plugins.DataQuery) (plugins.DataResponse, error) {
-		instanceSettings, err := modelToInstanceSettings(ds)
+		instanceSettings, err := adapters.ModelToInstanceSettings(ds)
 		if err != nil {
 			return plugins.DataResponse{}, err
 		}
@@ -78,6 +80,13 @@ func dataPluginQueryAdapter(pluginID string, handler backend.QueryDataHandler, o
 				if f.RefID == "" {
 					f.RefID = refID
 				}
+				// The data sources querying through the legacy API read the custom
+				// meta data of the frames from the meta data of the query result.
+				if f.Meta != nil && f.Meta.Custom != nil {
+					if err := addCustomMeta(&qr, f.Meta.Custom); err != nil {
+						return plugins.DataResponse{}, err
+					}
+				}
 			}
 
 			qr.Dataframes = plugins.NewDecodedDataFrames(r.Frames)
@@ -93,23 +102,28 @@ func dataPluginQueryAdapter(pluginID string, handler backend.QueryDataHandler, o
 	})
 }
 
-func modelToInstanceSettings(ds *models.DataSource) (*backend.DataSourceInstanceSettings, error) {
-	jsonDataBytes, err := ds.JsonData.MarshalJSON()
+// addCustomMeta merges the custom meta data of a frame into the meta data of the query result.
+// nolint:staticcheck // plugins.DataQueryResult deprecated
+func addCustomMeta(qr *plugins.DataQueryResult, custom interface{}) error {
+	b, err := json.Marshal(custom)
+	if err != nil {
+		return err
+	}
+	meta, err := simplejson.NewJson(b)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	values, err := meta.Map()
+	if err != nil {
+		// only the meta data with fields can be merged
+		return nil
 	}
 
-	return &backend.DataSourceInstanceSettings{
-		ID:                      ds.Id,
-		Name:                    ds.Name,
-		URL:                     ds.Url,
-		Database:                ds.Database,
-		User:                    ds.User,
-		BasicAuthEnabled:        ds.BasicAuth,
-		BasicAuthUser:           ds.BasicAuthUser,
-		JSONData:                jsonDataBytes,
-		DecryptedSecureJSONData: ds.DecryptedValues(),
-		Updated:                 ds.Updated,
-		UID:                     ds.Uid,
-	}, nil
+	if qr.Meta == nil {
+		qr.Meta = simplejson.New()
+	}
+	for k, v := range values {
+		qr.Meta.Set(k, v)
+	}
+	return nil
 }
@@ -14,13 +14,12 @@ import (
 	"time"
 
 	"github.com/Masterminds/semver"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/components/simplejson"
-	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/tsdb/interval"
 
 	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/plugins"
 	"golang.org/x/net/context/ctxhttp"
 )
 
@@ -30,8 +29,13 @@ var (
 	clientLog = log.New(loggerName)
 )
 
-var newDatasourceHttpClient = func(httpClientProvider httpclient.Provider, ds *models.DataSource) (*http.Client, error) {
-	return ds.GetHTTPClient(httpClientProvider)
+// DatasourceInfo represents the settings of an elasticsearch data source instance
+type DatasourceInfo struct {
+	ID         int64
+	HTTPClient *http.Client
+	URL        string
+	Database   string
+	JSONData   *simplejson.Json
 }
 
 // Client represents a client which can interact with elasticsearch api
@@ -75,25 +79,19 @@ func coerceVersion(v *simplejson.Json) (*semver.Version, error) {
 }
 
 // NewClient creates a new elasticsearch client
-var NewClient = func(ctx context.Context, httpClientProvider httpclient.Provider, ds *models.DataSource, timeRange plugins.DataTimeRange) (Client, error) {
-	version, err := coerceVersion(ds.JsonData.Get("esVersion"))
+var NewClient = func(ctx context.Context, ds *DatasourceInfo, timeRange backend.TimeRange) (Client, error) {
+	version, err := coerceVersion(ds.JSONData.Get("esVersion"))
 
 	if err != nil {
 		return nil, fmt.Errorf("elasticsearch version is required, err=%v", err)
 	}
 
-	timeField, err := ds.JsonData.Get("timeField").String()
+	timeField, err := ds.JSONData.Get("timeField").String()
 	if err != nil {
 		return nil, fmt.Errorf("elasticsearch time field name is required, err=%v", err)
 	}
 
-	indexInterval := ds.JsonData.Get("interval").MustString()
-	ip, err := newIndexPattern(indexInterval, ds.Database)
-	if err != nil {
-		return nil, err
-	}
-
-	indices, err := ip.GetIndices(timeRange)
+	indices, err := GetIndices(ds, timeRange)
 	if err != nil {
 		return nil, err
 	}
@@ -101,25 +99,32 @@ var NewClient = func(ctx context.Context, httpClientProvider httpclient.Provider
 	clientLog.Info("Creating new client", "version", version.String(), "timeField", timeField, "indices", strings.Join(indices, ", "))
 
 	return &baseClientImpl{
-		ctx:                ctx,
-		httpClientProvider: httpClientProvider,
-		ds:                 ds,
-		version:            version,
-		timeField:          timeField,
-		indices:            indices,
-		timeRange:          timeRange,
+		ctx:       ctx,
+		ds:        ds,
+		version:   version,
+		timeField: timeField,
+		indices:   indices,
+		timeRange: timeRange,
 	}, nil
 }
 
+// GetIndices returns the indices of the index pattern of the data source for the time range.
+func GetIndices(ds *DatasourceInfo, timeRange backend.TimeRange) ([]string, error) {
+	ip, err := newIndexPattern(ds.JSONData.Get("interval").MustString(), ds.Database)
+	if err != nil {
+		return nil, err
+	}
+	return ip.GetIndices(timeRange)
+}
+
 type baseClientImpl struct {
-	ctx                context.Context
-	httpClientProvider httpclient.Provider
-	ds                 *models.DataSource
-	version            *semver.Version
-	timeField          string
-	indices            []string
-	timeRange          plugins.DataTimeRange
-	debugEnabled       bool
+	ctx          context.Context
+	ds           *DatasourceInfo
+	version      *semver.Version
+	timeField    string
+	indices      []string
+	timeRange    backend.TimeRange
+	debugEnabled bool
 }
 
 func (c *baseClientImpl) GetVersion() *semver.Version {
@@ -131,13 +136,13 @@ func (c *baseClientImpl) GetTimeField() string {
 }
 
 func (c *baseClientImpl) GetMinInterval(queryInterval string) (time.Duration, error) {
-	return interval.GetIntervalFrom(c.ds, simplejson.NewFromAny(map[string]interface{}{
+	return interval.GetIntervalFrom(&models.DataSource{JsonData: c.ds.JSONData}, simplejson.NewFromAny(map[string]interface{}{
 		"interval": queryInterval,
 	}), 5*time.Second)
 }
 
 func (c *baseClientImpl) getSettings() *simplejson.Json {
-	return c.ds.JsonData
+	return c.ds.JSONData
 }
 
 type multiRequest struct {
@@ -185,7 +190,7 @@ func (c *baseClientImpl) encodeBatchRequests(requests []*multiRequest) ([]byte,
 }
 
 func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body []byte) (*response, error) {
-	u, err := url.Parse(c.ds.Url)
+	u, err := url.Parse(c.ds.URL)
 	if err != nil {
 		return nil, err
 	}
@@ -215,18 +220,13 @@ func (c *baseClientImpl) executeRequest(method, uriPath, uriQuery string, body [
 
 	req.Header.Set("Content-Type", "application/x-ndjson")
 
-	httpClient, err := newDatasourceHttpClient(c.httpClientProvider, c.ds)
-	if err != nil {
-		return nil, err
-	}
-
 	start := time.Now()
 	defer func() {
 		elapsed := time.Since(start)
 		clientLog.Debug("Executed request", "took", elapsed)
 	}()
 	//nolint:bodyclose
-	resp, err := ctxhttp.Do(c.ctx, httpClient, req)
+	resp, err := ctxhttp.Do(c.ctx, c.ds.HTTPClient, req)
 	if err != nil {
 		return nil, err
 	}
@@ -3,17 +3,14 @@ package es
 import (
 	"bytes"
 	"context"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/components/simplejson"
-	"github.com/grafana/grafana/pkg/infra/httpclient"
-	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/tsdb/interval"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,99 +18,99 @@ import (
 
 func TestNewClient(t *testing.T) {
 	t.Run("When no version set should return error", func(t *testing.T) {
-		ds := &models.DataSource{
-			JsonData: simplejson.NewFromAny(make(map[string]interface{})),
+		ds := &DatasourceInfo{
+			JSONData: simplejson.NewFromAny(make(map[string]interface{})),
 		}
 
-		_, err := NewClient(context.Background(), httpclient.NewProvider(), ds, plugins.DataTimeRange{})
+		_, err := NewClient(context.Background(), ds, backend.TimeRange{})
 		require.Error(t, err)
 	})
 
 	t.Run("When no time field name set should return error", func(t *testing.T) {
-		ds := &models.DataSource{
-			JsonData: simplejson.NewFromAny(map[string]interface{}{
+		ds := &DatasourceInfo{
+			JSONData: simplejson.NewFromAny(map[string]interface{}{
 				"esVersion": 5,
 			}),
 		}
 
-		_, err := NewClient(context.Background(), httpclient.NewProvider(), ds, plugins.DataTimeRange{})
+		_, err := NewClient(context.Background(), ds, backend.TimeRange{})
 		require.Error(t, err)
 	})
 
 	t.Run("When using legacy version numbers", func(t *testing.T) {
 		t.Run("When unsupported version set should return error", func(t *testing.T) {
-			ds := &models.DataSource{
-				JsonData: simplejson.NewFromAny(map[string]interface{}{
+			ds := &DatasourceInfo{
+				JSONData: simplejson.NewFromAny(map[string]interface{}{
 					"esVersion": 6,
 					"timeField": "@timestamp",
 				}),
 			}
 
-			_, err := NewClient(context.Background(), httpclient.NewProvider(), ds, plugins.DataTimeRange{})
+			_, err := NewClient(context.Background(), ds, backend.TimeRange{})
 			require.Error(t, err)
 		})
 
 		t.Run("When version 2 should return v2 client", func(t *testing.T) {
-			ds := &models.DataSource{
-				JsonData: simplejson.NewFromAny(map[string]interface{}{
+			ds := &DatasourceInfo{
+				JSONData: simplejson.NewFromAny(map[string]interface{}{
 					"esVersion": 2,
 					"timeField": "@timestamp",
 				}),
 			}
 
-			c, err := NewClient(context.Background(), httpclient.NewProvider(), ds, plugins.DataTimeRange{})
+			c, err := NewClient(context.Background(), ds, backend.TimeRange{})
 			require.NoError(t, err)
 			assert.Equal(t, "2.0.0", c.GetVersion().String())
 		})
 
 		t.Run("When version 5 should return v5 client", func(t *testing.T) {
-			ds := &models.DataSource{
-				JsonData: simplejson.NewFromAny(map[string]interface{}{
+			ds := &DatasourceInfo{
+				JSONData: simplejson.NewFromAny(map[string]interface{}{
 					"esVersion": 5,
 					"timeField": "@timestamp",
 				}),
 			}
 
-			c, err := NewClient(context.Background(), httpclient.NewProvider(), ds, plugins.DataTimeRange{})
+			c, err := NewClient(context.Background(), ds, backend.TimeRange{})
 			require.NoError(t, err)
 			assert.Equal(t, "5.0.0", c.GetVersion().String())
 		})
 
 		t.Run("When version 56 should return v5.6 client", func(t *testing.T) {
-			ds := &models.DataSource{
-				JsonData: simplejson.NewFromAny(map[string]interface{}{
+			ds := &DatasourceInfo{
+				JSONData: simplejson.NewFromAny(map[string]interface{}{
 					"esVersion": 56,
 					"timeField": "@timestamp",
 				}),
 			}
 
-			c, err := NewClient(context.Background(), httpclient.NewProvider(), ds, plugins.DataTimeRange{})
+			c, err := NewClient(context.Background(), ds, backend.TimeRange{})
 			require.NoError(t, err)
 			assert.Equal(t, "5.6.0", c.GetVersion().String())
 		})
 
 		t.Run("When version 60 should return v6.0 client", func(t *testing.T) {
-			ds := &models.DataSource{
-				JsonData: simplejson.NewFromAny(map[string]interface{}{
+			ds := &DatasourceInfo{
+				JSONData: simplejson.NewFromAny(map[string]interface{}{
 					"esVersion": 60,
 					"timeField": "@timestamp",
 				}),
 			}
 
-			c, err := NewClient(context.Background(), httpclient.NewProvider(), ds, plugins.DataTimeRange{})
+			c, err := NewClient(context.Background(), ds, backend.TimeRange{})
 			require.NoError(t, err)
 			assert.Equal(t, "6.0.0", c.GetVersion().String())
 		})
 
 		t.Run("When version 70 should return v7.0 client", func(t *testing.T) {
-			ds := &models.DataSource{
-				JsonData: simplejson.NewFromAny(map[string]interface{}{
+			ds := &DatasourceInfo{
+				JSONData: simplejson.NewFromAny(map[string]interface{}{
 					"esVersion": 70,
 					"timeField": "@timestamp",
 				}),
 			}
 
-			c, err := NewClient(context.Background(), httpclient.NewProvider(), ds, plugins.DataTimeRange{})
+			c, err := NewClient(context.Background(), ds, backend.TimeRange{})
 			require.NoError(t, err)
 			assert.Equal(t, "7.0.0", c.GetVersion().String())
 		})
@@ -121,36 +118,36 @@ func TestNewClient(t *testing.T) {
 
 	t.Run("When version is a valid semver string should create a client", func(t *testing.T) {
 		version := "7.2.4"
-		ds := &models.DataSource{
-			JsonData: simplejson.NewFromAny(map[string]interface{}{
+		ds := &DatasourceInfo{
+			JSONData: simplejson.NewFromAny(map[string]interface{}{
 				"esVersion": version,
 				"timeField": "@timestamp",
 			}),
 		}
 
-		c, err := NewClient(context.Background(), httpclient.NewProvider(), ds, plugins.DataTimeRange{})
+		c, err := NewClient(context.Background(), ds, backend.TimeRange{})
 		require.NoError(t, err)
 		assert.Equal(t, version, c.GetVersion().String())
 	})
 
 	t.Run("When version is NOT a valid semver string should return error", func(t *testing.T) {
 		version := "7.NOT_VALID.4"
-		ds := &models.DataSource{
-			JsonData: simplejson.NewFromAny(map[string]interface{}{
+		ds := &DatasourceInfo{
+			JSONData: simplejson.NewFromAny(map[string]interface{}{
 				"esVersion": version,
 				"timeField": "@timestamp",
 			}),
 		}
 
-		_, err := NewClient(context.Background(), httpclient.NewProvider(), ds, plugins.DataTimeRange{})
+		_, err := NewClient(context.Background(), ds, backend.TimeRange{})
 		require.Error(t, err)
 	})
 }
 
 func TestClient_ExecuteMultisearch(t *testing.T) {
-	httpClientScenario(t, "Given a fake http client and a v2.x client with response", &models.DataSource{
+	httpClientScenario(t, "Given a fake http client and a v2.x client with response", &DatasourceInfo{
 		Database: "[metrics-]YYYY.MM.DD",
-		JsonData: simplejson.NewFromAny(map[string]interface{}{
+		JSONData: simplejson.NewFromAny(map[string]interface{}{
 			"esVersion": 2,
 			"timeField": "@timestamp",
 			"interval":  "Daily",
@@ -198,9 +195,9 @@ func TestClient_ExecuteMultisearch(t *testing.T) {
 		require.Len(t, res.Responses, 1)
 	})
 
-	httpClientScenario(t, "Given a fake http client and a v5.x client with response", &models.DataSource{
+	httpClientScenario(t, "Given a fake http client and a v5.x client with response", &DatasourceInfo{
 		Database: "[metrics-]YYYY.MM.DD",
-		JsonData: simplejson.NewFromAny(map[string]interface{}{
+		JSONData: simplejson.NewFromAny(map[string]interface{}{
 			"esVersion":                  5,
 			"maxConcurrentShardRequests": 100,
 			"timeField":                  "@timestamp",
@@ -250,9 +247,9 @@ func TestClient_ExecuteMultisearch(t *testing.T) {
 		require.Len(t, res.Responses, 1)
 	})
 
-	httpClientScenario(t, "Given a fake http client and a v5.6 client with response", &models.DataSource{
+	httpClientScenario(t, "Given a fake http client and a v5.6 client with response", &DatasourceInfo{
 		Database: "[metrics-]YYYY.MM.DD",
-		JsonData: simplejson.NewFromAny(map[string]interface{}{
+		JSONData: simplejson.NewFromAny(map[string]interface{}{
 			"esVersion":                  56,
 			"maxConcurrentShardRequests": 100,
 			"timeField":                  "@timestamp",
@@ -302,9 +299,9 @@ func TestClient_ExecuteMultisearch(t *testing.T) {
 		require.Len(t, res.Responses, 1)
 	})
 
-	httpClientScenario(t, "Given a fake http client and a v7.0 client with response", &models.DataSource{
+	httpClientScenario(t, "Given a fake http client and a v7.0 client with response", &DatasourceInfo{
 		Database: "[metrics-]YYYY.MM.DD",
-		JsonData: simplejson.NewFromAny(map[string]interface{}{
+		JSONData: simplejson.NewFromAny(map[string]interface{}{
 			"esVersion":                  70,
 			"maxConcurrentShardRequests": 6,
 			"timeField":                  "@timestamp",
@@ -381,7 +378,7 @@ type scenarioContext struct {
 
 type scenarioFunc func(*scenarioContext)
 
-func httpClientScenario(t *testing.T, desc string, ds *models.DataSource, fn scenarioFunc) {
+func httpClientScenario(t *testing.T, desc string, ds *DatasourceInfo, fn scenarioFunc) {
 	t.Helper()
 
 	t.Run(desc, func(t *testing.T) {
@@ -401,29 +398,20 @@ func httpClientScenario(t *testing.T, desc string, ds *models.DataSource, fn sce
 			require.NoError(t, err)
 			rw.WriteHeader(sc.responseStatus)
 		}))
-		ds.Url = ts.URL
+		ds.URL = ts.URL
+		ds.HTTPClient = ts.Client()
 
-		from := time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC)
-		to := time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC)
-		fromStr := fmt.Sprintf("%d", from.UnixNano()/int64(time.Millisecond))
-		toStr := fmt.Sprintf("%d", to.UnixNano()/int64(time.Millisecond))
-		timeRange := plugins.NewDataTimeRange(fromStr, toStr)
+		timeRange := backend.TimeRange{
+			From: time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC),
+			To:   time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC),
+		}
 
-		c, err := NewClient(context.Background(), httpclient.NewProvider(), ds, timeRange)
+		c, err := NewClient(context.Background(), ds, timeRange)
 		require.NoError(t, err)
 		require.NotNil(t, c)
 		sc.client = c
 
-		currentNewDatasourceHTTPClient := newDatasourceHttpClient
-
-		newDatasourceHttpClient = func(httpClientProvider httpclient.Provider, ds *models.DataSource) (*http.Client, error) {
-			return ts.Client(), nil
-		}
-
-		t.Cleanup(func() {
-			ts.Close()
-			newDatasourceHttpClient = currentNewDatasourceHTTPClient
-		})
+		t.Cleanup(ts.Close)
 
 		fn(sc)
 	})
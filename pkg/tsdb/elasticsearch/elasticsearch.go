@@ -4,48 +4,109 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/grafana/grafana/pkg/registry"
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
 	"github.com/grafana/grafana/pkg/tsdb/interval"
 )
 
-// ElasticsearchExecutor represents a handler for handling elasticsearch datasource request
-type Executor struct {
-	httpClientProvider httpclient.Provider
+var eslog = log.New("tsdb.elasticsearch")
+
+func init() {
+	registry.Register(&registry.Descriptor{Instance: &Service{}})
+}
+
+// Service represents a handler for handling elasticsearch datasource request
+type Service struct {
+	HTTPClientProvider   httpclient.Provider   `inject:""`
+	BackendPluginManager backendplugin.Manager `inject:""`
+
 	intervalCalculator interval.Calculator
+	im                 instancemgmt.InstanceManager
 }
 
-// New creates a new Executor func.
-// nolint:staticcheck // plugins.DataPlugin deprecated
-func New(httpClientProvider httpclient.Provider) func(*models.DataSource) (plugins.DataPlugin, error) {
-	// nolint:staticcheck // plugins.DataPlugin deprecated
-	return func(dsInfo *models.DataSource) (plugins.DataPlugin, error) {
-		return &Executor{
-			httpClientProvider: httpClientProvider,
-			intervalCalculator: interval.NewCalculator(),
+func (s *Service) Init() error {
+	s.intervalCalculator = interval.NewCalculator()
+	s.im = datasource.NewInstanceManager(newInstanceSettings(s.HTTPClientProvider))
+
+	factory := coreplugin.New(backend.ServeOpts{
+		QueryDataHandler:   s,
+		CheckHealthHandler: s,
+	})
+
+	if err := s.BackendPluginManager.Register("elasticsearch", factory); err != nil {
+		eslog.Error("Failed to register plugin", "error", err)
+	}
+
+	return nil
+}
+
+func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		jsonData, err := simplejson.NewJson(settings.JSONData)
+		if err != nil {
+			return nil, fmt.Errorf("error reading settings: %w", err)
+		}
+
+		opts, err := models.HTTPClientOptionsFromInstanceSettings(models.DS_ES, settings)
+		if err != nil {
+			return nil, err
+		}
+
+		client, err := httpClientProvider.New(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return &es.DatasourceInfo{
+			ID:         settings.ID,
+			HTTPClient: client,
+			URL:        settings.URL,
+			Database:   settings.Database,
+			JSONData:   jsonData,
 		}, nil
 	}
 }
 
-// Query handles an elasticsearch datasource request
-//nolint: staticcheck // plugins.DataResponse deprecated
-func (e *Executor) DataQuery(ctx context.Context, dsInfo *models.DataSource,
-	tsdbQuery plugins.DataQuery) (plugins.DataResponse, error) {
-	if len(tsdbQuery.Queries) == 0 {
-		return plugins.DataResponse{}, fmt.Errorf("query contains no queries")
+// QueryData handles an elasticsearch datasource request
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if len(req.Queries) == 0 {
+		return nil, fmt.Errorf("query contains no queries")
 	}
 
-	client, err := es.NewClient(ctx, e.httpClientProvider, dsInfo, *tsdbQuery.TimeRange)
+	dsInfo, err := s.getDSInfo(req.PluginContext)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
-	if tsdbQuery.Debug {
-		client.EnableDebug()
+	// The time range is the same for all the queries of the request.
+	client, err := es.NewClient(ctx, dsInfo, req.Queries[0].TimeRange)
+	if err != nil {
+		return nil, err
 	}
 
-	query := newTimeSeriesQuery(client, tsdbQuery, e.intervalCalculator)
+	query := newTimeSeriesQuery(client, req.Queries, s.intervalCalculator)
 	return query.execute()
 }
+
+func (s *Service) getDSInfo(pluginCtx backend.PluginContext) (*es.DatasourceInfo, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := i.(*es.DatasourceInfo)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast data source info")
+	}
+
+	return instance, nil
+}
@@ -0,0 +1,130 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// healthCheckRange is the time range of the indices the health check looks for the time field in.
+const healthCheckRange = 24 * time.Hour
+
+// clusterInfo is the response of the root endpoint of Elasticsearch.
+type clusterInfo struct {
+	ClusterName string `json:"cluster_name"`
+	Version     struct {
+		Number string `json:"number"`
+	} `json:"version"`
+}
+
+// fieldCapabilities is the response of the field capabilities API of Elasticsearch,
+// with the types of the fields.
+type fieldCapabilities struct {
+	Fields map[string]map[string]interface{} `json:"fields"`
+}
+
+// CheckHealth checks that the Elasticsearch cluster responds, and that the indices of the index pattern
+// of the data source of the last day have the time field as a date field.
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	var cluster clusterInfo
+	if err := getJSON(ctx, dsInfo, "", nil, &cluster); err != nil {
+		return healthError("Elasticsearch error: %s", err), nil
+	}
+
+	timeField := dsInfo.JSONData.Get("timeField").MustString()
+	if timeField == "" {
+		return healthError("Elasticsearch time field name is required"), nil
+	}
+	to := time.Now()
+	indices, err := es.GetIndices(dsInfo, backend.TimeRange{From: to.Add(-healthCheckRange), To: to})
+	if err != nil {
+		return healthError("Invalid index pattern: %s", err), nil
+	}
+
+	var fields fieldCapabilities
+	params := url.Values{
+		"fields":             []string{timeField},
+		"ignore_unavailable": []string{"true"},
+		"allow_no_indices":   []string{"false"},
+	}
+	if err := getJSON(ctx, dsInfo, path.Join(strings.Join(indices, ","), "_field_caps"), params, &fields); err != nil {
+		return healthError("Elasticsearch error: %s", err), nil
+	}
+	types := fields.Fields[timeField]
+	if _, ok := types["date"]; !ok {
+		if _, ok := types["date_nanos"]; !ok {
+			return healthError("No date field named %s found", timeField), nil
+		}
+	}
+
+	details, err := json.Marshal(map[string]string{
+		"clusterName": cluster.ClusterName,
+		"version":     cluster.Version.Number,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &backend.CheckHealthResult{
+		Status:      backend.HealthStatusOk,
+		Message:     "Index OK. Time field name OK.",
+		JSONDetails: details,
+	}, nil
+}
+
+func healthError(format string, args ...interface{}) *backend.CheckHealthResult {
+	return &backend.CheckHealthResult{
+		Status:  backend.HealthStatusError,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// getJSON decodes the JSON response of a GET request to the path of the data source URL.
+func getJSON(ctx context.Context, dsInfo *es.DatasourceInfo, uriPath string, params url.Values, out interface{}) error {
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, uriPath)
+	u.RawQuery = params.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	res, err := ctxhttp.Do(ctx, dsInfo.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := res.Body.Close(); err != nil {
+			eslog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if res.StatusCode/100 != 2 {
+		var body struct {
+			Error struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err == nil && body.Error.Reason != "" {
+			return fmt.Errorf("%s: %s", res.Status, body.Error.Reason)
+		}
+		return fmt.Errorf("request failed with status %s", res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,67 @@
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHealth(t *testing.T) {
+	// checkHealth returns the result of the health check and the fields whose capabilities were requested
+	checkHealth := func(t *testing.T, fieldCaps string) (*backend.CheckHealthResult, string) {
+		t.Helper()
+		var mu sync.Mutex
+		var fields string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/":
+				_, _ = w.Write([]byte(`{"cluster_name": "logs", "version": {"number": "7.10.2"}}`))
+			case "/logs-*/_field_caps":
+				mu.Lock()
+				fields = r.URL.Query().Get("fields")
+				mu.Unlock()
+				_, _ = w.Write([]byte(fieldCaps))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error": {"reason": "no such index [` + r.URL.Path + `]"}}`))
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		service := &Service{im: datasource.NewInstanceManager(newInstanceSettings(httpclient.NewProvider()))}
+		res, err := service.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					ID:       1,
+					URL:      srv.URL,
+					Database: "logs-*",
+					JSONData: []byte(`{"esVersion": "7.10.0", "timeField": "@timestamp"}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		return res, fields
+	}
+
+	t.Run("the cluster and the time field of the indices are checked", func(t *testing.T) {
+		res, fields := checkHealth(t, `{"indices": ["logs-1"], "fields": {"@timestamp": {"date": {"type": "date"}}}}`)
+		require.Equal(t, "@timestamp", fields)
+		require.Equal(t, backend.HealthStatusOk, res.Status)
+		require.JSONEq(t, `{"clusterName": "logs", "version": "7.10.2"}`, string(res.JSONDetails))
+	})
+
+	t.Run("the time field must be a date field", func(t *testing.T) {
+		res, _ := checkHealth(t, `{"indices": ["logs-1"], "fields": {"@timestamp": {"keyword": {"type": "keyword"}}}}`)
+		require.Equal(t, backend.HealthStatusError, res.Status)
+		require.Equal(t, "No date field named @timestamp found", res.Message)
+	})
+}
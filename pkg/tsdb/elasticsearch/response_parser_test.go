@@ -6,8 +6,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/grafana/grafana/pkg/components/simplejson"
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -47,12 +46,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 1)
 
 			frame := dataframes[0]
@@ -99,12 +97,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 2)
 
 			frame := dataframes[0]
@@ -168,10 +165,9 @@ func TestResponseParser(t *testing.T) {
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 2)
 
 			frame := dataframes[0]
@@ -238,12 +234,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 4)
 
 			frame := dataframes[0]
@@ -313,12 +308,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 2)
 
 			frame := dataframes[0]
@@ -397,12 +391,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 6)
 
 			frame := dataframes[0]
@@ -503,12 +496,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 3)
 
 			frame := dataframes[0]
@@ -559,12 +551,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 1)
 		})
 
@@ -611,12 +602,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 2)
 
 			frame := dataframes[0]
@@ -682,12 +672,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 2)
 
 			frame := dataframes[0]
@@ -741,12 +730,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 1)
 
 			frame := dataframes[0]
@@ -790,12 +778,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 1)
 
 			frame := dataframes[0]
@@ -857,12 +844,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 3)
 
 			frame := dataframes[0]
@@ -947,12 +933,11 @@ func TestResponseParser(t *testing.T) {
 			require.NoError(t, err)
 			result, err := rp.getTimeSeries()
 			require.NoError(t, err)
-			require.Len(t, result.Results, 1)
+			require.Len(t, result.Responses, 1)
 
-			queryRes := result.Results["A"]
+			queryRes := result.Responses["A"]
 			require.NotNil(t, queryRes)
-			dataframes, err := queryRes.Dataframes.Decoded()
-			require.NoError(t, err)
+			dataframes := queryRes.Frames
 			require.Len(t, dataframes, 1)
 
 			frame := dataframes[0]
@@ -1021,12 +1006,11 @@ func TestResponseParser(t *testing.T) {
 		assert.Nil(t, err)
 		result, err := rp.getTimeSeries()
 		assert.Nil(t, err)
-		assert.Len(t, result.Results, 1)
+		assert.Len(t, result.Responses, 1)
 
-		queryRes := result.Results["A"]
+		queryRes := result.Responses["A"]
 		assert.NotNil(t, queryRes)
-		dataframes, err := queryRes.Dataframes.Decoded()
-		assert.NoError(t, err)
+		dataframes := queryRes.Frames
 		assert.Len(t, dataframes, 2)
 
 		frame := dataframes[0]
@@ -1064,25 +1048,17 @@ func TestResponseParser(t *testing.T) {
 }
 
 func newResponseParserForTest(tsdbQueries map[string]string, responseBody string) (*responseParser, error) {
-	from := time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC)
-	to := time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC)
-	fromStr := fmt.Sprintf("%d", from.UnixNano()/int64(time.Millisecond))
-	toStr := fmt.Sprintf("%d", to.UnixNano()/int64(time.Millisecond))
-	timeRange := plugins.NewDataTimeRange(fromStr, toStr)
-	tsdbQuery := plugins.DataQuery{
-		Queries:   []plugins.DataSubQuery{},
-		TimeRange: &timeRange,
+	timeRange := backend.TimeRange{
+		From: time.Date(2018, 5, 15, 17, 50, 0, 0, time.UTC),
+		To:   time.Date(2018, 5, 15, 17, 55, 0, 0, time.UTC),
 	}
+	dataQueries := []backend.DataQuery{}
 
 	for refID, tsdbQueryBody := range tsdbQueries {
-		tsdbQueryJSON, err := simplejson.NewJson([]byte(tsdbQueryBody))
-		if err != nil {
-			return nil, err
-		}
-
-		tsdbQuery.Queries = append(tsdbQuery.Queries, plugins.DataSubQuery{
-			Model: tsdbQueryJSON,
-			RefID: refID,
+		dataQueries = append(dataQueries, backend.DataQuery{
+			RefID:     refID,
+			TimeRange: timeRange,
+			JSON:      json.RawMessage(tsdbQueryBody),
 		})
 	}
 
@@ -1093,7 +1069,7 @@ func newResponseParserForTest(tsdbQueries map[string]string, responseBody string
 	}
 
 	tsQueryParser := newTimeSeriesQueryParser()
-	queries, err := tsQueryParser.parse(tsdbQuery)
+	queries, err := tsQueryParser.parse(dataQueries)
 	if err != nil {
 		return nil, err
 	}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"time"
 
 	"github.com/Masterminds/semver"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/plugins"
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
@@ -14,62 +16,60 @@ import (
 
 type timeSeriesQuery struct {
 	client             es.Client
-	tsdbQuery          plugins.DataQuery
+	dataQueries        []backend.DataQuery
 	intervalCalculator interval.Calculator
 }
 
-var newTimeSeriesQuery = func(client es.Client, dataQuery plugins.DataQuery,
+var newTimeSeriesQuery = func(client es.Client, dataQuery []backend.DataQuery,
 	intervalCalculator interval.Calculator) *timeSeriesQuery {
 	return &timeSeriesQuery{
 		client:             client,
-		tsdbQuery:          dataQuery,
+		dataQueries:        dataQuery,
 		intervalCalculator: intervalCalculator,
 	}
 }
 
-// nolint:staticcheck // plugins.DataQueryResult deprecated
-func (e *timeSeriesQuery) execute() (plugins.DataResponse, error) {
+func (e *timeSeriesQuery) execute() (*backend.QueryDataResponse, error) {
 	tsQueryParser := newTimeSeriesQueryParser()
-	queries, err := tsQueryParser.parse(e.tsdbQuery)
+	queries, err := tsQueryParser.parse(e.dataQueries)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
 	ms := e.client.MultiSearch()
 
-	from := fmt.Sprintf("%d", e.tsdbQuery.TimeRange.GetFromAsMsEpoch())
-	to := fmt.Sprintf("%d", e.tsdbQuery.TimeRange.GetToAsMsEpoch())
-	result := plugins.DataResponse{
-		Results: make(map[string]plugins.DataQueryResult),
-	}
+	// The time range is the same for all the queries of the request.
+	timeRange := e.dataQueries[0].TimeRange
+	from := strconv.FormatInt(timeRange.From.UnixNano()/int64(time.Millisecond), 10)
+	to := strconv.FormatInt(timeRange.To.UnixNano()/int64(time.Millisecond), 10)
+	result := backend.NewQueryDataResponse()
 	for _, q := range queries {
-		if err := e.processQuery(q, ms, from, to, result); err != nil {
-			return plugins.DataResponse{}, err
+		if err := e.processQuery(q, ms, from, to, timeRange, result); err != nil {
+			return nil, err
 		}
 	}
 
 	req, err := ms.Build()
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
 	res, err := e.client.ExecuteMultisearch(req)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
 	rp := newResponseParser(res.Responses, queries, res.DebugInfo)
 	return rp.getTimeSeries()
 }
 
-// nolint:staticcheck // plugins.DataQueryResult deprecated
 func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilder, from, to string,
-	result plugins.DataResponse) error {
+	timeRange backend.TimeRange, result *backend.QueryDataResponse) error {
 	minInterval, err := e.client.GetMinInterval(q.Interval)
 	if err != nil {
 		return err
 	}
-	interval := e.intervalCalculator.Calculate(*e.tsdbQuery.TimeRange, minInterval)
+	interval := e.intervalCalculator.Calculate(plugins.NewDataTimeRangeFromBackend(timeRange), minInterval)
 
 	b := ms.Search(interval)
 	b.Size(0)
@@ -82,10 +82,8 @@ func (e *timeSeriesQuery) processQuery(q *Query, ms *es.MultiSearchRequestBuilde
 
 	if len(q.BucketAggs) == 0 {
 		if len(q.Metrics) == 0 || q.Metrics[0].Type != "raw_document" {
-			result.Results[q.RefID] = plugins.DataQueryResult{
-				RefID:       q.RefID,
-				Error:       fmt.Errorf("invalid query, missing metrics and aggregations"),
-				ErrorString: "invalid query, missing metrics and aggregations",
+			result.Responses[q.RefID] = backend.DataResponse{
+				Error: fmt.Errorf("invalid query, missing metrics and aggregations"),
 			}
 			return nil
 		}
@@ -377,10 +375,13 @@ func newTimeSeriesQueryParser() *timeSeriesQueryParser {
 	return &timeSeriesQueryParser{}
 }
 
-func (p *timeSeriesQueryParser) parse(tsdbQuery plugins.DataQuery) ([]*Query, error) {
+func (p *timeSeriesQueryParser) parse(dataQueries []backend.DataQuery) ([]*Query, error) {
 	queries := make([]*Query, 0)
-	for _, q := range tsdbQuery.Queries {
-		model := q.Model
+	for _, q := range dataQueries {
+		model, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			return nil, err
+		}
 		timeField, err := model.Get("timeField").String()
 		if err != nil {
 			return nil, err
@@ -1,17 +1,17 @@
 package elasticsearch
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/Masterminds/semver"
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	es "github.com/grafana/grafana/pkg/tsdb/elasticsearch/client"
 	"github.com/grafana/grafana/pkg/tsdb/interval"
 	"github.com/stretchr/testify/assert"
 
-	"github.com/grafana/grafana/pkg/components/simplejson"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -1126,39 +1126,26 @@ func (c *fakeClient) MultiSearch() *es.MultiSearchRequestBuilder {
 	return c.builder
 }
 
-func newDataQuery(body string) (plugins.DataQuery, error) {
-	json, err := simplejson.NewJson([]byte(body))
-	if err != nil {
-		return plugins.DataQuery{}, err
-	}
-	return plugins.DataQuery{
-		Queries: []plugins.DataSubQuery{
-			{
-				Model: json,
-			},
+func newDataQuery(body string) ([]backend.DataQuery, error) {
+	return []backend.DataQuery{
+		{
+			JSON: json.RawMessage(body),
 		},
 	}, nil
 }
 
-// nolint:staticcheck // plugins.DataQueryResult deprecated
 func executeTsdbQuery(c es.Client, body string, from, to time.Time, minInterval time.Duration) (
-	plugins.DataResponse, error) {
-	json, err := simplejson.NewJson([]byte(body))
-	if err != nil {
-		return plugins.DataResponse{}, err
-	}
-	fromStr := fmt.Sprintf("%d", from.UnixNano()/int64(time.Millisecond))
-	toStr := fmt.Sprintf("%d", to.UnixNano()/int64(time.Millisecond))
-	timeRange := plugins.NewDataTimeRange(fromStr, toStr)
-	tsdbQuery := plugins.DataQuery{
-		Queries: []plugins.DataSubQuery{
-			{
-				Model: json,
+	*backend.QueryDataResponse, error) {
+	dataQueries := []backend.DataQuery{
+		{
+			JSON: json.RawMessage(body),
+			TimeRange: backend.TimeRange{
+				From: from,
+				To:   to,
 			},
 		},
-		TimeRange: &timeRange,
 	}
-	query := newTimeSeriesQuery(c, tsdbQuery, interval.NewCalculator(interval.CalculatorOptions{MinInterval: minInterval}))
+	query := newTimeSeriesQuery(c, dataQueries, interval.NewCalculator(interval.CalculatorOptions{MinInterval: minInterval}))
 	return query.execute()
 }
 
@@ -16,48 +16,87 @@ import (
 
 	"golang.org/x/net/context/ctxhttp"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/opentracing/opentracing-go"
 )
 
-type GraphiteExecutor struct {
-	httpClientProvider httpclient.Provider
+type Service struct {
+	HTTPClientProvider   httpclient.Provider   `inject:""`
+	BackendPluginManager backendplugin.Manager `inject:""`
+
+	im instancemgmt.InstanceManager
 }
 
-// nolint:staticcheck // plugins.DataPlugin deprecated
-func New(httpClientProvider httpclient.Provider) func(*models.DataSource) (plugins.DataPlugin, error) {
-	// nolint:staticcheck // plugins.DataPlugin deprecated
-	return func(dsInfo *models.DataSource) (plugins.DataPlugin, error) {
-		return &GraphiteExecutor{
-			httpClientProvider: httpClientProvider,
-		}, nil
-	}
+type datasourceInfo struct {
+	HTTPClient *http.Client
+	URL        string
+	Id         int64
 }
 
 var glog = log.New("tsdb.graphite")
 
-//nolint: staticcheck // plugins.DataQuery deprecated
-func (e *GraphiteExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource, tsdbQuery plugins.DataQuery) (
-	plugins.DataResponse, error) {
-	// This logic is used when called from Dashboard Alerting.
-	from := "-" + formatTimeRange(tsdbQuery.TimeRange.From)
-	until := formatTimeRange(tsdbQuery.TimeRange.To)
-
-	// This logic is used when called through server side expressions.
-	if isTimeRangeNumeric(*tsdbQuery.TimeRange) {
-		var err error
-		from, until, err = epochMStoGraphiteTime(*tsdbQuery.TimeRange)
+func init() {
+	registry.Register(&registry.Descriptor{Instance: &Service{}})
+}
+
+func (s *Service) Init() error {
+	s.im = datasource.NewInstanceManager(newInstanceSettings(s.HTTPClientProvider))
+
+	factory := coreplugin.New(backend.ServeOpts{
+		QueryDataHandler:   s,
+		CheckHealthHandler: s,
+	})
+
+	if err := s.BackendPluginManager.Register("graphite", factory); err != nil {
+		glog.Error("Failed to register plugin", "error", err)
+	}
+
+	return nil
+}
+
+func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		opts, err := models.HTTPClientOptionsFromInstanceSettings(models.DS_GRAPHITE, settings)
 		if err != nil {
-			return plugins.DataResponse{}, err
+			return nil, err
 		}
+
+		client, err := httpClientProvider.New(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return &datasourceInfo{
+			HTTPClient: client,
+			URL:        settings.URL,
+			Id:         settings.ID,
+		}, nil
 	}
+}
+
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if len(req.Queries) == 0 {
+		return nil, errors.New("query contains no queries")
+	}
+
+	// The time range is the same for all the queries of the request.
+	timeRange := req.Queries[0].TimeRange
+	from := strconv.FormatInt(timeRange.From.Unix(), 10)
+	until := strconv.FormatInt(timeRange.To.Unix(), 10)
 
-	var target string
+	var target, refID string
 
 	formData := url.Values{
 		"from":          []string{from},
@@ -67,25 +106,30 @@ func (e *GraphiteExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSou
 	}
 
 	emptyQueries := make([]string, 0)
-	for _, query := range tsdbQuery.Queries {
-		glog.Debug("graphite", "query", query.Model)
+	for _, query := range req.Queries {
+		model, err := simplejson.NewJson(query.JSON)
+		if err != nil {
+			return nil, err
+		}
+		glog.Debug("graphite", "query", model)
 		currTarget := ""
-		if fullTarget, err := query.Model.Get("targetFull").String(); err == nil {
+		if fullTarget, err := model.Get("targetFull").String(); err == nil {
 			currTarget = fullTarget
 		} else {
-			currTarget = query.Model.Get("target").MustString()
+			currTarget = model.Get("target").MustString()
 		}
 		if currTarget == "" {
-			glog.Debug("graphite", "empty query target", query.Model)
-			emptyQueries = append(emptyQueries, fmt.Sprintf("Query: %v has no target", query.Model))
+			glog.Debug("graphite", "empty query target", model)
+			emptyQueries = append(emptyQueries, fmt.Sprintf("Query: %v has no target", model))
 			continue
 		}
 		target = fixIntervalFormat(currTarget)
+		refID = query.RefID
 	}
 
 	if target == "" {
 		glog.Error("No targets in query model", "models without targets", strings.Join(emptyQueries, "\n"))
-		return plugins.DataResponse{}, errors.New("no query target found for the alert rule")
+		return nil, errors.New("no query target found for the alert rule")
 	}
 
 	formData["target"] = []string{target}
@@ -94,14 +138,14 @@ func (e *GraphiteExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSou
 		glog.Debug("Graphite request", "params", formData)
 	}
 
-	req, err := e.createRequest(dsInfo, formData)
+	dsInfo, err := s.getDSInfo(req.PluginContext)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
-	httpClient, err := dsInfo.GetHTTPClient(e.httpClientProvider)
+	graphiteReq, err := s.createRequest(dsInfo, formData)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
 	span, ctx := opentracing.StartSpanFromContext(ctx, "graphite query")
@@ -109,38 +153,38 @@ func (e *GraphiteExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSou
 	span.SetTag("from", from)
 	span.SetTag("until", until)
 	span.SetTag("datasource_id", dsInfo.Id)
-	span.SetTag("org_id", dsInfo.OrgId)
+	span.SetTag("org_id", req.PluginContext.OrgID)
 
 	defer span.Finish()
 
 	if err := opentracing.GlobalTracer().Inject(
 		span.Context(),
 		opentracing.HTTPHeaders,
-		opentracing.HTTPHeadersCarrier(req.Header)); err != nil {
-		return plugins.DataResponse{}, err
+		opentracing.HTTPHeadersCarrier(graphiteReq.Header)); err != nil {
+		return nil, err
 	}
 
-	res, err := ctxhttp.Do(ctx, httpClient, req)
+	res, err := ctxhttp.Do(ctx, dsInfo.HTTPClient, graphiteReq)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
-	frames, err := e.toDataFrames(res)
+	frames, err := s.toDataFrames(res)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
-
-	result := plugins.DataResponse{
-		Results: make(map[string]plugins.DataQueryResult),
+	for _, frame := range frames {
+		frame.RefID = refID
 	}
-	result.Results["A"] = plugins.DataQueryResult{
-		RefID:      "A",
-		Dataframes: plugins.NewDecodedDataFrames(frames),
+
+	result := backend.NewQueryDataResponse()
+	result.Responses[refID] = backend.DataResponse{
+		Frames: frames,
 	}
 	return result, nil
 }
 
-func (e *GraphiteExecutor) parseResponse(res *http.Response) ([]TargetResponseDTO, error) {
+func (s *Service) parseResponse(res *http.Response) ([]TargetResponseDTO, error) {
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
@@ -166,8 +210,8 @@ func (e *GraphiteExecutor) parseResponse(res *http.Response) ([]TargetResponseDT
 	return data, nil
 }
 
-func (e *GraphiteExecutor) toDataFrames(response *http.Response) (frames data.Frames, error error) {
-	responseData, err := e.parseResponse(response)
+func (s *Service) toDataFrames(response *http.Response) (frames data.Frames, error error) {
+	responseData, err := s.parseResponse(response)
 	if err != nil {
 		return nil, err
 	}
@@ -198,8 +242,8 @@ func (e *GraphiteExecutor) toDataFrames(response *http.Response) (frames data.Fr
 	return
 }
 
-func (e *GraphiteExecutor) createRequest(dsInfo *models.DataSource, data url.Values) (*http.Request, error) {
-	u, err := url.Parse(dsInfo.Url)
+func (s *Service) createRequest(dsInfo *datasourceInfo, data url.Values) (*http.Request, error) {
+	u, err := url.Parse(dsInfo.URL)
 	if err != nil {
 		return nil, err
 	}
@@ -212,20 +256,10 @@ func (e *GraphiteExecutor) createRequest(dsInfo *models.DataSource, data url.Val
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	if dsInfo.BasicAuth {
-		req.SetBasicAuth(dsInfo.BasicAuthUser, dsInfo.DecryptedBasicAuthPassword())
-	}
 
 	return req, err
 }
 
-func formatTimeRange(input string) string {
-	if input == "now" {
-		return input
-	}
-	return strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(input, "now", ""), "m", "min"), "M", "mon")
-}
-
 func fixIntervalFormat(target string) string {
 	rMinute := regexp.MustCompile(`'(\d+)m'`)
 	target = rMinute.ReplaceAllStringFunc(target, func(m string) string {
@@ -238,30 +272,6 @@ func fixIntervalFormat(target string) string {
 	return target
 }
 
-func isTimeRangeNumeric(tr plugins.DataTimeRange) bool {
-	if _, err := strconv.ParseInt(tr.From, 10, 64); err != nil {
-		return false
-	}
-	if _, err := strconv.ParseInt(tr.To, 10, 64); err != nil {
-		return false
-	}
-	return true
-}
-
-func epochMStoGraphiteTime(tr plugins.DataTimeRange) (string, string, error) {
-	from, err := strconv.ParseInt(tr.From, 10, 64)
-	if err != nil {
-		return "", "", err
-	}
-
-	to, err := strconv.ParseInt(tr.To, 10, 64)
-	if err != nil {
-		return "", "", err
-	}
-
-	return fmt.Sprintf("%d", from/1000), fmt.Sprintf("%d", to/1000), nil
-}
-
 /**
  * Graphite should always return timestamp as a number but values might be nil when data is missing
  */
@@ -280,3 +290,17 @@ func parseDataTimePoint(dataTimePoint plugins.DataTimePoint) (time.Time, *float6
 		return timestamp, nil, nil
 	}
 }
+
+func (s *Service) getDSInfo(pluginCtx backend.PluginContext) (*datasourceInfo, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := i.(*datasourceInfo)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast data source info")
+	}
+
+	return instance, nil
+}
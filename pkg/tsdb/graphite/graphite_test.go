@@ -1,37 +1,25 @@
 package graphite
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestFormatTimeRange(t *testing.T) {
-	testCases := []struct {
-		input    string
-		expected string
-	}{
-		{"now", "now"},
-		{"now-1m", "-1min"},
-		{"now-1M", "-1mon"},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.input, func(t *testing.T) {
-			tr := formatTimeRange(tc.input)
-			assert.Equal(t, tc.expected, tr)
-		})
-	}
-}
-
 func TestFixIntervalFormat(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -67,7 +55,7 @@ func TestFixIntervalFormat(t *testing.T) {
 		})
 	}
 
-	executor := &GraphiteExecutor{}
+	service := &Service{}
 
 	t.Run("Converts response to data frames", func(*testing.T) {
 		body := `
@@ -90,7 +78,7 @@ func TestFixIntervalFormat(t *testing.T) {
 		expectedFrames := data.Frames{expectedFrame}
 
 		httpResponse := &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))}
-		dataFrames, err := executor.toDataFrames(httpResponse)
+		dataFrames, err := service.toDataFrames(httpResponse)
 
 		require.NoError(t, err)
 		if !reflect.DeepEqual(expectedFrames, dataFrames) {
@@ -100,3 +88,36 @@ func TestFixIntervalFormat(t *testing.T) {
 		}
 	})
 }
+
+func TestQueryData(t *testing.T) {
+	var form url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/render", r.URL.Path)
+		assert.NoError(t, r.ParseForm())
+		form = r.PostForm
+		_, _ = w.Write([]byte(`[{"target": "target", "datapoints": [[50, 1]]}]`))
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{
+		im: datasource.NewInstanceManager(newInstanceSettings(httpclient.NewProvider())),
+	}
+	from := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	resp, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, URL: srv.URL},
+		},
+		Queries: []backend.DataQuery{{
+			RefID:     "B",
+			TimeRange: backend.TimeRange{From: from, To: from.Add(time.Hour)},
+			JSON:      []byte(`{"target": "summarize(foo, '1m')"}`),
+		}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "1622505600", form.Get("from"))
+	assert.Equal(t, "1622509200", form.Get("until"))
+	assert.Equal(t, "summarize(foo, '1min')", form.Get("target"))
+	require.Len(t, resp.Responses["B"].Frames, 1)
+	assert.Equal(t, "B", resp.Responses["B"].Frames[0].RefID)
+}
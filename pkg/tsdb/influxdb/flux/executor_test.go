@@ -14,10 +14,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/experimental"
-	"github.com/grafana/grafana/pkg/components/securejsondata"
-	"github.com/grafana/grafana/pkg/components/simplejson"
-	"github.com/grafana/grafana/pkg/infra/httpclient"
-	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/xorcare/pointer"
@@ -220,18 +217,14 @@ func TestRealQuery(t *testing.T) {
 	t.Skip() // this is used for local testing
 
 	t.Run("Check buckets() query on localhost", func(t *testing.T) {
-		json := simplejson.New()
-		json.Set("organization", "test-org")
-
-		dsInfo := &models.DataSource{
-			Url:      "http://localhost:9999", // NOTE! no api/v2
-			JsonData: json,
-			SecureJsonData: securejsondata.GetEncryptedJsonData(map[string]string{
-				"token": "PjSEcM5oWhqg2eI6IXcqYJFe5UbMM_xt-UNlAL0BRYJqLeVpcdMWidiPfWxGhu4Xrh6wioRR-CiadCg-ady68Q==",
-			}),
+		dsInfo := &models.DatasourceInfo{
+			HTTPClient:   http.DefaultClient,
+			URL:          "http://localhost:9999", // NOTE! no api/v2
+			Organization: "test-org",
+			Token:        "PjSEcM5oWhqg2eI6IXcqYJFe5UbMM_xt-UNlAL0BRYJqLeVpcdMWidiPfWxGhu4Xrh6wioRR-CiadCg-ady68Q==",
 		}
 
-		runner, err := runnerFromDataSource(httpclient.NewProvider(), dsInfo)
+		runner, err := runnerFromDataSource(dsInfo)
 		require.NoError(t, err)
 
 		dr := executeQuery(context.Background(), queryModel{
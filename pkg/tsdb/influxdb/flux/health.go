@@ -0,0 +1,50 @@
+package flux
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/models"
+)
+
+// CheckHealth checks that the buckets of the organization of the data source can be listed.
+func CheckHealth(ctx context.Context, dsInfo *models.DatasourceInfo) (*backend.CheckHealthResult, error) {
+	r, err := runnerFromDataSource(dsInfo)
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: err.Error(),
+		}, nil
+	}
+	defer r.client.Close()
+
+	tables, err := r.runQuery(ctx, "buckets()")
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: fmt.Sprintf("InfluxDB error: %s", err),
+		}, nil
+	}
+	buckets := 0
+	for tables.Next() {
+		buckets++
+	}
+	if err := tables.Err(); err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: fmt.Sprintf("InfluxDB error: %s", err),
+		}, nil
+	}
+	if buckets == 0 {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: "Error reading buckets",
+		}, nil
+	}
+
+	return &backend.CheckHealthResult{
+		Status:  backend.HealthStatusOk,
+		Message: fmt.Sprintf("%d buckets found", buckets),
+	}, nil
+}
@@ -0,0 +1,121 @@
+package influxdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/tsdb/influxdb/flux"
+	influxmodels "github.com/grafana/grafana/pkg/tsdb/influxdb/models"
+)
+
+// CheckHealth checks that InfluxDB responds to pings, and that the retention policies of the database
+// of the data source can be listed. The health of the Flux data sources is checked by listing their buckets.
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	if dsInfo.Version == "Flux" {
+		return flux.CheckHealth(ctx, dsInfo)
+	}
+
+	version, err := ping(ctx, dsInfo)
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: fmt.Sprintf("InfluxDB error: %s", err),
+		}, nil
+	}
+
+	if dsInfo.DbName != "" {
+		if err := s.showRetentionPolicies(ctx, dsInfo); err != nil {
+			return &backend.CheckHealthResult{
+				Status:  backend.HealthStatusError,
+				Message: fmt.Sprintf("InfluxDB error: %s", err),
+			}, nil
+		}
+	}
+
+	details, err := json.Marshal(map[string]string{"version": version})
+	if err != nil {
+		return nil, err
+	}
+	return &backend.CheckHealthResult{
+		Status:      backend.HealthStatusOk,
+		Message:     "Data source is working",
+		JSONDetails: details,
+	}, nil
+}
+
+// ping pings InfluxDB and returns its version.
+func ping(ctx context.Context, dsInfo *influxmodels.DatasourceInfo) (string, error) {
+	u, err := url.Parse(dsInfo.URL)
+	if err != nil {
+		return "", err
+	}
+	u.Path = path.Join(u.Path, "ping")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Grafana")
+
+	resp, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			glog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("ping failed with status %s", resp.Status)
+	}
+	return resp.Header.Get("X-Influxdb-Version"), nil
+}
+
+// showRetentionPolicies lists the retention policies of the database of the data source,
+// to check that the database exists and can be queried.
+func (s *Service) showRetentionPolicies(ctx context.Context, dsInfo *influxmodels.DatasourceInfo) error {
+	req, err := s.createRequest(ctx, dsInfo, fmt.Sprintf("SHOW RETENTION POLICIES on %q", dsInfo.DbName))
+	if err != nil {
+		return err
+	}
+
+	resp, err := dsInfo.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			glog.Warn("Failed to close response body", "err", err)
+		}
+	}()
+
+	var response Response
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to read the response: %s (%w)", resp.Status, err)
+	}
+	if response.Error != "" {
+		return errors.New(response.Error)
+	}
+	for _, result := range response.Results {
+		if result.Error != "" {
+			return errors.New(result.Error)
+		}
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("query failed with status %s", resp.Status)
+	}
+	return nil
+}
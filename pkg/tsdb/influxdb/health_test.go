@@ -0,0 +1,67 @@
+package influxdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHealth(t *testing.T) {
+	// checkHealth returns the result of the health check and the query sent to InfluxDB
+	checkHealth := func(t *testing.T, queryResponse string) (*backend.CheckHealthResult, string) {
+		t.Helper()
+		var mu sync.Mutex
+		var query string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/ping":
+				w.Header().Set("X-Influxdb-Version", "1.8.4")
+				w.WriteHeader(http.StatusNoContent)
+			case "/query":
+				mu.Lock()
+				query = r.URL.Query().Get("q")
+				mu.Unlock()
+				_, _ = w.Write([]byte(queryResponse))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		service := &Service{im: datasource.NewInstanceManager(newInstanceSettings(httpclient.NewProvider()))}
+		res, err := service.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					ID:       1,
+					URL:      srv.URL,
+					Database: "telegraf",
+					JSONData: []byte(`{}`),
+				},
+			},
+		})
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		return res, query
+	}
+
+	t.Run("InfluxDB is pinged and the database is queried", func(t *testing.T) {
+		res, query := checkHealth(t, `{"results": [{"statement_id": 0, "series": [{"columns": ["name"], "values": [["autogen"]]}]}]}`)
+		require.Equal(t, `SHOW RETENTION POLICIES on "telegraf"`, query)
+		require.Equal(t, backend.HealthStatusOk, res.Status)
+		require.JSONEq(t, `{"version": "1.8.4"}`, string(res.JSONDetails))
+	})
+
+	t.Run("the error of the query is returned", func(t *testing.T) {
+		res, _ := checkHealth(t, `{"results": [{"statement_id": 0, "error": "database not found: telegraf"}]}`)
+		require.Equal(t, backend.HealthStatusError, res.Status)
+		require.Equal(t, "InfluxDB error: database not found: telegraf", res.Message)
+	})
+}
@@ -9,30 +9,28 @@ import (
 	"path"
 	"strings"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/influxdb/flux"
+	influxmodels "github.com/grafana/grafana/pkg/tsdb/influxdb/models"
 )
 
-type Executor struct {
-	httpClientProvider httpclient.Provider
-	QueryParser        *InfluxdbQueryParser
-	ResponseParser     *ResponseParser
-}
+type Service struct {
+	HTTPClientProvider   httpclient.Provider   `inject:""`
+	BackendPluginManager backendplugin.Manager `inject:""`
 
-// nolint:staticcheck // plugins.DataPlugin deprecated
-func New(httpClientProvider httpclient.Provider) func(*models.DataSource) (plugins.DataPlugin, error) {
-	// nolint:staticcheck // plugins.DataPlugin deprecated
-	return func(dsInfo *models.DataSource) (plugins.DataPlugin, error) {
-		return &Executor{
-			httpClientProvider: httpClientProvider,
-			QueryParser:        &InfluxdbQueryParser{},
-			ResponseParser:     &ResponseParser{},
-		}, nil
-	}
+	QueryParser    *InfluxdbQueryParser
+	ResponseParser *ResponseParser
+	im             instancemgmt.InstanceManager
 }
 
 var (
@@ -43,16 +41,71 @@ var ErrInvalidHttpMode error = errors.New("'httpMode' should be either 'GET' or
 
 func init() {
 	glog = log.New("tsdb.influxdb")
+	registry.Register(&registry.Descriptor{Instance: &Service{}})
+}
+
+func (s *Service) Init() error {
+	s.QueryParser = &InfluxdbQueryParser{}
+	s.ResponseParser = &ResponseParser{}
+	s.im = datasource.NewInstanceManager(newInstanceSettings(s.HTTPClientProvider))
+
+	factory := coreplugin.New(backend.ServeOpts{
+		QueryDataHandler:   s,
+		CheckHealthHandler: s,
+	})
+
+	if err := s.BackendPluginManager.Register("influxdb", factory); err != nil {
+		glog.Error("Failed to register plugin", "error", err)
+	}
+
+	return nil
+}
+
+func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		opts, err := models.HTTPClientOptionsFromInstanceSettings(models.DS_INFLUXDB, settings)
+		if err != nil {
+			return nil, err
+		}
+
+		client, err := httpClientProvider.New(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		jsonData := simplejson.New()
+		if len(settings.JSONData) > 0 {
+			if jsonData, err = simplejson.NewJson(settings.JSONData); err != nil {
+				return nil, err
+			}
+		}
+
+		return &influxmodels.DatasourceInfo{
+			HTTPClient:    client,
+			URL:           settings.URL,
+			DbName:        settings.Database,
+			Version:       jsonData.Get("version").MustString(""),
+			HTTPMode:      jsonData.Get("httpMode").MustString("GET"),
+			TimeInterval:  jsonData.Get("timeInterval").MustString(""),
+			Token:         settings.DecryptedSecureJSONData["token"],
+			Organization:  jsonData.Get("organization").MustString(""),
+			DefaultBucket: jsonData.Get("defaultBucket").MustString(""),
+			// If the default changes also update labels/placeholder in config page.
+			MaxSeries: jsonData.Get("maxSeries").MustInt(1000),
+		}, nil
+	}
 }
 
-//nolint: staticcheck // plugins.DataResponse deprecated
-func (e *Executor) DataQuery(ctx context.Context, dsInfo *models.DataSource, tsdbQuery plugins.DataQuery) (
-	plugins.DataResponse, error) {
-	glog.Debug("Received a query request", "numQueries", len(tsdbQuery.Queries))
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	glog.Debug("Received a query request", "numQueries", len(req.Queries))
 
-	version := dsInfo.JsonData.Get("version").MustString("")
-	if version == "Flux" {
-		return flux.Query(ctx, e.httpClientProvider, dsInfo, tsdbQuery)
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	if dsInfo.Version == "Flux" {
+		return flux.Query(ctx, dsInfo, req)
 	}
 
 	glog.Debug("Making a non-Flux type query")
@@ -60,33 +113,28 @@ func (e *Executor) DataQuery(ctx context.Context, dsInfo *models.DataSource, tsd
 	// NOTE: the following path is currently only called from alerting queries
 	// In dashboards, the request runs through proxy and are managed in the frontend
 
-	query, err := e.getQuery(dsInfo, tsdbQuery)
+	query, err := s.getQuery(dsInfo, req)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
-	rawQuery, err := query.Build(tsdbQuery)
+	rawQuery, err := query.Build(req)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
 	if setting.Env == setting.Dev {
 		glog.Debug("Influxdb query", "raw query", rawQuery)
 	}
 
-	req, err := e.createRequest(ctx, dsInfo, rawQuery)
+	request, err := s.createRequest(ctx, dsInfo, rawQuery)
 	if err != nil {
-		return plugins.DataResponse{}, err
-	}
-
-	httpClient, err := dsInfo.GetHTTPClient(e.httpClientProvider)
-	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := dsInfo.HTTPClient.Do(request)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -94,36 +142,37 @@ func (e *Executor) DataQuery(ctx context.Context, dsInfo *models.DataSource, tsd
 		}
 	}()
 	if resp.StatusCode/100 != 2 {
-		return plugins.DataResponse{}, fmt.Errorf("InfluxDB returned error status: %s", resp.Status)
+		return nil, fmt.Errorf("InfluxDB returned error status: %s", resp.Status)
 	}
 
-	result := plugins.DataResponse{
-		Results: map[string]plugins.DataQueryResult{
-			"A": e.ResponseParser.Parse(resp.Body, query),
-		},
-	}
+	result := backend.NewQueryDataResponse()
+	result.Responses[req.Queries[0].RefID] = s.ResponseParser.Parse(resp.Body, query)
 
 	return result, nil
 }
 
-func (e *Executor) getQuery(dsInfo *models.DataSource, query plugins.DataQuery) (*Query, error) {
+func (s *Service) getQuery(dsInfo *influxmodels.DatasourceInfo, query *backend.QueryDataRequest) (*Query, error) {
 	if len(query.Queries) == 0 {
 		return nil, fmt.Errorf("query request contains no queries")
 	}
 
 	// The model supports multiple queries, but right now this is only used from
 	// alerting so we only needed to support batch executing 1 query at a time.
-	return e.QueryParser.Parse(query.Queries[0].Model, dsInfo)
+	model, err := simplejson.NewJson(query.Queries[0].JSON)
+	if err != nil {
+		return nil, err
+	}
+	return s.QueryParser.Parse(model, dsInfo)
 }
 
-func (e *Executor) createRequest(ctx context.Context, dsInfo *models.DataSource, query string) (*http.Request, error) {
-	u, err := url.Parse(dsInfo.Url)
+func (s *Service) createRequest(ctx context.Context, dsInfo *influxmodels.DatasourceInfo, query string) (*http.Request, error) {
+	u, err := url.Parse(dsInfo.URL)
 	if err != nil {
 		return nil, err
 	}
 
 	u.Path = path.Join(u.Path, "query")
-	httpMode := dsInfo.JsonData.Get("httpMode").MustString("GET")
+	httpMode := dsInfo.HTTPMode
 
 	var req *http.Request
 	switch httpMode {
@@ -147,7 +196,7 @@ func (e *Executor) createRequest(ctx context.Context, dsInfo *models.DataSource,
 	req.Header.Set("User-Agent", "Grafana")
 
 	params := req.URL.Query()
-	params.Set("db", dsInfo.Database)
+	params.Set("db", dsInfo.DbName)
 	params.Set("epoch", "s")
 
 	if httpMode == "GET" {
@@ -158,14 +207,20 @@ func (e *Executor) createRequest(ctx context.Context, dsInfo *models.DataSource,
 
 	req.URL.RawQuery = params.Encode()
 
-	if dsInfo.BasicAuth {
-		req.SetBasicAuth(dsInfo.BasicAuthUser, dsInfo.DecryptedBasicAuthPassword())
+	glog.Debug("Influxdb request", "url", req.URL.String())
+	return req, nil
+}
+
+func (s *Service) getDSInfo(pluginCtx backend.PluginContext) (*influxmodels.DatasourceInfo, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
 	}
 
-	if !dsInfo.BasicAuth && dsInfo.User != "" {
-		req.SetBasicAuth(dsInfo.User, dsInfo.DecryptedPassword())
+	instance, ok := i.(*influxmodels.DatasourceInfo)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast data source info")
 	}
 
-	glog.Debug("Influxdb request", "url", req.URL.String())
-	return req, nil
+	return instance, nil
 }
@@ -6,12 +6,13 @@ import (
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/models"
+	influxmodels "github.com/grafana/grafana/pkg/tsdb/influxdb/models"
 	"github.com/grafana/grafana/pkg/tsdb/interval"
 )
 
 type InfluxdbQueryParser struct{}
 
-func (qp *InfluxdbQueryParser) Parse(model *simplejson.Json, dsInfo *models.DataSource) (*Query, error) {
+func (qp *InfluxdbQueryParser) Parse(model *simplejson.Json, dsInfo *influxmodels.DatasourceInfo) (*Query, error) {
 	policy := model.Get("policy").MustString("default")
 	rawQuery := model.Get("query").MustString("")
 	useRawQuery := model.Get("rawQuery").MustBool(false)
@@ -40,7 +41,9 @@ func (qp *InfluxdbQueryParser) Parse(model *simplejson.Json, dsInfo *models.Data
 		return nil, err
 	}
 
-	parsedInterval, err := interval.GetIntervalFrom(dsInfo, model, time.Millisecond*1)
+	parsedInterval, err := interval.GetIntervalFrom(&models.DataSource{
+		JsonData: simplejson.NewFromAny(map[string]interface{}{"timeInterval": dsInfo.TimeInterval}),
+	}, model, time.Millisecond*1)
 	if err != nil {
 		return nil, err
 	}
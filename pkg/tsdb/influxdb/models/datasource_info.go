@@ -0,0 +1,22 @@
+package models
+
+import (
+	"net/http"
+)
+
+// DatasourceInfo is the configuration of an InfluxDB data source instance,
+// for both InfluxQL and Flux queries.
+type DatasourceInfo struct {
+	HTTPClient *http.Client
+	URL        string
+	// DbName is the database of the InfluxQL queries.
+	DbName       string
+	Version      string
+	HTTPMode     string
+	TimeInterval string
+	// Token, Organization, DefaultBucket and MaxSeries are the settings of the Flux queries.
+	Token         string
+	Organization  string
+	DefaultBucket string
+	MaxSeries     int
+}
@@ -5,7 +5,9 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/tsdb/interval"
 )
@@ -15,7 +17,7 @@ var (
 	regexpMeasurementPattern = regexp.MustCompile(`^\/.*\/$`)
 )
 
-func (query *Query) Build(queryContext plugins.DataQuery) (string, error) {
+func (query *Query) Build(queryContext *backend.QueryDataRequest) (string, error) {
 	var res string
 	if query.UseRawQuery && query.RawQuery != "" {
 		res = query.RawQuery
@@ -29,7 +31,7 @@ func (query *Query) Build(queryContext plugins.DataQuery) (string, error) {
 	}
 
 	calculator := interval.NewCalculator(interval.CalculatorOptions{})
-	i := calculator.Calculate(*queryContext.TimeRange, query.Interval)
+	i := calculator.Calculate(plugins.NewDataTimeRangeFromBackend(queryContext.Queries[0].TimeRange), query.Interval)
 
 	res = strings.ReplaceAll(res, "$timeFilter", query.renderTimeFilter(queryContext))
 	res = strings.ReplaceAll(res, "$interval", i.Text)
@@ -78,40 +80,15 @@ func (query *Query) renderTags() []string {
 	return res
 }
 
-func isTimeRangeNumeric(tr *plugins.DataTimeRange) bool {
-	if _, err := strconv.ParseInt(tr.From, 10, 64); err != nil {
-		return false
-	}
-	if _, err := strconv.ParseInt(tr.To, 10, 64); err != nil {
-		return false
-	}
-	return true
-}
-
-func (query *Query) renderTimeFilter(queryContext plugins.DataQuery) string {
-	// If from expressions
-	if isTimeRangeNumeric(queryContext.TimeRange) {
-		from, to, err := epochMStoInfluxTime(queryContext.TimeRange)
-		if err == nil {
-			return fmt.Sprintf(" time > %s and time < %s ", from, to)
-		}
-
-		// on error fallback to original time range processing.
-		glog.Warn("failed to parse expected time range in query, falling back to non-expression time range processing", "error", err)
-	}
-
-	// else from dashboard alerting
-	from := "now() - " + queryContext.TimeRange.From
-	to := ""
+func (query *Query) renderTimeFilter(queryContext *backend.QueryDataRequest) string {
+	timeRange := queryContext.Queries[0].TimeRange
+	from := timeRange.From.UnixNano() / int64(time.Millisecond)
+	to := timeRange.To.UnixNano() / int64(time.Millisecond)
 
-	if queryContext.TimeRange.To != "now" && queryContext.TimeRange.To != "" {
-		to = " and time < now() - " + strings.Replace(queryContext.TimeRange.To, "now-", "", 1)
-	}
-
-	return fmt.Sprintf("time > %s%s", from, to)
+	return fmt.Sprintf("time > %dms and time < %dms", from, to)
 }
 
-func (query *Query) renderSelectors(queryContext plugins.DataQuery) string {
+func (query *Query) renderSelectors(queryContext *backend.QueryDataRequest) string {
 	res := "SELECT "
 
 	var selectors []string
@@ -158,7 +135,7 @@ func (query *Query) renderWhereClause() string {
 	return res
 }
 
-func (query *Query) renderGroupBy(queryContext plugins.DataQuery) string {
+func (query *Query) renderGroupBy(queryContext *backend.QueryDataRequest) string {
 	groupBy := ""
 	for i, group := range query.GroupBy {
 		if i == 0 {
@@ -184,17 +161,3 @@ func (query *Query) renderTz() string {
 	}
 	return fmt.Sprintf(" tz('%s')", tz)
 }
-
-func epochMStoInfluxTime(tr *plugins.DataTimeRange) (string, string, error) {
-	from, err := strconv.ParseInt(tr.From, 10, 64)
-	if err != nil {
-		return "", "", err
-	}
-
-	to, err := strconv.ParseInt(tr.To, 10, 64)
-	if err != nil {
-		return "", "", err
-	}
-
-	return fmt.Sprintf("%dms", from), fmt.Sprintf("%dms", to), nil
-}
@@ -2,8 +2,9 @@ package influxdb
 
 import (
 	"testing"
+	"time"
 
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 )
 
 func TestInfluxdbQueryPart(t *testing.T) {
@@ -27,8 +28,12 @@ func TestInfluxdbQueryPart(t *testing.T) {
 		{mode: "non_negative_difference", params: []string{}, input: "max(value)", expected: `non_negative_difference(max(value))`},
 	}
 
-	timeRange := plugins.NewDataTimeRange("5m", "now")
-	queryContext := plugins.DataQuery{TimeRange: &timeRange}
+	now := time.Now()
+	queryContext := &backend.QueryDataRequest{
+		Queries: []backend.DataQuery{{
+			TimeRange: backend.TimeRange{From: now.Add(-5 * time.Minute), To: now},
+		}},
+	}
 	query := &Query{}
 
 	for _, tc := range tcs {
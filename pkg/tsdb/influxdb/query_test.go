@@ -6,7 +6,7 @@ import (
 
 	"strings"
 
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -27,9 +27,11 @@ func TestInfluxdbQueryBuilder(t *testing.T) {
 		tag1 := &Tag{Key: "hostname", Value: "server1", Operator: "="}
 		tag2 := &Tag{Key: "hostname", Value: "server2", Operator: "=", Condition: "OR"}
 
-		timeRange := plugins.NewDataTimeRange("5m", "now")
-		queryContext := plugins.DataQuery{
-			TimeRange: &timeRange,
+		from := time.Unix(1620000000, 0)
+		queryContext := &backend.QueryDataRequest{
+			Queries: []backend.DataQuery{{
+				TimeRange: backend.TimeRange{From: from, To: from.Add(5 * time.Minute)},
+			}},
 		}
 
 		Convey("can build simple query", func() {
@@ -43,7 +45,7 @@ func TestInfluxdbQueryBuilder(t *testing.T) {
 
 			rawQuery, err := query.Build(queryContext)
 			So(err, ShouldBeNil)
-			So(rawQuery, ShouldEqual, `SELECT mean("value") FROM "policy"."cpu" WHERE time > now() - 5m GROUP BY time(10s) fill(null)`)
+			So(rawQuery, ShouldEqual, `SELECT mean("value") FROM "policy"."cpu" WHERE time > 1620000000000ms and time < 1620000300000ms GROUP BY time(10s) fill(null)`)
 		})
 
 		Convey("can build query with tz", func() {
@@ -57,7 +59,7 @@ func TestInfluxdbQueryBuilder(t *testing.T) {
 
 			rawQuery, err := query.Build(queryContext)
 			So(err, ShouldBeNil)
-			So(rawQuery, ShouldEqual, `SELECT mean("value") FROM "cpu" WHERE time > now() - 5m GROUP BY time(5s) tz('Europe/Paris')`)
+			So(rawQuery, ShouldEqual, `SELECT mean("value") FROM "cpu" WHERE time > 1620000000000ms and time < 1620000300000ms GROUP BY time(5s) tz('Europe/Paris')`)
 		})
 
 		Convey("can build query with group bys", func() {
@@ -71,7 +73,7 @@ func TestInfluxdbQueryBuilder(t *testing.T) {
 
 			rawQuery, err := query.Build(queryContext)
 			So(err, ShouldBeNil)
-			So(rawQuery, ShouldEqual, `SELECT mean("value") FROM "cpu" WHERE ("hostname" = 'server1' OR "hostname" = 'server2') AND time > now() - 5m GROUP BY time(5s), "datacenter" fill(null)`)
+			So(rawQuery, ShouldEqual, `SELECT mean("value") FROM "cpu" WHERE ("hostname" = 'server1' OR "hostname" = 'server2') AND time > 1620000000000ms and time < 1620000300000ms GROUP BY time(5s), "datacenter" fill(null)`)
 		})
 
 		Convey("can build query with math part", func() {
@@ -83,7 +85,7 @@ func TestInfluxdbQueryBuilder(t *testing.T) {
 
 			rawQuery, err := query.Build(queryContext)
 			So(err, ShouldBeNil)
-			So(rawQuery, ShouldEqual, `SELECT mean("value") / 100 FROM "cpu" WHERE time > now() - 5m`)
+			So(rawQuery, ShouldEqual, `SELECT mean("value") / 100 FROM "cpu" WHERE time > 1620000000000ms and time < 1620000300000ms`)
 		})
 
 		Convey("can build query with math part using $__interval_ms variable", func() {
@@ -95,7 +97,7 @@ func TestInfluxdbQueryBuilder(t *testing.T) {
 
 			rawQuery, err := query.Build(queryContext)
 			So(err, ShouldBeNil)
-			So(rawQuery, ShouldEqual, `SELECT mean("value") / 5000 FROM "cpu" WHERE time > now() - 5m`)
+			So(rawQuery, ShouldEqual, `SELECT mean("value") / 5000 FROM "cpu" WHERE time > 1620000000000ms and time < 1620000300000ms`)
 		})
 
 		Convey("can build query with old $interval variable", func() {
@@ -108,23 +110,12 @@ func TestInfluxdbQueryBuilder(t *testing.T) {
 
 			rawQuery, err := query.Build(queryContext)
 			So(err, ShouldBeNil)
-			So(rawQuery, ShouldEqual, `SELECT mean("value") FROM "cpu" WHERE time > now() - 5m GROUP BY time(200ms)`)
+			So(rawQuery, ShouldEqual, `SELECT mean("value") FROM "cpu" WHERE time > 1620000000000ms and time < 1620000300000ms GROUP BY time(200ms)`)
 		})
 
 		Convey("can render time range", func() {
 			query := Query{}
-			Convey("render from: 2h to now-1h", func() {
-				query := Query{}
-				timeRange := plugins.NewDataTimeRange("2h", "now-1h")
-				queryContext := plugins.DataQuery{TimeRange: &timeRange}
-				So(query.renderTimeFilter(queryContext), ShouldEqual, "time > now() - 2h and time < now() - 1h")
-			})
-
-			Convey("render from: 10m", func() {
-				timeRange := plugins.NewDataTimeRange("10m", "now")
-				queryContext := plugins.DataQuery{TimeRange: &timeRange}
-				So(query.renderTimeFilter(queryContext), ShouldEqual, "time > now() - 10m")
-			})
+			So(query.renderTimeFilter(queryContext), ShouldEqual, "time > 1620000000000ms and time < 1620000300000ms")
 		})
 
 		Convey("can build query from raw query", func() {
@@ -9,8 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
-	"github.com/grafana/grafana/pkg/plugins"
 )
 
 type ResponseParser struct{}
@@ -23,9 +23,8 @@ func init() {
 	legendFormat = regexp.MustCompile(`\[\[([\@\/\w-]+)(\.[\@\/\w-]+)*\]\]*|\$\s*([\@\/\w-]+?)*`)
 }
 
-// nolint:staticcheck // plugins.DataQueryResult deprecated
-func (rp *ResponseParser) Parse(buf io.ReadCloser, query *Query) plugins.DataQueryResult {
-	var queryRes plugins.DataQueryResult
+func (rp *ResponseParser) Parse(buf io.ReadCloser, query *Query) backend.DataResponse {
+	var queryRes backend.DataResponse
 
 	response, jsonErr := parseJSON(buf)
 	if jsonErr != nil {
@@ -45,7 +44,7 @@ func (rp *ResponseParser) Parse(buf io.ReadCloser, query *Query) plugins.DataQue
 			queryRes.Error = fmt.Errorf(result.Error)
 		}
 	}
-	queryRes.Dataframes = plugins.NewDecodedDataFrames(frames)
+	queryRes.Frames = frames
 
 	return queryRes
 }
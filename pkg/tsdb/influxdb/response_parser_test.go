@@ -8,8 +8,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/grafana/grafana-plugin-sdk-go/data"
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,16 +16,8 @@ func prepare(text string) io.ReadCloser {
 	return ioutil.NopCloser(strings.NewReader(text))
 }
 
-// nolint:staticcheck // plugins.DataQueryResult deprecated
-func decodedFrames(t *testing.T, result plugins.DataQueryResult) data.Frames {
-	decoded, err := result.Dataframes.Decoded()
-	require.NoError(t, err)
-	return decoded
-}
-
-// nolint:staticcheck // plugins.DataQueryResult deprecated
-func assertSeriesName(t *testing.T, result plugins.DataQueryResult, index int, name string) {
-	decoded := decodedFrames(t, result)
+func assertSeriesName(t *testing.T, result backend.DataResponse, index int, name string) {
+	decoded := result.Frames
 
 	frame := decoded[index]
 
@@ -53,7 +44,7 @@ func TestInfluxdbResponseParser(t *testing.T) {
 
 		result := parser.Parse(prepare(response), query)
 
-		require.Nil(t, result.Dataframes)
+		require.Nil(t, result.Frames)
 		require.Error(t, result.Error)
 	})
 
@@ -85,7 +76,7 @@ func TestInfluxdbResponseParser(t *testing.T) {
 
 		result := parser.Parse(prepare(response), query)
 
-		decoded := decodedFrames(t, result)
+		decoded := result.Frames
 		require.Len(t, decoded, 2)
 		frame1 := decoded[0]
 		frame2 := decoded[1]
@@ -135,9 +126,8 @@ func TestInfluxdbResponseParser(t *testing.T) {
 
 		// the current behavior is that we do not report an error, we turn the invalid value into `nil`
 		require.Nil(t, result.Error)
-		require.Equal(t, result.ErrorString, "")
 
-		decoded := decodedFrames(t, result)
+		decoded := result.Frames
 		require.Len(t, decoded, 1)
 
 		frame := decoded[0]
@@ -185,9 +175,8 @@ func TestInfluxdbResponseParser(t *testing.T) {
 
 		// the current behavior is that we do not report an error, we skip the item with the invalid timestamp
 		require.Nil(t, result.Error)
-		require.Equal(t, result.ErrorString, "")
 
-		decoded := decodedFrames(t, result)
+		decoded := result.Frames
 		require.Len(t, decoded, 1)
 
 		frame := decoded[0]
@@ -336,7 +325,7 @@ func TestInfluxdbResponseParser(t *testing.T) {
 
 		result := parser.Parse(prepare(response), query)
 
-		decoded := decodedFrames(t, result)
+		decoded := result.Frames
 
 		require.Len(t, decoded, 2)
 
@@ -361,7 +350,7 @@ func TestInfluxdbResponseParser(t *testing.T) {
 
 		result := parser.Parse(prepare(response), query)
 
-		require.Nil(t, result.Dataframes)
+		require.Nil(t, result.Frames)
 
 		require.EqualError(t, result.Error, "error parsing query: found THING")
 	})
@@ -0,0 +1,42 @@
+package loki
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// healthCheckRange is the time range of the labels the health check gets, which is short because
+// getting the labels of longer time ranges takes too long.
+const healthCheckRange = 10 * time.Minute
+
+// CheckHealth checks that Loki returns the labels of the last minutes. Loki having no labels
+// is not an error, as it can have received no logs in these minutes.
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	labels, err := newClient(ctx, dsInfo).ListLabelNames(true, end.Add(-healthCheckRange), end)
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: fmt.Sprintf("Loki: %s", err),
+		}, nil
+	}
+	if len(labels.Data) == 0 {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusOk,
+			Message: "Data source connected, but no labels received. Verify that Loki and Promtail is configured properly.",
+		}, nil
+	}
+
+	return &backend.CheckHealthResult{
+		Status:  backend.HealthStatusOk,
+		Message: "Data source connected and labels found.",
+	}, nil
+}
@@ -0,0 +1,61 @@
+package loki
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHealth(t *testing.T) {
+	checkHealth := func(t *testing.T, ctx context.Context, labels string) (*backend.CheckHealthResult, int32) {
+		t.Helper()
+		var requests int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			if r.URL.Path != "/loki/api/v1/labels" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"status": "success", "data": ` + labels + `}`))
+		}))
+		t.Cleanup(srv.Close)
+
+		service := &Service{im: datasource.NewInstanceManager(newInstanceSettings(httpclient.NewProvider()))}
+		res, err := service.CheckHealth(ctx, &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, URL: srv.URL},
+			},
+		})
+		require.NoError(t, err)
+		return res, atomic.LoadInt32(&requests)
+	}
+
+	t.Run("the data source is healthy when labels are returned", func(t *testing.T) {
+		res, requests := checkHealth(t, context.Background(), `["app", "job"]`)
+		require.Equal(t, backend.HealthStatusOk, res.Status)
+		require.Equal(t, "Data source connected and labels found.", res.Message)
+		require.Equal(t, int32(1), requests)
+	})
+
+	t.Run("the data source is healthy when no labels are returned", func(t *testing.T) {
+		res, _ := checkHealth(t, context.Background(), `[]`)
+		require.Equal(t, backend.HealthStatusOk, res.Status)
+		require.Contains(t, res.Message, "no labels received")
+	})
+
+	t.Run("the labels are not requested when the context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		res, requests := checkHealth(t, ctx, `["app"]`)
+		require.Equal(t, backend.HealthStatusError, res.Status)
+		require.Contains(t, res.Message, context.Canceled.Error())
+		require.Equal(t, int32(0), requests)
+	})
+}
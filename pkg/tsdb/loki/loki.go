@@ -3,107 +3,198 @@ package loki
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/tsdb/interval"
 	"github.com/grafana/loki/pkg/logcli/client"
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/opentracing/opentracing-go"
-	"github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
+	"golang.org/x/sync/errgroup"
 )
 
-type LokiExecutor struct {
+type Service struct {
+	HTTPClientProvider   httpclient.Provider   `inject:""`
+	BackendPluginManager backendplugin.Manager `inject:""`
+
 	intervalCalculator interval.Calculator
-	httpClientProvider httpclient.Provider
+	im                 instancemgmt.InstanceManager
 }
 
-// nolint:staticcheck // plugins.DataPlugin deprecated
-func New(httpClientProvider httpclient.Provider) func(dsInfo *models.DataSource) (plugins.DataPlugin, error) {
-	// nolint:staticcheck // plugins.DataPlugin deprecated
-	return func(dsInfo *models.DataSource) (plugins.DataPlugin, error) {
-		return &LokiExecutor{
-			intervalCalculator: interval.NewCalculator(interval.CalculatorOptions{MinInterval: time.Second * 1}),
-			httpClientProvider: httpClientProvider,
-		}, nil
-	}
+type datasourceInfo struct {
+	HTTPClient *http.Client
+	URL        string
+	JSONData   *simplejson.Json
+	// MaxLines is the maximum number of log lines of the queries.
+	MaxLines int
 }
 
+// defaultMaxLines is the maximum number of log lines of the queries when the data source does not set it.
+const defaultMaxLines = 1000
+
 var (
 	plog         = log.New("tsdb.loki")
 	legendFormat = regexp.MustCompile(`\{\{\s*(.+?)\s*\}\}`)
 )
 
-// DataQuery executes a Loki query.
-//nolint: staticcheck // plugins.DataPlugin deprecated
-func (e *LokiExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource,
-	queryContext plugins.DataQuery) (plugins.DataResponse, error) {
-	result := plugins.DataResponse{
-		Results: map[string]plugins.DataQueryResult{},
+func init() {
+	registry.Register(&registry.Descriptor{Instance: &Service{}})
+}
+
+func (s *Service) Init() error {
+	s.intervalCalculator = interval.NewCalculator(interval.CalculatorOptions{MinInterval: time.Second * 1})
+	s.im = datasource.NewInstanceManager(newInstanceSettings(s.HTTPClientProvider))
+
+	factory := coreplugin.New(backend.ServeOpts{
+		QueryDataHandler:   s,
+		CheckHealthHandler: s,
+	})
+
+	if err := s.BackendPluginManager.Register("loki", factory); err != nil {
+		plog.Error("Failed to register plugin", "error", err)
 	}
 
-	tlsConfig, err := dsInfo.GetTLSConfig(e.httpClientProvider)
-	if err != nil {
-		return plugins.DataResponse{}, err
+	return nil
+}
+
+func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		opts, err := models.HTTPClientOptionsFromInstanceSettings("loki", settings)
+		if err != nil {
+			return nil, err
+		}
+
+		client, err := httpClientProvider.New(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		jsonData := simplejson.New()
+		if len(settings.JSONData) > 0 {
+			if jsonData, err = simplejson.NewJson(settings.JSONData); err != nil {
+				return nil, err
+			}
+		}
+
+		// the number of log lines of the queries is limited by the one of the data source
+		maxLines, err := intValue(jsonData.Get("maxLines"))
+		if err != nil || maxLines <= 0 {
+			maxLines = defaultMaxLines
+		}
+
+		return &datasourceInfo{
+			HTTPClient: client,
+			URL:        settings.URL,
+			JSONData:   jsonData,
+			MaxLines:   maxLines,
+		}, nil
 	}
+}
 
-	transport, err := dsInfo.GetHTTPTransport(e.httpClientProvider)
-	if err != nil {
-		return plugins.DataResponse{}, err
+// intValue returns the integer of a JSON value that is either a number or a string, as the
+// settings saved by the frontend can be either.
+func intValue(j *simplejson.Json) (int, error) {
+	if s, err := j.String(); err == nil {
+		return strconv.Atoi(s)
 	}
+	return j.Int()
+}
 
-	client := &client.DefaultClient{
-		Address:  dsInfo.Url,
-		Username: dsInfo.BasicAuthUser,
-		Password: dsInfo.DecryptedBasicAuthPassword(),
-		TLSConfig: config.TLSConfig{
-			InsecureSkipVerify: tlsConfig.InsecureSkipVerify,
-		},
+// newClient returns a client of the Loki API of the data source instance. The client does not
+// take a context, the requests are therefore sent with the given one.
+func newClient(ctx context.Context, dsInfo *datasourceInfo) *client.DefaultClient {
+	return &client.DefaultClient{
+		Address: dsInfo.URL,
 		Tripperware: func(t http.RoundTripper) http.RoundTripper {
-			return transport
+			return sdkhttpclient.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				return dsInfo.HTTPClient.Transport.RoundTrip(req.WithContext(ctx))
+			})
 		},
 	}
+}
+
+// QueryData executes Loki queries.
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	result := backend.NewQueryDataResponse()
 
-	queries, err := e.parseQuery(dsInfo, queryContext)
+	dsInfo, err := s.getDSInfo(req.PluginContext)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
+	queries, err := s.parseQuery(dsInfo, req.Queries)
+	if err != nil {
+		return nil, err
+	}
+
+	// the queries are run concurrently, the response fails when one of them fails
+	var mu sync.Mutex
+	g, ctx := errgroup.WithContext(ctx)
+	client := newClient(ctx, dsInfo)
 	for _, query := range queries {
-		plog.Debug("Sending query", "start", query.Start, "end", query.End, "step", query.Step, "query", query.Expr)
-		span, _ := opentracing.StartSpanFromContext(ctx, "alerting.loki")
-		span.SetTag("expr", query.Expr)
-		span.SetTag("start_unixnano", query.Start.UnixNano())
-		span.SetTag("stop_unixnano", query.End.UnixNano())
-		defer span.Finish()
-
-		//Currently hard coded as not used - applies to log queries
-		limit := 1000
+		query := query
+		g.Go(func() error {
+			frames, err := runQuery(ctx, client, query)
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			result.Responses[query.RefID] = backend.DataResponse{Frames: frames}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// runQuery runs a query and converts its result to data frames.
+func runQuery(ctx context.Context, client *client.DefaultClient, query *lokiQuery) (data.Frames, error) {
+	plog.Debug("Sending query", "type", query.QueryType, "start", query.Start, "end", query.End, "step", query.Step, "query", query.Expr)
+	span, _ := opentracing.StartSpanFromContext(ctx, "alerting.loki")
+	span.SetTag("expr", query.Expr)
+	span.SetTag("start_unixnano", query.Start.UnixNano())
+	span.SetTag("stop_unixnano", query.End.UnixNano())
+	defer span.Finish()
+
+	var value *loghttp.QueryResponse
+	var err error
+	if query.QueryType == queryTypeInstant {
+		value, err = client.Query(query.Expr, query.MaxLines, query.End, query.Direction, false)
+	} else {
 		//Currently hard coded as not used - applies to queries which produce a stream response
 		interval := time.Second * 1
 
-		value, err := client.QueryRange(query.Expr, limit, query.Start, query.End, logproto.BACKWARD, query.Step, interval, false)
-		if err != nil {
-			return plugins.DataResponse{}, err
-		}
-
-		queryResult, err := parseResponse(value, query)
-		if err != nil {
-			return plugins.DataResponse{}, err
-		}
-		result.Results[query.RefID] = queryResult
+		value, err = client.QueryRange(query.Expr, query.MaxLines, query.Start, query.End, query.Direction, query.Step, interval, false)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return parseResponse(value, query)
 }
 
 //If legend (using of name or pattern instead of time series name) is used, use that name/pattern for formatting
@@ -125,58 +216,110 @@ func formatLegend(metric model.Metric, query *lokiQuery) string {
 	return string(result)
 }
 
-func (e *LokiExecutor) parseQuery(dsInfo *models.DataSource, queryContext plugins.DataQuery) ([]*lokiQuery, error) {
+func (s *Service) parseQuery(dsInfo *datasourceInfo, queries []backend.DataQuery) ([]*lokiQuery, error) {
 	qs := []*lokiQuery{}
-	for _, queryModel := range queryContext.Queries {
-		expr, err := queryModel.Model.Get("expr").String()
+	for _, query := range queries {
+		model, err := simplejson.NewJson(query.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse query model: %v", err)
+		}
+
+		expr, err := model.Get("expr").String()
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse Expr: %v", err)
 		}
 
-		format := queryModel.Model.Get("legendFormat").MustString("")
+		format := model.Get("legendFormat").MustString("")
 
-		start, err := queryContext.TimeRange.ParseFrom()
+		queryType, err := parseQueryType(model)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse From: %v", err)
+			return nil, err
 		}
 
-		end, err := queryContext.TimeRange.ParseTo()
+		direction, err := parseDirection(model.Get("direction").MustString(""))
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse To: %v", err)
+			return nil, err
 		}
 
-		dsInterval, err := interval.GetIntervalFrom(dsInfo, queryModel.Model, time.Second)
+		maxLines, err := intValue(model.Get("maxLines"))
+		if err != nil || maxLines <= 0 || maxLines > dsInfo.MaxLines {
+			maxLines = dsInfo.MaxLines
+		}
+
+		dsInterval, err := interval.GetIntervalFrom(&models.DataSource{JsonData: dsInfo.JSONData}, model, time.Second)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse Interval: %v", err)
 		}
 
-		interval := e.intervalCalculator.Calculate(*queryContext.TimeRange, dsInterval)
+		interval := s.intervalCalculator.Calculate(plugins.NewDataTimeRangeFromBackend(query.TimeRange), dsInterval)
 		step := time.Duration(int64(interval.Value))
 
 		qs = append(qs, &lokiQuery{
 			Expr:         expr,
+			QueryType:    queryType,
+			Direction:    direction,
+			MaxLines:     maxLines,
 			Step:         step,
 			LegendFormat: format,
-			Start:        start,
-			End:          end,
-			RefID:        queryModel.RefID,
+			Start:        query.TimeRange.From,
+			End:          query.TimeRange.To,
+			RefID:        query.RefID,
 		})
 	}
 
 	return qs, nil
 }
 
-//nolint: staticcheck // plugins.DataPlugin deprecated
-func parseResponse(value *loghttp.QueryResponse, query *lokiQuery) (plugins.DataQueryResult, error) {
-	var queryRes plugins.DataQueryResult
-	frames := data.Frames{}
+// parseQueryType returns the type of the query, from its queryType, or from the instant
+// flag of the queries of the frontend. The queries are range queries by default.
+func parseQueryType(model *simplejson.Json) (lokiQueryType, error) {
+	switch queryType := lokiQueryType(model.Get("queryType").MustString("")); queryType {
+	case queryTypeRange, queryTypeInstant:
+		return queryType, nil
+	case "":
+		if model.Get("instant").MustBool(false) {
+			return queryTypeInstant, nil
+		}
+		return queryTypeRange, nil
+	default:
+		return "", fmt.Errorf("unsupported query type: %q", queryType)
+	}
+}
 
-	//We are currently processing only matrix results (for alerting)
-	matrix, ok := value.Data.Result.(loghttp.Matrix)
+// parseDirection returns the order of the log lines of the query, the most recent
+// log lines come first by default.
+func parseDirection(direction string) (logproto.Direction, error) {
+	if direction == "" {
+		return logproto.BACKWARD, nil
+	}
+	d, ok := logproto.Direction_value[strings.ToUpper(direction)]
 	if !ok {
-		return queryRes, fmt.Errorf("unsupported result format: %q", value.Data.ResultType)
+		return 0, fmt.Errorf("unsupported direction: %q", direction)
+	}
+	return logproto.Direction(d), nil
+}
+
+func parseResponse(value *loghttp.QueryResponse, query *lokiQuery) (data.Frames, error) {
+	var frames data.Frames
+
+	switch result := value.Data.Result.(type) {
+	case loghttp.Matrix:
+		frames = matrixToFrames(result, query)
+	case loghttp.Vector:
+		frames = vectorToFrames(result, query)
+	case loghttp.Scalar:
+		frames = data.Frames{scalarToFrame(result)}
+	case loghttp.Streams:
+		frames = streamsToFrames(result, query)
+	default:
+		return nil, fmt.Errorf("unsupported result format: %q", value.Data.ResultType)
 	}
 
+	return frames, nil
+}
+
+func matrixToFrames(matrix loghttp.Matrix, query *lokiQuery) data.Frames {
+	frames := make(data.Frames, 0, len(matrix))
 	for _, v := range matrix {
 		name := formatLegend(v.Metric, query)
 		tags := make(map[string]string, len(v.Metric))
@@ -196,7 +339,93 @@ func parseResponse(value *loghttp.QueryResponse, query *lokiQuery) (plugins.Data
 			data.NewField("time", nil, timeVector),
 			data.NewField("value", tags, values).SetConfig(&data.FieldConfig{DisplayNameFromDS: name})))
 	}
-	queryRes.Dataframes = plugins.NewDecodedDataFrames(frames)
+	return frames
+}
+
+// vectorToFrames converts the result of an instant metric query, with a frame of a single value for each series.
+func vectorToFrames(vector loghttp.Vector, query *lokiQuery) data.Frames {
+	frames := make(data.Frames, 0, len(vector))
+	for _, v := range vector {
+		name := formatLegend(v.Metric, query)
+		tags := make(map[string]string, len(v.Metric))
+		for k, v := range v.Metric {
+			tags[string(k)] = string(v)
+		}
+
+		frames = append(frames, data.NewFrame(name,
+			data.NewField("time", nil, []time.Time{time.Unix(v.Timestamp.Unix(), 0).UTC()}),
+			data.NewField("value", tags, []float64{float64(v.Value)}).SetConfig(&data.FieldConfig{DisplayNameFromDS: name})))
+	}
+	return frames
+}
+
+func scalarToFrame(scalar loghttp.Scalar) *data.Frame {
+	return data.NewFrame("",
+		data.NewField("time", nil, []time.Time{time.Unix(scalar.Timestamp.Unix(), 0).UTC()}),
+		data.NewField("value", nil, []float64{float64(scalar.Value)}))
+}
+
+// streamsToFrames converts the log lines of a log query, with a frame for each stream. The labels
+// of the stream are the ones of its line field, and each log line has an ID that is unique in the
+// response, as the log lines of the frontend.
+func streamsToFrames(streams loghttp.Streams, query *lokiQuery) data.Frames {
+	frames := make(data.Frames, 0, len(streams))
+	for _, stream := range streams {
+		labels := data.Labels(stream.Labels.Map())
+		times := make([]time.Time, 0, len(stream.Entries))
+		timesNs := make([]string, 0, len(stream.Entries))
+		lines := make([]string, 0, len(stream.Entries))
+		ids := make([]string, 0, len(stream.Entries))
+		usedIDs := make(map[string]int, len(stream.Entries))
+
+		for _, entry := range stream.Entries {
+			tsNs := strconv.FormatInt(entry.Timestamp.UnixNano(), 10)
+			times = append(times, entry.Timestamp.UTC())
+			timesNs = append(timesNs, tsNs)
+			lines = append(lines, entry.Line)
+			ids = append(ids, lineID(tsNs, labels, entry.Line, query.RefID, usedIDs))
+		}
+
+		frame := data.NewFrame("",
+			data.NewField("ts", nil, times).SetConfig(&data.FieldConfig{DisplayName: "Time"}),
+			data.NewField("line", labels, lines),
+			data.NewField("id", nil, ids),
+			data.NewField("tsNs", nil, timesNs).SetConfig(&data.FieldConfig{DisplayName: "Time ns"}))
+		frame.RefID = query.RefID
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// lineID returns the ID of a log line, a hash of its timestamp, labels and content that is
+// followed by a counter when several log lines have the same hash.
+func lineID(tsNs string, labels data.Labels, line, refID string, usedIDs map[string]int) string {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%s_%s_%s", tsNs, labels.String(), line)
+	id := strconv.FormatUint(h.Sum64(), 16)
+
+	if count, ok := usedIDs[id]; ok {
+		usedIDs[id] = count + 1
+		id = fmt.Sprintf("%s_%d", id, count+1)
+	} else {
+		usedIDs[id] = 0
+	}
+	if refID != "" {
+		id = fmt.Sprintf("%s_%s", id, refID)
+	}
+	return id
+}
+
+func (s *Service) getDSInfo(pluginCtx backend.PluginContext) (*datasourceInfo, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := i.(*datasourceInfo)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast data source info")
+	}
 
-	return queryRes, nil
+	return instance, nil
 }
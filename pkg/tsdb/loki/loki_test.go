@@ -1,22 +1,34 @@
 package loki
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
-	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/tsdb/interval"
 	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
 	p "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestLoki(t *testing.T) {
-	dsInfo := &models.DataSource{
-		JsonData: simplejson.New(),
+	dsInfo := &datasourceInfo{
+		JSONData: simplejson.New(),
+		MaxLines: defaultMaxLines,
 	}
+	service := &Service{
+		intervalCalculator: interval.NewCalculator(interval.CalculatorOptions{MinInterval: time.Second * 1}),
+	}
+	now := time.Now()
 
 	t.Run("converting metric name", func(t *testing.T) {
 		metric := map[p.LabelName]p.LabelValue{
@@ -51,22 +63,72 @@ func TestLoki(t *testing.T) {
 				"format": "time_series",
 				"refId": "A"
 			}`
-		jsonModel, err := simplejson.NewJson([]byte(json))
+		queries := []backend.DataQuery{{
+			JSON:      []byte(json),
+			TimeRange: backend.TimeRange{From: now.Add(-12 * time.Hour), To: now},
+		}}
+
+		models, err := service.parseQuery(dsInfo, queries)
 		require.NoError(t, err)
-		timeRange := plugins.NewDataTimeRange("12h", "now")
-		queryContext := plugins.DataQuery{
-			Queries: []plugins.DataSubQuery{
-				{Model: jsonModel},
-			},
-			TimeRange: &timeRange,
-		}
+		require.Equal(t, time.Second*30, models[0].Step)
+	})
 
-		exe, err := New(httpclient.NewProvider())(dsInfo)
+	t.Run("parsing query model with the defaults of the log queries", func(t *testing.T) {
+		queries := []backend.DataQuery{{
+			JSON:      []byte(`{"expr": "{app=\"backend\"}", "refId": "A"}`),
+			TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+		}}
+
+		models, err := service.parseQuery(dsInfo, queries)
 		require.NoError(t, err)
-		lokiExecutor := exe.(*LokiExecutor)
-		models, err := lokiExecutor.parseQuery(dsInfo, queryContext)
+		require.Equal(t, queryTypeRange, models[0].QueryType)
+		require.Equal(t, logproto.BACKWARD, models[0].Direction)
+		require.Equal(t, 1000, models[0].MaxLines)
+	})
+
+	t.Run("parsing query model with query type, direction and max lines", func(t *testing.T) {
+		instance, err := newInstanceSettings(httpclient.NewProvider())(backend.DataSourceInstanceSettings{
+			JSONData: []byte(`{"maxLines": "500"}`),
+		})
 		require.NoError(t, err)
-		require.Equal(t, time.Second*30, models[0].Step)
+		dsInfo := instance.(*datasourceInfo)
+		timeRange := backend.TimeRange{From: now.Add(-time.Hour), To: now}
+
+		for _, tc := range []struct {
+			json      string
+			queryType lokiQueryType
+			direction logproto.Direction
+			maxLines  int
+		}{
+			{`{"expr": "{app=\"backend\"}", "queryType": "instant", "direction": "forward", "maxLines": 100}`, queryTypeInstant, logproto.FORWARD, 100},
+			{`{"expr": "{app=\"backend\"}", "instant": true, "direction": "BACKWARD", "maxLines": 5000}`, queryTypeInstant, logproto.BACKWARD, 500},
+			{`{"expr": "{app=\"backend\"}", "queryType": "range", "instant": true}`, queryTypeRange, logproto.BACKWARD, 500},
+			{`{"expr": "{app=\"backend\"}", "maxLines": "200"}`, queryTypeRange, logproto.BACKWARD, 200},
+		} {
+			queries := []backend.DataQuery{{JSON: []byte(tc.json), TimeRange: timeRange}}
+			models, err := service.parseQuery(dsInfo, queries)
+			require.NoError(t, err, tc.json)
+			require.Equal(t, tc.queryType, models[0].QueryType, tc.json)
+			require.Equal(t, tc.direction, models[0].Direction, tc.json)
+			require.Equal(t, tc.maxLines, models[0].MaxLines, tc.json)
+		}
+
+		for _, jsonData := range []string{`{"maxLines": 500}`, `{"maxLines": "500"}`} {
+			instance, err := newInstanceSettings(httpclient.NewProvider())(backend.DataSourceInstanceSettings{
+				JSONData: []byte(jsonData),
+			})
+			require.NoError(t, err)
+			require.Equal(t, 500, instance.(*datasourceInfo).MaxLines, jsonData)
+		}
+
+		for _, json := range []string{
+			`{"expr": "{app=\"backend\"}", "queryType": "logs"}`,
+			`{"expr": "{app=\"backend\"}", "direction": "up"}`,
+		} {
+			queries := []backend.DataQuery{{JSON: []byte(json), TimeRange: timeRange}}
+			_, err := service.parseQuery(dsInfo, queries)
+			require.Error(t, err, json)
+		}
 	})
 
 	t.Run("parsing query model without step parameter", func(t *testing.T) {
@@ -75,43 +137,31 @@ func TestLoki(t *testing.T) {
 				"format": "time_series",
 				"refId": "A"
 			}`
-		jsonModel, err := simplejson.NewJson([]byte(json))
-		require.NoError(t, err)
-		timeRange := plugins.NewDataTimeRange("48h", "now")
-		queryContext := plugins.DataQuery{
-			TimeRange: &timeRange,
-			Queries: []plugins.DataSubQuery{
-				{Model: jsonModel},
-			},
-		}
-		exe, err := New(httpclient.NewProvider())(dsInfo)
-		require.NoError(t, err)
-		lokiExecutor := exe.(*LokiExecutor)
-		models, err := lokiExecutor.parseQuery(dsInfo, queryContext)
+		queries := []backend.DataQuery{{
+			JSON:      []byte(json),
+			TimeRange: backend.TimeRange{From: now.Add(-48 * time.Hour), To: now},
+		}}
+		models, err := service.parseQuery(dsInfo, queries)
 		require.NoError(t, err)
 		require.Equal(t, time.Minute*2, models[0].Step)
 
-		timeRange = plugins.NewDataTimeRange("1h", "now")
-		queryContext.TimeRange = &timeRange
-		models, err = lokiExecutor.parseQuery(dsInfo, queryContext)
+		queries[0].TimeRange = backend.TimeRange{From: now.Add(-time.Hour), To: now}
+		models, err = service.parseQuery(dsInfo, queries)
 		require.NoError(t, err)
 		require.Equal(t, time.Second*2, models[0].Step)
 	})
 }
 
 func TestParseResponse(t *testing.T) {
-	t.Run("value is not of type matrix", func(t *testing.T) {
-		//nolint: staticcheck // plugins.DataPlugin deprecated
-		queryRes := plugins.DataQueryResult{}
-
+	t.Run("value is not of a supported type", func(t *testing.T) {
 		value := loghttp.QueryResponse{
 			Data: loghttp.QueryResponseData{
-				Result: loghttp.Vector{},
+				ResultType: "unknown",
 			},
 		}
 		res, err := parseResponse(&value, nil)
 
-		require.Equal(t, queryRes, res)
+		require.Nil(t, res)
 		require.Error(t, err)
 	})
 
@@ -137,10 +187,9 @@ func TestParseResponse(t *testing.T) {
 		query := &lokiQuery{
 			LegendFormat: "legend {{app}}",
 		}
-		res, err := parseResponse(&value, query)
+		decoded, err := parseResponse(&value, query)
 		require.NoError(t, err)
 
-		decoded, _ := res.Dataframes.Decoded()
 		require.Len(t, decoded, 1)
 		require.Equal(t, decoded[0].Name, "legend Application")
 		require.Len(t, decoded[0].Fields, 2)
@@ -155,4 +204,128 @@ func TestParseResponse(t *testing.T) {
 		testValue := decoded[0].Fields[0].At(0)
 		require.Equal(t, "UTC", testValue.(time.Time).Location().String())
 	})
+
+	t.Run("instant metric queries are parsed with a single value for each series", func(t *testing.T) {
+		value := loghttp.QueryResponse{
+			Data: loghttp.QueryResponseData{
+				Result: loghttp.Vector{
+					p.Sample{Metric: p.Metric{"app": "Application"}, Value: 3, Timestamp: 1000},
+				},
+			},
+		}
+
+		decoded, err := parseResponse(&value, &lokiQuery{LegendFormat: "legend {{app}}"})
+		require.NoError(t, err)
+
+		require.Len(t, decoded, 1)
+		require.Equal(t, "legend Application", decoded[0].Name)
+		require.Equal(t, 1, decoded[0].Rows())
+		require.Equal(t, time.Unix(1, 0).UTC(), decoded[0].Fields[0].At(0))
+		require.Equal(t, 3.0, decoded[0].Fields[1].At(0))
+		require.Equal(t, "app=Application", decoded[0].Fields[1].Labels.String())
+	})
+
+	t.Run("log streams are parsed with a frame for each stream", func(t *testing.T) {
+		ts := time.Date(2021, 6, 1, 12, 0, 0, 1, time.UTC)
+		value := loghttp.QueryResponse{
+			Data: loghttp.QueryResponseData{
+				Result: loghttp.Streams{
+					{
+						Labels: loghttp.LabelSet{"app": "backend"},
+						Entries: []loghttp.Entry{
+							{Timestamp: ts, Line: "line 1"},
+							{Timestamp: ts, Line: "line 1"},
+						},
+					},
+					{
+						Labels:  loghttp.LabelSet{"app": "frontend"},
+						Entries: []loghttp.Entry{{Timestamp: ts, Line: "line 1"}},
+					},
+				},
+			},
+		}
+
+		decoded, err := parseResponse(&value, &lokiQuery{RefID: "A"})
+		require.NoError(t, err)
+
+		require.Len(t, decoded, 2)
+		frame := decoded[0]
+		require.Equal(t, "A", frame.RefID)
+		require.Len(t, frame.Fields, 4)
+		require.Equal(t, "ts", frame.Fields[0].Name)
+		require.Equal(t, ts, frame.Fields[0].At(0))
+		require.Equal(t, "line", frame.Fields[1].Name)
+		require.Equal(t, "line 1", frame.Fields[1].At(0))
+		require.Equal(t, "app=backend", frame.Fields[1].Labels.String())
+		require.Equal(t, "id", frame.Fields[2].Name)
+		require.Equal(t, "tsNs", frame.Fields[3].Name)
+		require.Equal(t, "1622548800000000001", frame.Fields[3].At(0))
+
+		// the log lines have unique IDs, even when they are identical
+		ids := map[string]bool{}
+		for _, f := range decoded {
+			for i := 0; i < f.Rows(); i++ {
+				ids[f.Fields[2].At(i).(string)] = true
+			}
+		}
+		require.Len(t, ids, 3)
+	})
+}
+
+func TestDataQuery(t *testing.T) {
+	var paths sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		paths.Store(query.Get("query"), r.URL.Path)
+		assert.Equal(t, "300", query.Get("limit"))
+		assert.Equal(t, "FORWARD", query.Get("direction"))
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/loki/api/v1/query" {
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "vector", "result": [{"metric": {"app": "backend"}, "value": [1622548800, "4"]}]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "streams", "result": [{"stream": {"app": "backend"}, "values": [["1622548800000000000", "line"]]}]}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{
+		intervalCalculator: interval.NewCalculator(interval.CalculatorOptions{MinInterval: time.Second * 1}),
+		im:                 datasource.NewInstanceManager(newInstanceSettings(httpclient.NewProvider())),
+	}
+
+	now := time.Now()
+	timeRange := backend.TimeRange{From: now.Add(-time.Hour), To: now}
+	res, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+		PluginContext: backend.PluginContext{
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, URL: srv.URL},
+		},
+		Queries: []backend.DataQuery{
+			{
+				RefID:     "A",
+				TimeRange: timeRange,
+				JSON:      []byte(`{"expr": "{app=\"backend\"}", "direction": "FORWARD", "maxLines": 300}`),
+			},
+			{
+				RefID:     "B",
+				TimeRange: timeRange,
+				JSON:      []byte(`{"expr": "count_over_time({app=\"backend\"}[1m])", "queryType": "instant", "direction": "FORWARD", "maxLines": 300}`),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Responses, 2)
+
+	path, _ := paths.Load(`{app="backend"}`)
+	require.Equal(t, "/loki/api/v1/query_range", path)
+	path, _ = paths.Load(`count_over_time({app="backend"}[1m])`)
+	require.Equal(t, "/loki/api/v1/query", path)
+
+	logs := res.Responses["A"].Frames
+	require.Len(t, logs, 1)
+	require.Equal(t, "line", logs[0].Fields[1].At(0))
+
+	metrics := res.Responses["B"].Frames
+	require.Len(t, metrics, 1)
+	require.Equal(t, 4.0, metrics[0].Fields[1].At(0))
 }
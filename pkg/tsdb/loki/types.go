@@ -1,9 +1,25 @@
 package loki
 
-import "time"
+import (
+	"time"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// lokiQueryType is the type of a Loki query, range queries are run for the time range of the request,
+// and instant queries at its end.
+type lokiQueryType string
+
+const (
+	queryTypeRange   lokiQueryType = "range"
+	queryTypeInstant lokiQueryType = "instant"
+)
 
 type lokiQuery struct {
 	Expr         string
+	QueryType    lokiQueryType
+	Direction    logproto.Direction
+	MaxLines     int
 	Step         time.Duration
 	LegendFormat string
 	Start        time.Time
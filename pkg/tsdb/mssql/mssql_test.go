@@ -2,18 +2,17 @@ package mssql
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
-	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
-	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/sqlutil"
 	"github.com/grafana/grafana/pkg/tsdb/sqleng"
@@ -51,11 +50,7 @@ func TestMSSQL(t *testing.T) {
 		return x, nil
 	}
 
-	endpoint, err := NewExecutor(&models.DataSource{
-		JsonData:       simplejson.New(),
-		SecureJsonData: securejsondata.SecureJsonData{},
-	})
-	require.NoError(t, err)
+	endpoint := newDataSourceHandler(t)
 
 	sess := x.NewSession()
 	t.Cleanup(sess.Close)
@@ -127,10 +122,10 @@ func TestMSSQL(t *testing.T) {
 		require.NoError(t, err)
 
 		t.Run("When doing a table query should map MSSQL column types to Go types", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": "SELECT * FROM mssql_types",
 							"format": "table",
 						}),
@@ -139,15 +134,14 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NotNil(t, queryResult)
 			require.NoError(t, queryResult.Error)
-			require.NotNil(t, queryResult.Dataframes)
+			require.NotNil(t, queryResult.Frames)
 
-			frames, err := queryResult.Dataframes.Decoded()
-			require.NoError(t, err)
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 24, len(frames[0].Fields))
 
@@ -224,10 +218,10 @@ func TestMSSQL(t *testing.T) {
 		require.NoError(t, err)
 
 		t.Run("When doing a metric query using timeGroup", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": "SELECT $__timeGroup(time, '5m') AS time, avg(value) as value FROM metric GROUP BY $__timeGroup(time, '5m') ORDER BY 1",
 							"format": "time_series",
 						}),
@@ -236,12 +230,12 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Len(t, frames, 1)
 			// without fill this should result in 4 buckets
 			require.Equal(t, 4, frames[0].Fields[0].Len())
@@ -268,28 +262,25 @@ func TestMSSQL(t *testing.T) {
 		})
 
 		t.Run("When doing a metric query using timeGroup with NULL fill enabled", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": "SELECT $__timeGroup(time, '5m', NULL) AS time, avg(value) as value FROM metric GROUP BY $__timeGroup(time, '5m') ORDER BY 1",
 							"format": "time_series",
 						}),
-						RefID: "A",
+						RefID:     "A",
+						TimeRange: backend.TimeRange{From: fromStart, To: fromStart.Add(34 * time.Minute)},
 					},
 				},
-				TimeRange: &plugins.DataTimeRange{
-					From: fmt.Sprintf("%v", fromStart.Unix()*1000),
-					To:   fmt.Sprintf("%v", fromStart.Add(34*time.Minute).Unix()*1000),
-				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Len(t, frames, 1)
 			require.Equal(t, 7, frames[0].Fields[0].Len())
 
@@ -322,56 +313,49 @@ func TestMSSQL(t *testing.T) {
 
 		t.Run("When doing a metric query using timeGroup and $__interval", func(t *testing.T) {
 			t.Run("Should replace $__interval", func(t *testing.T) {
-				query := plugins.DataQuery{
-					Queries: []plugins.DataSubQuery{
+				query := &backend.QueryDataRequest{
+					Queries: []backend.DataQuery{
 						{
-							DataSource: &models.DataSource{},
-							Model: simplejson.NewFromAny(map[string]interface{}{
+							JSON: queryModel(t, map[string]interface{}{
 								"rawSql": "SELECT $__timeGroup(time, $__interval) AS time, avg(value) as value FROM metric GROUP BY $__timeGroup(time, $__interval) ORDER BY 1",
 								"format": "time_series",
 							}),
-							RefID: "A",
+							RefID:     "A",
+							TimeRange: backend.TimeRange{From: fromStart, To: fromStart.Add(30 * time.Minute)},
 						},
 					},
-					TimeRange: &plugins.DataTimeRange{
-						From: fmt.Sprintf("%v", fromStart.Unix()*1000),
-						To:   fmt.Sprintf("%v", fromStart.Add(30*time.Minute).Unix()*1000),
-					},
 				}
 
-				resp, err := endpoint.DataQuery(context.Background(), nil, query)
+				resp, err := endpoint.QueryData(context.Background(), query)
 				require.NoError(t, err)
-				queryResult := resp.Results["A"]
+				queryResult := resp.Responses["A"]
 				require.NoError(t, queryResult.Error)
 
-				frames, _ := queryResult.Dataframes.Decoded()
+				frames := queryResult.Frames
 				require.Len(t, frames, 1)
 				require.Equal(t, "SELECT FLOOR(DATEDIFF(second, '1970-01-01', time)/60)*60 AS time, avg(value) as value FROM metric GROUP BY FLOOR(DATEDIFF(second, '1970-01-01', time)/60)*60 ORDER BY 1", frames[0].Meta.ExecutedQueryString)
 			})
 		})
 		t.Run("When doing a metric query using timeGroup with float fill enabled", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": "SELECT $__timeGroup(time, '5m', 1.5) AS time, avg(value) as value FROM metric GROUP BY $__timeGroup(time, '5m') ORDER BY 1",
 							"format": "time_series",
 						}),
-						RefID: "A",
+						RefID:     "A",
+						TimeRange: backend.TimeRange{From: fromStart, To: fromStart.Add(34 * time.Minute)},
 					},
 				},
-				TimeRange: &plugins.DataTimeRange{
-					From: fmt.Sprintf("%v", fromStart.Unix()*1000),
-					To:   fmt.Sprintf("%v", fromStart.Add(34*time.Minute).Unix()*1000),
-				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 7, frames[0].Fields[0].Len())
 			require.Equal(t, 1.5, *frames[0].Fields[1].At(3).(*float64))
@@ -447,10 +431,10 @@ func TestMSSQL(t *testing.T) {
 		require.NoError(t, err)
 
 		t.Run("When doing a metric query using epoch (int64) as time column and value column (int64) should return metric with time in time.Time", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": `SELECT TOP 1 timeInt64 as time, timeInt64 FROM metric_values ORDER BY time`,
 							"format": "time_series",
 						}),
@@ -459,21 +443,21 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, tInitial, *frames[0].Fields[0].At(0).(*time.Time))
 		})
 
 		t.Run("When doing a metric query using epoch (int64 nullable) as time column and value column (int64 nullable) should return metric with time in time.Time", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": `SELECT TOP 1 timeInt64Nullable as time, timeInt64Nullable FROM metric_values ORDER BY time`,
 							"format": "time_series",
 						}),
@@ -482,21 +466,21 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, tInitial, *frames[0].Fields[0].At(0).(*time.Time))
 		})
 
 		t.Run("When doing a metric query using epoch (float64) as time column and value column (float64) should return metric with time in time.Time", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": `SELECT TOP 1 timeFloat64 as time, timeFloat64 FROM metric_values ORDER BY time`,
 							"format": "time_series",
 						}),
@@ -505,21 +489,21 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, tInitial, *frames[0].Fields[0].At(0).(*time.Time))
 		})
 
 		t.Run("When doing a metric query using epoch (float64 nullable) as time column and value column (float64 nullable) should return metric with time in time.Time", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": `SELECT TOP 1 timeFloat64Nullable as time, timeFloat64Nullable FROM metric_values ORDER BY time`,
 							"format": "time_series",
 						}),
@@ -528,21 +512,21 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, tInitial, *frames[0].Fields[0].At(0).(*time.Time))
 		})
 
 		t.Run("When doing a metric query using epoch (int32) as time column and value column (int32) should return metric with time in time.Time", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": `SELECT TOP 1 timeInt32 as time, timeInt32 FROM metric_values ORDER BY time`,
 							"format": "time_series",
 						}),
@@ -551,21 +535,21 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, tInitial, *frames[0].Fields[0].At(0).(*time.Time))
 		})
 
 		t.Run("When doing a metric query using epoch (int32 nullable) as time column and value column (int32 nullable) should return metric with time in time.Time", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": `SELECT TOP 1 timeInt32Nullable as time, timeInt32Nullable FROM metric_values ORDER BY time`,
 							"format": "time_series",
 						}),
@@ -574,21 +558,21 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, tInitial, *frames[0].Fields[0].At(0).(*time.Time))
 		})
 
 		t.Run("When doing a metric query using epoch (float32) as time column and value column (float32) should return metric with time in time.Time", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": `SELECT TOP 1 timeFloat32 as time, timeFloat32 FROM metric_values ORDER BY time`,
 							"format": "time_series",
 						}),
@@ -597,21 +581,21 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, tInitial, *frames[0].Fields[0].At(0).(*time.Time))
 		})
 
 		t.Run("When doing a metric query using epoch (float32 nullable) as time column and value column (float32 nullable) should return metric with time in milliseconds", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": `SELECT TOP 1 timeFloat32Nullable as time, timeFloat32Nullable FROM metric_values ORDER BY time`,
 							"format": "time_series",
 						}),
@@ -620,22 +604,22 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 
 			require.Equal(t, time.Unix(0, int64(float64(float32(tInitial.Unix()))*1e3)*int64(time.Millisecond)), *frames[0].Fields[0].At(0).(*time.Time))
 		})
 
 		t.Run("When doing a metric query grouping by time and select metric column should return correct series", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": "SELECT $__timeEpoch(time), measurement + ' - value one' as metric, valueOne FROM metric_values ORDER BY 1",
 							"format": "time_series",
 						}),
@@ -644,13 +628,12 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, err := queryResult.Dataframes.Decoded()
-			require.NoError(t, err)
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 
 			require.Equal(t, 3, len(frames[0].Fields))
@@ -659,10 +642,10 @@ func TestMSSQL(t *testing.T) {
 		})
 
 		t.Run("When doing a metric query grouping by time should return correct series", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": "SELECT $__timeEpoch(time), valueOne, valueTwo FROM metric_values ORDER BY 1",
 							"format": "time_series",
 						}),
@@ -671,13 +654,12 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, err := queryResult.Dataframes.Decoded()
-			require.NoError(t, err)
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 3, len(frames[0].Fields))
 			require.Equal(t, "valueOne", frames[0].Fields[1].Name)
@@ -685,10 +667,10 @@ func TestMSSQL(t *testing.T) {
 		})
 
 		t.Run("When doing a metric query with metric column and multiple value columns", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": "SELECT $__timeEpoch(time), measurement, valueOne, valueTwo FROM metric_values ORDER BY 1",
 							"format": "time_series",
 						}),
@@ -697,13 +679,12 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, err := queryResult.Dataframes.Decoded()
-			require.NoError(t, err)
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 5, len(frames[0].Fields))
 			require.Equal(t, "valueOne", frames[0].Fields[1].Name)
@@ -717,27 +698,25 @@ func TestMSSQL(t *testing.T) {
 		})
 
 		t.Run("When doing a query with timeFrom,timeTo,unixEpochFrom,unixEpochTo macros", func(t *testing.T) {
-			timeRange := plugins.DataTimeRange{From: "5m", To: "now", Now: fromStart}
-			query := plugins.DataQuery{
-				TimeRange: &timeRange,
-				Queries: []plugins.DataSubQuery{
+			timeRange := backend.TimeRange{From: fromStart.Add(-5 * time.Minute), To: fromStart}
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						DataSource: &models.DataSource{JsonData: simplejson.New()},
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": `SELECT time FROM metric_values WHERE time > $__timeFrom() OR time < $__timeFrom() OR 1 < $__unixEpochFrom() OR $__unixEpochTo() > 1 ORDER BY 1`,
 							"format": "time_series",
 						}),
-						RefID: "A",
+						RefID:     "A",
+						TimeRange: timeRange,
 					},
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
-			frames, err := queryResult.Dataframes.Decoded()
-			require.NoError(t, err)
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, "SELECT time FROM metric_values WHERE time > '2018-03-15T12:55:00Z' OR time < '2018-03-15T12:55:00Z' OR 1 < 1521118500 OR 1521118800 > 1 ORDER BY 1", frames[0].Meta.ExecutedQueryString)
 		})
@@ -786,16 +765,11 @@ func TestMSSQL(t *testing.T) {
 			require.NoError(t, err)
 
 			t.Run("When doing a metric query using stored procedure should return correct result", func(t *testing.T) {
-				endpoint, err := NewExecutor(&models.DataSource{
-					JsonData:       simplejson.New(),
-					SecureJsonData: securejsondata.SecureJsonData{},
-				})
-				require.NoError(t, err)
-				query := plugins.DataQuery{
-					Queries: []plugins.DataSubQuery{
+				endpoint := newDataSourceHandler(t)
+				query := &backend.QueryDataRequest{
+					Queries: []backend.DataQuery{
 						{
-							DataSource: &models.DataSource{JsonData: simplejson.New()},
-							Model: simplejson.NewFromAny(map[string]interface{}{
+							JSON: queryModel(t, map[string]interface{}{
 								"rawSql": `DECLARE
 													@from int = $__unixEpochFrom(),
 													@to int = $__unixEpochTo()
@@ -803,21 +777,17 @@ func TestMSSQL(t *testing.T) {
 													EXEC dbo.sp_test_epoch @from, @to`,
 								"format": "time_series",
 							}),
-							RefID: "A",
+							RefID:     "A",
+							TimeRange: backend.TimeRange{From: time.Unix(1521117000, 0), To: time.Unix(1521122100, 0)},
 						},
 					},
-					TimeRange: &plugins.DataTimeRange{
-						From: "1521117000000",
-						To:   "1521122100000",
-					},
 				}
 
-				resp, err := endpoint.DataQuery(context.Background(), nil, query)
+				resp, err := endpoint.QueryData(context.Background(), query)
 				require.NoError(t, err)
-				queryResult := resp.Results["A"]
+				queryResult := resp.Responses["A"]
 				require.NoError(t, queryResult.Error)
-				frames, err := queryResult.Dataframes.Decoded()
-				require.NoError(t, err)
+				frames := queryResult.Frames
 				require.Equal(t, 1, len(frames))
 				require.Equal(t, 5, len(frames[0].Fields))
 				require.Equal(t, "valueOne", frames[0].Fields[1].Name)
@@ -875,11 +845,10 @@ func TestMSSQL(t *testing.T) {
 			require.NoError(t, err)
 
 			t.Run("When doing a metric query using stored procedure should return correct result", func(t *testing.T) {
-				query := plugins.DataQuery{
-					Queries: []plugins.DataSubQuery{
+				query := &backend.QueryDataRequest{
+					Queries: []backend.DataQuery{
 						{
-							DataSource: &models.DataSource{JsonData: simplejson.New()},
-							Model: simplejson.NewFromAny(map[string]interface{}{
+							JSON: queryModel(t, map[string]interface{}{
 								"rawSql": `DECLARE
 													@from int = $__unixEpochFrom(),
 													@to int = $__unixEpochTo()
@@ -887,22 +856,18 @@ func TestMSSQL(t *testing.T) {
 													EXEC dbo.sp_test_epoch @from, @to`,
 								"format": "time_series",
 							}),
-							RefID: "A",
+							RefID:     "A",
+							TimeRange: backend.TimeRange{From: time.Unix(1521117000, 0), To: time.Unix(1521122100, 0)},
 						},
 					},
-					TimeRange: &plugins.DataTimeRange{
-						From: "1521117000000",
-						To:   "1521122100000",
-					},
 				}
 
-				resp, err := endpoint.DataQuery(context.Background(), nil, query)
+				resp, err := endpoint.QueryData(context.Background(), query)
 				require.NoError(t, err)
-				queryResult := resp.Results["A"]
+				queryResult := resp.Responses["A"]
 				require.NoError(t, queryResult.Error)
 
-				frames, err := queryResult.Dataframes.Decoded()
-				require.NoError(t, err)
+				frames := queryResult.Frames
 				require.Equal(t, 1, len(frames))
 				require.Equal(t, 5, len(frames[0].Fields))
 				require.Equal(t, "valueOne", frames[0].Fields[1].Name)
@@ -963,54 +928,45 @@ func TestMSSQL(t *testing.T) {
 		}
 
 		t.Run("When doing an annotation query of deploy events should return expected result", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						DataSource: &models.DataSource{},
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": "SELECT time_sec as time, description as [text], tags FROM [event] WHERE $__unixEpochFilter(time_sec) AND tags='deploy' ORDER BY 1 ASC",
 							"format": "table",
 						}),
-						RefID: "Deploys",
+						RefID:     "Deploys",
+						TimeRange: backend.TimeRange{From: fromStart.Add(-20 * time.Minute), To: fromStart.Add(40 * time.Minute)},
 					},
 				},
-				TimeRange: &plugins.DataTimeRange{
-					From: fmt.Sprintf("%v", fromStart.Add(-20*time.Minute).Unix()*1000),
-					To:   fmt.Sprintf("%v", fromStart.Add(40*time.Minute).Unix()*1000),
-				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
-			require.NoError(t, err)
-			queryResult := resp.Results["Deploys"]
-			frames, err := queryResult.Dataframes.Decoded()
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
+			queryResult := resp.Responses["Deploys"]
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 3, frames[0].Fields[0].Len())
 		})
 
 		t.Run("When doing an annotation query of ticket events should return expected result", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": "SELECT time_sec as time, description as [text], tags FROM [event] WHERE $__unixEpochFilter(time_sec) AND tags='ticket' ORDER BY 1 ASC",
 							"format": "table",
 						}),
-						RefID: "Tickets",
+						RefID:     "Tickets",
+						TimeRange: backend.TimeRange{From: fromStart.Add(-20 * time.Minute), To: fromStart.Add(40 * time.Minute)},
 					},
 				},
-				TimeRange: &plugins.DataTimeRange{
-					From: fmt.Sprintf("%v", fromStart.Add(-20*time.Minute).Unix()*1000),
-					To:   fmt.Sprintf("%v", fromStart.Add(40*time.Minute).Unix()*1000),
-				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
-			require.NoError(t, err)
-			queryResult := resp.Results["Tickets"]
-			frames, err := queryResult.Dataframes.Decoded()
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
+			queryResult := resp.Responses["Tickets"]
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 3, frames[0].Fields[0].Len())
 		})
@@ -1019,10 +975,10 @@ func TestMSSQL(t *testing.T) {
 			dt := time.Date(2018, 3, 14, 21, 20, 6, 527e6, time.UTC)
 			const dtFormat = "2006-01-02 15:04:05.999999999"
 
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": fmt.Sprintf(`SELECT
 											CAST('%s' AS DATETIME) as time,
 											'message' as text,
@@ -1035,12 +991,12 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 1, frames[0].Fields[0].Len())
 
@@ -1051,10 +1007,10 @@ func TestMSSQL(t *testing.T) {
 		t.Run("When doing an annotation query with a time column in epoch second format should return ms", func(t *testing.T) {
 			dt := time.Date(2018, 3, 14, 21, 20, 6, 527e6, time.UTC)
 
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": fmt.Sprintf(`SELECT
 											 %d as time,
 											'message' as text,
@@ -1067,12 +1023,12 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 1, frames[0].Fields[0].Len())
 
@@ -1083,10 +1039,10 @@ func TestMSSQL(t *testing.T) {
 		t.Run("When doing an annotation query with a time column in epoch second format (int) should return ms", func(t *testing.T) {
 			dt := time.Date(2018, 3, 14, 21, 20, 6, 527e6, time.UTC)
 
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": fmt.Sprintf(`SELECT
 											 cast(%d as int) as time,
 											'message' as text,
@@ -1099,12 +1055,12 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 1, frames[0].Fields[0].Len())
 
@@ -1115,10 +1071,10 @@ func TestMSSQL(t *testing.T) {
 		t.Run("When doing an annotation query with a time column in epoch millisecond format should return ms", func(t *testing.T) {
 			dt := time.Date(2018, 3, 14, 21, 20, 6, 527e6, time.UTC)
 
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": fmt.Sprintf(`SELECT
 											 %d as time,
 											'message' as text,
@@ -1131,12 +1087,12 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 1, frames[0].Fields[0].Len())
 
@@ -1145,10 +1101,10 @@ func TestMSSQL(t *testing.T) {
 		})
 
 		t.Run("When doing an annotation query with a time column holding a bigint null value should return nil", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": `SELECT
 											 cast(null as bigint) as time,
 											'message' as text,
@@ -1161,12 +1117,12 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 1, frames[0].Fields[0].Len())
 
@@ -1175,10 +1131,10 @@ func TestMSSQL(t *testing.T) {
 		})
 
 		t.Run("When doing an annotation query with a time column holding a datetime null value should return nil", func(t *testing.T) {
-			query := plugins.DataQuery{
-				Queries: []plugins.DataSubQuery{
+			query := &backend.QueryDataRequest{
+				Queries: []backend.DataQuery{
 					{
-						Model: simplejson.NewFromAny(map[string]interface{}{
+						JSON: queryModel(t, map[string]interface{}{
 							"rawSql": `SELECT
 											 cast(null as datetime) as time,
 											'message' as text,
@@ -1191,12 +1147,12 @@ func TestMSSQL(t *testing.T) {
 				},
 			}
 
-			resp, err := endpoint.DataQuery(context.Background(), nil, query)
+			resp, err := endpoint.QueryData(context.Background(), query)
 			require.NoError(t, err)
-			queryResult := resp.Results["A"]
+			queryResult := resp.Responses["A"]
 			require.NoError(t, queryResult.Error)
 
-			frames, _ := queryResult.Dataframes.Decoded()
+			frames := queryResult.Frames
 			require.Equal(t, 1, len(frames))
 			require.Equal(t, 1, frames[0].Fields[0].Len())
 
@@ -1231,60 +1187,76 @@ func TestTransformQueryError(t *testing.T) {
 func TestGenerateConnectionString(t *testing.T) {
 	testCases := []struct {
 		desc       string
-		dataSource *models.DataSource
+		dsInfo     sqleng.DataSourceInfo
 		expConnStr string
 	}{
 		{
 			desc: "From URL w/ port",
-			dataSource: &models.DataSource{
-				Url:      "localhost:1001",
+			dsInfo: sqleng.DataSourceInfo{
+				URL:      "localhost:1001",
 				Database: "database",
 				User:     "user",
-				JsonData: simplejson.NewFromAny(map[string]interface{}{}),
+				JSONData: simplejson.NewFromAny(map[string]interface{}{}),
 			},
 			expConnStr: "server=localhost;database=database;user id=user;password=;port=1001;",
 		},
 		// When no port is specified, the driver should be allowed to choose
 		{
 			desc: "From URL w/o port",
-			dataSource: &models.DataSource{
-				Url:      "localhost",
+			dsInfo: sqleng.DataSourceInfo{
+				URL:      "localhost",
 				Database: "database",
 				User:     "user",
-				JsonData: simplejson.NewFromAny(map[string]interface{}{}),
+				JSONData: simplejson.NewFromAny(map[string]interface{}{}),
 			},
 			expConnStr: "server=localhost;database=database;user id=user;password=;",
 		},
 		// Port 0 should be equivalent to not specifying a port, i.e. let the driver choose
 		{
 			desc: "From URL w port 0",
-			dataSource: &models.DataSource{
-				Url:      "localhost:0",
+			dsInfo: sqleng.DataSourceInfo{
+				URL:      "localhost:0",
 				Database: "database",
 				User:     "user",
-				JsonData: simplejson.NewFromAny(map[string]interface{}{}),
+				JSONData: simplejson.NewFromAny(map[string]interface{}{}),
 			},
 			expConnStr: "server=localhost;database=database;user id=user;password=;",
 		},
 		{
 			desc: "Defaults",
-			dataSource: &models.DataSource{
+			dsInfo: sqleng.DataSourceInfo{
 				Database: "database",
 				User:     "user",
-				JsonData: simplejson.NewFromAny(map[string]interface{}{}),
+				JSONData: simplejson.NewFromAny(map[string]interface{}{}),
 			},
 			expConnStr: "server=localhost;database=database;user id=user;password=;",
 		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.desc, func(t *testing.T) {
-			connStr, err := generateConnectionString(tc.dataSource)
+			connStr, err := generateConnectionString(tc.dsInfo)
 			require.NoError(t, err)
 			assert.Equal(t, tc.expConnStr, connStr)
 		})
 	}
 }
 
+func newDataSourceHandler(t *testing.T) *sqleng.DataSourceHandler {
+	t.Helper()
+
+	instance, err := newInstanceSettings()(backend.DataSourceInstanceSettings{})
+	require.NoError(t, err)
+	return instance.(*sqleng.DataSourceHandler)
+}
+
+func queryModel(t *testing.T, model map[string]interface{}) json.RawMessage {
+	t.Helper()
+
+	b, err := json.Marshal(model)
+	require.NoError(t, err)
+	return b
+}
+
 func initMSSQLTestDB(t *testing.T) *xorm.Engine {
 	t.Helper()
 
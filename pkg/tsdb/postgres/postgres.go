@@ -1,20 +1,24 @@
 package postgres
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
 	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util/errutil"
 
 	"github.com/grafana/grafana/pkg/infra/log"
-	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/tsdb/sqleng"
 )
 
@@ -27,52 +31,99 @@ func init() {
 }
 
 type PostgresService struct {
-	Cfg        *setting.Cfg `inject:""`
-	logger     log.Logger
-	tlsManager tlsSettingsProvider
+	Cfg                  *setting.Cfg          `inject:""`
+	BackendPluginManager backendplugin.Manager `inject:""`
+	logger               log.Logger
+	tlsManager           tlsSettingsProvider
+	im                   instancemgmt.InstanceManager
 }
 
 func (s *PostgresService) Init() error {
 	s.logger = log.New("tsdb.postgres")
 	s.tlsManager = newTLSManager(s.logger, s.Cfg.DataPath)
+	s.im = datasource.NewInstanceManager(s.newInstanceSettings())
+	factory := coreplugin.New(backend.ServeOpts{
+		QueryDataHandler:   s,
+		CheckHealthHandler: s,
+	})
+
+	if err := s.BackendPluginManager.Register("postgres", factory); err != nil {
+		s.logger.Error("Failed to register plugin", "error", err)
+	}
 	return nil
 }
 
-//nolint: staticcheck // plugins.DataPlugin deprecated
-func (s *PostgresService) NewExecutor(datasource *models.DataSource) (plugins.DataPlugin, error) {
-	s.logger.Debug("Creating Postgres query endpoint")
-
-	cnnstr, err := s.generateConnectionString(datasource)
+func (s *PostgresService) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	dsHandler, err := s.getDataSourceHandler(req.PluginContext)
 	if err != nil {
 		return nil, err
 	}
+	return dsHandler.QueryData(ctx, req)
+}
 
-	if s.Cfg.Env == setting.Dev {
-		s.logger.Debug("getEngine", "connection", cnnstr)
+// CheckHealth checks the connection to the database of the data source instance.
+func (s *PostgresService) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsHandler, err := s.getDataSourceHandler(req.PluginContext)
+	if err != nil {
+		return nil, err
 	}
+	return dsHandler.CheckHealth(ctx, req)
+}
 
-	config := sqleng.DataPluginConfiguration{
-		DriverName:        "postgres",
-		ConnectionString:  cnnstr,
-		Datasource:        datasource,
-		MetricColumnTypes: []string{"UNKNOWN", "TEXT", "VARCHAR", "CHAR"},
+func (s *PostgresService) getDataSourceHandler(pluginCtx backend.PluginContext) (*sqleng.DataSourceHandler, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
 	}
 
-	queryResultTransformer := postgresQueryResultTransformer{
-		log: s.logger,
+	instance, ok := i.(*sqleng.DataSourceHandler)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast data source info")
 	}
+	return instance, nil
+}
 
-	timescaledb := datasource.JsonData.Get("timescaledb").MustBool(false)
+func (s *PostgresService) newInstanceSettings() datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		s.logger.Debug("Creating Postgres query endpoint")
 
-	plugin, err := sqleng.NewDataPlugin(config, &queryResultTransformer, newPostgresMacroEngine(timescaledb),
-		s.logger)
-	if err != nil {
-		s.logger.Error("Failed connecting to Postgres", "err", err)
-		return nil, err
-	}
+		dsInfo, err := sqleng.NewDataSourceInfo(settings)
+		if err != nil {
+			return nil, err
+		}
+
+		cnnstr, err := s.generateConnectionString(dsInfo)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.Cfg.Env == setting.Dev {
+			s.logger.Debug("getEngine", "connection", cnnstr)
+		}
+
+		config := sqleng.DataPluginConfiguration{
+			DriverName:        "postgres",
+			ConnectionString:  cnnstr,
+			DSInfo:            dsInfo,
+			MetricColumnTypes: []string{"UNKNOWN", "TEXT", "VARCHAR", "CHAR"},
+		}
 
-	s.logger.Debug("Successfully connected to Postgres")
-	return plugin, nil
+		queryResultTransformer := postgresQueryResultTransformer{
+			log: s.logger,
+		}
+
+		timescaledb := dsInfo.JSONData.Get("timescaledb").MustBool(false)
+
+		handler, err := sqleng.NewQueryDataHandler(config, &queryResultTransformer, newPostgresMacroEngine(timescaledb),
+			s.logger)
+		if err != nil {
+			s.logger.Error("Failed connecting to Postgres", "err", err)
+			return nil, err
+		}
+
+		s.logger.Debug("Successfully connected to Postgres")
+		return handler, nil
+	}
 }
 
 // escape single quotes and backslashes in Postgres connection string parameters.
@@ -80,14 +131,14 @@ func escape(input string) string {
 	return strings.ReplaceAll(strings.ReplaceAll(input, `\`, `\\`), "'", `\'`)
 }
 
-func (s *PostgresService) generateConnectionString(datasource *models.DataSource) (string, error) {
+func (s *PostgresService) generateConnectionString(dsInfo sqleng.DataSourceInfo) (string, error) {
 	var host string
 	var port int
-	if strings.HasPrefix(datasource.Url, "/") {
-		host = datasource.Url
+	if strings.HasPrefix(dsInfo.URL, "/") {
+		host = dsInfo.URL
 		s.logger.Debug("Generating connection string with Unix socket specifier", "socket", host)
 	} else {
-		sp := strings.SplitN(datasource.Url, ":", 2)
+		sp := strings.SplitN(dsInfo.URL, ":", 2)
 		host = sp[0]
 		if len(sp) > 1 {
 			var err error
@@ -103,12 +154,12 @@ func (s *PostgresService) generateConnectionString(datasource *models.DataSource
 	}
 
 	connStr := fmt.Sprintf("user='%s' password='%s' host='%s' dbname='%s'",
-		escape(datasource.User), escape(datasource.DecryptedPassword()), escape(host), escape(datasource.Database))
+		escape(dsInfo.User), escape(dsInfo.DecryptedPassword()), escape(host), escape(dsInfo.Database))
 	if port > 0 {
 		connStr += fmt.Sprintf(" port=%d", port)
 	}
 
-	tlsSettings, err := s.tlsManager.getTLSSettings(datasource)
+	tlsSettings, err := s.tlsManager.getTLSSettings(dsInfo)
 	if err != nil {
 		return "", err
 	}
@@ -7,12 +7,12 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
-	"github.com/grafana/grafana/pkg/components/securejsondata"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
-	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/tsdb/sqleng"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -35,11 +35,11 @@ func TestDataSourceCacheManager(t *testing.T) {
 		"sslmode":                "verify-full",
 		"tlsConfigurationMethod": "file-content",
 	})
-	secureJSONData := securejsondata.GetEncryptedJsonData(map[string]string{
+	secureJSONData := map[string]string{
 		"tlsClientCert": "I am client certification",
 		"tlsClientKey":  "I am client key",
 		"tlsCACert":     "I am CA certification",
-	})
+	}
 
 	mockValidateCertFilePaths()
 	t.Cleanup(resetValidateCertFilePaths)
@@ -49,13 +49,13 @@ func TestDataSourceCacheManager(t *testing.T) {
 		wg.Add(10)
 		for id := int64(1); id <= 10; id++ {
 			go func(id int64) {
-				ds := &models.DataSource{
-					Id:             id,
-					Version:        1,
-					Database:       "database",
-					JsonData:       jsonData,
-					SecureJsonData: secureJSONData,
-					Uid:            "testData",
+				ds := sqleng.DataSourceInfo{
+					ID:                      id,
+					Updated:                 time.Unix(1, 0),
+					Database:                "database",
+					JSONData:                jsonData,
+					DecryptedSecureJSONData: secureJSONData,
+					UID:                     "testData",
 				}
 				s := tlsSettings{}
 				err := mng.writeCertFiles(ds, &s)
@@ -69,7 +69,7 @@ func TestDataSourceCacheManager(t *testing.T) {
 			for id := int64(1); id <= 10; id++ {
 				version, ok := mng.dsCacheInstance.cache.Load(strconv.Itoa(int(id)))
 				require.True(t, ok)
-				require.Equal(t, int(1), version)
+				require.Equal(t, time.Unix(1, 0), version)
 			}
 		})
 	})
@@ -82,13 +82,13 @@ func TestDataSourceCacheManager(t *testing.T) {
 			wg1.Add(5)
 			for id := int64(1); id <= 5; id++ {
 				go func(id int64) {
-					ds := &models.DataSource{
-						Id:             1,
-						Version:        2,
-						Database:       "database",
-						JsonData:       jsonData,
-						SecureJsonData: secureJSONData,
-						Uid:            "testData",
+					ds := sqleng.DataSourceInfo{
+						ID:                      1,
+						Updated:                 time.Unix(2, 0),
+						Database:                "database",
+						JSONData:                jsonData,
+						DecryptedSecureJSONData: secureJSONData,
+						UID:                     "testData",
 					}
 					s := tlsSettings{}
 					err := mng.writeCertFiles(ds, &s)
@@ -101,21 +101,21 @@ func TestDataSourceCacheManager(t *testing.T) {
 		})
 
 		t.Run("cache is updated with the last datasource version", func(t *testing.T) {
-			dsV2 := &models.DataSource{
-				Id:             1,
-				Version:        2,
-				Database:       "database",
-				JsonData:       jsonData,
-				SecureJsonData: secureJSONData,
-				Uid:            "testData",
+			dsV2 := sqleng.DataSourceInfo{
+				ID:                      1,
+				Updated:                 time.Unix(2, 0),
+				Database:                "database",
+				JSONData:                jsonData,
+				DecryptedSecureJSONData: secureJSONData,
+				UID:                     "testData",
 			}
-			dsV3 := &models.DataSource{
-				Id:             1,
-				Version:        3,
-				Database:       "database",
-				JsonData:       jsonData,
-				SecureJsonData: secureJSONData,
-				Uid:            "testData",
+			dsV3 := sqleng.DataSourceInfo{
+				ID:                      1,
+				Updated:                 time.Unix(3, 0),
+				Database:                "database",
+				JSONData:                jsonData,
+				DecryptedSecureJSONData: secureJSONData,
+				UID:                     "testData",
 			}
 			s := tlsSettings{}
 			err := mng.writeCertFiles(dsV2, &s)
@@ -124,7 +124,7 @@ func TestDataSourceCacheManager(t *testing.T) {
 			require.NoError(t, err)
 			version, ok := mng.dsCacheInstance.cache.Load("1")
 			require.True(t, ok)
-			require.Equal(t, int(3), version)
+			require.Equal(t, time.Unix(3, 0), version)
 		})
 	})
 }
@@ -245,11 +245,11 @@ func TestGetTLSSettings(t *testing.T) {
 			}
 
 			jsonData := simplejson.NewFromAny(tt.jsonData)
-			ds := &models.DataSource{
-				JsonData:       jsonData,
-				SecureJsonData: securejsondata.GetEncryptedJsonData(tt.secureJSONData),
-				Uid:            tt.uid,
-				Version:        tt.version,
+			ds := sqleng.DataSourceInfo{
+				JSONData:                jsonData,
+				DecryptedSecureJSONData: tt.secureJSONData,
+				UID:                     tt.uid,
+				Updated:                 time.Unix(int64(tt.version), 0),
 			}
 
 			settings, err = mng.getTLSSettings(ds)
@@ -278,7 +278,7 @@ func resetValidateCertFilePaths() {
 
 func mockWriteCertFile() {
 	writeCertFileCallNum = 0
-	writeCertFileFunc = func(ds *models.DataSource, logger log.Logger, fileContent string, generatedFilePath string) error {
+	writeCertFileFunc = func(dsInfo sqleng.DataSourceInfo, logger log.Logger, fileContent string, generatedFilePath string) error {
 		writeCertFileCallNum++
 		return nil
 	}
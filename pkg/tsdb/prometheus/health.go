@@ -0,0 +1,47 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// CheckHealth checks that Prometheus returns its build information. Servers which do not
+// support the build information API, like old versions of Prometheus, must evaluate a query.
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	buildInfo, err := dsInfo.client.Buildinfo(ctx)
+	if err != nil {
+		plog.Debug("Failed to get the build information", "err", err)
+		if _, _, err := dsInfo.client.Query(ctx, "1+1", time.Now()); err != nil {
+			return &backend.CheckHealthResult{
+				Status:  backend.HealthStatusError,
+				Message: fmt.Sprintf("Prometheus error: %s", ConvertAPIError(err)),
+			}, nil
+		}
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusOk,
+			Message: "Data source is working",
+		}, nil
+	}
+
+	details, err := json.Marshal(map[string]string{
+		"version":  buildInfo.Version,
+		"revision": buildInfo.Revision,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &backend.CheckHealthResult{
+		Status:      backend.HealthStatusOk,
+		Message:     "Data source is working",
+		JSONDetails: details,
+	}, nil
+}
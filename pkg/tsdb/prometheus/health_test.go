@@ -0,0 +1,76 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHealth(t *testing.T) {
+	// checkHealth returns the result of the health check and the paths requested to Prometheus
+	checkHealth := func(t *testing.T, handler http.HandlerFunc) (*backend.CheckHealthResult, []string) {
+		t.Helper()
+		var mu sync.Mutex
+		var paths []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			paths = append(paths, r.URL.Path)
+			mu.Unlock()
+			handler(w, r)
+		}))
+		t.Cleanup(srv.Close)
+
+		service := &Service{im: datasource.NewInstanceManager(newInstanceSettings(httpclient.NewProvider()))}
+		res, err := service.CheckHealth(context.Background(), &backend.CheckHealthRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{ID: 1, URL: srv.URL},
+			},
+		})
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		return res, paths
+	}
+
+	t.Run("the build information is returned", func(t *testing.T) {
+		res, paths := checkHealth(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"version": "2.28.0", "revision": "ff58416"}}`))
+		})
+		require.Equal(t, []string{"/api/v1/status/buildinfo"}, paths)
+		require.Equal(t, backend.HealthStatusOk, res.Status)
+		require.JSONEq(t, `{"version": "2.28.0", "revision": "ff58416"}`, string(res.JSONDetails))
+	})
+
+	t.Run("a query is evaluated when the build information is not supported", func(t *testing.T) {
+		res, paths := checkHealth(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/v1/query" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "scalar", "result": [1622548800, "2"]}}`))
+		})
+		require.Equal(t, []string{"/api/v1/status/buildinfo", "/api/v1/query"}, paths)
+		require.Equal(t, backend.HealthStatusOk, res.Status)
+		require.Empty(t, res.JSONDetails)
+	})
+
+	t.Run("the error of the query is returned", func(t *testing.T) {
+		res, _ := checkHealth(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/v1/query" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"status": "error", "errorType": "execution", "error": "query timed out"}`))
+		})
+		require.Equal(t, backend.HealthStatusError, res.Status)
+		require.Contains(t, res.Message, "query timed out")
+	})
+}
@@ -2,17 +2,30 @@ package prometheus
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"net/http"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/grafana/grafana/pkg/registry"
 	"github.com/grafana/grafana/pkg/tsdb/interval"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/api"
@@ -29,49 +42,124 @@ func init() {
 	plog = log.New("tsdb.prometheus")
 }
 
-type PrometheusExecutor struct {
-	client             apiv1.API
+// exemplarsPath is the endpoint of the exemplars API of Prometheus.
+const exemplarsPath = "/api/v1/query_exemplars"
+
+type Service struct {
+	HTTPClientProvider   httpclient.Provider   `inject:""`
+	BackendPluginManager backendplugin.Manager `inject:""`
+
 	intervalCalculator interval.Calculator
+	im                 instancemgmt.InstanceManager
+}
+
+type datasourceInfo struct {
+	client       apiv1.API
+	apiClient    api.Client
+	JSONData     *simplejson.Json
+	Destinations []ExemplarTraceIDDestination
 }
 
-//nolint: staticcheck // plugins.DataPlugin deprecated
-func New(provider httpclient.Provider) func(*models.DataSource) (plugins.DataPlugin, error) {
-	return func(dsInfo *models.DataSource) (plugins.DataPlugin, error) {
-		transport, err := dsInfo.GetHTTPTransport(provider, customQueryParametersMiddleware(plog))
+func init() {
+	registry.Register(&registry.Descriptor{Instance: &Service{}})
+}
+
+func (s *Service) Init() error {
+	s.intervalCalculator = interval.NewCalculator(interval.CalculatorOptions{MinInterval: time.Second * 1})
+	s.im = datasource.NewInstanceManager(newInstanceSettings(s.HTTPClientProvider))
+
+	factory := coreplugin.New(backend.ServeOpts{
+		QueryDataHandler:   s,
+		CheckHealthHandler: s,
+	})
+
+	if err := s.BackendPluginManager.Register("prometheus", factory); err != nil {
+		plog.Error("Failed to register plugin", "error", err)
+	}
+
+	return nil
+}
+
+func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		opts, err := models.HTTPClientOptionsFromInstanceSettings(models.DS_PROMETHEUS, settings)
 		if err != nil {
 			return nil, err
 		}
+		opts.Middlewares = []sdkhttpclient.Middleware{customQueryParametersMiddleware(plog)}
 
-		cfg := api.Config{
-			Address:      dsInfo.Url,
-			RoundTripper: transport,
+		transport, err := httpClientProvider.GetTransport(opts)
+		if err != nil {
+			return nil, err
 		}
 
-		client, err := api.NewClient(cfg)
+		client, err := api.NewClient(api.Config{
+			Address:      settings.URL,
+			RoundTripper: transport,
+		})
 		if err != nil {
 			return nil, err
 		}
 
-		return &PrometheusExecutor{
-			intervalCalculator: interval.NewCalculator(interval.CalculatorOptions{MinInterval: time.Second * 1}),
-			client:             apiv1.NewAPI(client),
+		jsonData := simplejson.New()
+		if len(settings.JSONData) > 0 {
+			if jsonData, err = simplejson.NewJson(settings.JSONData); err != nil {
+				return nil, err
+			}
+		}
+
+		var destinations []ExemplarTraceIDDestination
+		if err := decodeJSON(jsonData.Get("exemplarTraceIdDestinations"), &destinations); err != nil {
+			plog.Warn("Failed to parse the exemplar trace ID destinations", "err", err)
+		}
+
+		return &datasourceInfo{
+			client:       apiv1.NewAPI(client),
+			apiClient:    client,
+			JSONData:     jsonData,
+			Destinations: destinations,
 		}, nil
 	}
 }
 
-//nolint: staticcheck // plugins.DataResponse deprecated
-func (e *PrometheusExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource,
-	tsdbQuery plugins.DataQuery) (plugins.DataResponse, error) {
-	result := plugins.DataResponse{
-		Results: map[string]plugins.DataQueryResult{},
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	result := backend.NewQueryDataResponse()
+
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
 	}
 
-	queries, err := e.parseQuery(dsInfo, tsdbQuery)
+	queries, err := s.parseQuery(dsInfo, req.Queries)
 	if err != nil {
-		return result, err
+		return nil, err
 	}
 
 	for _, query := range queries {
+		frames, err := runQuery(ctx, dsInfo, query)
+		if err != nil {
+			return nil, err
+		}
+		result.Responses[query.RefId] = backend.DataResponse{
+			Frames: frames,
+		}
+	}
+
+	return result, nil
+}
+
+// runQuery runs the range, instant and exemplar queries of the query, and returns their frames. The
+// resultType of the custom metadata of the frames is the query they are the result of, "range",
+// "instant" or "exemplar". The warnings of the Prometheus API are notices of the frames.
+func runQuery(ctx context.Context, dsInfo *datasourceInfo, query *PrometheusQuery) (data.Frames, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "datasource.prometheus")
+	span.SetTag("expr", query.Expr)
+	span.SetTag("start_unixnano", query.Start.UnixNano())
+	span.SetTag("stop_unixnano", query.End.UnixNano())
+	defer span.Finish()
+
+	frames := data.Frames{}
+	if query.RangeQuery {
 		timeRange := apiv1.Range{
 			Start: query.Start,
 			End:   query.End,
@@ -79,27 +167,81 @@ func (e *PrometheusExecutor) DataQuery(ctx context.Context, dsInfo *models.DataS
 		}
 
 		plog.Debug("Sending query", "start", timeRange.Start, "end", timeRange.End, "step", timeRange.Step, "query", query.Expr)
+		value, warnings, err := dsInfo.client.QueryRange(ctx, query.Expr, timeRange)
+		if err != nil {
+			return nil, err
+		}
+		rangeFrames, err := parseResponse(value, query)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, withResultType(withWarnings(rangeFrames, warnings), "range")...)
+	}
 
-		span, ctx := opentracing.StartSpanFromContext(ctx, "datasource.prometheus")
-		span.SetTag("expr", query.Expr)
-		span.SetTag("start_unixnano", query.Start.UnixNano())
-		span.SetTag("stop_unixnano", query.End.UnixNano())
-		defer span.Finish()
-
-		value, _, err := e.client.QueryRange(ctx, query.Expr, timeRange)
-
+	if query.InstantQuery {
+		plog.Debug("Sending instant query", "time", query.End, "query", query.Expr)
+		value, warnings, err := dsInfo.client.Query(ctx, query.Expr, query.End)
 		if err != nil {
-			return result, err
+			return nil, err
 		}
+		instantFrames, err := parseResponse(value, query)
+		if err != nil {
+			return nil, err
+		}
+		frames = append(frames, withResultType(withWarnings(instantFrames, warnings), "instant")...)
+	}
 
-		queryResult, err := parseResponse(value, query)
+	if query.ExemplarQuery {
+		// the series are still returned when the exemplars cannot be queried,
+		// for example because the exemplar storage of Prometheus is disabled
+		plog.Debug("Sending exemplar query", "start", query.Start, "end", query.End, "query", query.Expr)
+		results, warnings, err := queryExemplars(ctx, dsInfo.apiClient, query)
 		if err != nil {
-			return result, err
+			warnings = append(warnings, fmt.Sprintf("failed to query exemplars: %s", ConvertAPIError(err)))
+		}
+		var exemplarFrames data.Frames
+		if len(results) > 0 {
+			exemplarFrames = data.Frames{exemplarFrame(results, query, dsInfo.Destinations)}
 		}
-		result.Results[query.RefId] = queryResult
+		frames = append(frames, withResultType(withWarnings(exemplarFrames, warnings), "exemplar")...)
 	}
 
-	return result, nil
+	return frames, nil
+}
+
+// queryExemplars fetches the exemplars of the series of the query over its time range, with the
+// exemplars API that the client does not support.
+func queryExemplars(ctx context.Context, apiClient api.Client, query *PrometheusQuery) ([]exemplarQueryResult, apiv1.Warnings, error) {
+	u := apiClient.URL(exemplarsPath, nil)
+	q := u.Query()
+	q.Set("query", query.Expr)
+	q.Set("start", strconv.FormatFloat(float64(query.Start.UnixNano())/float64(time.Second), 'f', -1, 64))
+	q.Set("end", strconv.FormatFloat(float64(query.End.UnixNano())/float64(time.Second), 'f', -1, 64))
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, body, err := apiClient.Do(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var apiResp struct {
+		Status    string                `json:"status"`
+		Data      []exemplarQueryResult `json:"data"`
+		ErrorType apiv1.ErrorType       `json:"errorType"`
+		Error     string                `json:"error"`
+		Warnings  []string              `json:"warnings"`
+	}
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse the exemplars of status %d: %w", resp.StatusCode, err)
+	}
+	if apiResp.Status != "success" {
+		return nil, apiResp.Warnings, &apiv1.Error{Type: apiResp.ErrorType, Msg: apiResp.Error}
+	}
+	return apiResp.Data, apiResp.Warnings, nil
 }
 
 func formatLegend(metric model.Metric, query *PrometheusQuery) string {
@@ -120,59 +262,88 @@ func formatLegend(metric model.Metric, query *PrometheusQuery) string {
 	return string(result)
 }
 
-func (e *PrometheusExecutor) parseQuery(dsInfo *models.DataSource, query plugins.DataQuery) (
-	[]*PrometheusQuery, error) {
+func (s *Service) parseQuery(dsInfo *datasourceInfo, queries []backend.DataQuery) ([]*PrometheusQuery, error) {
 	qs := []*PrometheusQuery{}
-	for _, queryModel := range query.Queries {
-		expr, err := queryModel.Model.Get("expr").String()
+	for _, query := range queries {
+		model, err := simplejson.NewJson(query.JSON)
 		if err != nil {
 			return nil, err
 		}
 
-		format := queryModel.Model.Get("legendFormat").MustString("")
-
-		start, err := query.TimeRange.ParseFrom()
+		expr, err := model.Get("expr").String()
 		if err != nil {
 			return nil, err
 		}
 
-		end, err := query.TimeRange.ParseTo()
+		format := model.Get("legendFormat").MustString("")
+
+		rangeQuery, instantQuery, err := parseQueryType(model)
 		if err != nil {
 			return nil, err
 		}
 
-		dsInterval, err := interval.GetIntervalFrom(dsInfo, queryModel.Model, time.Second*15)
+		dsInterval, err := interval.GetIntervalFrom(&models.DataSource{JsonData: dsInfo.JSONData}, model, time.Second*15)
 		if err != nil {
 			return nil, err
 		}
 
-		intervalFactor := queryModel.Model.Get("intervalFactor").MustInt64(1)
-		interval := e.intervalCalculator.Calculate(*query.TimeRange, dsInterval)
+		intervalFactor := model.Get("intervalFactor").MustInt64(1)
+		interval := s.intervalCalculator.Calculate(plugins.NewDataTimeRangeFromBackend(query.TimeRange), dsInterval)
 		step := time.Duration(int64(interval.Value) * intervalFactor)
 
 		qs = append(qs, &PrometheusQuery{
 			Expr:         expr,
 			Step:         step,
 			LegendFormat: format,
-			Start:        start,
-			End:          end,
-			RefId:        queryModel.RefID,
+			Start:        query.TimeRange.From,
+			End:          query.TimeRange.To,
+			RefId:        query.RefID,
+			RangeQuery:   rangeQuery,
+			InstantQuery: instantQuery,
+			// the exemplars are the ones of the series over the time range
+			ExemplarQuery: model.Get("exemplar").MustBool(false) && rangeQuery,
 		})
 	}
 
 	return qs, nil
 }
 
-//nolint: staticcheck // plugins.DataQueryResult deprecated
-func parseResponse(value model.Value, query *PrometheusQuery) (plugins.DataQueryResult, error) {
-	var queryRes plugins.DataQueryResult
-	frames := data.Frames{}
+// parseQueryType returns whether the query is a range query, an instant query, or both, from its
+// queryType, or from the range and instant flags of the queries of the frontend. The queries are
+// range queries by default.
+func parseQueryType(model *simplejson.Json) (rangeQuery bool, instantQuery bool, err error) {
+	switch qt := queryType(model.Get("queryType").MustString("")); qt {
+	case queryTypeRange:
+		return true, false, nil
+	case queryTypeInstant:
+		return false, true, nil
+	case "":
+		instantQuery = model.Get("instant").MustBool(false)
+		rangeQuery = model.Get("range").MustBool(!instantQuery)
+		if !rangeQuery && !instantQuery {
+			return false, false, fmt.Errorf("the query is neither a range nor an instant query")
+		}
+		return rangeQuery, instantQuery, nil
+	default:
+		return false, false, fmt.Errorf("unsupported query type: %q", qt)
+	}
+}
 
-	matrix, ok := value.(model.Matrix)
-	if !ok {
-		return queryRes, fmt.Errorf("unsupported result format: %q", value.Type().String())
+func parseResponse(value model.Value, query *PrometheusQuery) (data.Frames, error) {
+	switch v := value.(type) {
+	case model.Matrix:
+		return matrixToFrames(v, query), nil
+	case model.Vector:
+		return vectorToFrames(v, query), nil
+	case *model.Scalar:
+		return data.Frames{scalarToFrame(v, query)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported result format: %q", value.Type().String())
 	}
+}
 
+func matrixToFrames(matrix model.Matrix, query *PrometheusQuery) data.Frames {
+	frames := data.Frames{}
 	for _, v := range matrix {
 		name := formatLegend(v.Metric, query)
 		tags := make(map[string]string, len(v.Metric))
@@ -191,9 +362,136 @@ func parseResponse(value model.Value, query *PrometheusQuery) (plugins.DataQuery
 			data.NewField("time", nil, timeVector),
 			data.NewField("value", tags, values).SetConfig(&data.FieldConfig{DisplayNameFromDS: name})))
 	}
-	queryRes.Dataframes = plugins.NewDecodedDataFrames(frames)
+	return frames
+}
+
+// vectorToFrames converts the result of an instant query, with a frame of a single value for each
+// series that is displayed as a table.
+func vectorToFrames(vector model.Vector, query *PrometheusQuery) data.Frames {
+	frames := data.Frames{}
+	for _, v := range vector {
+		name := formatLegend(v.Metric, query)
+		tags := make(map[string]string, len(v.Metric))
+		for k, v := range v.Metric {
+			tags[string(k)] = string(v)
+		}
+
+		frame := data.NewFrame(name,
+			data.NewField("time", nil, []time.Time{time.Unix(v.Timestamp.Unix(), 0).UTC()}),
+			data.NewField("value", tags, []float64{float64(v.Value)}).SetConfig(&data.FieldConfig{DisplayNameFromDS: name}))
+		frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeTable}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func scalarToFrame(scalar *model.Scalar, query *PrometheusQuery) *data.Frame {
+	frame := data.NewFrame(query.Expr,
+		data.NewField("time", nil, []time.Time{time.Unix(scalar.Timestamp.Unix(), 0).UTC()}),
+		data.NewField("value", nil, []float64{float64(scalar.Value)}))
+	frame.Meta = &data.FrameMeta{PreferredVisualization: data.VisTypeTable}
+	return frame
+}
+
+// exemplarFrame returns the exemplars of the series of a query in a frame named exemplar, with a
+// field for each label of the exemplars and of their series. The fields of the labels with trace IDs
+// link to the URL of their trace ID destination, the links to data sources are added by the frontend.
+func exemplarFrame(results []exemplarQueryResult, query *PrometheusQuery, destinations []ExemplarTraceIDDestination) *data.Frame {
+	var rows []map[string]string
+	var times []time.Time
+	var values []float64
+	labelNames := map[string]struct{}{}
+	for _, result := range results {
+		for _, e := range result.Exemplars {
+			labels := make(map[string]string, len(e.Labels)+len(result.SeriesLabels))
+			for _, l := range []map[string]string{e.Labels, result.SeriesLabels} {
+				for name, value := range l {
+					labels[name] = value
+					labelNames[name] = struct{}{}
+				}
+			}
+			rows = append(rows, labels)
+			times = append(times, time.Unix(0, int64(math.Round(e.Timestamp*1000))*int64(time.Millisecond)).UTC())
+			values = append(values, e.Value)
+		}
+	}
 
-	return queryRes, nil
+	names := make([]string, 0, len(labelNames))
+	for name := range labelNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	frame := data.NewFrame("exemplar",
+		data.NewField("Time", nil, times),
+		data.NewField("Value", nil, values))
+	for _, name := range names {
+		labelValues := make([]string, 0, len(rows))
+		for _, row := range rows {
+			labelValues = append(labelValues, row[name])
+		}
+		field := data.NewField(name, nil, labelValues)
+		for _, d := range destinations {
+			if d.Name != name || d.URL == "" {
+				continue
+			}
+			if field.Config == nil {
+				field.Config = &data.FieldConfig{}
+			}
+			field.Config.Links = append(field.Config.Links, data.DataLink{
+				Title:       fmt.Sprintf("Go to %s", d.URL),
+				URL:         d.URL,
+				TargetBlank: true,
+			})
+		}
+		frame.Fields = append(frame.Fields, field)
+	}
+	frame.RefID = query.RefId
+	return frame
+}
+
+// withResultType sets the resultType of the custom metadata of the frames, so that the frames of the
+// range, instant and exemplar queries of a query can be told apart.
+func withResultType(frames data.Frames, resultType string) data.Frames {
+	for _, frame := range frames {
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		frame.Meta.Custom = map[string]string{"resultType": resultType}
+	}
+	return frames
+}
+
+// withWarnings adds the warnings of the Prometheus API to the notices of the frames, or to an
+// empty frame when there are none so that the warnings are not lost.
+func withWarnings(frames data.Frames, warnings apiv1.Warnings) data.Frames {
+	if len(warnings) == 0 {
+		return frames
+	}
+	if len(frames) == 0 {
+		frames = data.Frames{data.NewFrame("")}
+	}
+	for _, frame := range frames {
+		if frame.Meta == nil {
+			frame.Meta = &data.FrameMeta{}
+		}
+		for _, w := range warnings {
+			frame.Meta.Notices = append(frame.Meta.Notices, data.Notice{Severity: data.NoticeSeverityWarning, Text: w})
+		}
+	}
+	return frames
+}
+
+// decodeJSON decodes a property of the JSON data of the data source.
+func decodeJSON(j *simplejson.Json, v interface{}) error {
+	if j.Interface() == nil {
+		return nil
+	}
+	b, err := j.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
 }
 
 // IsAPIError returns whether err is or wraps a Prometheus error.
@@ -210,3 +508,17 @@ func ConvertAPIError(err error) error {
 	}
 	return err
 }
+
+func (s *Service) getDSInfo(pluginCtx backend.PluginContext) (*datasourceInfo, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := i.(*datasourceInfo)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast data source info")
+	}
+
+	return instance, nil
+}
@@ -3,24 +3,23 @@ package prometheus
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	sdkhttpclient "github.com/grafana/grafana-plugin-sdk-go/backend/httpclient"
-	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
-	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/tsdb/interval"
 	p "github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 )
 
 func TestPrometheus(t *testing.T) {
-	json, _ := simplejson.NewJson([]byte(`
-		{ "customQueryParameters": "custom=par/am&second=f oo"}
-	`))
-	dsInfo := &models.DataSource{
-		JsonData: json,
+	settings := backend.DataSourceInstanceSettings{
+		JSONData: []byte(`{ "customQueryParameters": "custom=par/am&second=f oo"}`),
 	}
 	var capturedRequest *http.Request
 	mw := sdkhttpclient.MiddlewareFunc(func(opts sdkhttpclient.Options, next http.RoundTripper) http.RoundTripper {
@@ -32,9 +31,12 @@ func TestPrometheus(t *testing.T) {
 	provider := httpclient.NewProvider(sdkhttpclient.ProviderOptions{
 		Middlewares: []sdkhttpclient.Middleware{mw},
 	})
-	plug, err := New(provider)(dsInfo)
+	instance, err := newInstanceSettings(provider)(settings)
 	require.NoError(t, err)
-	executor := plug.(*PrometheusExecutor)
+	dsInfo := instance.(*datasourceInfo)
+	service := &Service{
+		intervalCalculator: interval.NewCalculator(interval.CalculatorOptions{MinInterval: time.Second * 1}),
+	}
 
 	t.Run("converting metric name", func(t *testing.T) {
 		metric := map[p.LabelName]p.LabelValue{
@@ -69,9 +71,9 @@ func TestPrometheus(t *testing.T) {
 			"format": "time_series",
 			"refId": "A"
 		}`)
-		timerange := plugins.NewDataTimeRange("12h", "now")
-		query.TimeRange = &timerange
-		models, err := executor.parseQuery(dsInfo, query)
+		now := time.Now()
+		query[0].TimeRange = backend.TimeRange{From: now.Add(-12 * time.Hour), To: now}
+		models, err := service.parseQuery(dsInfo, query)
 		require.NoError(t, err)
 		require.Equal(t, time.Second*30, models[0].Step)
 	})
@@ -83,19 +85,19 @@ func TestPrometheus(t *testing.T) {
 			"intervalFactor": 1,
 			"refId": "A"
 		}`)
-		models, err := executor.parseQuery(dsInfo, query)
+		models, err := service.parseQuery(dsInfo, query)
 		require.NoError(t, err)
 		require.Equal(t, time.Minute*2, models[0].Step)
 
-		timeRange := plugins.NewDataTimeRange("1h", "now")
-		query.TimeRange = &timeRange
-		models, err = executor.parseQuery(dsInfo, query)
+		now := time.Now()
+		query[0].TimeRange = backend.TimeRange{From: now.Add(-time.Hour), To: now}
+		models, err = service.parseQuery(dsInfo, query)
 		require.NoError(t, err)
 		require.Equal(t, time.Second*15, models[0].Step)
 	})
 
 	t.Run("parsing query model with high intervalFactor", func(t *testing.T) {
-		models, err := executor.parseQuery(dsInfo, queryContext(`{
+		models, err := service.parseQuery(dsInfo, queryContext(`{
 			"expr": "go_goroutines",
 			"format": "time_series",
 			"intervalFactor": 10,
@@ -106,7 +108,7 @@ func TestPrometheus(t *testing.T) {
 	})
 
 	t.Run("parsing query model with low intervalFactor", func(t *testing.T) {
-		models, err := executor.parseQuery(dsInfo, queryContext(`{
+		models, err := service.parseQuery(dsInfo, queryContext(`{
 			"expr": "go_goroutines",
 			"format": "time_series",
 			"intervalFactor": 1,
@@ -117,39 +119,26 @@ func TestPrometheus(t *testing.T) {
 	})
 
 	t.Run("runs query with custom params", func(t *testing.T) {
-		query := queryContext(`{
-			"expr": "go_goroutines",
-			"format": "time_series",
-			"intervalFactor": 1,
-			"refId": "A"
-		}`)
-		_, _ = executor.DataQuery(context.Background(), dsInfo, query)
+		_, _ = runQuery(context.Background(), dsInfo, &PrometheusQuery{Expr: "go_goroutines", RangeQuery: true})
 		require.NotNil(t, capturedRequest)
 		require.Equal(t, "custom=par%2Fam&second=f+oo", capturedRequest.URL.RawQuery)
 	})
 }
 
-func queryContext(json string) plugins.DataQuery {
-	jsonModel, _ := simplejson.NewJson([]byte(json))
-	queryModels := []plugins.DataSubQuery{
-		{Model: jsonModel},
-	}
-
-	timeRange := plugins.NewDataTimeRange("48h", "now")
-	return plugins.DataQuery{
-		TimeRange: &timeRange,
-		Queries:   queryModels,
-	}
+func queryContext(json string) []backend.DataQuery {
+	now := time.Now()
+	return []backend.DataQuery{{
+		JSON:      []byte(json),
+		TimeRange: backend.TimeRange{From: now.Add(-48 * time.Hour), To: now},
+	}}
 }
 
 func TestParseResponse(t *testing.T) {
-	t.Run("value is not of type matrix", func(t *testing.T) {
-		//nolint: staticcheck // plugins.DataQueryResult deprecated
-		queryRes := plugins.DataQueryResult{}
-		value := p.Vector{}
+	t.Run("value is not of a supported type", func(t *testing.T) {
+		value := &p.String{Value: "string"}
 		res, err := parseResponse(value, nil)
 
-		require.Equal(t, queryRes, res)
+		require.Nil(t, res)
 		require.Error(t, err)
 	})
 
@@ -170,10 +159,9 @@ func TestParseResponse(t *testing.T) {
 		query := &PrometheusQuery{
 			LegendFormat: "legend {{app}}",
 		}
-		res, err := parseResponse(value, query)
+		decoded, err := parseResponse(value, query)
 		require.NoError(t, err)
 
-		decoded, _ := res.Dataframes.Decoded()
 		require.Len(t, decoded, 1)
 		require.Equal(t, decoded[0].Name, "legend Application")
 		require.Len(t, decoded[0].Fields, 2)
@@ -188,4 +176,99 @@ func TestParseResponse(t *testing.T) {
 		testValue := decoded[0].Fields[0].At(0)
 		require.Equal(t, "UTC", testValue.(time.Time).Location().String())
 	})
+
+	t.Run("instant query response should be parsed with a single value for each series", func(t *testing.T) {
+		value := p.Vector{
+			&p.Sample{Metric: p.Metric{"app": "Application"}, Value: 3, Timestamp: 1000},
+		}
+		decoded, err := parseResponse(value, &PrometheusQuery{LegendFormat: "legend {{app}}"})
+		require.NoError(t, err)
+
+		require.Len(t, decoded, 1)
+		require.Equal(t, "legend Application", decoded[0].Name)
+		require.Equal(t, 1, decoded[0].Rows())
+		require.Equal(t, time.Unix(1, 0).UTC(), decoded[0].Fields[0].At(0))
+		require.Equal(t, 3.0, decoded[0].Fields[1].At(0))
+		require.Equal(t, data.VisType(data.VisTypeTable), decoded[0].Meta.PreferredVisualization)
+	})
+}
+
+func TestDataQuery(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/query_range":
+			_, _ = w.Write([]byte(`{"status": "success", "warnings": ["the range is too long"], "data": {"resultType": "matrix", "result": [{"metric": {"app": "backend"}, "values": [[1622548800, "1"]]}]}}`))
+		case "/api/v1/query":
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "vector", "result": [{"metric": {"app": "backend"}, "value": [1622548800, "2"]}]}}`))
+		case "/api/v1/query_exemplars":
+			_, _ = w.Write([]byte(`{"status": "success", "data": [{"seriesLabels": {"app": "backend"}, "exemplars": [{"labels": {"traceID": "abc"}, "value": "0.5", "timestamp": 1622548800.123}]}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	service := &Service{
+		intervalCalculator: interval.NewCalculator(interval.CalculatorOptions{MinInterval: time.Second * 1}),
+		im:                 datasource.NewInstanceManager(newInstanceSettings(httpclient.NewProvider())),
+	}
+	queryData := func(queries []backend.DataQuery) (*backend.QueryDataResponse, error) {
+		return service.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					ID:       1,
+					URL:      srv.URL,
+					JSONData: []byte(`{"exemplarTraceIdDestinations": [{"name": "traceID", "url": "http://tempo/trace/${__value.raw}"}]}`),
+				},
+			},
+			Queries: queries,
+		})
+	}
+
+	t.Run("the range, instant and exemplar queries are run", func(t *testing.T) {
+		paths = nil
+		query := queryContext(`{"expr": "rate(requests[5m])", "range": true, "instant": true, "exemplar": true}`)
+		query[0].RefID = "A"
+		res, err := queryData(query)
+		require.NoError(t, err)
+		require.Equal(t, []string{"/api/v1/query_range", "/api/v1/query", "/api/v1/query_exemplars"}, paths)
+
+		frames := res.Responses["A"].Frames
+		require.Len(t, frames, 3)
+
+		for i, resultType := range []string{"range", "instant", "exemplar"} {
+			require.Equal(t, map[string]string{"resultType": resultType}, frames[i].Meta.Custom)
+		}
+
+		require.Equal(t, 1.0, frames[0].Fields[1].At(0))
+		require.Equal(t, []data.Notice{{Severity: data.NoticeSeverityWarning, Text: "the range is too long"}}, frames[0].Meta.Notices)
+
+		require.Equal(t, 2.0, frames[1].Fields[1].At(0))
+		require.Empty(t, frames[1].Meta.Notices)
+
+		exemplars := frames[2]
+		require.Equal(t, "exemplar", exemplars.Name)
+		require.Len(t, exemplars.Fields, 4)
+		require.Equal(t, time.Unix(1622548800, 123000000).UTC(), exemplars.Fields[0].At(0))
+		require.Equal(t, 0.5, exemplars.Fields[1].At(0))
+		require.Equal(t, "app", exemplars.Fields[2].Name)
+		require.Equal(t, "traceID", exemplars.Fields[3].Name)
+		require.Equal(t, "abc", exemplars.Fields[3].At(0))
+		require.Equal(t, "http://tempo/trace/${__value.raw}", exemplars.Fields[3].Config.Links[0].URL)
+	})
+
+	t.Run("only the instant query is run for the instant query type", func(t *testing.T) {
+		paths = nil
+		_, err := queryData(queryContext(`{"expr": "requests", "queryType": "instant", "exemplar": true}`))
+		require.NoError(t, err)
+		require.Equal(t, []string{"/api/v1/query"}, paths)
+	})
+
+	t.Run("unsupported query types are rejected", func(t *testing.T) {
+		_, err := queryData(queryContext(`{"expr": "requests", "queryType": "logs"}`))
+		require.Error(t, err)
+	})
 }
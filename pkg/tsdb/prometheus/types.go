@@ -9,4 +9,38 @@ type PrometheusQuery struct {
 	Start        time.Time
 	End          time.Time
 	RefId        string
+	// RangeQuery runs the query over the time range, at the step.
+	RangeQuery bool
+	// InstantQuery runs the query at the end of the time range.
+	InstantQuery bool
+	// ExemplarQuery fetches the exemplars of the series of the query over the time range.
+	ExemplarQuery bool
+}
+
+// queryType is the type of a query of the queryType property of the query model.
+type queryType string
+
+const (
+	queryTypeRange   queryType = "range"
+	queryTypeInstant queryType = "instant"
+)
+
+// ExemplarTraceIDDestination is a link from an exemplar label with a trace ID, configured in
+// the exemplarTraceIdDestinations of the data source.
+type ExemplarTraceIDDestination struct {
+	Name          string `json:"name"`
+	URL           string `json:"url"`
+	DatasourceUID string `json:"datasourceUid"`
+}
+
+// exemplarQueryResult is the exemplars of a series, in the response of the exemplars API.
+type exemplarQueryResult struct {
+	SeriesLabels map[string]string `json:"seriesLabels"`
+	Exemplars    []exemplar        `json:"exemplars"`
+}
+
+type exemplar struct {
+	Labels    map[string]string `json:"labels"`
+	Value     float64           `json:"value,string"`
+	Timestamp float64           `json:"timestamp"`
 }
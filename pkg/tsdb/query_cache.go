@@ -0,0 +1,243 @@
+package tsdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/gtime"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+)
+
+const (
+	// queryCacheTTLKey is the key of the JSON data of a data source which enables the caching of
+	// its query results. It is set to how long the results are cached, for example "1m".
+	queryCacheTTLKey = "queryCacheTTL"
+
+	queryCacheKeyPrefix = "tsdb-query-cache-"
+
+	queryCacheHit    = "hit"
+	queryCacheMiss   = "miss"
+	queryCacheBypass = "bypass"
+)
+
+var qclog = log.New("tsdb.querycache")
+
+// queryCacheTTL returns how long the results of the query are cached, which is zero when they are not.
+// Only the results of the queries of users are cached: alerting and expressions expect fresh results.
+// The results of the data sources forwarding the OAuth identity of the users are not cached, as they
+// depend on the user.
+//nolint: staticcheck // plugins.DataPlugin deprecated
+func (s *Service) queryCacheTTL(ds *models.DataSource, query plugins.DataQuery) time.Duration {
+	if s.RemoteCache == nil || ds.JsonData == nil || query.User == nil || query.TimeRange == nil {
+		return 0
+	}
+	if ds.JsonData.Get("oauthPassThru").MustBool() {
+		return 0
+	}
+
+	value := ds.JsonData.Get(queryCacheTTLKey).MustString()
+	if value == "" {
+		return 0
+	}
+	ttl, err := gtime.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		qclog.Warn("Invalid query cache TTL of data source", "datasource", ds.Name, "ttl", value)
+		return 0
+	}
+	return ttl
+}
+
+// queryCacheKeyQuery is the part of a query its results depend on.
+type queryCacheKeyQuery struct {
+	RefID         string           `json:"refId"`
+	QueryType     string           `json:"queryType"`
+	MaxDataPoints int64            `json:"maxDataPoints"`
+	IntervalMS    int64            `json:"intervalMs"`
+	Model         *simplejson.Json `json:"model"`
+}
+
+// queryCacheKey returns the key the results of the query are cached with. The queries are sorted and
+// their models serialized with sorted keys, so identical queries have the same key. The time range is
+// aligned to the TTL, so the queries of relative time ranges have the same key for as long as their
+// results are cached. The version of the data source is part of the key, so editing it invalidates
+// the cached results.
+//nolint: staticcheck // plugins.DataPlugin deprecated
+func queryCacheKey(ds *models.DataSource, query plugins.DataQuery, ttl time.Duration) (string, error) {
+	bucket := ttl.Milliseconds()
+	from := query.TimeRange.GetFromAsMsEpoch()
+	to := query.TimeRange.GetToAsMsEpoch()
+
+	queries := make([]queryCacheKeyQuery, 0, len(query.Queries))
+	for _, q := range query.Queries {
+		queries = append(queries, queryCacheKeyQuery{
+			RefID:         q.RefID,
+			QueryType:     q.QueryType,
+			MaxDataPoints: q.MaxDataPoints,
+			IntervalMS:    q.IntervalMS,
+			Model:         q.Model,
+		})
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].RefID < queries[j].RefID })
+
+	b, err := json.Marshal(map[string]interface{}{
+		"orgId":             ds.OrgId,
+		"datasourceId":      ds.Id,
+		"datasourceVersion": ds.Version,
+		"from":              from - from%bucket,
+		"to":                to - to%bucket,
+		"queries":           queries,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return queryCacheKeyPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// cachedDataQueryResult is a plugins.DataQueryResult as it is cached, the data frames are Arrow encoded.
+type cachedDataQueryResult struct {
+	RefID      string                      `json:"refId"`
+	Meta       *simplejson.Json            `json:"meta,omitempty"`
+	Series     plugins.DataTimeSeriesSlice `json:"series"`
+	Tables     []plugins.DataTable         `json:"tables"`
+	Dataframes [][]byte                    `json:"dataframes"`
+}
+
+// cachedDataResponse is a plugins.DataResponse as it is cached.
+type cachedDataResponse struct {
+	Results map[string]cachedDataQueryResult `json:"results"`
+	Message string                           `json:"message,omitempty"`
+}
+
+// encodeCachedResponse encodes the response to be cached. It returns false when the response
+// cannot be cached, because some of its queries failed.
+//nolint: staticcheck // plugins.DataPlugin deprecated
+func encodeCachedResponse(resp plugins.DataResponse) ([]byte, bool, error) {
+	cached := cachedDataResponse{
+		Results: make(map[string]cachedDataQueryResult, len(resp.Results)),
+		Message: resp.Message,
+	}
+	for refID, res := range resp.Results {
+		if res.Error != nil || res.ErrorString != "" {
+			return nil, false, nil
+		}
+		result := cachedDataQueryResult{
+			RefID:  res.RefID,
+			Meta:   res.Meta,
+			Series: res.Series,
+			Tables: res.Tables,
+		}
+		if res.Dataframes != nil {
+			encoded, err := res.Dataframes.Encoded()
+			if err != nil {
+				return nil, false, err
+			}
+			result.Dataframes = encoded
+		}
+		cached.Results[refID] = result
+	}
+
+	b, err := json.Marshal(cached)
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+//nolint: staticcheck // plugins.DataPlugin deprecated
+func decodeCachedResponse(b []byte) (plugins.DataResponse, error) {
+	var cached cachedDataResponse
+	if err := json.Unmarshal(b, &cached); err != nil {
+		return plugins.DataResponse{}, err
+	}
+
+	resp := plugins.DataResponse{
+		Results: make(map[string]plugins.DataQueryResult, len(cached.Results)),
+		Message: cached.Message,
+	}
+	for refID, res := range cached.Results {
+		result := plugins.DataQueryResult{
+			RefID:  res.RefID,
+			Meta:   res.Meta,
+			Series: res.Series,
+			Tables: res.Tables,
+		}
+		if res.Dataframes != nil {
+			result.Dataframes = plugins.NewEncodedDataFrames(res.Dataframes)
+		}
+		resp.Results[refID] = result
+	}
+	return resp, nil
+}
+
+// getCachedResponse returns the cached response of the key, if any.
+//nolint: staticcheck // plugins.DataPlugin deprecated
+func (s *Service) getCachedResponse(key string) (plugins.DataResponse, bool) {
+	value, err := s.RemoteCache.Get(key)
+	if err != nil {
+		return plugins.DataResponse{}, false
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return plugins.DataResponse{}, false
+	}
+	resp, err := decodeCachedResponse(b)
+	if err != nil {
+		qclog.Warn("Failed to decode cached query results", "err", err)
+		return plugins.DataResponse{}, false
+	}
+	return resp, true
+}
+
+// setCachedResponse caches the response with the key, unless some of its queries failed.
+//nolint: staticcheck // plugins.DataPlugin deprecated
+func (s *Service) setCachedResponse(key string, resp plugins.DataResponse, ttl time.Duration) {
+	b, ok, err := encodeCachedResponse(resp)
+	if err != nil {
+		qclog.Warn("Failed to encode query results", "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	if err := s.RemoteCache.Set(key, b, ttl); err != nil {
+		qclog.Warn("Failed to cache query results", "err", err)
+	}
+}
+
+// handleCachedRequest handles the request with the results cached for the data source when there are,
+// and caches the results otherwise. The cached results are not used when the request skips the cache,
+// but its results are still cached.
+//nolint: staticcheck // plugins.DataPlugin deprecated
+func (s *Service) handleCachedRequest(ds *models.DataSource, query plugins.DataQuery, ttl time.Duration,
+	handle func() (plugins.DataResponse, error)) (plugins.DataResponse, error) {
+	key, err := queryCacheKey(ds, query, ttl)
+	if err != nil {
+		qclog.Warn("Failed to compute the query cache key", "err", err)
+		return handle()
+	}
+
+	status := queryCacheBypass
+	if !query.SkipCache {
+		if resp, ok := s.getCachedResponse(key); ok {
+			metrics.MDataSourceQueryCacheTotal.WithLabelValues(ds.Type, queryCacheHit).Inc()
+			return resp, nil
+		}
+		status = queryCacheMiss
+	}
+	metrics.MDataSourceQueryCacheTotal.WithLabelValues(ds.Type, status).Inc()
+
+	resp, err := handle()
+	if err != nil {
+		return resp, err
+	}
+	s.setCachedResponse(key, resp, ttl)
+	return resp, nil
+}
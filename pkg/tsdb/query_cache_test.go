@@ -0,0 +1,133 @@
+package tsdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	"github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCache(t *testing.T) {
+	ds := &models.DataSource{Id: 1, OrgId: 1, Version: 1, Type: "test", JsonData: simplejson.NewFromAny(map[string]interface{}{
+		queryCacheTTLKey: "1m",
+	})}
+	now := time.Date(2021, 6, 1, 12, 0, 10, 0, time.UTC)
+	newQuery := func(expr string) plugins.DataQuery {
+		return plugins.DataQuery{
+			TimeRange: &plugins.DataTimeRange{From: "now-1h", To: "now", Now: now},
+			User:      &models.SignedInUser{OrgId: 1},
+			Queries: []plugins.DataSubQuery{
+				{RefID: "A", DataSource: ds, Model: simplejson.NewFromAny(map[string]interface{}{"expr": expr})},
+			},
+		}
+	}
+
+	svc, exe, _ := createService()
+	svc.RemoteCache = remotecache.NewFakeStore(t)
+	calls := 0
+	//nolint: staticcheck // plugins.DataPlugin deprecated
+	exe.HandleQuery("A", func(query plugins.DataQuery) plugins.DataQueryResult {
+		calls++
+		return plugins.DataQueryResult{
+			RefID:      "A",
+			Meta:       simplejson.NewFromAny(map[string]interface{}{"calls": calls}),
+			Series:     plugins.DataTimeSeriesSlice{{Name: query.Queries[0].Model.Get("expr").MustString()}},
+			Dataframes: plugins.NewDecodedDataFrames(data.Frames{data.NewFrame("frame", data.NewField("value", nil, []float64{1}))}),
+		}
+	})
+
+	t.Run("the results of identical queries are cached", func(t *testing.T) {
+		res, err := svc.HandleRequest(context.Background(), ds, newQuery("up"))
+		require.NoError(t, err)
+		require.Equal(t, 1, calls)
+
+		query := newQuery("up")
+		query.TimeRange.Now = now.Add(30 * time.Second)
+		cached, err := svc.HandleRequest(context.Background(), ds, query)
+		require.NoError(t, err)
+		require.Equal(t, 1, calls, "the time range is aligned to the TTL")
+		require.Equal(t, res.Results["A"].Series, cached.Results["A"].Series)
+		require.Equal(t, 1, cached.Results["A"].Meta.Get("calls").MustInt())
+		frames, err := cached.Results["A"].Dataframes.Decoded()
+		require.NoError(t, err)
+		require.Len(t, frames, 1)
+		require.Equal(t, "frame", frames[0].Name)
+	})
+
+	t.Run("the results of other queries are not shared", func(t *testing.T) {
+		res, err := svc.HandleRequest(context.Background(), ds, newQuery("rate(up[5m])"))
+		require.NoError(t, err)
+		require.Equal(t, 2, calls)
+		require.Equal(t, "rate(up[5m])", res.Results["A"].Series[0].Name)
+
+		query := newQuery("up")
+		query.TimeRange.Now = now.Add(time.Minute)
+		_, err = svc.HandleRequest(context.Background(), ds, query)
+		require.NoError(t, err)
+		require.Equal(t, 3, calls, "the time range is in the next bucket")
+	})
+
+	t.Run("the cache is skipped when requested", func(t *testing.T) {
+		query := newQuery("up")
+		query.SkipCache = true
+		_, err := svc.HandleRequest(context.Background(), ds, query)
+		require.NoError(t, err)
+		require.Equal(t, 4, calls)
+	})
+
+	t.Run("the results are not cached for requests without user", func(t *testing.T) {
+		query := newQuery("up")
+		query.User = nil
+		_, err := svc.HandleRequest(context.Background(), ds, query)
+		require.NoError(t, err)
+		require.Equal(t, 5, calls)
+	})
+
+	t.Run("the results are not cached for data sources without TTL", func(t *testing.T) {
+		other := &models.DataSource{Id: 2, OrgId: 1, Type: "test", JsonData: simplejson.New()}
+		for i := 0; i < 2; i++ {
+			_, err := svc.HandleRequest(context.Background(), other, newQuery("up"))
+			require.NoError(t, err)
+		}
+		require.Equal(t, 7, calls)
+	})
+
+	t.Run("the results are not cached for data sources forwarding the OAuth identity", func(t *testing.T) {
+		other := &models.DataSource{Id: 3, OrgId: 1, Type: "test", JsonData: simplejson.NewFromAny(map[string]interface{}{
+			queryCacheTTLKey: "1m",
+			"oauthPassThru":  true,
+		})}
+		for i := 0; i < 2; i++ {
+			_, err := svc.HandleRequest(context.Background(), other, newQuery("up"))
+			require.NoError(t, err)
+		}
+		require.Equal(t, 9, calls)
+	})
+
+	t.Run("the results are cached again when the data source is updated", func(t *testing.T) {
+		updated := *ds
+		updated.Version = 2
+		_, err := svc.HandleRequest(context.Background(), &updated, newQuery("up"))
+		require.NoError(t, err)
+		require.Equal(t, 10, calls)
+	})
+
+	t.Run("failed queries are not cached", func(t *testing.T) {
+		//nolint: staticcheck // plugins.DataPlugin deprecated
+		exe.HandleQuery("A", func(query plugins.DataQuery) plugins.DataQueryResult {
+			calls++
+			return plugins.DataQueryResult{RefID: "A", ErrorString: "failed"}
+		})
+		for i := 0; i < 2; i++ {
+			_, err := svc.HandleRequest(context.Background(), ds, newQuery("failing"))
+			require.NoError(t, err)
+		}
+		require.Equal(t, 12, calls)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/grafana/grafana/pkg/infra/httpclient"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/plugins/backendplugin"
@@ -12,16 +13,6 @@ import (
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tsdb/azuremonitor"
-	"github.com/grafana/grafana/pkg/tsdb/cloudmonitoring"
-	"github.com/grafana/grafana/pkg/tsdb/elasticsearch"
-	"github.com/grafana/grafana/pkg/tsdb/graphite"
-	"github.com/grafana/grafana/pkg/tsdb/influxdb"
-	"github.com/grafana/grafana/pkg/tsdb/loki"
-	"github.com/grafana/grafana/pkg/tsdb/mssql"
-	"github.com/grafana/grafana/pkg/tsdb/mysql"
-	"github.com/grafana/grafana/pkg/tsdb/postgres"
-	"github.com/grafana/grafana/pkg/tsdb/prometheus"
-	"github.com/grafana/grafana/pkg/tsdb/tempo"
 )
 
 // NewService returns a new Service.
@@ -42,14 +33,13 @@ func init() {
 
 // Service handles data requests to data sources.
 type Service struct {
-	Cfg                    *setting.Cfg              `inject:""`
-	PostgresService        *postgres.PostgresService `inject:""`
-	CloudMonitoringService *cloudmonitoring.Service  `inject:""`
-	AzureMonitorService    *azuremonitor.Service     `inject:""`
-	PluginManager          plugins.Manager           `inject:""`
-	BackendPluginManager   backendplugin.Manager     `inject:""`
-	HTTPClientProvider     httpclient.Provider       `inject:""`
-	OAuthTokenService      *oauthtoken.Service       `inject:""`
+	Cfg                  *setting.Cfg             `inject:""`
+	AzureMonitorService  *azuremonitor.Service    `inject:""`
+	PluginManager        plugins.Manager          `inject:""`
+	BackendPluginManager backendplugin.Manager    `inject:""`
+	HTTPClientProvider   httpclient.Provider      `inject:""`
+	OAuthTokenService    *oauthtoken.Service      `inject:""`
+	RemoteCache          *remotecache.RemoteCache `inject:""`
 
 	//nolint: staticcheck // plugins.DataPlugin deprecated
 	registry map[string]func(*models.DataSource) (plugins.DataPlugin, error)
@@ -57,21 +47,23 @@ type Service struct {
 
 // Init initialises the service.
 func (s *Service) Init() error {
-	s.registry["graphite"] = graphite.New(s.HTTPClientProvider)
-	s.registry["prometheus"] = prometheus.New(s.HTTPClientProvider)
-	s.registry["influxdb"] = influxdb.New(s.HTTPClientProvider)
-	s.registry["mssql"] = mssql.NewExecutor
-	s.registry["postgres"] = s.PostgresService.NewExecutor
-	s.registry["mysql"] = mysql.New(s.HTTPClientProvider)
-	s.registry["elasticsearch"] = elasticsearch.New(s.HTTPClientProvider)
-	s.registry["stackdriver"] = s.CloudMonitoringService.NewExecutor
-	s.registry["loki"] = loki.New(s.HTTPClientProvider)
-	s.registry["tempo"] = tempo.New(s.HTTPClientProvider)
 	return nil
 }
 
+// HandleRequest handles a data request to the data source. The results are cached when the
+// data source has a query cache TTL.
 //nolint: staticcheck // plugins.DataPlugin deprecated
 func (s *Service) HandleRequest(ctx context.Context, ds *models.DataSource, query plugins.DataQuery) (plugins.DataResponse, error) {
+	if ttl := s.queryCacheTTL(ds, query); ttl > 0 {
+		return s.handleCachedRequest(ds, query, ttl, func() (plugins.DataResponse, error) {
+			return s.handleRequest(ctx, ds, query)
+		})
+	}
+	return s.handleRequest(ctx, ds, query)
+}
+
+//nolint: staticcheck // plugins.DataPlugin deprecated
+func (s *Service) handleRequest(ctx context.Context, ds *models.DataSource, query plugins.DataQuery) (plugins.DataResponse, error) {
 	if factory, exists := s.registry[ds.Type]; exists {
 		var err error
 		plugin, err := factory(ds)
@@ -32,7 +32,7 @@ var ErrConnectionFailed = errors.New("failed to connect to server - please inspe
 // SQLMacroEngine interpolates macros into sql. It takes in the Query to have access to query context and
 // timeRange to be able to generate queries that use from and to.
 type SQLMacroEngine interface {
-	Interpolate(query plugins.DataSubQuery, timeRange plugins.DataTimeRange, sql string) (string, error)
+	Interpolate(query *backend.DataQuery, timeRange backend.TimeRange, sql string) (string, error)
 }
 
 // SqlQueryResultTransformer transforms a query result row to RowValues with proper types.
@@ -43,17 +43,6 @@ type SqlQueryResultTransformer interface {
 	GetConverterList() []sqlutil.StringConverter
 }
 
-type engineCacheType struct {
-	cache    map[int64]*xorm.Engine
-	versions map[int64]int
-	sync.Mutex
-}
-
-var engineCache = engineCacheType{
-	cache:    make(map[int64]*xorm.Engine),
-	versions: make(map[int64]int),
-}
-
 var sqlIntervalCalculator = interval.NewCalculator()
 
 // NewXormEngine is an xorm.Engine factory, that can be stubbed by tests.
@@ -62,24 +51,70 @@ var NewXormEngine = func(driverName string, connectionString string) (*xorm.Engi
 	return xorm.NewEngine(driverName, connectionString)
 }
 
-type dataPlugin struct {
+// DataSourceInfo is the configuration of a SQL data source instance.
+type DataSourceInfo struct {
+	ID                      int64
+	UID                     string
+	Updated                 time.Time
+	URL                     string
+	User                    string
+	Database                string
+	JSONData                *simplejson.Json
+	DecryptedSecureJSONData map[string]string
+}
+
+// NewDataSourceInfo returns the configuration of the data source instance with these settings.
+func NewDataSourceInfo(settings backend.DataSourceInstanceSettings) (DataSourceInfo, error) {
+	jsonData := simplejson.New()
+	if len(settings.JSONData) > 0 {
+		var err error
+		if jsonData, err = simplejson.NewJson(settings.JSONData); err != nil {
+			return DataSourceInfo{}, fmt.Errorf("error reading settings: %w", err)
+		}
+	}
+
+	decrypted := settings.DecryptedSecureJSONData
+	if decrypted == nil {
+		decrypted = map[string]string{}
+	}
+
+	return DataSourceInfo{
+		ID:                      settings.ID,
+		UID:                     settings.UID,
+		Updated:                 settings.Updated,
+		URL:                     settings.URL,
+		User:                    settings.User,
+		Database:                settings.Database,
+		JSONData:                jsonData,
+		DecryptedSecureJSONData: decrypted,
+	}, nil
+}
+
+// DecryptedPassword returns the password of the database user.
+func (dsInfo DataSourceInfo) DecryptedPassword() string {
+	return dsInfo.DecryptedSecureJSONData["password"]
+}
+
+// DataSourceHandler runs the queries of a SQL data source instance over its connection pool.
+type DataSourceHandler struct {
 	macroEngine            SQLMacroEngine
 	queryResultTransformer SqlQueryResultTransformer
 	engine                 *xorm.Engine
 	timeColumnNames        []string
 	metricColumnTypes      []string
+	timeInterval           string
 	log                    log.Logger
 }
 
 type DataPluginConfiguration struct {
 	DriverName        string
-	Datasource        *models.DataSource
+	DSInfo            DataSourceInfo
 	ConnectionString  string
 	TimeColumnNames   []string
 	MetricColumnTypes []string
 }
 
-func (e *dataPlugin) transformQueryError(err error) error {
+func (e *DataSourceHandler) transformQueryError(err error) error {
 	// OpError is the error type usually returned by functions in the net
 	// package. It describes the operation, network type, and address of
 	// an error. We log this error rather than return it to the client
@@ -93,33 +128,24 @@ func (e *dataPlugin) transformQueryError(err error) error {
 	return e.queryResultTransformer.TransformQueryError(err)
 }
 
-// NewDataPlugin returns a new plugins.DataPlugin
-//nolint: staticcheck // plugins.DataPlugin deprecated
-func NewDataPlugin(config DataPluginConfiguration, queryResultTransformer SqlQueryResultTransformer,
-	macroEngine SQLMacroEngine, log log.Logger) (plugins.DataPlugin, error) {
-	plugin := dataPlugin{
+// NewQueryDataHandler returns the handler of the queries of a SQL data source instance,
+// which opens its connection pool.
+func NewQueryDataHandler(config DataPluginConfiguration, queryResultTransformer SqlQueryResultTransformer,
+	macroEngine SQLMacroEngine, log log.Logger) (*DataSourceHandler, error) {
+	handler := DataSourceHandler{
 		queryResultTransformer: queryResultTransformer,
 		macroEngine:            macroEngine,
 		timeColumnNames:        []string{"time"},
+		timeInterval:           config.DSInfo.JSONData.Get("timeInterval").MustString(""),
 		log:                    log,
 	}
 
 	if len(config.TimeColumnNames) > 0 {
-		plugin.timeColumnNames = config.TimeColumnNames
+		handler.timeColumnNames = config.TimeColumnNames
 	}
 
 	if len(config.MetricColumnTypes) > 0 {
-		plugin.metricColumnTypes = config.MetricColumnTypes
-	}
-
-	engineCache.Lock()
-	defer engineCache.Unlock()
-
-	if engine, present := engineCache.cache[config.Datasource.Id]; present {
-		if version := engineCache.versions[config.Datasource.Id]; version == config.Datasource.Version {
-			plugin.engine = engine
-			return &plugin, nil
-		}
+		handler.metricColumnTypes = config.MetricColumnTypes
 	}
 
 	engine, err := NewXormEngine(config.DriverName, config.ConnectionString)
@@ -127,104 +153,126 @@ func NewDataPlugin(config DataPluginConfiguration, queryResultTransformer SqlQue
 		return nil, err
 	}
 
-	maxOpenConns := config.Datasource.JsonData.Get("maxOpenConns").MustInt(0)
+	maxOpenConns := config.DSInfo.JSONData.Get("maxOpenConns").MustInt(0)
 	engine.SetMaxOpenConns(maxOpenConns)
-	maxIdleConns := config.Datasource.JsonData.Get("maxIdleConns").MustInt(2)
+	maxIdleConns := config.DSInfo.JSONData.Get("maxIdleConns").MustInt(2)
 	engine.SetMaxIdleConns(maxIdleConns)
-	connMaxLifetime := config.Datasource.JsonData.Get("connMaxLifetime").MustInt(14400)
+	connMaxLifetime := config.DSInfo.JSONData.Get("connMaxLifetime").MustInt(14400)
 	engine.SetConnMaxLifetime(time.Duration(connMaxLifetime) * time.Second)
 
-	engineCache.versions[config.Datasource.Id] = config.Datasource.Version
-	engineCache.cache[config.Datasource.Id] = engine
-	plugin.engine = engine
+	handler.engine = engine
 
-	return &plugin, nil
+	return &handler, nil
+}
+
+// Dispose closes the connection pool when the data source instance is updated or removed.
+func (e *DataSourceHandler) Dispose() {
+	e.log.Debug("Disposing the connection pool of the data source instance")
+	if err := e.engine.Close(); err != nil {
+		e.log.Error("Failed to close the connection pool", "err", err)
+	}
+}
+
+// CheckHealth checks that a connection to the database can be established.
+func (e *DataSourceHandler) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	if err := e.engine.DB().PingContext(ctx); err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: e.transformQueryError(err).Error(),
+		}, nil
+	}
+
+	return &backend.CheckHealthResult{
+		Status:  backend.HealthStatusOk,
+		Message: "Database Connection OK",
+	}, nil
 }
 
 const rowLimit = 1000000
 
-// DataQuery queries for data.
-//nolint: staticcheck // plugins.DataPlugin deprecated
-func (e *dataPlugin) DataQuery(ctx context.Context, dsInfo *models.DataSource,
-	queryContext plugins.DataQuery) (plugins.DataResponse, error) {
-	ch := make(chan plugins.DataQueryResult, len(queryContext.Queries))
+type dbDataResponse struct {
+	dataResponse backend.DataResponse
+	refID        string
+}
+
+// QueryData runs the queries concurrently and returns their results.
+func (e *DataSourceHandler) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	result := backend.NewQueryDataResponse()
+	ch := make(chan dbDataResponse, len(req.Queries))
 	var wg sync.WaitGroup
 	// Execute each query in a goroutine and wait for them to finish afterwards
-	for _, query := range queryContext.Queries {
-		if query.Model.Get("rawSql").MustString() == "" {
+	for _, query := range req.Queries {
+		model, err := simplejson.NewJson(query.JSON)
+		if err != nil {
+			result.Responses[query.RefID] = backend.DataResponse{Error: fmt.Errorf("error reading query: %w", err)}
+			continue
+		}
+		if model.Get("rawSql").MustString() == "" {
 			continue
 		}
 
 		wg.Add(1)
-		go e.executeQuery(query, &wg, queryContext, ch)
+		go e.executeQuery(query, model, &wg, ch)
 	}
 
 	wg.Wait()
 
 	// Read results from channels
 	close(ch)
-	result := plugins.DataResponse{
-		Results: make(map[string]plugins.DataQueryResult),
-	}
 	for queryResult := range ch {
-		result.Results[queryResult.RefID] = queryResult
+		result.Responses[queryResult.refID] = queryResult.dataResponse
 	}
 
 	return result, nil
 }
 
-//nolint: staticcheck // plugins.DataQueryResult deprecated
-func (e *dataPlugin) executeQuery(query plugins.DataSubQuery, wg *sync.WaitGroup, queryContext plugins.DataQuery,
-	ch chan plugins.DataQueryResult) {
+func (e *DataSourceHandler) executeQuery(query backend.DataQuery, model *simplejson.Json, wg *sync.WaitGroup,
+	ch chan dbDataResponse) {
 	defer wg.Done()
 
-	queryResult := plugins.DataQueryResult{
-		Meta:  simplejson.New(),
-		RefID: query.RefID,
+	queryResult := dbDataResponse{
+		refID: query.RefID,
 	}
 
 	defer func() {
 		if r := recover(); r != nil {
 			e.log.Error("executeQuery panic", "error", r, "stack", log.Stack(1))
 			if theErr, ok := r.(error); ok {
-				queryResult.Error = theErr
+				queryResult.dataResponse.Error = theErr
 			} else if theErrString, ok := r.(string); ok {
-				queryResult.Error = fmt.Errorf(theErrString)
+				queryResult.dataResponse.Error = fmt.Errorf(theErrString)
 			} else {
-				queryResult.Error = fmt.Errorf("unexpected error, see the server log for details")
+				queryResult.dataResponse.Error = fmt.Errorf("unexpected error, see the server log for details")
 			}
 			ch <- queryResult
 		}
 	}()
 
-	rawSQL := query.Model.Get("rawSql").MustString()
+	rawSQL := model.Get("rawSql").MustString()
 	if rawSQL == "" {
 		panic("Query model property rawSql should not be empty at this point")
 	}
-	var timeRange plugins.DataTimeRange
-	if queryContext.TimeRange != nil {
-		timeRange = *queryContext.TimeRange
-	}
+	timeRange := query.TimeRange
 
 	errAppendDebug := func(frameErr string, err error, query string) {
 		var emptyFrame data.Frame
 		emptyFrame.SetMeta(&data.FrameMeta{
 			ExecutedQueryString: query,
 		})
-		queryResult.Error = fmt.Errorf("%s: %w", frameErr, err)
-		queryResult.Dataframes = plugins.NewDecodedDataFrames(data.Frames{&emptyFrame})
+		queryResult.dataResponse.Error = fmt.Errorf("%s: %w", frameErr, err)
+		queryResult.dataResponse.Frames = data.Frames{&emptyFrame}
 		ch <- queryResult
 	}
 
 	// global substitutions
-	interpolatedQuery, err := Interpolate(query, timeRange, rawSQL)
+	interpolatedQuery, err := Interpolate(query, timeRange, e.timeInterval, rawSQL)
 	if err != nil {
 		errAppendDebug("interpolation failed", e.transformQueryError(err), interpolatedQuery)
 		return
 	}
 
 	// data source specific substitutions
-	interpolatedQuery, err = e.macroEngine.Interpolate(query, timeRange, interpolatedQuery)
+	interpolatedQuery, err = e.macroEngine.Interpolate(&query, timeRange, interpolatedQuery)
 	if err != nil {
 		errAppendDebug("interpolation failed", e.transformQueryError(err), interpolatedQuery)
 		return
@@ -245,7 +293,7 @@ func (e *dataPlugin) executeQuery(query plugins.DataSubQuery, wg *sync.WaitGroup
 		}
 	}()
 
-	qm, err := e.newProcessCfg(query, queryContext, rows, interpolatedQuery)
+	qm, err := e.newProcessCfg(query, rows, interpolatedQuery)
 	if err != nil {
 		errAppendDebug("failed to get configurations", err, interpolatedQuery)
 		return
@@ -265,7 +313,7 @@ func (e *dataPlugin) executeQuery(query plugins.DataSubQuery, wg *sync.WaitGroup
 
 	// If no rows were returned, no point checking anything else.
 	if frame.Rows() == 0 {
-		queryResult.Dataframes = plugins.NewDecodedDataFrames(data.Frames{frame})
+		queryResult.dataResponse.Frames = data.Frames{frame}
 		ch <- queryResult
 		return
 	}
@@ -334,29 +382,34 @@ func (e *dataPlugin) executeQuery(query plugins.DataSubQuery, wg *sync.WaitGroup
 		}
 	}
 
-	queryResult.Dataframes = plugins.NewDecodedDataFrames(data.Frames{frame})
+	queryResult.dataResponse.Frames = data.Frames{frame}
 	ch <- queryResult
 }
 
 // Interpolate provides global macros/substitutions for all sql datasources.
-var Interpolate = func(query plugins.DataSubQuery, timeRange plugins.DataTimeRange, sql string) (string, error) {
-	minInterval, err := interval.GetIntervalFrom(query.DataSource, query.Model, time.Second*60)
+var Interpolate = func(query backend.DataQuery, timeRange backend.TimeRange, timeInterval string, sql string) (string, error) {
+	model, err := simplejson.NewJson(query.JSON)
 	if err != nil {
 		return "", err
 	}
-	interval := sqlIntervalCalculator.Calculate(timeRange, minInterval)
+	minInterval, err := interval.GetIntervalFrom(&models.DataSource{
+		JsonData: simplejson.NewFromAny(map[string]interface{}{"timeInterval": timeInterval}),
+	}, model, time.Second*60)
+	if err != nil {
+		return "", err
+	}
+	interval := sqlIntervalCalculator.Calculate(plugins.NewDataTimeRangeFromBackend(timeRange), minInterval)
 
 	sql = strings.ReplaceAll(sql, "$__interval_ms", strconv.FormatInt(interval.Milliseconds(), 10))
 	sql = strings.ReplaceAll(sql, "$__interval", interval.Text)
-	sql = strings.ReplaceAll(sql, "$__unixEpochFrom()", fmt.Sprintf("%d", timeRange.GetFromAsSecondsEpoch()))
-	sql = strings.ReplaceAll(sql, "$__unixEpochTo()", fmt.Sprintf("%d", timeRange.GetToAsSecondsEpoch()))
+	sql = strings.ReplaceAll(sql, "$__unixEpochFrom()", fmt.Sprintf("%d", timeRange.From.UTC().Unix()))
+	sql = strings.ReplaceAll(sql, "$__unixEpochTo()", fmt.Sprintf("%d", timeRange.To.UTC().Unix()))
 
 	return sql, nil
 }
 
-//nolint: staticcheck // plugins.DataPlugin deprecated
-func (e *dataPlugin) newProcessCfg(query plugins.DataSubQuery, queryContext plugins.DataQuery,
-	rows *core.Rows, interpolatedQuery string) (*dataQueryModel, error) {
+func (e *DataSourceHandler) newProcessCfg(query backend.DataQuery, rows *core.Rows,
+	interpolatedQuery string) (*dataQueryModel, error) {
 	columnNames, err := rows.Columns()
 	if err != nil {
 		return nil, err
@@ -373,31 +426,33 @@ func (e *dataPlugin) newProcessCfg(query plugins.DataSubQuery, queryContext plug
 		timeIndex:    -1,
 		metricIndex:  -1,
 		metricPrefix: false,
-		queryContext: queryContext,
 	}
 
-	if query.Model.Get("fill").MustBool(false) {
+	// the macros can set up the fill mode of the query model
+	model, err := simplejson.NewJson(query.JSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if model.Get("fill").MustBool(false) {
 		qm.FillMissing = &data.FillMissing{}
-		qm.Interval = time.Duration(query.Model.Get("fillInterval").MustFloat64() * float64(time.Second))
-		switch strings.ToLower(query.Model.Get("fillMode").MustString()) {
+		qm.Interval = time.Duration(model.Get("fillInterval").MustFloat64() * float64(time.Second))
+		switch strings.ToLower(model.Get("fillMode").MustString()) {
 		case "null":
 			qm.FillMissing.Mode = data.FillModeNull
 		case "previous":
 			qm.FillMissing.Mode = data.FillModePrevious
 		case "value":
 			qm.FillMissing.Mode = data.FillModeValue
-			qm.FillMissing.Value = query.Model.Get("fillValue").MustFloat64()
+			qm.FillMissing.Value = model.Get("fillValue").MustFloat64()
 		default:
 		}
 	}
-	//nolint: staticcheck // plugins.DataPlugin deprecated
 
-	if queryContext.TimeRange != nil {
-		qm.TimeRange.From = queryContext.TimeRange.GetFromAsTimeUTC()
-		qm.TimeRange.To = queryContext.TimeRange.GetToAsTimeUTC()
-	}
+	qm.TimeRange.From = query.TimeRange.From.UTC()
+	qm.TimeRange.To = query.TimeRange.To.UTC()
 
-	format := query.Model.Get("format").MustString("time_series")
+	format := model.Get("format").MustString("time_series")
 	switch format {
 	case "time_series":
 		qm.Format = dataQueryFormatSeries
@@ -455,7 +510,6 @@ type dataQueryModel struct {
 	metricIndex       int
 	rows              *core.Rows
 	metricPrefix      bool
-	queryContext      plugins.DataQuery
 }
 
 func convertInt64ToFloat64(origin *data.Field, newField *data.Field) {
@@ -895,24 +949,31 @@ func convertSQLValueColumnToFloat(frame *data.Frame, Index int) (*data.Frame, er
 	return frame, nil
 }
 
-func SetupFillmode(query plugins.DataSubQuery, interval time.Duration, fillmode string) error {
-	query.Model.Set("fill", true)
-	query.Model.Set("fillInterval", interval.Seconds())
+// SetupFillmode sets the fill mode of the query model.
+func SetupFillmode(query *backend.DataQuery, interval time.Duration, fillmode string) error {
+	model, err := simplejson.NewJson(query.JSON)
+	if err != nil {
+		return err
+	}
+
+	model.Set("fill", true)
+	model.Set("fillInterval", interval.Seconds())
 	switch fillmode {
 	case "NULL":
-		query.Model.Set("fillMode", "null")
+		model.Set("fillMode", "null")
 	case "previous":
-		query.Model.Set("fillMode", "previous")
+		model.Set("fillMode", "previous")
 	default:
-		query.Model.Set("fillMode", "value")
+		model.Set("fillMode", "value")
 		floatVal, err := strconv.ParseFloat(fillmode, 64)
 		if err != nil {
 			return fmt.Errorf("error parsing fill value %v", fillmode)
 		}
-		query.Model.Set("fillValue", floatVal)
+		model.Set("fillValue", floatVal)
 	}
 
-	return nil
+	query.JSON, err = model.MarshalJSON()
+	return err
 }
 
 type SQLMacroEngineBase struct{}
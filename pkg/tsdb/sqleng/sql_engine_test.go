@@ -7,11 +7,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana-plugin-sdk-go/data/sqlutil"
-	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
-	"github.com/grafana/grafana/pkg/models"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,35 +24,35 @@ func TestSQLEngine(t *testing.T) {
 	t.Run("Given a time range between 2018-04-12 00:00 and 2018-04-12 00:05", func(t *testing.T) {
 		from := time.Date(2018, 4, 12, 18, 0, 0, 0, time.UTC)
 		to := from.Add(5 * time.Minute)
-		timeRange := plugins.DataTimeRange{From: "5m", To: "now", Now: to}
-		query := plugins.DataSubQuery{DataSource: &models.DataSource{}, Model: simplejson.New()}
+		timeRange := backend.TimeRange{From: from, To: to}
+		query := backend.DataQuery{JSON: []byte("{}")}
 
 		t.Run("interpolate $__interval", func(t *testing.T) {
-			sql, err := Interpolate(query, timeRange, "select $__interval ")
+			sql, err := Interpolate(query, timeRange, "", "select $__interval ")
 			require.NoError(t, err)
 			require.Equal(t, "select 1m ", sql)
 		})
 
 		t.Run("interpolate $__interval in $__timeGroup", func(t *testing.T) {
-			sql, err := Interpolate(query, timeRange, "select $__timeGroupAlias(time,$__interval)")
+			sql, err := Interpolate(query, timeRange, "", "select $__timeGroupAlias(time,$__interval)")
 			require.NoError(t, err)
 			require.Equal(t, "select $__timeGroupAlias(time,1m)", sql)
 		})
 
 		t.Run("interpolate $__interval_ms", func(t *testing.T) {
-			sql, err := Interpolate(query, timeRange, "select $__interval_ms ")
+			sql, err := Interpolate(query, timeRange, "", "select $__interval_ms ")
 			require.NoError(t, err)
 			require.Equal(t, "select 60000 ", sql)
 		})
 
 		t.Run("interpolate __unixEpochFrom function", func(t *testing.T) {
-			sql, err := Interpolate(query, timeRange, "select $__unixEpochFrom()")
+			sql, err := Interpolate(query, timeRange, "", "select $__unixEpochFrom()")
 			require.NoError(t, err)
 			require.Equal(t, fmt.Sprintf("select %d", from.Unix()), sql)
 		})
 
 		t.Run("interpolate __unixEpochTo function", func(t *testing.T) {
-			sql, err := Interpolate(query, timeRange, "select $__unixEpochTo()")
+			sql, err := Interpolate(query, timeRange, "", "select $__unixEpochTo()")
 			require.NoError(t, err)
 			require.Equal(t, fmt.Sprintf("select %d", to.Unix()), sql)
 		})
@@ -406,7 +405,7 @@ func TestSQLEngine(t *testing.T) {
 
 		for _, tc := range tests {
 			transformer := &testQueryResultTransformer{}
-			dp := dataPlugin{
+			dp := DataSourceHandler{
 				log:                    log.New("test"),
 				queryResultTransformer: transformer,
 			}
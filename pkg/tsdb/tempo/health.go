@@ -0,0 +1,48 @@
+package tempo
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+)
+
+// CheckHealth checks that the echo endpoint of Tempo responds.
+func (s *Service) CheckHealth(ctx context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "GET", dsInfo.URL+"/api/echo", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := dsInfo.HTTPClient.Do(request)
+	if err != nil {
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: fmt.Sprintf("Data source is not working: %s", err),
+		}, nil
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			tlog.Warn("failed to close response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return &backend.CheckHealthResult{
+			Status:  backend.HealthStatusError,
+			Message: fmt.Sprintf("Data source is not working: %s %s", resp.Status, string(body)),
+		}, nil
+	}
+
+	return &backend.CheckHealthResult{
+		Status:  backend.HealthStatusOk,
+		Message: "Data source is working",
+	}, nil
+}
@@ -6,54 +6,101 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/instancemgmt"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/models"
-	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin"
+	"github.com/grafana/grafana/pkg/plugins/backendplugin/coreplugin"
+	"github.com/grafana/grafana/pkg/registry"
 
 	ot_pdata "go.opentelemetry.io/collector/consumer/pdata"
 )
 
-type tempoExecutor struct {
-	httpClient *http.Client
+type Service struct {
+	HTTPClientProvider   httpclient.Provider   `inject:""`
+	BackendPluginManager backendplugin.Manager `inject:""`
+
+	im instancemgmt.InstanceManager
+}
+
+type datasourceInfo struct {
+	HTTPClient *http.Client
+	URL        string
+}
+
+var (
+	tlog = log.New("tsdb.tempo")
+)
+
+func init() {
+	registry.Register(&registry.Descriptor{Instance: &Service{}})
+}
+
+func (s *Service) Init() error {
+	s.im = datasource.NewInstanceManager(newInstanceSettings(s.HTTPClientProvider))
+
+	factory := coreplugin.New(backend.ServeOpts{
+		QueryDataHandler:   s,
+		CheckHealthHandler: s,
+	})
+
+	if err := s.BackendPluginManager.Register("tempo", factory); err != nil {
+		tlog.Error("Failed to register plugin", "error", err)
+	}
+
+	return nil
 }
 
-// NewExecutor returns a tempoExecutor.
-//nolint: staticcheck // plugins.DataPlugin deprecated
-func New(httpClientProvider httpclient.Provider) func(*models.DataSource) (plugins.DataPlugin, error) {
-	//nolint: staticcheck // plugins.DataPlugin deprecated
-	return func(dsInfo *models.DataSource) (plugins.DataPlugin, error) {
-		httpClient, err := dsInfo.GetHTTPClient(httpClientProvider)
+func newInstanceSettings(httpClientProvider httpclient.Provider) datasource.InstanceFactoryFunc {
+	return func(settings backend.DataSourceInstanceSettings) (instancemgmt.Instance, error) {
+		opts, err := models.HTTPClientOptionsFromInstanceSettings("tempo", settings)
+		if err != nil {
+			return nil, err
+		}
+
+		client, err := httpClientProvider.New(opts)
 		if err != nil {
 			return nil, err
 		}
 
-		return &tempoExecutor{
-			httpClient: httpClient,
+		return &datasourceInfo{
+			HTTPClient: client,
+			URL:        settings.URL,
 		}, nil
 	}
 }
 
-var (
-	tlog = log.New("tsdb.tempo")
-)
+func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	result := backend.NewQueryDataResponse()
+	if len(req.Queries) == 0 {
+		return result, nil
+	}
 
-//nolint: staticcheck // plugins.DataQuery deprecated
-func (e *tempoExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource,
-	queryContext plugins.DataQuery) (plugins.DataResponse, error) {
-	refID := queryContext.Queries[0].RefID
-	queryResult := plugins.DataQueryResult{}
-	traceID := queryContext.Queries[0].Model.Get("query").MustString("")
+	dsInfo, err := s.getDSInfo(req.PluginContext)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.Queries[0]
+	model, err := simplejson.NewJson(q.JSON)
+	if err != nil {
+		return nil, err
+	}
+	traceID := model.Get("query").MustString("")
 
-	req, err := e.createRequest(ctx, dsInfo, traceID)
+	request, err := s.createRequest(ctx, dsInfo, traceID)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
-	resp, err := e.httpClient.Do(req)
+	resp, err := dsInfo.HTTPClient.Do(request)
 	if err != nil {
-		return plugins.DataResponse{}, fmt.Errorf("failed get to tempo: %w", err)
+		return nil, fmt.Errorf("failed get to tempo: %w", err)
 	}
 
 	defer func() {
@@ -64,51 +111,55 @@ func (e *tempoExecutor) DataQuery(ctx context.Context, dsInfo *models.DataSource
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return plugins.DataResponse{}, err
+		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		queryResult.Error = fmt.Errorf("failed to get trace with id: %s Status: %s Body: %s", traceID, resp.Status, string(body))
-		return plugins.DataResponse{
-			Results: map[string]plugins.DataQueryResult{
-				refID: queryResult,
-			},
-		}, nil
+		result.Responses[q.RefID] = backend.DataResponse{
+			Error: fmt.Errorf("failed to get trace with id: %s Status: %s Body: %s", traceID, resp.Status, string(body)),
+		}
+		return result, nil
 	}
 
 	otTrace, err := ot_pdata.TracesFromOtlpProtoBytes(body)
-
 	if err != nil {
-		return plugins.DataResponse{}, fmt.Errorf("failed to convert tempo response to Otlp: %w", err)
+		return nil, fmt.Errorf("failed to convert tempo response to Otlp: %w", err)
 	}
 
 	frame, err := TraceToFrame(otTrace)
 	if err != nil {
-		return plugins.DataResponse{}, fmt.Errorf("failed to transform trace %v to data frame: %w", traceID, err)
+		return nil, fmt.Errorf("failed to transform trace %v to data frame: %w", traceID, err)
+	}
+	frame.RefID = q.RefID
+	result.Responses[q.RefID] = backend.DataResponse{
+		Frames: data.Frames{frame},
 	}
-	frame.RefID = refID
-	frames := []*data.Frame{frame}
-	queryResult.Dataframes = plugins.NewDecodedDataFrames(frames)
-
-	return plugins.DataResponse{
-		Results: map[string]plugins.DataQueryResult{
-			refID: queryResult,
-		},
-	}, nil
+
+	return result, nil
 }
 
-func (e *tempoExecutor) createRequest(ctx context.Context, dsInfo *models.DataSource, traceID string) (*http.Request, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", dsInfo.Url+"/api/traces/"+traceID, nil)
+func (s *Service) createRequest(ctx context.Context, dsInfo *datasourceInfo, traceID string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", dsInfo.URL+"/api/traces/"+traceID, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	if dsInfo.BasicAuth {
-		req.SetBasicAuth(dsInfo.BasicAuthUser, dsInfo.DecryptedBasicAuthPassword())
-	}
-
 	req.Header.Set("Accept", "application/protobuf")
 
 	tlog.Debug("Tempo request", "url", req.URL.String(), "headers", req.Header)
 	return req, nil
 }
+
+func (s *Service) getDSInfo(pluginCtx backend.PluginContext) (*datasourceInfo, error) {
+	i, err := s.im.Get(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, ok := i.(*datasourceInfo)
+	if !ok {
+		return nil, fmt.Errorf("failed to cast data source info")
+	}
+
+	return instance, nil
+}
@@ -2,23 +2,52 @@ package tempo
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/datasource"
 	"github.com/grafana/grafana/pkg/infra/httpclient"
-	"github.com/grafana/grafana/pkg/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTempo(t *testing.T) {
-	plug, err := New(httpclient.NewProvider())(&models.DataSource{})
-	executor := plug.(*tempoExecutor)
-	require.NoError(t, err)
+	service := &Service{
+		im: datasource.NewInstanceManager(newInstanceSettings(httpclient.NewProvider())),
+	}
 
-	t.Run("createRequest should set Auth header when basic auth is true ", func(t *testing.T) {
-		req, err := executor.createRequest(context.Background(), &models.DataSource{BasicAuth: true, BasicAuthUser: "john", BasicAuthPassword: "pass"}, "traceID")
+	t.Run("createRequest should request the trace as protobuf", func(t *testing.T) {
+		req, err := service.createRequest(context.Background(), &datasourceInfo{URL: "http://localhost:3200"}, "traceID")
 		require.NoError(t, err)
-		assert.Equal(t, 2, len(req.Header))
-		assert.NotEqual(t, req.Header.Get("Authorization"), "")
+		assert.Equal(t, "http://localhost:3200/api/traces/traceID", req.URL.String())
+		assert.Equal(t, "application/protobuf", req.Header.Get("Accept"))
+	})
+
+	t.Run("QueryData should use the basic auth of the data source", func(t *testing.T) {
+		var user, password string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, password, _ = r.BasicAuth()
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		t.Cleanup(srv.Close)
+
+		resp, err := service.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					ID:                      1,
+					URL:                     srv.URL,
+					BasicAuthEnabled:        true,
+					BasicAuthUser:           "john",
+					DecryptedSecureJSONData: map[string]string{"basicAuthPassword": "pass"},
+				},
+			},
+			Queries: []backend.DataQuery{{RefID: "A", JSON: []byte(`{"query": "traceID"}`)}},
+		})
+		require.NoError(t, err)
+		require.Error(t, resp.Responses["A"].Error)
+		assert.Equal(t, "john", user)
+		assert.Equal(t, "pass", password)
 	})
 }
@@ -17,7 +17,7 @@ export function useIsRuleEditable(rule?: RulerRuleDTO): ResultBag {
     return { isEditable: false, loading: false };
   }
 
-  // grafana rules can be edited if user can edit the folder they're in
+  // grafana rules can be edited if user can edit the folder they're in, unless they are provisioned
   if (isGrafanaRulerRule(rule)) {
     if (!folderUID) {
       throw new Error(
@@ -25,7 +25,7 @@ export function useIsRuleEditable(rule?: RulerRuleDTO): ResultBag {
       );
     }
     return {
-      isEditable: folder?.canSave,
+      isEditable: folder?.canSave && !rule.grafana_alert.provenance,
       loading,
     };
   }
@@ -70,6 +70,7 @@ export type GrafanaManagedReceiverConfig = {
   name: string;
   updated?: string;
   created?: string;
+  provenance?: string;
 };
 
 export type Receiver = {
@@ -98,6 +99,7 @@ export type Route = {
   group_interval?: string;
   repeat_interval?: string;
   routes?: Route[];
+  mute_time_intervals?: string[];
 };
 
 export type InhibitRule = {
@@ -108,6 +110,25 @@ export type InhibitRule = {
   equal?: string[];
 };
 
+export type TimeRange = {
+  start_time: string;
+  end_time: string;
+};
+
+export type TimeInterval = {
+  times?: TimeRange[];
+  weekdays?: string[];
+  days_of_month?: string[];
+  months?: string[];
+  years?: string[];
+  location?: string;
+};
+
+export type MuteTimeInterval = {
+  name: string;
+  time_intervals: TimeInterval[];
+};
+
 export type AlertmanagerConfig = {
   global?: {
     smtp_from?: string;
@@ -133,6 +154,7 @@ export type AlertmanagerConfig = {
   templates?: string[];
   route?: Route;
   inhibit_rules?: InhibitRule[];
+  mute_time_intervals?: MuteTimeInterval[];
   receivers?: Receiver[];
 };
 